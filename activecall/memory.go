@@ -0,0 +1,131 @@
+package activecall
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+type memoryEntry struct {
+	call      model.ActiveCall
+	expiresAt time.Time
+}
+
+// MemoryRegistry keeps active calls in process. It is only accurate for a
+// single API instance and is meant for development and tests.
+type MemoryRegistry struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	now   func() time.Time
+	calls map[string]*memoryEntry
+}
+
+func NewMemoryRegistry(ttl time.Duration) *MemoryRegistry {
+	return &MemoryRegistry{
+		ttl:   ttl,
+		now:   time.Now,
+		calls: make(map[string]*memoryEntry),
+	}
+}
+
+func (r *MemoryRegistry) Acquire(call model.ActiveCall, limits model.CallLimits) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+
+	if existing, ok := r.calls[call.APIId]; ok {
+		existing.call = call
+		existing.expiresAt = r.now().Add(r.ttl)
+		return nil
+	}
+	err := r.check(call, limits)
+	if err != nil {
+		return err
+	}
+	r.calls[call.APIId] = &memoryEntry{call: call, expiresAt: r.now().Add(r.ttl)}
+	return nil
+}
+
+func (r *MemoryRegistry) Refresh(apiId string, callId int, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+
+	entry, ok := r.calls[apiId]
+	if !ok {
+		return ErrNotFound
+	}
+	if callId != 0 {
+		entry.call.CallId = callId
+	}
+	if status != "" {
+		entry.call.Status = status
+	}
+	entry.expiresAt = r.now().Add(r.ttl)
+	return nil
+}
+
+func (r *MemoryRegistry) Release(apiId string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.calls, apiId)
+	return nil
+}
+
+func (r *MemoryRegistry) Check(workspaceId int, trunkId int, limits model.CallLimits) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+	return r.check(model.ActiveCall{WorkspaceId: workspaceId, TrunkId: trunkId}, limits)
+}
+
+func (r *MemoryRegistry) List(workspaceId int) ([]model.ActiveCall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.prune()
+
+	calls := []model.ActiveCall{}
+	for _, entry := range r.calls {
+		if entry.call.WorkspaceId == workspaceId {
+			calls = append(calls, entry.call)
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].StartedAt < calls[j].StartedAt })
+	return calls, nil
+}
+
+func (r *MemoryRegistry) check(call model.ActiveCall, limits model.CallLimits) error {
+	var inWorkspace, onExtension, onTrunk int
+	for _, entry := range r.calls {
+		if entry.call.WorkspaceId == call.WorkspaceId {
+			inWorkspace++
+			if call.Extension != "" && entry.call.Extension == call.Extension {
+				onExtension++
+			}
+		}
+		if call.TrunkId != 0 && entry.call.TrunkId == call.TrunkId {
+			onTrunk++
+		}
+	}
+	if limits.Workspace > 0 && inWorkspace >= limits.Workspace {
+		return &LimitError{Scope: ScopeWorkspace, Limit: limits.Workspace}
+	}
+	if call.Extension != "" && limits.Extension > 0 && onExtension >= limits.Extension {
+		return &LimitError{Scope: ScopeExtension, Limit: limits.Extension}
+	}
+	if call.TrunkId != 0 && limits.Trunk > 0 && onTrunk >= limits.Trunk {
+		return &LimitError{Scope: ScopeTrunk, Limit: limits.Trunk}
+	}
+	return nil
+}
+
+func (r *MemoryRegistry) prune() {
+	now := r.now()
+	for apiId, entry := range r.calls {
+		if !entry.expiresAt.After(now) {
+			delete(r.calls, apiId)
+		}
+	}
+}
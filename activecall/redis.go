@@ -0,0 +1,242 @@
+package activecall
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+	"lineblocs.com/api/model"
+)
+
+// acquireScript checks every limit and registers the call in one step so
+// concurrent API instances can not both take the last free channel.
+//
+// KEYS[1] is the call details key, KEYS[2..] the sorted sets the call counts
+// against. ARGV holds now, the expiry score, the member, the details, the
+// TTL in seconds and then the limit of every sorted set, 0 is unlimited.
+var acquireScript = redis.NewScript(`
+local known = redis.call('EXISTS', KEYS[1]) == 1
+for i = 2, #KEYS do
+	redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', ARGV[1])
+	local limit = tonumber(ARGV[i + 4])
+	if not known and limit > 0 and redis.call('ZCARD', KEYS[i]) >= limit then
+		return i - 1
+	end
+end
+for i = 2, #KEYS do
+	redis.call('ZADD', KEYS[i], ARGV[2], ARGV[3])
+	redis.call('EXPIRE', KEYS[i], ARGV[5])
+end
+redis.call('SET', KEYS[1], ARGV[4], 'EX', ARGV[5])
+return 0
+`)
+
+// RedisRegistry shares the active calls between API instances. Every scope
+// is a sorted set of call API ids scored by their expiry time, expired
+// members are dropped before counting so a lost terminal status only holds
+// a channel until the TTL runs out.
+type RedisRegistry struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisRegistry(client *redis.Client, ttl time.Duration) *RedisRegistry {
+	return &RedisRegistry{client: client, ttl: ttl}
+}
+
+func (r *RedisRegistry) Acquire(call model.ActiveCall, limits model.CallLimits) error {
+	details, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	scopes, keys := scopeKeys(call)
+	limitFor := map[string]int{ScopeWorkspace: limits.Workspace, ScopeExtension: limits.Extension, ScopeTrunk: limits.Trunk}
+
+	now := time.Now()
+	args := []interface{}{
+		now.Unix(),
+		now.Add(r.ttl).Unix(),
+		call.APIId,
+		details,
+		int(r.ttl.Seconds()),
+	}
+	for _, scope := range scopes {
+		args = append(args, limitFor[scope])
+	}
+
+	rejected, err := acquireScript.Run(r.client, append([]string{callKey(call.APIId)}, keys...), args...).Int()
+	if err != nil {
+		return err
+	}
+	if rejected > 0 {
+		scope := scopes[rejected-1]
+		return &LimitError{Scope: scope, Limit: limitFor[scope]}
+	}
+	return nil
+}
+
+func (r *RedisRegistry) Refresh(apiId string, callId int, status string) error {
+	call, err := r.get(apiId)
+	if err != nil {
+		return err
+	}
+	if callId != 0 {
+		call.CallId = callId
+	}
+	if status != "" {
+		call.Status = status
+	}
+	details, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	expiry := redis.Z{Score: float64(time.Now().Add(r.ttl).Unix()), Member: apiId}
+	_, keys := scopeKeys(*call)
+	pipe := r.client.TxPipeline()
+	pipe.Set(callKey(apiId), details, r.ttl)
+	for _, key := range keys {
+		pipe.ZAddXX(key, expiry)
+		pipe.Expire(key, r.ttl)
+	}
+	_, err = pipe.Exec()
+	return err
+}
+
+func (r *RedisRegistry) Release(apiId string) error {
+	call, err := r.get(apiId)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, keys := scopeKeys(*call)
+	pipe := r.client.TxPipeline()
+	for _, key := range keys {
+		pipe.ZRem(key, apiId)
+	}
+	pipe.Del(callKey(apiId))
+	_, err = pipe.Exec()
+	return err
+}
+
+func (r *RedisRegistry) Check(workspaceId int, trunkId int, limits model.CallLimits) error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	count := func(key string) (int64, error) {
+		pipe := r.client.Pipeline()
+		pipe.ZRemRangeByScore(key, "-inf", now)
+		card := pipe.ZCard(key)
+		_, err := pipe.Exec()
+		if err != nil {
+			return 0, err
+		}
+		return card.Val(), nil
+	}
+
+	if limits.Workspace > 0 {
+		active, err := count(workspaceKey(workspaceId))
+		if err != nil {
+			return err
+		}
+		if active >= int64(limits.Workspace) {
+			return &LimitError{Scope: ScopeWorkspace, Limit: limits.Workspace}
+		}
+	}
+	if trunkId != 0 && limits.Trunk > 0 {
+		active, err := count(trunkKey(trunkId))
+		if err != nil {
+			return err
+		}
+		if active >= int64(limits.Trunk) {
+			return &LimitError{Scope: ScopeTrunk, Limit: limits.Trunk}
+		}
+	}
+	return nil
+}
+
+func (r *RedisRegistry) List(workspaceId int) ([]model.ActiveCall, error) {
+	key := workspaceKey(workspaceId)
+	err := r.client.ZRemRangeByScore(key, "-inf", strconv.FormatInt(time.Now().Unix(), 10)).Err()
+	if err != nil {
+		return nil, err
+	}
+	members, err := r.client.ZRange(key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	calls := []model.ActiveCall{}
+	if len(members) == 0 {
+		return calls, nil
+	}
+	detailKeys := make([]string, len(members))
+	for i, member := range members {
+		detailKeys[i] = callKey(member)
+	}
+	values, err := r.client.MGet(detailKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		details, ok := value.(string)
+		if !ok {
+			// the details expired before the sorted set member was pruned
+			continue
+		}
+		var call model.ActiveCall
+		if err := json.Unmarshal([]byte(details), &call); err != nil {
+			return nil, err
+		}
+		calls = append(calls, call)
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].StartedAt < calls[j].StartedAt })
+	return calls, nil
+}
+
+func (r *RedisRegistry) get(apiId string) (*model.ActiveCall, error) {
+	details, err := r.client.Get(callKey(apiId)).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var call model.ActiveCall
+	err = json.Unmarshal([]byte(details), &call)
+	if err != nil {
+		return nil, err
+	}
+	return &call, nil
+}
+
+// scopeKeys returns the sorted sets call counts against, with their scope.
+func scopeKeys(call model.ActiveCall) ([]string, []string) {
+	scopes := []string{ScopeWorkspace}
+	keys := []string{workspaceKey(call.WorkspaceId)}
+	if call.Extension != "" {
+		scopes = append(scopes, ScopeExtension)
+		keys = append(keys, "active_calls:extension:"+strconv.Itoa(call.WorkspaceId)+":"+call.Extension)
+	}
+	if call.TrunkId != 0 {
+		scopes = append(scopes, ScopeTrunk)
+		keys = append(keys, trunkKey(call.TrunkId))
+	}
+	return scopes, keys
+}
+
+func callKey(apiId string) string {
+	return "active_calls:call:" + apiId
+}
+
+func workspaceKey(workspaceId int) string {
+	return "active_calls:workspace:" + strconv.Itoa(workspaceId)
+}
+
+func trunkKey(trunkId int) string {
+	return "active_calls:trunk:" + strconv.Itoa(trunkId)
+}
@@ -0,0 +1,59 @@
+// Package activecall keeps track of the calls in progress per workspace,
+// extension and trunk so concurrent call limits can be enforced.
+package activecall
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// DefaultTTL bounds how long a call stays registered without being refreshed.
+// It protects the counters from calls whose terminal status never arrives.
+const DefaultTTL = 4 * time.Hour
+
+// Limit scopes, reported by LimitError.
+const (
+	ScopeWorkspace = "workspace"
+	ScopeExtension = "extension"
+	ScopeTrunk     = "trunk"
+)
+
+var (
+	ErrLimitReached = errors.New("concurrent call limit reached")
+	ErrNotFound     = errors.New("call is not registered")
+)
+
+// LimitError tells which limit rejected a call. It matches ErrLimitReached with errors.Is.
+type LimitError struct {
+	Scope string
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s concurrent call limit of %d reached", e.Scope, e.Limit)
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitReached
+}
+
+// Registry tracks active calls by their API id.
+type Registry interface {
+	// Acquire registers call unless doing so would exceed the limits of its
+	// workspace, extension or trunk. Acquiring an already registered call
+	// replaces its details without checking limits.
+	Acquire(call model.ActiveCall, limits model.CallLimits) error
+	// Refresh updates the call id and status of a registered call and
+	// extends its expiry. It returns ErrNotFound for unknown calls.
+	Refresh(apiId string, callId int, status string) error
+	// Release removes a call, releasing an unknown call is not an error.
+	Release(apiId string) error
+	// Check reports whether one more call fits in the workspace and trunk
+	// limits without registering anything.
+	Check(workspaceId int, trunkId int, limits model.CallLimits) error
+	// List returns the active calls of a workspace.
+	List(workspaceId int) ([]model.ActiveCall, error)
+}
@@ -0,0 +1,90 @@
+package activecall
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/model"
+)
+
+func TestMemoryRegistry(t *testing.T) {
+	newCall := func(apiId string, trunkId int) model.ActiveCall {
+		return model.ActiveCall{APIId: apiId, WorkspaceId: 1, TrunkId: trunkId, Status: "INITIATED"}
+	}
+
+	t.Run("Should enforce the workspace limit", func(t *testing.T) {
+		r := NewMemoryRegistry(DefaultTTL)
+		limits := model.CallLimits{Workspace: 2}
+		assert.NoError(t, r.Acquire(newCall("call-1", 0), limits))
+		assert.NoError(t, r.Acquire(newCall("call-2", 0), limits))
+
+		err := r.Acquire(newCall("call-3", 0), limits)
+		assert.ErrorIs(t, err, ErrLimitReached)
+		assert.Equal(t, ScopeWorkspace, err.(*LimitError).Scope)
+		assert.ErrorIs(t, r.Check(1, 0, limits), ErrLimitReached)
+		assert.NoError(t, r.Check(2, 0, limits))
+
+		// re-acquiring a registered call never counts twice
+		assert.NoError(t, r.Acquire(newCall("call-2", 0), limits))
+
+		assert.NoError(t, r.Release("call-1"))
+		assert.NoError(t, r.Acquire(newCall("call-3", 0), limits))
+	})
+
+	t.Run("Should enforce the trunk limit", func(t *testing.T) {
+		r := NewMemoryRegistry(DefaultTTL)
+		limits := model.CallLimits{Trunk: 1}
+		assert.NoError(t, r.Acquire(newCall("call-1", 7), limits))
+		assert.NoError(t, r.Acquire(newCall("call-2", 8), limits))
+
+		err := r.Acquire(newCall("call-3", 7), limits)
+		assert.ErrorIs(t, err, ErrLimitReached)
+		assert.Equal(t, ScopeTrunk, err.(*LimitError).Scope)
+	})
+
+	t.Run("Should enforce the extension limit", func(t *testing.T) {
+		r := NewMemoryRegistry(DefaultTTL)
+		limits := model.CallLimits{Extension: 1}
+		onExtension := func(apiId string, workspaceId int) model.ActiveCall {
+			return model.ActiveCall{APIId: apiId, WorkspaceId: workspaceId, Extension: "1001", Status: "INITIATED"}
+		}
+		assert.NoError(t, r.Acquire(onExtension("call-1", 1), limits))
+		// the same extension name in another workspace is another extension
+		assert.NoError(t, r.Acquire(onExtension("call-2", 2), limits))
+		assert.NoError(t, r.Acquire(newCall("call-3", 0), limits))
+
+		err := r.Acquire(onExtension("call-4", 1), limits)
+		assert.ErrorIs(t, err, ErrLimitReached)
+		assert.Equal(t, ScopeExtension, err.(*LimitError).Scope)
+	})
+
+	t.Run("Should drop calls that are not refreshed within the TTL", func(t *testing.T) {
+		now := time.Now()
+		r := NewMemoryRegistry(time.Minute)
+		r.now = func() time.Time { return now }
+		limits := model.CallLimits{Workspace: 1}
+
+		assert.NoError(t, r.Acquire(newCall("call-1", 0), limits))
+		now = now.Add(45 * time.Second)
+		assert.NoError(t, r.Refresh("call-1", 10, "ANSWERED"))
+		now = now.Add(45 * time.Second)
+
+		calls, _ := r.List(1)
+		if assert.Len(t, calls, 1) {
+			assert.Equal(t, 10, calls[0].CallId)
+			assert.Equal(t, "ANSWERED", calls[0].Status)
+		}
+
+		now = now.Add(time.Minute)
+		calls, _ = r.List(1)
+		assert.Empty(t, calls)
+		assert.ErrorIs(t, r.Refresh("call-1", 0, "ENDED"), ErrNotFound)
+		assert.NoError(t, r.Acquire(newCall("call-2", 0), limits))
+	})
+
+	t.Run("Should release unknown calls without error", func(t *testing.T) {
+		r := NewMemoryRegistry(DefaultTTL)
+		assert.NoError(t, r.Release("call-unknown"))
+	})
+}
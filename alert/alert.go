@@ -0,0 +1,94 @@
+// Package alert notifies workspaces of debugger logs through the channels
+// they configure: email, a Slack incoming webhook, a signed webhook or none.
+// Notifications are delivered in the background and retried.
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"lineblocs.com/api/model"
+)
+
+// Channel types.
+const (
+	TypeEmail   = "email"
+	TypeSlack   = "slack"
+	TypeWebhook = "webhook"
+	TypeNone    = "none"
+)
+
+// Levels of debugger logs, from the least to the most severe.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// ErrRejected is returned by a Channel for notifications that are not retried.
+var ErrRejected = errors.New("notification rejected")
+
+var severities = map[string]int{
+	LevelDebug: 0,
+	LevelInfo:  1,
+	LevelWarn:  2,
+	LevelError: 3,
+}
+
+// NormalizeLevel returns the level a log or channel is filed under, unknown levels are info.
+func NormalizeLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	switch level {
+	case "warning":
+		return LevelWarn
+	case "fatal", "critical":
+		return LevelError
+	}
+	if _, ok := severities[level]; ok {
+		return level
+	}
+	return LevelInfo
+}
+
+// ValidLevel reports whether level names a known level.
+func ValidLevel(level string) bool {
+	level = strings.ToLower(strings.TrimSpace(level))
+	_, ok := severities[level]
+	return ok || level == "warning" || level == "fatal" || level == "critical"
+}
+
+// AtLeast reports whether level is as severe as min.
+func AtLeast(level string, min string) bool {
+	return severities[NormalizeLevel(level)] >= severities[NormalizeLevel(min)]
+}
+
+// Alert is a debugger log a workspace is notified of.
+type Alert struct {
+	WorkspaceId int    `json:"workspace_id"`
+	UserId      int    `json:"-"`
+	LogId       string `json:"log_id"`
+	FlowId      int    `json:"flow_id"`
+	Level       string `json:"level"`
+	Title       string `json:"title"`
+	Report      string `json:"report"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	CreatedAt   string `json:"created_at"`
+	// Count is set on digests to the number of times the alert happened
+	Count int `json:"count,omitempty"`
+}
+
+// Channel delivers alerts to one destination.
+type Channel interface {
+	Send(ctx context.Context, alert *Alert) error
+}
+
+/*
+Interface of Notification Channel Store.
+Implementation of Notification Channel Store is located /store/logger
+*/
+type ChannelStoreInterface interface {
+	GetNotificationChannels(workspaceId int) ([]model.NotificationChannel, error)
+}
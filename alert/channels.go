@@ -0,0 +1,153 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/notification"
+)
+
+const (
+	// WebhookEvent names the deliveries of webhook channels
+	WebhookEvent   = "debugger.alert"
+	requestTimeout = 10 * time.Second
+	// SignatureHeader carries the HMAC-SHA256 of "<timestamp>.<body>" with the channel secret
+	SignatureHeader = "X-Lineblocs-Signature"
+	TimestampHeader = "X-Lineblocs-Timestamp"
+)
+
+// EmailChannel emails alerts to a list of addresses in a locale.
+type EmailChannel struct {
+	mailer mail.Mailer
+	to     []string
+	cc     []string
+	locale string
+}
+
+func NewEmailChannel(mailer mail.Mailer, to []string, cc []string, locale string) *EmailChannel {
+	return &EmailChannel{mailer: mailer, to: to, cc: cc, locale: locale}
+}
+
+func (ch *EmailChannel) Send(ctx context.Context, alert *Alert) error {
+	if ch.mailer == nil {
+		return fmt.Errorf("%w: email is not configured", ErrRejected)
+	}
+	message, err := notification.Render(notification.TypeDebuggerAlert, ch.locale, alert)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+	email := mail.NewEmail(ch.to, message)
+	email.CC = ch.cc
+	err = ch.mailer.Send(ctx, email)
+	if errors.Is(err, mail.ErrPermanent) {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+	return err
+}
+
+// SlackChannel posts alerts to a Slack compatible incoming webhook.
+type SlackChannel struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlackChannel(url string) *SlackChannel {
+	return &SlackChannel{url: url, client: NewPublicClient(requestTimeout)}
+}
+
+func (ch *SlackChannel) Send(ctx context.Context, alert *Alert) error {
+	text := fmt.Sprintf("*[%s] %s*", strings.ToUpper(NormalizeLevel(alert.Level)), slackEscape(alert.Title))
+	if alert.Report != "" {
+		text += "\n" + slackEscape(alert.Report)
+	}
+	if alert.From != "" || alert.To != "" {
+		text += fmt.Sprintf("\n%s → %s", slackEscape(alert.From), slackEscape(alert.To))
+	}
+	payload, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return post(ctx, ch.client, ch.url, payload, nil)
+}
+
+// slackEscape escapes the characters Slack reads as markup.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// WebhookChannel posts alerts as JSON signed with the secret of the channel.
+type WebhookChannel struct {
+	url    string
+	secret []byte
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookChannel(url string, secret string) *WebhookChannel {
+	return &WebhookChannel{url: url, secret: []byte(secret), client: NewPublicClient(requestTimeout), now: time.Now}
+}
+
+type webhookPayload struct {
+	Event string `json:"event"`
+	*Alert
+}
+
+func (ch *WebhookChannel) Send(ctx context.Context, alert *Alert) error {
+	payload, err := json.Marshal(webhookPayload{Event: WebhookEvent, Alert: alert})
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(ch.now().Unix(), 10)
+	headers := map[string]string{
+		TimestampHeader: timestamp,
+		SignatureHeader: "sha256=" + Sign(ch.secret, timestamp, payload),
+	}
+	return post(ctx, ch.client, ch.url, payload, headers)
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>", receivers
+// recompute it to check a delivery came from Lineblocs.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends a JSON payload, client errors other than 429 are not retried.
+func post(ctx context.Context, client *http.Client, url string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	res, err := client.Do(req)
+	if errors.Is(err, ErrPrivateTarget) {
+		return fmt.Errorf("%w: %s", ErrRejected, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode < 300 {
+		return nil
+	}
+	if res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: endpoint returned %s", ErrRejected, res.Status)
+	}
+	return fmt.Errorf("endpoint returned %s", res.Status)
+}
@@ -0,0 +1,157 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/mail"
+)
+
+// captureMailer keeps the alert emails it is asked to send.
+type captureMailer struct {
+	mu   sync.Mutex
+	err  error
+	sent []mail.Email
+}
+
+func (m *captureMailer) Send(ctx context.Context, email *mail.Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, *email)
+	return m.err
+}
+
+func TestLevels(t *testing.T) {
+	assert.Equal(t, LevelWarn, NormalizeLevel(" Warning "))
+	assert.Equal(t, LevelError, NormalizeLevel("fatal"))
+	assert.Equal(t, LevelInfo, NormalizeLevel("verbose"))
+	assert.True(t, ValidLevel("ERROR"))
+	assert.False(t, ValidLevel("verbose"))
+
+	assert.True(t, AtLeast(LevelError, LevelWarn))
+	assert.True(t, AtLeast(LevelWarn, "warning"))
+	assert.False(t, AtLeast(LevelInfo, LevelWarn))
+	assert.True(t, AtLeast(LevelDebug, LevelDebug))
+}
+
+func TestEmailChannel(t *testing.T) {
+	t.Run("Should escape the log in the email", func(t *testing.T) {
+		mailer := &captureMailer{}
+		channel := NewEmailChannel(mailer, []string{"ops@example.com"}, []string{"support@example.com"}, "")
+		err := channel.Send(context.Background(), &Alert{Level: LevelError, Title: "<b>Flow failed</b>", Report: "a & b"})
+		require.NoError(t, err)
+
+		require.Len(t, mailer.sent, 1)
+		assert.Equal(t, []string{"ops@example.com"}, mailer.sent[0].To)
+		assert.Equal(t, []string{"support@example.com"}, mailer.sent[0].CC)
+		assert.Equal(t, "Debug Monitor: <b>Flow failed</b>", mailer.sent[0].Subject)
+		assert.Contains(t, mailer.sent[0].Text, "[error] <b>Flow failed</b>\n\na & b")
+		assert.Contains(t, mailer.sent[0].HTML, "&lt;b&gt;Flow failed&lt;/b&gt;")
+		assert.Contains(t, mailer.sent[0].HTML, "a &amp; b")
+	})
+
+	t.Run("Should email in the locale of the channel", func(t *testing.T) {
+		mailer := &captureMailer{}
+		err := NewEmailChannel(mailer, []string{"ops@example.com"}, nil, "fr_CA").Send(context.Background(), &Alert{Title: "Flow failed"})
+		require.NoError(t, err)
+		require.Len(t, mailer.sent, 1)
+		assert.Equal(t, "Moniteur de débogage : Flow failed", mailer.sent[0].Subject)
+	})
+
+	t.Run("Should reject alerts without a mailer", func(t *testing.T) {
+		err := NewEmailChannel(nil, []string{"ops@example.com"}, nil, "").Send(context.Background(), &Alert{})
+		assert.ErrorIs(t, err, ErrRejected)
+	})
+}
+
+func TestSlackChannel(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer server.Close()
+
+	channel := NewSlackChannel(server.URL)
+	channel.client = server.Client()
+	err := channel.Send(context.Background(), &Alert{Level: "warning", Title: "Call <failed>", Report: "busy", From: "+15145550100", To: "+15145550101"})
+	require.NoError(t, err)
+	assert.Equal(t, "*[WARN] Call &lt;failed&gt;*\nbusy\n+15145550100 → +15145550101", payload["text"])
+}
+
+func TestWebhookChannel(t *testing.T) {
+	t.Run("Should sign deliveries with the secret", func(t *testing.T) {
+		var body []byte
+		var header http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			header = r.Header
+		}))
+		defer server.Close()
+
+		channel := NewWebhookChannel(server.URL, "s3cret")
+		channel.client = server.Client()
+		channel.now = func() time.Time { return time.Unix(1700000000, 0) }
+		err := channel.Send(context.Background(), &Alert{WorkspaceId: 7, UserId: 3, LogId: "12", Level: LevelError, Title: "Flow failed"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "1700000000", header.Get(TimestampHeader))
+		assert.Equal(t, "sha256="+Sign([]byte("s3cret"), "1700000000", body), header.Get(SignatureHeader))
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &payload))
+		assert.Equal(t, WebhookEvent, payload["event"])
+		assert.Equal(t, "12", payload["log_id"])
+		assert.Equal(t, float64(7), payload["workspace_id"])
+		assert.NotContains(t, payload, "UserId")
+	})
+
+	t.Run("Should only retry server errors and rate limits", func(t *testing.T) {
+		status := http.StatusGone
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		defer server.Close()
+		channel := NewWebhookChannel(server.URL, "s3cret")
+		channel.client = server.Client()
+
+		err := channel.Send(context.Background(), &Alert{})
+		assert.ErrorIs(t, err, ErrRejected)
+
+		for _, status = range []int{http.StatusTooManyRequests, http.StatusBadGateway} {
+			err = channel.Send(context.Background(), &Alert{})
+			require.Error(t, err)
+			assert.False(t, errors.Is(err, ErrRejected))
+		}
+	})
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1.{}' | openssl dgst -sha256 -hmac key
+	assert.Equal(t, "1ba6b8171186efc613e8bcc0cbdab2748f24984d7c5a84faa2637afa0e40d224", Sign([]byte("key"), "1", []byte("{}")))
+	assert.NotEqual(t, Sign([]byte("key"), "1", []byte("{}")), Sign([]byte("key"), "2", []byte("{}")))
+}
+
+func TestCheckTarget(t *testing.T) {
+	for _, target := range []string{"http://127.0.0.1:8080/hook", "https://10.0.0.4/hook", "http://169.254.169.254/latest/meta-data", "http://[::1]/hook", "http://localhost/hook"} {
+		assert.ErrorIs(t, CheckTarget(context.Background(), target), ErrPrivateTarget, target)
+	}
+	assert.NoError(t, CheckTarget(context.Background(), "https://93.184.216.34/hook"))
+
+	t.Run("Should not deliver to private addresses", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("private endpoint was called")
+		}))
+		defer server.Close()
+
+		err := NewWebhookChannel(server.URL, "s3cret").Send(context.Background(), &Alert{})
+		assert.ErrorIs(t, err, ErrRejected)
+	})
+}
@@ -0,0 +1,288 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultQueueSize = 1024
+	DefaultWorkers   = 4
+	DefaultAttempts  = 5
+	minRetryDelay    = time.Second
+	maxRetryDelay    = time.Minute
+	deliveryTimeout  = 30 * time.Second
+)
+
+var (
+	ErrQueueFull = errors.New("alert queue is full")
+	ErrClosed    = errors.New("alert dispatcher is closed")
+)
+
+// delivery is one alert on its way to one channel.
+type delivery struct {
+	alert    *Alert
+	channel  Channel
+	name     string
+	attempts int
+}
+
+// Dispatcher queues alerts in memory and delivers them from background
+// workers to every channel of the workspace whose minimum level they reach.
+// Failed deliveries are retried with backoff. Workspaces without channels
+// get the alerts by email, sent to the user of the log, as before channels
+// could be configured. With a Throttle repeated alerts are sent as digests.
+// Alerts still queued when the process exits are lost.
+type Dispatcher struct {
+	store    ChannelStoreInterface
+	mailer   mail.Mailer
+	queue    chan *delivery
+	attempts int
+	delay    func(attempts int) time.Duration
+	throttle Throttle
+	interval time.Duration
+	// owner returns the user of a log
+	owner func(userId int) (*model.User, error)
+
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+	done    sync.WaitGroup
+}
+
+func NewDispatcher(store ChannelStoreInterface, mailer mail.Mailer, queueSize int) *Dispatcher {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	d := &Dispatcher{
+		store:    store,
+		mailer:   mailer,
+		queue:    make(chan *delivery, queueSize),
+		attempts: DefaultAttempts,
+		delay:    RetryDelay,
+		interval: DefaultFlushInterval,
+		owner: func(userId int) (*model.User, error) {
+			user, err := helpers.GetUserFromDB(userId)
+			if err != nil {
+				return nil, err
+			}
+			return &model.User{Id: user.Id, Email: user.Email}, nil
+		},
+	}
+	for i := 0; i < DefaultWorkers; i++ {
+		d.done.Add(1)
+		go d.run()
+	}
+	return d
+}
+
+// SetUserLookup replaces the lookup of the users alerts are emailed to,
+// with it emails are sent in the locale of the user.
+func (d *Dispatcher) SetUserLookup(lookup func(userId int) (*model.User, error)) {
+	d.owner = lookup
+}
+
+// SetThrottle deduplicates alerts and caps them per workspace, Run delivers
+// the digests of repeated alerts.
+func (d *Dispatcher) SetThrottle(throttle Throttle) {
+	d.throttle = throttle
+}
+
+// Notify resolves the channels of the workspace and queues a delivery to
+// each, it never waits on a channel. Alerts held by the throttle are only
+// counted. When the throttle fails alerts are delivered.
+func (d *Dispatcher) Notify(alert Alert) error {
+	if d.throttle != nil {
+		admitted, err := d.throttle.Admit(&alert)
+		if err != nil {
+			utils.Log(logrus.WarnLevel, fmt.Sprintf("could not throttle alert of log id = %s: %s", alert.LogId, err.Error()))
+		} else if !admitted {
+			return nil
+		}
+	}
+	return d.dispatch(alert)
+}
+
+// Run delivers the digests of the throttle until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.throttle == nil {
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		d.Flush()
+	}
+}
+
+// Flush delivers the digests of the groups whose window ended.
+func (d *Dispatcher) Flush() {
+	digests, err := d.throttle.Digests()
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not read alert digests: "+err.Error())
+	}
+	for _, digest := range digests {
+		if err := d.dispatch(digest); err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not deliver digest of log id = %s: %s", digest.LogId, err.Error()))
+		}
+	}
+}
+
+func (d *Dispatcher) dispatch(alert Alert) error {
+	channels, err := d.channels(&alert)
+	if err != nil {
+		return err
+	}
+	for name, channel := range channels {
+		err = d.enqueue(&delivery{alert: &alert, channel: channel, name: name})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// channels returns the channels an alert goes to, by name.
+func (d *Dispatcher) channels(alert *Alert) (map[string]Channel, error) {
+	configured, err := d.store.GetNotificationChannels(alert.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	channels := map[string]Channel{}
+	if len(configured) == 0 {
+		user, err := d.owner(alert.UserId)
+		if err != nil {
+			return nil, err
+		}
+		channels["default email"] = NewEmailChannel(d.mailer, []string{user.Email}, nil, user.Locale)
+		return channels, nil
+	}
+	// emails are sent in the locale of the user of the log
+	locale, lookedUp := "", false
+	for _, config := range configured {
+		if !AtLeast(alert.Level, config.MinLevel) {
+			continue
+		}
+		if config.Type == TypeEmail && !lookedUp {
+			if user, err := d.owner(alert.UserId); err == nil {
+				locale = user.Locale
+			}
+			lookedUp = true
+		}
+		channel := NewChannel(config, d.mailer, locale)
+		if channel != nil {
+			channels[fmt.Sprintf("%s channel id = %d", config.Type, config.Id)] = channel
+		}
+	}
+	return channels, nil
+}
+
+// NewChannel creates the channel configured in config, nil for none.
+// Email channels send in locale.
+func NewChannel(config model.NotificationChannel, mailer mail.Mailer, locale string) Channel {
+	switch config.Type {
+	case TypeEmail:
+		to := []string{}
+		for _, address := range strings.Split(config.Target, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				to = append(to, address)
+			}
+		}
+		return NewEmailChannel(mailer, to, nil, locale)
+	case TypeSlack:
+		return NewSlackChannel(config.Target)
+	case TypeWebhook:
+		return NewWebhookChannel(config.Target, config.Secret)
+	}
+	return nil
+}
+
+func (d *Dispatcher) enqueue(item *delivery) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return ErrClosed
+	}
+	return d.push(item)
+}
+
+// push queues item. Retries are pushed after Close as well, the pending
+// retry keeps Close waiting so the queue is still open.
+func (d *Dispatcher) push(item *delivery) error {
+	d.pending.Add(1)
+	select {
+	case d.queue <- item:
+		return nil
+	default:
+		d.pending.Done()
+		return ErrQueueFull
+	}
+}
+
+// Close stops taking alerts and waits for the queued deliveries and their retries.
+func (d *Dispatcher) Close() {
+	d.mu.Lock()
+	closed := d.closed
+	d.closed = true
+	d.mu.Unlock()
+	if closed {
+		return
+	}
+	// alerts are only added while the deliveries they follow are pending
+	d.pending.Wait()
+	close(d.queue)
+	d.done.Wait()
+}
+
+func (d *Dispatcher) run() {
+	defer d.done.Done()
+	for item := range d.queue {
+		d.deliver(item)
+	}
+}
+
+func (d *Dispatcher) deliver(item *delivery) {
+	defer d.pending.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	err := item.channel.Send(ctx, item.alert)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	item.attempts++
+	if errors.Is(err, ErrRejected) || item.attempts >= d.attempts {
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not deliver alert of log id = %s to %s: %s", item.alert.LogId, item.name, err.Error()))
+		return
+	}
+	utils.Log(logrus.WarnLevel, fmt.Sprintf("delivery of alert of log id = %s to %s failed on attempt %d: %s", item.alert.LogId, item.name, item.attempts, err.Error()))
+	// the retry is pending until it is queued again
+	d.pending.Add(1)
+	time.AfterFunc(d.delay(item.attempts), func() {
+		defer d.pending.Done()
+		if err := d.push(item); err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not retry alert of log id = %s to %s: %s", item.alert.LogId, item.name, err.Error()))
+		}
+	})
+}
+
+// RetryDelay backs off exponentially from a second, capped at a minute.
+func RetryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, minRetryDelay, maxRetryDelay)
+}
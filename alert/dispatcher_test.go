@@ -0,0 +1,116 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+)
+
+// flakyChannel fails the first failures deliveries.
+type flakyChannel struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (ch *flakyChannel) Send(ctx context.Context, alert *Alert) error {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.calls++
+	if ch.calls <= ch.failures {
+		return ch.err
+	}
+	return nil
+}
+
+func newTestDispatcher(t *testing.T, channels []model.NotificationChannel, mailer mail.Mailer) *Dispatcher {
+	store := mocks.NewLoggerStoreInterface(t)
+	store.EXPECT().GetNotificationChannels(7).Return(channels, nil)
+	d := NewDispatcher(store, mailer, 0)
+	d.delay = func(int) time.Duration { return time.Millisecond }
+	d.owner = func(userId int) (*model.User, error) {
+		if userId != 3 {
+			return nil, errors.New("unknown user")
+		}
+		return &model.User{Id: 3, Email: "owner@example.com", Locale: "fr"}, nil
+	}
+	return d
+}
+
+func TestDispatcher(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should email the owner of workspaces without channels", func(t *testing.T) {
+		mailer := &captureMailer{}
+		d := newTestDispatcher(t, []model.NotificationChannel{}, mailer)
+		require.NoError(t, d.Notify(Alert{WorkspaceId: 7, UserId: 3, Level: LevelDebug, Title: "Flow started"}))
+		d.Close()
+
+		require.Len(t, mailer.sent, 1)
+		assert.Equal(t, []string{"owner@example.com"}, mailer.sent[0].To)
+		assert.Empty(t, mailer.sent[0].CC)
+		assert.Contains(t, mailer.sent[0].Subject, "Moniteur de débogage")
+	})
+
+	t.Run("Should only deliver to channels of a lower minimum level", func(t *testing.T) {
+		mailer := &captureMailer{}
+		d := newTestDispatcher(t, []model.NotificationChannel{
+			{Id: 1, Type: TypeEmail, MinLevel: LevelError, Target: "oncall@example.com"},
+			{Id: 2, Type: TypeEmail, MinLevel: LevelInfo, Target: "ops@example.com, dev@example.com"},
+			{Id: 3, Type: TypeNone, MinLevel: LevelDebug},
+		}, mailer)
+		require.NoError(t, d.Notify(Alert{WorkspaceId: 7, UserId: 3, Level: LevelWarn, Title: "Call failed"}))
+		d.Close()
+
+		require.Len(t, mailer.sent, 1)
+		assert.Equal(t, []string{"ops@example.com", "dev@example.com"}, mailer.sent[0].To)
+		assert.Empty(t, mailer.sent[0].CC)
+	})
+
+	t.Run("Should not notify workspaces with a none channel", func(t *testing.T) {
+		mailer := &captureMailer{}
+		d := newTestDispatcher(t, []model.NotificationChannel{{Id: 1, Type: TypeNone, MinLevel: LevelDebug}}, mailer)
+		require.NoError(t, d.Notify(Alert{WorkspaceId: 7, UserId: 3, Level: LevelError}))
+		d.Close()
+
+		assert.Empty(t, mailer.sent)
+	})
+
+	t.Run("Should retry failed deliveries", func(t *testing.T) {
+		mailer := &captureMailer{err: errors.New("timeout")}
+		d := newTestDispatcher(t, []model.NotificationChannel{}, mailer)
+		require.NoError(t, d.Notify(Alert{WorkspaceId: 7, UserId: 3, Level: LevelError}))
+		d.Close()
+
+		assert.Len(t, mailer.sent, DefaultAttempts)
+	})
+
+	t.Run("Should deliver once a retry succeeds and not retry rejections", func(t *testing.T) {
+		d := NewDispatcher(mocks.NewLoggerStoreInterface(t), nil, 0)
+		d.delay = func(int) time.Duration { return time.Millisecond }
+		flaky := &flakyChannel{failures: 2, err: errors.New("timeout")}
+		rejecting := &flakyChannel{failures: 5, err: ErrRejected}
+		require.NoError(t, d.enqueue(&delivery{alert: &Alert{}, channel: flaky, name: "flaky"}))
+		require.NoError(t, d.enqueue(&delivery{alert: &Alert{}, channel: rejecting, name: "rejecting"}))
+		d.Close()
+
+		assert.Equal(t, 3, flaky.calls)
+		assert.Equal(t, 1, rejecting.calls)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, RetryDelay(0))
+	assert.Equal(t, 4*time.Second, RetryDelay(3))
+	assert.Equal(t, time.Minute, RetryDelay(20))
+}
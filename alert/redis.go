@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+const (
+	digestsKey = "alerts:digests"
+	// groupTTL outlives the windows of held groups so they are not lost
+	groupTTL     = time.Hour
+	digestsBatch = 100
+)
+
+// admitScript opens a group or counts a repeat in one step so concurrent
+// API instances do not both deliver the first alert of a group.
+//
+// KEYS[1] is the group, KEYS[2] the hourly counter of the workspace and
+// KEYS[3] the schedule of digests. ARGV holds now, the end of the window,
+// the group TTL in seconds, the hourly cap, 0 is unlimited, and the alert.
+var admitScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], 'count', 1)
+	redis.call('HSET', KEYS[1], 'alert', ARGV[5])
+	return 0
+end
+redis.call('HMSET', KEYS[1], 'count', 1, 'sent', 0, 'first', ARGV[1], 'alert', ARGV[5])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('ZADD', KEYS[3], ARGV[2], KEYS[1])
+local cap = tonumber(ARGV[4])
+if cap > 0 then
+	local sent = redis.call('INCR', KEYS[2])
+	if sent == 1 then
+		redis.call('EXPIRE', KEYS[2], 3600)
+	end
+	if sent > cap then
+		return 0
+	end
+end
+redis.call('HSET', KEYS[1], 'sent', 1)
+return 1
+`)
+
+// claimScript removes a group whose window ended and returns its count,
+// sent, first and alert fields. Only one instance claims a group.
+//
+// KEYS[1] is the schedule of digests and KEYS[2] the group.
+var claimScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], KEYS[2]) == 0 then
+	return false
+end
+local group = redis.call('HMGET', KEYS[2], 'count', 'sent', 'first', 'alert')
+redis.call('DEL', KEYS[2])
+return group
+`)
+
+// holdScript puts a claimed group back for another window, merging it with
+// a group opened by repeats since it was claimed.
+//
+// KEYS[1] is the group and KEYS[2] the schedule of digests. ARGV holds the
+// count, sent, first and alert fields, the end of the window and the TTL.
+var holdScript = redis.NewScript(`
+redis.call('HINCRBY', KEYS[1], 'count', ARGV[1])
+redis.call('HSET', KEYS[1], 'sent', ARGV[2])
+redis.call('HSET', KEYS[1], 'first', ARGV[3])
+redis.call('HSETNX', KEYS[1], 'alert', ARGV[4])
+redis.call('EXPIRE', KEYS[1], ARGV[6])
+redis.call('ZADD', KEYS[2], ARGV[5], KEYS[1])
+return 1
+`)
+
+// RedisThrottle shares groups and hourly counters between API instances.
+// Every group is a hash scheduled for its digest in a sorted set scored by
+// the end of its window.
+type RedisThrottle struct {
+	client    *redis.Client
+	window    time.Duration
+	hourlyCap int
+	now       func() time.Time
+}
+
+func NewRedisThrottle(client *redis.Client, window time.Duration, hourlyCap int) *RedisThrottle {
+	return &RedisThrottle{client: client, window: window, hourlyCap: hourlyCap, now: time.Now}
+}
+
+// storedAlert keeps the user of an alert, it is not part of its JSON.
+type storedAlert struct {
+	*Alert
+	UserId int `json:"user_id"`
+}
+
+func (t *RedisThrottle) Admit(alert *Alert) (bool, error) {
+	details, err := json.Marshal(storedAlert{Alert: alert, UserId: alert.UserId})
+	if err != nil {
+		return false, err
+	}
+	now := t.now()
+	keys := []string{groupRedisKey(alert), hourlyKey(alert.WorkspaceId, now), digestsKey}
+	admitted, err := admitScript.Run(t.client, keys,
+		now.Unix(), now.Add(t.window).Unix(), int((t.window + groupTTL).Seconds()), t.hourlyCap, details).Int()
+	if err != nil {
+		return false, err
+	}
+	return admitted == 1, nil
+}
+
+func (t *RedisThrottle) Digests() ([]Alert, error) {
+	now := t.now()
+	members, err := t.client.ZRangeByScore(digestsKey, redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.Unix(), 10),
+		Count: digestsBatch,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	digests := []Alert{}
+	for _, member := range members {
+		fields, err := claimScript.Run(t.client, []string{digestsKey, member}).Result()
+		if err == redis.Nil {
+			// claimed by another instance
+			continue
+		}
+		if err != nil {
+			return digests, err
+		}
+		values, ok := fields.([]interface{})
+		if !ok || len(values) != 4 || values[0] == nil || values[3] == nil {
+			// the group expired
+			continue
+		}
+		count, _ := strconv.Atoi(fmt.Sprint(values[0]))
+		sent, _ := strconv.Atoi(fmt.Sprint(values[1]))
+		first, _ := strconv.ParseInt(fmt.Sprint(values[2]), 10, 64)
+		var alert Alert
+		stored := storedAlert{Alert: &alert}
+		if err := json.Unmarshal([]byte(fmt.Sprint(values[3])), &stored); err != nil {
+			return digests, err
+		}
+		alert.UserId = stored.UserId
+		if count <= sent {
+			continue
+		}
+
+		taken, err := t.take(alert.WorkspaceId, now)
+		if err == nil && !taken {
+			err = holdScript.Run(t.client, []string{member, digestsKey},
+				count, sent, first, fmt.Sprint(values[3]), now.Add(t.window).Unix(), int((t.window + groupTTL).Seconds())).Err()
+			if err == nil {
+				continue
+			}
+		}
+		if err != nil {
+			return digests, err
+		}
+		digests = append(digests, Digest(alert, count, now.Sub(time.Unix(first, 0))))
+	}
+	return digests, nil
+}
+
+// take counts one notification against the hourly cap of a workspace.
+func (t *RedisThrottle) take(workspaceId int, now time.Time) (bool, error) {
+	if t.hourlyCap <= 0 {
+		return true, nil
+	}
+	key := hourlyKey(workspaceId, now)
+	pipe := t.client.TxPipeline()
+	sent := pipe.Incr(key)
+	pipe.Expire(key, time.Hour)
+	if _, err := pipe.Exec(); err != nil {
+		return false, err
+	}
+	return sent.Val() <= int64(t.hourlyCap), nil
+}
+
+// groupRedisKey hashes the title so the key stays short.
+func groupRedisKey(alert *Alert) string {
+	sum := sha256.Sum256([]byte(alert.Title))
+	return fmt.Sprintf("alerts:group:%d:%d:%s", alert.WorkspaceId, alert.FlowId, hex.EncodeToString(sum[:12]))
+}
+
+func hourlyKey(workspaceId int, now time.Time) string {
+	return fmt.Sprintf("alerts:sent:%d:%d", workspaceId, now.Unix()/3600)
+}
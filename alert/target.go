@@ -0,0 +1,70 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget is returned for channel targets on loopback, private or
+// link-local addresses, alerts are only posted to public endpoints.
+var ErrPrivateTarget = errors.New("target must be a public address")
+
+// CheckTarget returns ErrPrivateTarget unless every address the host of the
+// URL resolves to is public.
+func CheckTarget(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := parsed.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicIP(ip) {
+			return ErrPrivateTarget
+		}
+		return nil
+	}
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return err
+	}
+	for _, address := range addresses {
+		if !publicIP(address.IP) {
+			return ErrPrivateTarget
+		}
+	}
+	return nil
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
+
+// NewPublicClient returns a client that refuses to connect to addresses that
+// are not public, so a host that resolved to a public address when a target
+// was saved can not be pointed inside later. Slack and webhook channels and
+// the other webhooks of workspaces are posted with it.
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
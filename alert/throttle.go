@@ -0,0 +1,160 @@
+package alert
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWindow is how long repeats of an alert are grouped into one digest
+	DefaultWindow = 10 * time.Minute
+	// DefaultHourlyCap bounds the notifications of a workspace per hour, 0 is unlimited
+	DefaultHourlyCap     = 60
+	DefaultFlushInterval = 30 * time.Second
+)
+
+// Throttle deduplicates alerts and caps the notifications of a workspace.
+//
+// Alerts with the same workspace, title and flow form a group for a window.
+// The first alert of a group is delivered, the repeats are only counted and
+// once the window ends a digest tells how many times the alert happened.
+// Alerts and digests over the hourly cap of the workspace are held: they are
+// counted in their group and its digest is delayed by another window.
+type Throttle interface {
+	// Admit records alert and reports whether it is delivered now.
+	Admit(alert *Alert) (bool, error)
+	// Digests closes the groups whose window ended and returns a digest for
+	// each that had alerts which were not delivered.
+	Digests() ([]Alert, error)
+}
+
+// Digest summarizes count occurrences of alert over the last since.
+func Digest(alert Alert, count int, since time.Duration) Alert {
+	times := fmt.Sprintf("%d times", count)
+	if count == 1 {
+		times = "once"
+	}
+	report := fmt.Sprintf("This alert happened %s in the last %s.", times, describe(since))
+	if alert.Report != "" {
+		report += "\n\n" + alert.Report
+	}
+	alert.Report = report
+	alert.Count = count
+	return alert
+}
+
+// describe spells a duration out in minutes or hours.
+func describe(d time.Duration) string {
+	minutes := max(1, int(math.Round(d.Minutes())))
+	switch {
+	case minutes == 1:
+		return "minute"
+	case minutes == 60:
+		return "hour"
+	case minutes%60 == 0:
+		return fmt.Sprintf("%d hours", minutes/60)
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
+
+type groupKey struct {
+	workspaceId int
+	flowId      int
+	title       string
+}
+
+type memoryGroup struct {
+	alert     Alert
+	count     int
+	sent      int
+	first     time.Time
+	windowEnd time.Time
+}
+
+type hourlyCount struct {
+	hour  time.Time
+	count int
+}
+
+// MemoryThrottle keeps its groups and counters in process. It is only
+// accurate for a single API instance and is meant for development and tests.
+type MemoryThrottle struct {
+	mu        sync.Mutex
+	window    time.Duration
+	hourlyCap int
+	now       func() time.Time
+	groups    map[groupKey]*memoryGroup
+	sent      map[int]*hourlyCount
+}
+
+func NewMemoryThrottle(window time.Duration, hourlyCap int) *MemoryThrottle {
+	return &MemoryThrottle{
+		window:    window,
+		hourlyCap: hourlyCap,
+		now:       time.Now,
+		groups:    make(map[groupKey]*memoryGroup),
+		sent:      make(map[int]*hourlyCount),
+	}
+}
+
+func (t *MemoryThrottle) Admit(alert *Alert) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := groupKey{alert.WorkspaceId, alert.FlowId, alert.Title}
+	if group, ok := t.groups[key]; ok {
+		group.count++
+		group.alert = *alert
+		return false, nil
+	}
+	now := t.now()
+	group := &memoryGroup{alert: *alert, count: 1, first: now, windowEnd: now.Add(t.window)}
+	t.groups[key] = group
+	if !t.take(alert.WorkspaceId, now) {
+		return false, nil
+	}
+	group.sent = 1
+	return true, nil
+}
+
+func (t *MemoryThrottle) Digests() ([]Alert, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	digests := []Alert{}
+	for key, group := range t.groups {
+		if now.Before(group.windowEnd) {
+			continue
+		}
+		if group.count > group.sent {
+			if !t.take(key.workspaceId, now) {
+				group.windowEnd = now.Add(t.window)
+				continue
+			}
+			digests = append(digests, Digest(group.alert, group.count, now.Sub(group.first)))
+		}
+		delete(t.groups, key)
+	}
+	return digests, nil
+}
+
+// take counts one notification against the hourly cap of a workspace.
+func (t *MemoryThrottle) take(workspaceId int, now time.Time) bool {
+	if t.hourlyCap <= 0 {
+		return true
+	}
+	hour := now.Truncate(time.Hour)
+	sent, ok := t.sent[workspaceId]
+	if !ok || !sent.hour.Equal(hour) {
+		sent = &hourlyCount{hour: hour}
+		t.sent[workspaceId] = sent
+	}
+	if sent.count >= t.hourlyCap {
+		return false
+	}
+	sent.count++
+	return true
+}
@@ -0,0 +1,97 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/model"
+)
+
+func newTestThrottle(hourlyCap int) (*MemoryThrottle, *time.Time) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	throttle := NewMemoryThrottle(10*time.Minute, hourlyCap)
+	throttle.now = func() time.Time { return now }
+	return throttle, &now
+}
+
+func TestMemoryThrottle(t *testing.T) {
+	t.Run("Should deliver the first alert of a group and digest the repeats", func(t *testing.T) {
+		throttle, now := newTestThrottle(0)
+		alert := &Alert{WorkspaceId: 7, FlowId: 2, Title: "Flow failed", Report: "timeout"}
+
+		admitted, err := throttle.Admit(alert)
+		require.NoError(t, err)
+		assert.True(t, admitted)
+		for i := 0; i < 133; i++ {
+			admitted, err = throttle.Admit(alert)
+			require.NoError(t, err)
+			assert.False(t, admitted)
+		}
+		// other flows and titles are grouped apart
+		admitted, _ = throttle.Admit(&Alert{WorkspaceId: 7, FlowId: 3, Title: "Flow failed"})
+		assert.True(t, admitted)
+		admitted, _ = throttle.Admit(&Alert{WorkspaceId: 7, FlowId: 2, Title: "Call failed"})
+		assert.True(t, admitted)
+
+		*now = now.Add(9 * time.Minute)
+		digests, err := throttle.Digests()
+		require.NoError(t, err)
+		assert.Empty(t, digests)
+
+		*now = now.Add(time.Minute)
+		digests, err = throttle.Digests()
+		require.NoError(t, err)
+		require.Len(t, digests, 1)
+		assert.Equal(t, 134, digests[0].Count)
+		assert.Equal(t, "This alert happened 134 times in the last 10 minutes.\n\ntimeout", digests[0].Report)
+
+		// the window is over
+		admitted, _ = throttle.Admit(alert)
+		assert.True(t, admitted)
+	})
+
+	t.Run("Should hold alerts over the hourly cap", func(t *testing.T) {
+		throttle, now := newTestThrottle(2)
+		admitted, _ := throttle.Admit(&Alert{WorkspaceId: 7, Title: "a"})
+		assert.True(t, admitted)
+		admitted, _ = throttle.Admit(&Alert{WorkspaceId: 7, Title: "b"})
+		assert.True(t, admitted)
+		admitted, _ = throttle.Admit(&Alert{WorkspaceId: 7, Title: "c"})
+		assert.False(t, admitted)
+		admitted, _ = throttle.Admit(&Alert{WorkspaceId: 8, Title: "c"})
+		assert.True(t, admitted)
+
+		// the digest of c waits for the cap
+		*now = now.Add(10 * time.Minute)
+		digests, _ := throttle.Digests()
+		assert.Empty(t, digests)
+
+		*now = now.Add(50 * time.Minute)
+		digests, _ = throttle.Digests()
+		require.Len(t, digests, 1)
+		assert.Equal(t, "c", digests[0].Title)
+		assert.Equal(t, "This alert happened once in the last hour.", digests[0].Report)
+	})
+}
+
+func TestDispatcherDigests(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	mailer := &captureMailer{}
+	d := newTestDispatcher(t, []model.NotificationChannel{{Id: 1, Type: TypeEmail, MinLevel: LevelInfo, Target: "ops@example.com"}}, mailer)
+	throttle, now := newTestThrottle(0)
+	d.SetThrottle(throttle)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, d.Notify(Alert{WorkspaceId: 7, Level: LevelError, Title: "Flow failed"}))
+	}
+	*now = now.Add(10 * time.Minute)
+	d.Flush()
+	d.Close()
+
+	require.Len(t, mailer.sent, 2)
+	assert.Contains(t, mailer.sent[1].HTML, "This alert happened 3 times in the last 10 minutes.")
+}
@@ -0,0 +1,132 @@
+// Package apikey authenticates the microservices calling the API. Every
+// service has its own keys, each granting scopes such as call:write. Only
+// the SHA-256 hash of a key is stored. A rotation creates a new key while
+// the replaced key stays valid for an overlap, so services can be redeployed
+// with the new key without downtime.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// Scopes of the routes, a key with "*" has every scope and one with
+// "<resource>:*" every scope of the resource.
+const (
+	ScopeAll            = "*"
+	ScopeCallRead       = "call:read"
+	ScopeCallWrite      = "call:write"
+	ScopeBillingWrite   = "billing:write"
+	ScopeDebuggerRead   = "debugger:read"
+	ScopeDebuggerWrite  = "debugger:write"
+	ScopeFaxRead        = "fax:read"
+	ScopeFaxWrite       = "fax:write"
+	ScopeRecordingRead  = "recording:read"
+	ScopeRecordingWrite = "recording:write"
+	ScopeStorageWrite   = "storage:write"
+	ScopeCarrierRead    = "carrier:read"
+	ScopeCarrierWrite   = "carrier:write"
+	ScopeUserRead       = "user:read"
+	ScopeUserWrite      = "user:write"
+	ScopeAdminRead      = "admin:read"
+	ScopeAdminWrite     = "admin:write"
+	ScopeAPIKeyRead     = "apikey:read"
+	ScopeAPIKeyWrite    = "apikey:write"
+)
+
+var Scopes = []string{
+	ScopeCallRead, ScopeCallWrite,
+	ScopeBillingWrite,
+	ScopeDebuggerRead, ScopeDebuggerWrite,
+	ScopeFaxRead, ScopeFaxWrite,
+	ScopeRecordingRead, ScopeRecordingWrite,
+	ScopeStorageWrite,
+	ScopeCarrierRead, ScopeCarrierWrite,
+	ScopeUserRead, ScopeUserWrite,
+	ScopeAdminRead, ScopeAdminWrite,
+	ScopeAPIKeyRead, ScopeAPIKeyWrite,
+}
+
+const (
+	// TokenPrefix starts every key so leaked keys are easy to search for
+	TokenPrefix = "lbk_"
+	// prefixLength is the length of the start of a key kept to identify it
+	prefixLength = len(TokenPrefix) + 8
+)
+
+// DefaultOverlap keeps a rotated key valid for a day.
+const DefaultOverlap = 24 * time.Hour
+
+/*
+Interface of API Key Store.
+Implementation of API Key Store is located /store/apikey
+*/
+type APIKeyStoreInterface interface {
+	CreateAPIKey(key *model.APIKey) (int, error)
+	GetAPIKey(id int) (*model.APIKey, error)
+	GetAPIKeyByHash(hash string) (*model.APIKey, error)
+	ListAPIKeys(service string) ([]model.APIKey, error)
+	ExpireAPIKey(id int, expiresAt time.Time) error
+	RotateAPIKey(key *model.APIKey, replacedId int, expiresAt time.Time) (int, error)
+}
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return TokenPrefix + hex.EncodeToString(secret), nil
+}
+
+// Hash returns the hex SHA-256 of a key, the form keys are stored and looked up in.
+func Hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Prefix returns the start of a key that identifies it in listings and logs.
+func Prefix(token string) string {
+	if len(token) < prefixLength {
+		return ""
+	}
+	return token[:prefixLength]
+}
+
+// Fingerprint returns the start of the hash of a key, it identifies a
+// presented key in logs without revealing any of it.
+func Fingerprint(token string) string {
+	return Hash(token)[:12]
+}
+
+// ValidScope reports whether scope can be granted.
+func ValidScope(scope string) bool {
+	if scope == ScopeAll {
+		return true
+	}
+	for _, known := range Scopes {
+		if scope == known {
+			return true
+		}
+		if resource, _, _ := strings.Cut(known, ":"); scope == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether the granted scopes include required.
+func Allows(granted []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, scope := range granted {
+		if scope == ScopeAll || scope == required || scope == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package apikey
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+)
+
+func TestScopes(t *testing.T) {
+	t.Run("Should only allow granted scopes", func(t *testing.T) {
+		assert.True(t, Allows([]string{ScopeCallWrite, ScopeUserRead}, ScopeUserRead))
+		assert.False(t, Allows([]string{ScopeCallWrite}, ScopeCallRead))
+		assert.True(t, Allows([]string{"call:*"}, ScopeCallRead))
+		assert.False(t, Allows([]string{"call:*"}, ScopeBillingWrite))
+		assert.True(t, Allows([]string{ScopeAll}, ScopeBillingWrite))
+		assert.False(t, Allows(nil, ScopeUserRead))
+	})
+
+	t.Run("Should validate scopes", func(t *testing.T) {
+		assert.True(t, ValidScope(ScopeBillingWrite))
+		assert.True(t, ValidScope("recording:*"))
+		assert.True(t, ValidScope(ScopeAll))
+		assert.False(t, ValidScope("billing:read:extra"))
+		assert.False(t, ValidScope("unknown:*"))
+	})
+
+	t.Run("Should generate distinct keys identified by their prefix", func(t *testing.T) {
+		first, err := Generate()
+		require.NoError(t, err)
+		second, err := Generate()
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+		assert.True(t, strings.HasPrefix(first, TokenPrefix))
+		assert.Len(t, Prefix(first), len(TokenPrefix)+8)
+		assert.True(t, strings.HasPrefix(first, Prefix(first)))
+		assert.Len(t, Hash(first), 64)
+		assert.NotContains(t, Hash(first), first[len(TokenPrefix):])
+		assert.Empty(t, Prefix("short"))
+	})
+}
+
+func TestManager(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	newManager := func(store APIKeyStoreInterface) *Manager {
+		m := NewManager(store)
+		m.now = func() time.Time { return now }
+		return m
+	}
+
+	t.Run("Should authenticate stored keys and cache them", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		key := &model.APIKey{Id: 1, Service: "router", Scopes: []string{ScopeCallWrite}}
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_router")).Return(key, nil).Once()
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_unknown")).Return(nil, sql.ErrNoRows)
+
+		m := newManager(store)
+		for i := 0; i < 2; i++ {
+			authenticated, err := m.Authenticate("lbk_router")
+			require.NoError(t, err)
+			assert.Equal(t, key, authenticated)
+		}
+		_, err := m.Authenticate("lbk_unknown")
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Should read keys again once the cache expires", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_router")).Return(&model.APIKey{Id: 1}, nil).Twice()
+
+		m := newManager(store)
+		_, err := m.Authenticate("lbk_router")
+		require.NoError(t, err)
+		now = now.Add(DefaultCacheTTL)
+		_, err = m.Authenticate("lbk_router")
+		require.NoError(t, err)
+	})
+
+	t.Run("Should reject expired keys", func(t *testing.T) {
+		expiresAt := now
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_old")).Return(&model.APIKey{Id: 1, ExpiresAt: &expiresAt}, nil)
+
+		_, err := newManager(store).Authenticate("lbk_old")
+		assert.ErrorIs(t, err, ErrExpiredKey)
+	})
+
+	t.Run("Should accept the legacy key with every scope", func(t *testing.T) {
+		m := newManager(nil)
+		m.SetLegacyToken("shared")
+		key, err := m.Authenticate("shared")
+		require.NoError(t, err)
+		assert.Equal(t, LegacyService, key.Service)
+		assert.True(t, Allows(key.Scopes, ScopeBillingWrite))
+
+		_, err = m.Authenticate("other")
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("Should only store the hash of created keys", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		var stored *model.APIKey
+		store.EXPECT().CreateAPIKey(mock.Anything).RunAndReturn(func(key *model.APIKey) (int, error) {
+			copied := *key
+			stored = &copied
+			return 9, nil
+		})
+
+		key, err := newManager(store).Create("billing", []string{ScopeBillingWrite}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 9, key.Id)
+		assert.Empty(t, stored.Token)
+		assert.Equal(t, Hash(key.Token), stored.Hash)
+		assert.Equal(t, Prefix(key.Token), stored.Prefix)
+		assert.Equal(t, []string{ScopeBillingWrite}, stored.Scopes)
+	})
+
+	t.Run("Should keep rotated keys valid for the overlap", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		old := &model.APIKey{Id: 1, Service: "router", Hash: Hash("lbk_old"), Scopes: []string{ScopeCallWrite}}
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_old")).Return(old, nil).Once()
+		store.EXPECT().GetAPIKey(1).Return(old, nil)
+		store.EXPECT().RotateAPIKey(mock.MatchedBy(func(key *model.APIKey) bool {
+			return key.Service == "router" && key.ExpiresAt == nil
+		}), 1, now.Add(time.Hour)).Return(2, nil)
+
+		m := newManager(store)
+		_, err := m.Authenticate("lbk_old")
+		require.NoError(t, err)
+		key, err := m.Rotate(1, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 2, key.Id)
+		assert.Equal(t, []string{ScopeCallWrite}, key.Scopes)
+
+		// the replaced key is read again with its expiry
+		expiresAt := now.Add(time.Hour)
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_old")).Return(&model.APIKey{Id: 1, ExpiresAt: &expiresAt}, nil)
+		_, err = m.Authenticate("lbk_old")
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+		_, err = m.Authenticate("lbk_old")
+		assert.ErrorIs(t, err, ErrExpiredKey)
+	})
+
+	t.Run("Should not extend keys expiring within the overlap", func(t *testing.T) {
+		expiresAt := now.Add(time.Minute)
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKey(1).Return(&model.APIKey{Id: 1, Service: "router", ExpiresAt: &expiresAt}, nil)
+		store.EXPECT().RotateAPIKey(mock.Anything, 1, expiresAt).Return(2, nil)
+
+		_, err := newManager(store).Rotate(1, DefaultOverlap)
+		require.NoError(t, err)
+	})
+
+	t.Run("Should keep the replaced key when the rotation fails", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKey(1).Return(&model.APIKey{Id: 1, Service: "router"}, nil)
+		store.EXPECT().RotateAPIKey(mock.Anything, 1, now.Add(DefaultOverlap)).Return(0, errors.New("db down"))
+
+		_, err := newManager(store).Rotate(1, DefaultOverlap)
+		assert.Error(t, err)
+	})
+
+	t.Run("Should look unknown keys up again after a while", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_unknown")).Return(nil, sql.ErrNoRows).Once()
+
+		m := newManager(store)
+		for i := 0; i < 3; i++ {
+			_, err := m.Authenticate("lbk_unknown")
+			assert.ErrorIs(t, err, ErrInvalidKey)
+		}
+
+		// a key created meanwhile is accepted once the rejection expired
+		store.EXPECT().GetAPIKeyByHash(Hash("lbk_unknown")).Return(&model.APIKey{Id: 3}, nil).Once()
+		now = now.Add(DefaultInvalidTTL)
+		key, err := m.Authenticate("lbk_unknown")
+		require.NoError(t, err)
+		assert.Equal(t, 3, key.Id)
+	})
+
+	t.Run("Should revoke keys right away", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKey(1).Return(&model.APIKey{Id: 1}, nil)
+		store.EXPECT().ExpireAPIKey(1, now).Return(nil)
+
+		require.NoError(t, newManager(store).Revoke(1))
+	})
+}
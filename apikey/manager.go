@@ -0,0 +1,232 @@
+package apikey
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// DefaultCacheTTL is how long a key is used without being read again, a
+// revoked key can still be used for that long on other instances.
+const DefaultCacheTTL = 30 * time.Second
+
+const (
+	// DefaultInvalidTTL is how long an unknown key is rejected without being
+	// looked up again, so retried or guessed keys do not each hit the database
+	DefaultInvalidTTL = 5 * time.Second
+	// maxInvalid bounds the unknown keys remembered, the oldest are dropped first
+	maxInvalid = 10000
+)
+
+// LegacyService names the key of LINEBLOCS_KEY.
+const LegacyService = "legacy"
+
+var (
+	ErrInvalidKey = errors.New("API key is invalid")
+	ErrExpiredKey = errors.New("API key has expired")
+)
+
+type cachedKey struct {
+	key      *model.APIKey
+	cachedAt time.Time
+}
+
+// Manager authenticates API keys and creates, rotates and revokes them.
+// Keys are cached for DefaultCacheTTL after they are read, unknown keys for
+// DefaultInvalidTTL.
+type Manager struct {
+	store      APIKeyStoreInterface
+	legacy     string
+	ttl        time.Duration
+	invalidTTL time.Duration
+	now        func() time.Time
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey
+	invalid map[string]time.Time
+}
+
+// NewManager authenticates the keys of store, without a store only the
+// legacy key is accepted.
+func NewManager(store APIKeyStoreInterface) *Manager {
+	return &Manager{
+		store:      store,
+		ttl:        DefaultCacheTTL,
+		invalidTTL: DefaultInvalidTTL,
+		now:        time.Now,
+		cache:      map[string]cachedKey{},
+		invalid:    map[string]time.Time{},
+	}
+}
+
+// SetLegacyToken accepts the single shared key used before per-service keys
+// with every scope, until the services are moved to their own keys.
+func (m *Manager) SetLegacyToken(token string) {
+	m.legacy = ""
+	if token != "" {
+		m.legacy = Hash(token)
+	}
+}
+
+// Authenticate returns the key of token, ErrInvalidKey for unknown keys
+// and ErrExpiredKey for expired ones.
+func (m *Manager) Authenticate(token string) (*model.APIKey, error) {
+	hash := Hash(token)
+	if m.legacy != "" && subtle.ConstantTimeCompare([]byte(hash), []byte(m.legacy)) == 1 {
+		return &model.APIKey{Service: LegacyService, Scopes: []string{ScopeAll}}, nil
+	}
+	key, err := m.lookup(hash)
+	if err != nil {
+		return nil, err
+	}
+	if key.ExpiresAt != nil && !m.now().Before(*key.ExpiresAt) {
+		return nil, ErrExpiredKey
+	}
+	return key, nil
+}
+
+func (m *Manager) lookup(hash string) (*model.APIKey, error) {
+	now := m.now()
+	m.mu.Lock()
+	cached, ok := m.cache[hash]
+	rejectedAt, rejected := m.invalid[hash]
+	m.mu.Unlock()
+	if ok && now.Sub(cached.cachedAt) < m.ttl {
+		return cached.key, nil
+	}
+	if rejected && now.Sub(rejectedAt) < m.invalidTTL {
+		return nil, ErrInvalidKey
+	}
+	if m.store == nil {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := m.store.GetAPIKeyByHash(hash)
+	if err == sql.ErrNoRows {
+		m.reject(hash, now)
+		return nil, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	m.mu.Lock()
+	m.cache[hash] = cachedKey{key: key, cachedAt: now}
+	delete(m.invalid, hash)
+	m.mu.Unlock()
+	return key, nil
+}
+
+// reject remembers an unknown key, expired entries make room first.
+func (m *Manager) reject(hash string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.invalid) >= maxInvalid {
+		for rejected, rejectedAt := range m.invalid {
+			if now.Sub(rejectedAt) >= m.invalidTTL {
+				delete(m.invalid, rejected)
+			}
+		}
+	}
+	if len(m.invalid) >= maxInvalid {
+		// every entry is recent, they are looked up again instead
+		m.invalid = map[string]time.Time{}
+	}
+	m.invalid[hash] = now
+}
+
+// Get returns key id, sql.ErrNoRows for unknown keys.
+func (m *Manager) Get(id int) (*model.APIKey, error) {
+	return m.store.GetAPIKey(id)
+}
+
+// List returns the keys of service, of every service when it is empty.
+func (m *Manager) List(service string) ([]model.APIKey, error) {
+	return m.store.ListAPIKeys(service)
+}
+
+// Create stores a new key of service, the key is only returned in its Token.
+func (m *Manager) Create(service string, scopes []string, expiresAt *time.Time) (*model.APIKey, error) {
+	key, token, err := m.newKey(service, scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Id, err = m.store.CreateAPIKey(key)
+	if err != nil {
+		return nil, err
+	}
+	key.Token = token
+	return key, nil
+}
+
+// newKey generates a key of service and returns it with its token.
+func (m *Manager) newKey(service string, scopes []string, expiresAt *time.Time) (*model.APIKey, string, error) {
+	token, err := Generate()
+	if err != nil {
+		return nil, "", err
+	}
+	return &model.APIKey{
+		Service:   service,
+		Prefix:    Prefix(token),
+		Hash:      Hash(token),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: m.now().UTC(),
+	}, token, nil
+}
+
+// Rotate creates a key with the service and scopes of key id and expires
+// key id after overlap, both keys are valid in between. The new key does
+// not expire. Both changes are stored together, a failed rotation leaves
+// key id as it was.
+func (m *Manager) Rotate(id int, overlap time.Duration) (*model.APIKey, error) {
+	old, err := m.store.GetAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := m.now().Add(overlap).UTC()
+	if old.ExpiresAt != nil && !expiresAt.Before(*old.ExpiresAt) {
+		if !m.now().Before(*old.ExpiresAt) {
+			return nil, ErrExpiredKey
+		}
+		expiresAt = *old.ExpiresAt
+	}
+	key, token, err := m.newKey(old.Service, old.Scopes, nil)
+	if err != nil {
+		return nil, err
+	}
+	key.Id, err = m.store.RotateAPIKey(key, old.Id, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	m.forget(old)
+	key.Token = token
+	return key, nil
+}
+
+// Revoke expires key id now.
+func (m *Manager) Revoke(id int) error {
+	key, err := m.store.GetAPIKey(id)
+	if err != nil {
+		return err
+	}
+	return m.expire(key, m.now().UTC())
+}
+
+func (m *Manager) expire(key *model.APIKey, expiresAt time.Time) error {
+	if err := m.store.ExpireAPIKey(key.Id, expiresAt); err != nil {
+		return err
+	}
+	m.forget(key)
+	return nil
+}
+
+// forget drops key from the cache so its new expiry is read.
+func (m *Manager) forget(key *model.APIKey) {
+	m.mu.Lock()
+	delete(m.cache, key.Hash)
+	m.mu.Unlock()
+}
@@ -0,0 +1,166 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	// DefaultThreshold is the loudness in dBFS a frame must reach to count as sound
+	DefaultThreshold = -40.0
+	// DefaultFrame is the length in milliseconds of the frames the energy is measured over
+	DefaultFrame = 20
+	// DefaultPadding is the silence in milliseconds kept before and after the sound
+	DefaultPadding = 100
+)
+
+// TrimOptions tune the silence detection, zero values take the defaults.
+type TrimOptions struct {
+	Threshold float64
+	Frame     int
+	Padding   int
+}
+
+func (o TrimOptions) withDefaults() TrimOptions {
+	if o.Threshold == 0 {
+		o.Threshold = DefaultThreshold
+	}
+	if o.Frame <= 0 {
+		o.Frame = DefaultFrame
+	}
+	if o.Padding < 0 {
+		o.Padding = 0
+	} else if o.Padding == 0 {
+		o.Padding = DefaultPadding
+	}
+	return o
+}
+
+// FindSound measures the RMS energy of the audio frame by frame and returns
+// the byte range of the samples, relative to the start of the data, from the
+// first to the last frame above the threshold with padding on both sides.
+// start equals end when the recording holds nothing but silence.
+func FindSound(r io.ReaderAt, info *Info, opts TrimOptions) (start int64, end int64, err error) {
+	decode := sampleDecoder(info)
+	if decode == nil || info.Channels < 1 || info.BlockAlign != info.Channels*info.BitsPerSample/8 {
+		return 0, 0, ErrUnsupportedFormat
+	}
+	opts = opts.withDefaults()
+
+	frameBlocks := max(1, info.SampleRate*opts.Frame/1000)
+	frameSize := int64(frameBlocks * info.BlockAlign)
+	threshold := math.Pow(10, opts.Threshold/20)
+	sampleSize := info.BlockAlign / info.Channels
+
+	reader := bufio.NewReaderSize(io.NewSectionReader(r, info.DataOffset, info.DataSize), int(frameSize))
+	frame := make([]byte, frameSize)
+	first, last := int64(-1), int64(-1)
+	for offset := int64(0); offset < info.DataSize; offset += frameSize {
+		n, err := io.ReadFull(reader, frame)
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+		n -= n % info.BlockAlign
+
+		var sum float64
+		for i := 0; i+sampleSize <= n; i += sampleSize {
+			sample := decode(frame[i : i+sampleSize])
+			sum += sample * sample
+		}
+		samples := n / sampleSize
+		if samples > 0 && math.Sqrt(sum/float64(samples)) >= threshold {
+			if first < 0 {
+				first = offset
+			}
+			last = offset + int64(n)
+		}
+	}
+	if first < 0 {
+		return 0, 0, nil
+	}
+
+	padding := int64(info.SampleRate*opts.Padding/1000) * int64(info.BlockAlign)
+	return max(0, first-padding), min(info.DataSize, last+padding), nil
+}
+
+// Trim returns the WAV file limited to the samples between start and end,
+// as found by FindSound, along with its size and audio info. Chunks that
+// came after the samples in the original file are dropped.
+func Trim(r io.ReaderAt, info *Info, start int64, end int64) (io.Reader, int64, *Info, error) {
+	header := make([]byte, info.DataOffset)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, 0, nil, err
+	}
+	length := end - start
+	pad := length % 2
+	binary.LittleEndian.PutUint32(header[4:8], uint32(info.DataOffset-8+length+pad))
+	binary.LittleEndian.PutUint32(header[info.DataOffset-4:], uint32(length))
+
+	parts := []io.Reader{bytes.NewReader(header), io.NewSectionReader(r, info.DataOffset+start, length)}
+	if pad > 0 {
+		parts = append(parts, bytes.NewReader([]byte{0}))
+	}
+
+	trimmed := *info
+	trimmed.DataSize = length
+	trimmed.Duration = float64(length) / float64(info.BlockAlign*info.SampleRate)
+	return io.MultiReader(parts...), info.DataOffset + length + pad, &trimmed, nil
+}
+
+// sampleDecoder returns a function decoding one sample to the range -1 to 1,
+// or nil when the format can not be measured.
+func sampleDecoder(info *Info) func([]byte) float64 {
+	switch {
+	case info.FormatTag == formatPCM && info.BitsPerSample == 8:
+		return func(b []byte) float64 { return (float64(b[0]) - 128) / 128 }
+	case info.FormatTag == formatPCM && info.BitsPerSample == 16:
+		return func(b []byte) float64 { return float64(int16(binary.LittleEndian.Uint16(b))) / 32768 }
+	case info.FormatTag == formatPCM && info.BitsPerSample == 24:
+		return func(b []byte) float64 {
+			return float64(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / 8388608
+		}
+	case info.FormatTag == formatPCM && info.BitsPerSample == 32:
+		return func(b []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648 }
+	case info.FormatTag == formatFloat && info.BitsPerSample == 32:
+		return func(b []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(b))) }
+	case info.FormatTag == formatMuLaw && info.BitsPerSample == 8:
+		return func(b []byte) float64 { return float64(muLaw(b[0])) / 32768 }
+	case info.FormatTag == formatALaw && info.BitsPerSample == 8:
+		return func(b []byte) float64 { return float64(aLaw(b[0])) / 32768 }
+	}
+	return nil
+}
+
+// muLaw expands a G.711 μ-law sample to 16 bit linear.
+func muLaw(u byte) int16 {
+	u = ^u
+	magnitude := ((int16(u&0x0F) << 3) + 0x84) << ((u & 0x70) >> 4)
+	if u&0x80 != 0 {
+		return 0x84 - magnitude
+	}
+	return magnitude - 0x84
+}
+
+// aLaw expands a G.711 A-law sample to 16 bit linear.
+func aLaw(a byte) int16 {
+	a ^= 0x55
+	magnitude := int16(a&0x0F) << 4
+	switch segment := (a & 0x70) >> 4; segment {
+	case 0:
+		magnitude += 8
+	case 1:
+		magnitude += 0x108
+	default:
+		magnitude = (magnitude + 0x108) << (segment - 1)
+	}
+	if a&0x80 != 0 {
+		return magnitude
+	}
+	return -magnitude
+}
@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tone returns 16 bit mono samples of ms milliseconds, silent when amplitude is 0.
+func tone(sampleRate int, ms int, amplitude float64) []byte {
+	samples := make([]byte, sampleRate*ms/1000*2)
+	for i := 0; i < len(samples)/2; i++ {
+		value := amplitude * math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate))
+		binary.LittleEndian.PutUint16(samples[i*2:], uint16(int16(value*32767)))
+	}
+	return samples
+}
+
+func TestFindSound(t *testing.T) {
+	t.Run("Should find the sound between the silences with padding", func(t *testing.T) {
+		samples := append(append(tone(8000, 1000, 0), tone(8000, 500, 0.5)...), tone(8000, 2000, 0)...)
+		file := wav(formatPCM, 8000, 1, 16, samples)
+		info, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.NoError(t, err)
+
+		start, end, err := FindSound(bytes.NewReader(file), info, TrimOptions{})
+		assert.NoError(t, err)
+		// 900ms and 1600ms of 16 bit samples at 8kHz
+		assert.Equal(t, int64(14400), start)
+		assert.Equal(t, int64(25600), end)
+
+		trimmed, size, trimmedInfo, err := Trim(bytes.NewReader(file), info, start, end)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(trimmed)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(data)), size)
+		assert.InDelta(t, 0.7, trimmedInfo.Duration, 0.001)
+
+		reparsed, err := ParseWAV(bytes.NewReader(data), size)
+		assert.NoError(t, err)
+		assert.Equal(t, trimmedInfo.DataSize, reparsed.DataSize)
+		assert.Equal(t, samples[start:end], data[reparsed.DataOffset:])
+	})
+
+	t.Run("Should report a silent recording", func(t *testing.T) {
+		file := wav(formatPCM, 8000, 1, 16, tone(8000, 1000, 0.001))
+		info, _ := ParseWAV(bytes.NewReader(file), int64(len(file)))
+
+		start, end, err := FindSound(bytes.NewReader(file), info, TrimOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, start, end)
+	})
+
+	t.Run("Should measure G.711 audio", func(t *testing.T) {
+		// 0xFF is the μ-law code for zero, 0x80 its loudest positive sample
+		samples := append(bytes.Repeat([]byte{0xFF}, 8000), bytes.Repeat([]byte{0x80}, 800)...)
+		file := wav(formatMuLaw, 8000, 1, 8, samples)
+		info, _ := ParseWAV(bytes.NewReader(file), int64(len(file)))
+
+		start, end, err := FindSound(bytes.NewReader(file), info, TrimOptions{Padding: -1})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8000), start)
+		assert.Equal(t, int64(8800), end)
+
+		assert.Equal(t, int16(0), muLaw(0xFF))
+		assert.Equal(t, int16(32124), muLaw(0x80))
+		assert.Equal(t, int16(8), aLaw(0xD5))
+		assert.Equal(t, int16(-32256), aLaw(0x2A))
+	})
+
+	t.Run("Should refuse formats it can not measure", func(t *testing.T) {
+		file := wav(0x0011, 8000, 1, 8, make([]byte, 100))
+		info, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.NoError(t, err)
+
+		_, _, err = FindSound(bytes.NewReader(file), info, TrimOptions{})
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("Should refuse blocks that do not hold a sample per channel", func(t *testing.T) {
+		info := &Info{FormatTag: formatPCM, SampleRate: 8000, Channels: 2, BitsPerSample: 16, BlockAlign: 1, DataSize: 100}
+
+		_, _, err := FindSound(bytes.NewReader(make([]byte, 100)), info, TrimOptions{})
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+}
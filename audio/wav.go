@@ -0,0 +1,136 @@
+// Package audio reads the metadata of WAV recordings and trims the silence
+// around them. Files are read through io.ReaderAt so long recordings are
+// never loaded into memory.
+package audio
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// WAVE format tags.
+const (
+	formatPCM        = 0x0001
+	formatFloat      = 0x0003
+	formatALaw       = 0x0006
+	formatMuLaw      = 0x0007
+	formatExtensible = 0xFFFE
+)
+
+var (
+	ErrNotWAV            = errors.New("not a WAV file")
+	ErrUnsupportedFormat = errors.New("unsupported WAV format")
+)
+
+// Info describes the audio of a WAV file. DataOffset and DataSize locate the
+// samples in the file, Duration is in seconds.
+type Info struct {
+	Codec         string
+	FormatTag     uint16
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+	BlockAlign    int
+	DataOffset    int64
+	DataSize      int64
+	Duration      float64
+}
+
+// ParseWAV reads the header of the WAV file of size bytes in r.
+func ParseWAV(r io.ReaderAt, size int64) (*Info, error) {
+	header := make([]byte, 12)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, ErrNotWAV
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, ErrNotWAV
+	}
+
+	var info *Info
+	offset := int64(12)
+	chunk := make([]byte, 8)
+	for offset+8 <= size {
+		if _, err := r.ReadAt(chunk, offset); err != nil {
+			return nil, fmt.Errorf("%w: truncated chunk at %d", ErrNotWAV, offset)
+		}
+		id := string(chunk[0:4])
+		length := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "fmt ":
+			if length < 16 {
+				return nil, fmt.Errorf("%w: fmt chunk too short", ErrNotWAV)
+			}
+			format := make([]byte, min(length, 40))
+			if _, err := r.ReadAt(format, body); err != nil {
+				return nil, fmt.Errorf("%w: truncated fmt chunk", ErrNotWAV)
+			}
+			parsed, err := parseFormat(format)
+			if err != nil {
+				return nil, err
+			}
+			info = parsed
+		case "data":
+			if info == nil {
+				return nil, fmt.Errorf("%w: data before fmt chunk", ErrNotWAV)
+			}
+			// streaming writers leave the size at 0 or the maximum until the file is closed
+			if length == 0 || body+length > size {
+				length = size - body
+			}
+			info.DataOffset = body
+			info.DataSize = length - length%int64(info.BlockAlign)
+			info.Duration = float64(info.DataSize) / float64(info.BlockAlign*info.SampleRate)
+			return info, nil
+		}
+		offset = body + length + length%2
+	}
+	return nil, fmt.Errorf("%w: no data chunk", ErrNotWAV)
+}
+
+func parseFormat(format []byte) (*Info, error) {
+	info := &Info{
+		FormatTag:     binary.LittleEndian.Uint16(format[0:2]),
+		Channels:      int(binary.LittleEndian.Uint16(format[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(format[4:8])),
+		BlockAlign:    int(binary.LittleEndian.Uint16(format[12:14])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(format[14:16])),
+	}
+	// the real format of WAVE_FORMAT_EXTENSIBLE starts its sub format GUID
+	if info.FormatTag == formatExtensible && len(format) >= 26 {
+		info.FormatTag = binary.LittleEndian.Uint16(format[24:26])
+	}
+	// the samples are read block by block, a block that does not hold one
+	// sample of every channel can not be measured or cut
+	if info.Channels < 1 {
+		return nil, fmt.Errorf("%w: no channels", ErrNotWAV)
+	}
+	if info.BlockAlign < 1 || info.BlockAlign != info.Channels*info.BitsPerSample/8 {
+		return nil, fmt.Errorf("%w: block align %d does not match %d channels of %d bits", ErrNotWAV, info.BlockAlign, info.Channels, info.BitsPerSample)
+	}
+	if info.SampleRate < 1 {
+		info.SampleRate = 8000
+	}
+	info.Codec = codecName(info.FormatTag, info.BitsPerSample)
+	return info, nil
+}
+
+func codecName(formatTag uint16, bits int) string {
+	switch formatTag {
+	case formatPCM:
+		if bits == 8 {
+			return "pcm_u8"
+		}
+		return fmt.Sprintf("pcm_s%dle", bits)
+	case formatFloat:
+		return fmt.Sprintf("pcm_f%dle", bits)
+	case formatALaw:
+		return "pcm_alaw"
+	case formatMuLaw:
+		return "pcm_mulaw"
+	}
+	return fmt.Sprintf("wav_0x%04x", formatTag)
+}
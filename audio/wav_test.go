@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// wav builds a WAV file around samples, extra chunks go between fmt and data.
+func wav(formatTag uint16, sampleRate int, channels int, bits int, samples []byte, extra ...[]byte) []byte {
+	format := make([]byte, 16)
+	blockAlign := channels * bits / 8
+	binary.LittleEndian.PutUint16(format[0:2], formatTag)
+	binary.LittleEndian.PutUint16(format[2:4], uint16(channels))
+	binary.LittleEndian.PutUint32(format[4:8], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(format[8:12], uint32(sampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(format[12:14], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(format[14:16], uint16(bits))
+
+	body := &bytes.Buffer{}
+	body.WriteString("WAVE")
+	writeChunk(body, "fmt ", format)
+	for _, chunk := range extra {
+		writeChunk(body, "LIST", chunk)
+	}
+	writeChunk(body, "data", samples)
+
+	file := &bytes.Buffer{}
+	file.WriteString("RIFF")
+	binary.Write(file, binary.LittleEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func writeChunk(buf *bytes.Buffer, id string, data []byte) {
+	buf.WriteString(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if len(data)%2 == 1 {
+		buf.WriteByte(0)
+	}
+}
+
+func TestParseWAV(t *testing.T) {
+	t.Run("Should read the format and duration of PCM audio", func(t *testing.T) {
+		file := wav(formatPCM, 8000, 1, 16, make([]byte, 8000*2*3))
+
+		info, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.NoError(t, err)
+		assert.Equal(t, "pcm_s16le", info.Codec)
+		assert.Equal(t, 8000, info.SampleRate)
+		assert.Equal(t, 1, info.Channels)
+		assert.Equal(t, int64(44), info.DataOffset)
+		assert.Equal(t, int64(48000), info.DataSize)
+		assert.Equal(t, 3.0, info.Duration)
+	})
+
+	t.Run("Should skip chunks before the data and read G.711 audio", func(t *testing.T) {
+		file := wav(formatMuLaw, 8000, 2, 8, make([]byte, 8000), []byte("INFOsome tag"), []byte("odd"))
+
+		info, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.NoError(t, err)
+		assert.Equal(t, "pcm_mulaw", info.Codec)
+		assert.Equal(t, 2, info.Channels)
+		assert.Equal(t, 0.5, info.Duration)
+	})
+
+	t.Run("Should use the file size when the data size was never written", func(t *testing.T) {
+		file := wav(formatALaw, 8000, 1, 8, make([]byte, 4000))
+		binary.LittleEndian.PutUint32(file[40:44], 0)
+
+		info, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.NoError(t, err)
+		assert.Equal(t, "pcm_alaw", info.Codec)
+		assert.Equal(t, int64(4000), info.DataSize)
+	})
+
+	t.Run("Should reject files that are not WAV", func(t *testing.T) {
+		_, err := ParseWAV(bytes.NewReader([]byte("ID3 mp3 audio")), 13)
+		assert.ErrorIs(t, err, ErrNotWAV)
+
+		file := wav(formatPCM, 8000, 1, 16, nil)[:36]
+		_, err = ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrNotWAV)
+	})
+
+	t.Run("Should reject a format without channels or with a wrong block align", func(t *testing.T) {
+		file := wav(formatPCM, 8000, 2, 16, make([]byte, 16))
+		binary.LittleEndian.PutUint16(file[22:24], 0)
+		_, err := ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrNotWAV)
+
+		file = wav(formatPCM, 8000, 2, 16, make([]byte, 16))
+		binary.LittleEndian.PutUint16(file[32:34], 3)
+		_, err = ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrNotWAV)
+
+		file = wav(formatPCM, 8000, 2, 0, make([]byte, 16))
+		_, err = ParseWAV(bytes.NewReader(file), int64(len(file)))
+		assert.ErrorIs(t, err, ErrNotWAV)
+	})
+}
@@ -1,6 +1,9 @@
 package call
 
-import "lineblocs.com/api/model"
+import (
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/model"
+)
 
 /*
 Interface of Call Store.
@@ -13,12 +16,20 @@ type CallStoreInterface interface {
 	SetSIPCallID(string, string) error
 	SetProviderByIP(string, string) error
 	CreateConference(*model.Conference) (string, error)
-	ProcessUsersFirstCall(call model.Call)
+	GetConference(conferenceId int) (*model.Conference, error)
+	AddConferenceParticipant(participant *model.ConferenceParticipant) error
+	RemoveConferenceParticipant(callId int) (*model.ConferenceParticipant, error)
+	UpdateConferenceParticipant(update *model.ConferenceParticipantUpdate) (*model.ConferenceParticipant, error)
+	EndConference(conferenceId int) ([]model.ConferenceParticipant, error)
+	ProcessUsersFirstCall(call model.Call, mailer mail.Mailer)
 	GetWorkspaceFromDB(int) (*model.Workspace, error)
 	GetWorkspaceByDomain(string) (*model.Workspace, error)
 	GetUserFromDB(id int) (*model.User, error)
 	GetCallBySIPCallId(sipCallId string) (*model.Call, error)
+	GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error)
+	QueueCallActivity(eventType string, call *model.Call) error
 	IsUserAllowedToMakeCall(workspaceId int) (bool, error)
+	GetConcurrentCallLimits(workspaceId int, extension string, trunkId int) (*model.CallLimits, error)
 	IsCallerIdPermitted(workspaceId int, callerId string, toNumber string) (bool, error)
 	LookupBestCallRate(from string, to string, callDirection string) (*model.CallRate)
 }
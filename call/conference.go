@@ -0,0 +1,55 @@
+package call
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+/*
+Conference lifecycle.
+
+A conference is CREATED once and starts (IN_PROGRESS) when its first
+participant joins. It ends when the last participant leaves or when it is
+ended explicitly; a later join starts it again with new start and end times.
+*/
+const (
+	ConferenceCreated    = "CREATED"
+	ConferenceInProgress = "IN_PROGRESS"
+	ConferenceEnded      = "ENDED"
+
+	ParticipantJoined = "JOINED"
+	ParticipantLeft   = "LEFT"
+)
+
+var (
+	ErrConferenceFull      = errors.New("conference has reached its participant limit")
+	ErrInvalidPIN          = errors.New("invalid conference PIN")
+	ErrAlreadyInConference = errors.New("call is already in a conference")
+	ErrParticipantNotFound = errors.New("call is not in a conference")
+)
+
+// CheckConferencePIN validates the PIN a caller entered. Entering the
+// moderator PIN makes the caller a moderator; conferences without a PIN
+// accept anyone.
+func CheckConferencePIN(pin string, moderatorPin string, entered string) (moderator bool, err error) {
+	if moderatorPin != "" && pinEquals(moderatorPin, entered) {
+		return true, nil
+	}
+	if pin == "" || pinEquals(pin, entered) {
+		return false, nil
+	}
+	return false, ErrInvalidPIN
+}
+
+// CheckModeratorPIN validates the PIN entered to change the moderator flag
+// of a participant. Conferences without a moderator PIN have no moderators.
+func CheckModeratorPIN(moderatorPin string, entered string) error {
+	if moderatorPin == "" || !pinEquals(moderatorPin, entered) {
+		return ErrInvalidPIN
+	}
+	return nil
+}
+
+func pinEquals(pin string, entered string) bool {
+	return subtle.ConstantTimeCompare([]byte(pin), []byte(entered)) == 1
+}
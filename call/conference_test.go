@@ -0,0 +1,54 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckConferencePIN(t *testing.T) {
+	t.Run("Should accept anyone without a PIN", func(t *testing.T) {
+		moderator, err := CheckConferencePIN("", "", "")
+		assert.NoError(t, err)
+		assert.False(t, moderator)
+	})
+
+	t.Run("Should reject a wrong PIN", func(t *testing.T) {
+		_, err := CheckConferencePIN("1234", "", "4321")
+		assert.ErrorIs(t, err, ErrInvalidPIN)
+
+		_, err = CheckConferencePIN("1234", "9999", "")
+		assert.ErrorIs(t, err, ErrInvalidPIN)
+	})
+
+	t.Run("Should grant moderator with the moderator PIN", func(t *testing.T) {
+		moderator, err := CheckConferencePIN("1234", "9999", "9999")
+		assert.NoError(t, err)
+		assert.True(t, moderator)
+
+		moderator, err = CheckConferencePIN("1234", "9999", "1234")
+		assert.NoError(t, err)
+		assert.False(t, moderator)
+	})
+
+	t.Run("Should grant moderator on an open conference", func(t *testing.T) {
+		moderator, err := CheckConferencePIN("", "9999", "9999")
+		assert.NoError(t, err)
+		assert.True(t, moderator)
+	})
+}
+
+func TestCheckModeratorPIN(t *testing.T) {
+	t.Run("Should accept the moderator PIN", func(t *testing.T) {
+		assert.NoError(t, CheckModeratorPIN("9999", "9999"))
+	})
+
+	t.Run("Should reject a missing or wrong PIN", func(t *testing.T) {
+		assert.ErrorIs(t, CheckModeratorPIN("9999", ""), ErrInvalidPIN)
+		assert.ErrorIs(t, CheckModeratorPIN("9999", "1234"), ErrInvalidPIN)
+	})
+
+	t.Run("Should reject every PIN without a moderator PIN", func(t *testing.T) {
+		assert.ErrorIs(t, CheckModeratorPIN("", ""), ErrInvalidPIN)
+	})
+}
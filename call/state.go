@@ -0,0 +1,77 @@
+package call
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+Call lifecycle state machine.
+
+	INITIATED -> RINGING -> ANSWERED -> ENDED / FAILED
+	INITIATED / RINGING -> ENDED / FAILED / BUSY / NO_ANSWER
+
+ENDED, FAILED, BUSY and NO_ANSWER are terminal.
+*/
+const (
+	StatusInitiated = "INITIATED"
+	StatusRinging   = "RINGING"
+	StatusAnswered  = "ANSWERED"
+	StatusEnded     = "ENDED"
+	StatusFailed    = "FAILED"
+	StatusBusy      = "BUSY"
+	StatusNoAnswer  = "NO_ANSWER"
+)
+
+var (
+	ErrUnknownStatus     = errors.New("unknown call status")
+	ErrInvalidTransition = errors.New("invalid call status transition")
+)
+
+var transitions = map[string][]string{
+	StatusInitiated: {StatusRinging, StatusAnswered, StatusEnded, StatusFailed, StatusBusy, StatusNoAnswer},
+	StatusRinging:   {StatusAnswered, StatusEnded, StatusFailed, StatusBusy, StatusNoAnswer},
+	StatusAnswered:  {StatusEnded, StatusFailed},
+	StatusEnded:     {},
+	StatusFailed:    {},
+	StatusBusy:      {},
+	StatusNoAnswer:  {},
+}
+
+// NormalizeStatus upper-cases a status received from a client.
+func NormalizeStatus(status string) string {
+	return strings.ToUpper(strings.TrimSpace(status))
+}
+
+// IsKnownStatus reports whether status is part of the call lifecycle.
+func IsKnownStatus(status string) bool {
+	_, ok := transitions[status]
+	return ok
+}
+
+// IsTerminalStatus reports whether no further transitions are allowed from status.
+func IsTerminalStatus(status string) bool {
+	next, ok := transitions[status]
+	return ok && len(next) == 0
+}
+
+// ValidateTransition checks that a call may move from one status to another.
+// Rows created before the state machine existed may hold a status outside
+// the lifecycle; those are treated as INITIATED.
+func ValidateTransition(from string, to string) error {
+	from = NormalizeStatus(from)
+	to = NormalizeStatus(to)
+	if !IsKnownStatus(to) {
+		return ErrUnknownStatus
+	}
+	if !IsKnownStatus(from) {
+		from = StatusInitiated
+	}
+
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return ErrInvalidTransition
+}
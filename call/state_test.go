@@ -0,0 +1,44 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTransition(t *testing.T) {
+	t.Run("Should allow the normal lifecycle", func(t *testing.T) {
+		assert.NoError(t, ValidateTransition(StatusInitiated, StatusRinging))
+		assert.NoError(t, ValidateTransition(StatusRinging, StatusAnswered))
+		assert.NoError(t, ValidateTransition(StatusAnswered, StatusEnded))
+	})
+
+	t.Run("Should allow unanswered outcomes before answer", func(t *testing.T) {
+		assert.NoError(t, ValidateTransition(StatusRinging, StatusBusy))
+		assert.NoError(t, ValidateTransition(StatusRinging, StatusNoAnswer))
+		assert.NoError(t, ValidateTransition(StatusInitiated, StatusFailed))
+	})
+
+	t.Run("Should reject transitions out of terminal states", func(t *testing.T) {
+		assert.ErrorIs(t, ValidateTransition(StatusEnded, StatusAnswered), ErrInvalidTransition)
+		assert.ErrorIs(t, ValidateTransition(StatusEnded, StatusEnded), ErrInvalidTransition)
+		assert.ErrorIs(t, ValidateTransition(StatusAnswered, StatusRinging), ErrInvalidTransition)
+		assert.ErrorIs(t, ValidateTransition(StatusAnswered, StatusBusy), ErrInvalidTransition)
+	})
+
+	t.Run("Should reject unknown target statuses", func(t *testing.T) {
+		assert.ErrorIs(t, ValidateTransition(StatusInitiated, "ON_HOLD"), ErrUnknownStatus)
+	})
+
+	t.Run("Should normalize case and treat legacy statuses as initiated", func(t *testing.T) {
+		assert.NoError(t, ValidateTransition("ringing", "answered"))
+		assert.NoError(t, ValidateTransition("start", StatusRinging))
+	})
+}
+
+func TestIsTerminalStatus(t *testing.T) {
+	assert.True(t, IsTerminalStatus(StatusEnded))
+	assert.True(t, IsTerminalStatus(StatusNoAnswer))
+	assert.False(t, IsTerminalStatus(StatusAnswered))
+	assert.False(t, IsTerminalStatus("UNKNOWN"))
+}
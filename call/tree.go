@@ -0,0 +1,77 @@
+package call
+
+import (
+	"errors"
+	"sort"
+
+	"lineblocs.com/api/model"
+)
+
+// ErrParentWorkspaceMismatch is returned when a leg names a parent call
+// owned by a different workspace.
+var ErrParentWorkspaceMismatch = errors.New("parent call belongs to another workspace")
+
+// ErrParentCallNotFound is returned when a leg names a parent call that does not exist.
+var ErrParentCallNotFound = errors.New("parent call does not exist")
+
+// ErrSessionNotFound is returned when a leg joins a session that has no
+// legs in its workspace.
+var ErrSessionNotFound = errors.New("call session does not exist")
+
+// BuildCallTree links the legs of a session to their parent legs.
+// Legs whose parent is not part of the session are returned as roots, so
+// a partially loaded session still shows every leg. A parent is always
+// created before its children, which keeps the tree free of cycles.
+func BuildCallTree(sessionId string, calls []model.Call) *model.CallSession {
+	session := &model.CallSession{
+		SessionId: sessionId,
+		LegCount:  len(calls),
+		Legs:      []*model.CallLeg{},
+	}
+
+	sorted := make([]model.Call, len(calls))
+	copy(sorted, calls)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Id < sorted[j].Id })
+
+	legs := make(map[int]*model.CallLeg, len(sorted))
+	for _, c := range sorted {
+		legs[c.Id] = &model.CallLeg{Call: c, Legs: []*model.CallLeg{}}
+	}
+
+	for _, c := range sorted {
+		leg := legs[c.Id]
+		session.WorkspaceId = c.WorkspaceId
+		session.BillableSeconds += c.Duration
+
+		if session.StartedAt == "" || (c.StartedAt != "" && c.StartedAt < session.StartedAt) {
+			session.StartedAt = c.StartedAt
+		}
+		if c.EndedAt > session.EndedAt {
+			session.EndedAt = c.EndedAt
+		}
+
+		parent, ok := legs[c.ParentCallId]
+		if c.ParentCallId == 0 || !ok || c.ParentCallId >= c.Id {
+			session.Legs = append(session.Legs, leg)
+			continue
+		}
+		parent.Legs = append(parent.Legs, leg)
+	}
+
+	return session
+}
+
+// AddCharges sets the cents charged for each leg of a session, by call id,
+// and totals them for the conversation.
+func AddCharges(session *model.CallSession, charges map[int]float64) {
+	var add func(legs []*model.CallLeg)
+	add = func(legs []*model.CallLeg) {
+		for _, leg := range legs {
+			leg.Cents = charges[leg.Id]
+			session.Cents += leg.Cents
+			add(leg.Legs)
+		}
+	}
+	session.Cents = 0
+	add(session.Legs)
+}
@@ -0,0 +1,56 @@
+package call
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/model"
+)
+
+func TestBuildCallTree(t *testing.T) {
+	t.Run("Should nest transferred and forked legs under the inbound leg", func(t *testing.T) {
+		calls := []model.Call{
+			{Id: 12, ParentCallId: 10, Duration: 30, WorkspaceId: 1, StartedAt: "2024-05-01T10:00:05Z", EndedAt: "2024-05-01T10:00:40Z"},
+			{Id: 10, Duration: 60, WorkspaceId: 1, StartedAt: "2024-05-01T10:00:00Z", EndedAt: "2024-05-01T10:01:00Z"},
+			{Id: 11, ParentCallId: 10, WorkspaceId: 1, StartedAt: "2024-05-01T10:00:05Z"},
+			{Id: 13, ParentCallId: 12, Duration: 10, WorkspaceId: 1, StartedAt: "2024-05-01T10:00:30Z", EndedAt: "2024-05-01T10:01:10Z"},
+		}
+
+		session := BuildCallTree("session-1", calls)
+
+		assert.Equal(t, 4, session.LegCount)
+		assert.Equal(t, 100, session.BillableSeconds)
+		assert.Equal(t, "2024-05-01T10:00:00Z", session.StartedAt)
+		assert.Equal(t, "2024-05-01T10:01:10Z", session.EndedAt)
+		if assert.Len(t, session.Legs, 1) {
+			root := session.Legs[0]
+			assert.Equal(t, 10, root.Id)
+			if assert.Len(t, root.Legs, 2) {
+				assert.Equal(t, 11, root.Legs[0].Id)
+				assert.Equal(t, 12, root.Legs[1].Id)
+				assert.Len(t, root.Legs[1].Legs, 1)
+			}
+		}
+	})
+
+	t.Run("Should charge each leg and total the session", func(t *testing.T) {
+		session := BuildCallTree("session-3", []model.Call{
+			{Id: 1, Duration: 60},
+			{Id: 2, ParentCallId: 1, Duration: 30},
+			{Id: 3, ParentCallId: 2},
+		})
+		AddCharges(session, map[int]float64{1: 12, 2: 40})
+
+		assert.Equal(t, float64(52), session.Cents)
+		root := session.Legs[0]
+		assert.Equal(t, float64(12), root.Cents)
+		assert.Equal(t, float64(40), root.Legs[0].Cents)
+		assert.Zero(t, root.Legs[0].Legs[0].Cents)
+	})
+
+	t.Run("Should keep legs with a missing parent as roots", func(t *testing.T) {
+		session := BuildCallTree("session-2", []model.Call{{Id: 5, ParentCallId: 4}})
+
+		assert.Len(t, session.Legs, 1)
+	})
+}
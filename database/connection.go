@@ -119,6 +119,27 @@ func (m *MySQLConn) Exec(query string, args ...interface{}) (sql.Result, error)
 	return output.(sql.Result), nil
 }
 
+// Begin starts a transaction with circuit breaker protection.
+func (m *MySQLConn) Begin() (*sql.Tx, error) {
+	var tx *sql.Tx
+	var err error
+
+	// Start the transaction within the circuit breaker
+	output, err := m.circuit.Execute(func() (interface{}, error) {
+		tx, err = m.db.Begin()
+		return tx, err
+	})
+
+	// Update last attempt time
+	m.lastAttempt = time.Now()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	return output.(*sql.Tx), nil
+}
+
 // get the connection
 func (m *MySQLConn) GetConnection() (*sql.DB) {
 	return m.db;
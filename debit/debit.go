@@ -10,5 +10,6 @@ Implementation of Debit Store is located /store/debit
 */
 type DebitStoreInterface interface {
 	CreateDebit(*model.CallRate, *model.Debit) error
-	CreateAPIUsageDebit(*model.Workspace, *model.DebitAPI) error
+	CreateAPIUsageDebit(*model.Workspace, *model.DebitAPI) (float64, error)
+	GetCallCharges(workspaceId int, callIds []int) (map[int]float64, error)
 }
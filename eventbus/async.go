@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultAsyncQueueSize = 1024
+	DefaultPublishTimeout = 10 * time.Second
+)
+
+var ErrQueueFull = errors.New("event bus queue is full")
+
+// AsyncBus queues events in memory and publishes them to the wrapped bus
+// from a background worker, so request handlers never wait on a broker.
+// Events still queued when the process exits are lost; events that must
+// not be lost belong in the outbox.
+type AsyncBus struct {
+	inner   EventBus
+	queue   chan Event
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func NewAsyncBus(inner EventBus, queueSize int) *AsyncBus {
+	if queueSize <= 0 {
+		queueSize = DefaultAsyncQueueSize
+	}
+	b := &AsyncBus{
+		inner:   inner,
+		queue:   make(chan Event, queueSize),
+		timeout: DefaultPublishTimeout,
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Publish queues event and returns ErrQueueFull instead of blocking when the broker falls behind.
+func (b *AsyncBus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBusClosed
+	}
+	select {
+	case b.queue <- event:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (b *AsyncBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	return b.inner.Subscribe(eventType, handler)
+}
+
+// Close publishes the queued events and closes the wrapped bus.
+func (b *AsyncBus) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+
+	<-b.done
+	return b.inner.Close()
+}
+
+func (b *AsyncBus) run() {
+	defer close(b.done)
+	for event := range b.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+		err := b.inner.Publish(ctx, event)
+		cancel()
+		if err != nil {
+			utils.Log(logrus.ErrorLevel, "could not publish event "+event.Type+" id = "+event.Id+": "+err.Error())
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package eventbus
+
+// Event types in the catalogue. A breaking change to a payload adds a new
+// version with its own schema in schemas/<type>.v<version>.json; consumers
+// select on both Type and Version.
+const (
+	TypeDebitCreated           = "debit.created"
+	TypeRecordingCreated       = "recording.created"
+	TypeRecordingUploaded      = "recording.uploaded"
+	TypeRecordingStatusChanged = "recording.status_changed"
+	TypeRecordingTranscribed   = "recording.transcribed"
+	TypeFaxCreated             = "fax.created"
+	TypeFaxStatusChanged       = "fax.status_changed"
+	TypeFaxSendRequested       = "fax.send_requested"
+	TypeSuspensionCallBlocked  = "suspension.call_blocked"
+	TypeDebuggerLogCreated     = "debugger.log_created"
+)
+
+// Payload is implemented by every event in the catalogue.
+type Payload interface {
+	EventType() string
+	EventVersion() int
+}
+
+// DebitCreated is published when usage is charged to a workspace.
+type DebitCreated struct {
+	UserId   int     `json:"user_id"`
+	Source   string  `json:"source"`
+	ModuleId int     `json:"module_id"`
+	Number   string  `json:"number"`
+	Seconds  int     `json:"seconds"`
+	Cents    float64 `json:"cents"`
+}
+
+func (DebitCreated) EventType() string { return TypeDebitCreated }
+func (DebitCreated) EventVersion() int { return 1 }
+
+// RecordingCreated is published when a recording is started.
+type RecordingCreated struct {
+	RecordingId int    `json:"recording_id"`
+	APIId       string `json:"api_id"`
+	UserId      int    `json:"user_id"`
+	CallId      *int   `json:"call_id"`
+}
+
+func (RecordingCreated) EventType() string { return TypeRecordingCreated }
+func (RecordingCreated) EventVersion() int { return 1 }
+
+// RecordingUploaded is published when the media of a recording is stored.
+type RecordingUploaded struct {
+	RecordingId int    `json:"recording_id"`
+	APIId       string `json:"api_id"`
+	Status      string `json:"status"`
+	Size        int64  `json:"size"`
+}
+
+func (RecordingUploaded) EventType() string { return TypeRecordingUploaded }
+func (RecordingUploaded) EventVersion() int { return 1 }
+
+// RecordingStatusChanged is published when the status of a recording is set.
+type RecordingStatusChanged struct {
+	RecordingId int    `json:"recording_id"`
+	Status      string `json:"status"`
+}
+
+func (RecordingStatusChanged) EventType() string { return TypeRecordingStatusChanged }
+func (RecordingStatusChanged) EventVersion() int { return 1 }
+
+// RecordingTranscribed is published when a transcription is stored for a recording.
+type RecordingTranscribed struct {
+	RecordingId int    `json:"recording_id"`
+	Ready       bool   `json:"ready"`
+	Language    string `json:"language,omitempty"`
+}
+
+func (RecordingTranscribed) EventType() string { return TypeRecordingTranscribed }
+func (RecordingTranscribed) EventVersion() int { return 1 }
+
+// FaxCreated is published when a fax is stored.
+type FaxCreated struct {
+	FaxId  int64  `json:"fax_id"`
+	APIId  string `json:"api_id"`
+	UserId int    `json:"user_id"`
+	CallId int    `json:"call_id"`
+	Size   int64  `json:"size"`
+	Pages  int    `json:"pages"`
+}
+
+func (FaxCreated) EventType() string { return TypeFaxCreated }
+func (FaxCreated) EventVersion() int { return 1 }
+
+// FaxStatusChanged is published when a fax moves through its lifecycle.
+// ReasonCode and Reason explain failed transmissions.
+type FaxStatusChanged struct {
+	FaxId      int    `json:"fax_id"`
+	APIId      string `json:"api_id"`
+	Direction  string `json:"direction"`
+	Status     string `json:"status"`
+	ReasonCode int    `json:"reason_code"`
+	Reason     string `json:"reason"`
+	Attempts   int    `json:"attempts"`
+}
+
+func (FaxStatusChanged) EventType() string { return TypeFaxStatusChanged }
+func (FaxStatusChanged) EventVersion() int { return 1 }
+
+// FaxSendRequested is published when an outbound fax is due to be transmitted
+// by the media server, for the first time or as a retry. The document is
+// downloaded from DownloadURL until ExpiresAt.
+type FaxSendRequested struct {
+	FaxId       int    `json:"fax_id"`
+	APIId       string `json:"api_id"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Resolution  string `json:"resolution"`
+	DownloadURL string `json:"download_url"`
+	ExpiresAt   string `json:"expires_at"`
+	Attempt     int    `json:"attempt"`
+}
+
+func (FaxSendRequested) EventType() string { return TypeFaxSendRequested }
+func (FaxSendRequested) EventVersion() int { return 1 }
+
+// SuspensionCallBlocked is published when a suspended workspace tries to place a call.
+type SuspensionCallBlocked struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Direction string `json:"direction"`
+}
+
+func (SuspensionCallBlocked) EventType() string { return TypeSuspensionCallBlocked }
+func (SuspensionCallBlocked) EventVersion() int { return 1 }
+
+// DebuggerLogCreated is published when a debugger log is written for a workspace.
+type DebuggerLogCreated struct {
+	LogId  string `json:"log_id"`
+	Level  string `json:"level"`
+	Title  string `json:"title"`
+	FlowId int    `json:"flow_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+func (DebuggerLogCreated) EventType() string { return TypeDebuggerLogCreated }
+func (DebuggerLogCreated) EventVersion() int { return 1 }
+
+// Catalog lists the current version of every event type.
+var Catalog = []Payload{
+	DebitCreated{},
+	RecordingCreated{},
+	RecordingUploaded{},
+	RecordingStatusChanged{},
+	RecordingTranscribed{},
+	FaxCreated{},
+	FaxStatusChanged{},
+	FaxSendRequested{},
+	SuspensionCallBlocked{},
+	DebuggerLogCreated{},
+}
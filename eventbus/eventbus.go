@@ -0,0 +1,80 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"lineblocs.com/api/utils"
+)
+
+// Source identifies this service in published events.
+const Source = "internals-api"
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+var ErrBusClosed = errors.New("event bus is closed")
+
+// Event is the envelope every domain event is published in. Data holds the
+// payload of the catalogue type named by Type, encoded as described by the
+// schema of that Type and Version.
+type Event struct {
+	Id          string          `json:"id"`
+	Type        string          `json:"type"`
+	Version     int             `json:"version"`
+	Source      string          `json:"source"`
+	WorkspaceId int             `json:"workspace_id"`
+	OccurredAt  string          `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Handler receives events from a subscription.
+type Handler func(Event)
+
+// Subscription stops delivery to its handler when unsubscribed.
+type Subscription interface {
+	Unsubscribe() error
+}
+
+/*
+EventBus publishes domain events to other services.
+Implementations are located in memory.go, rabbitmq.go and nats.go
+*/
+type EventBus interface {
+	Publish(ctx context.Context, event Event) error
+	Subscribe(eventType string, handler Handler) (Subscription, error)
+	Close() error
+}
+
+// NewEvent wraps a catalogue payload in an envelope and validates it against its schema.
+func NewEvent(workspaceId int, payload Payload) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+	event := Event{
+		Id:          utils.CreateAPIID("evt"),
+		Type:        payload.EventType(),
+		Version:     payload.EventVersion(),
+		Source:      Source,
+		WorkspaceId: workspaceId,
+		OccurredAt:  time.Now().UTC().Format(time.RFC3339),
+		Data:        data,
+	}
+	err = Validate(event)
+	if err != nil {
+		return Event{}, err
+	}
+	return event, nil
+}
+
+// Decode unmarshals the payload of event into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+func matches(pattern string, eventType string) bool {
+	return pattern == AllEvents || pattern == eventType
+}
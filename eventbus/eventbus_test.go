@@ -0,0 +1,81 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogSchemas(t *testing.T) {
+	for _, payload := range Catalog {
+		t.Run(payload.EventType(), func(t *testing.T) {
+			_, err := loadSchema(payload.EventType(), payload.EventVersion())
+			assert.NoError(t, err)
+
+			// the zero value of every catalogue type must satisfy its own schema
+			_, err = NewEvent(1, payload)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("Should reject a missing required property", func(t *testing.T) {
+		event := Event{Type: TypeRecordingStatusChanged, Version: 1, Data: json.RawMessage(`{"recording_id": 4}`)}
+		assert.ErrorContains(t, Validate(event), "status")
+	})
+
+	t.Run("Should reject a property of the wrong type", func(t *testing.T) {
+		event := Event{Type: TypeRecordingStatusChanged, Version: 1, Data: json.RawMessage(`{"recording_id": 4.5, "status": "completed"}`)}
+		assert.ErrorContains(t, Validate(event), "recording_id")
+	})
+
+	t.Run("Should accept nullable properties", func(t *testing.T) {
+		event := Event{Type: TypeRecordingCreated, Version: 1, Data: json.RawMessage(`{"recording_id": 4, "api_id": "rec-1", "call_id": null}`)}
+		assert.NoError(t, Validate(event))
+	})
+
+	t.Run("Should reject unknown versions", func(t *testing.T) {
+		event := Event{Type: TypeRecordingCreated, Version: 9, Data: json.RawMessage(`{}`)}
+		assert.Error(t, Validate(event))
+	})
+}
+
+func TestMemoryBus(t *testing.T) {
+	bus := NewMemoryBus()
+	var received []Event
+	sub, err := bus.Subscribe(TypeFaxCreated, func(e Event) { received = append(received, e) })
+	assert.NoError(t, err)
+
+	fax, _ := NewEvent(3, FaxCreated{FaxId: 10, APIId: "fax-1", Size: 2048})
+	debit, _ := NewEvent(3, DebitCreated{Source: "CALL", Cents: 12})
+	assert.NoError(t, bus.Publish(context.Background(), fax))
+	assert.NoError(t, bus.Publish(context.Background(), debit))
+
+	assert.Len(t, received, 1)
+	assert.Len(t, bus.Published(""), 2)
+	if assert.Len(t, bus.Published(TypeDebitCreated), 1) {
+		var payload DebitCreated
+		assert.NoError(t, bus.Published(TypeDebitCreated)[0].Decode(&payload))
+		assert.Equal(t, float64(12), payload.Cents)
+	}
+
+	assert.NoError(t, sub.Unsubscribe())
+	assert.NoError(t, bus.Publish(context.Background(), fax))
+	assert.Len(t, received, 1)
+}
+
+func TestAsyncBus(t *testing.T) {
+	inner := NewMemoryBus()
+	bus := NewAsyncBus(inner, 10)
+
+	event, _ := NewEvent(1, RecordingTranscribed{RecordingId: 1, Ready: true})
+	assert.NoError(t, bus.Publish(context.Background(), event))
+
+	// close waits for the queue to drain
+	assert.NoError(t, bus.Close())
+	assert.Len(t, inner.Published(TypeRecordingTranscribed), 1)
+	assert.ErrorIs(t, bus.Publish(context.Background(), event), ErrBusClosed)
+}
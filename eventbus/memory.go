@@ -0,0 +1,105 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryHistorySize bounds the events a MemoryBus remembers for Published.
+const memoryHistorySize = 1000
+
+// MemoryBus delivers events synchronously inside the process. It keeps the
+// most recent published events so tests can assert on them without a broker.
+type MemoryBus struct {
+	mu        sync.RWMutex
+	nextId    int
+	handlers  map[int]memoryHandler
+	published []Event
+	closed    bool
+}
+
+type memoryHandler struct {
+	eventType string
+	handler   Handler
+}
+
+type memorySubscription struct {
+	bus *MemoryBus
+	id  int
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		handlers: make(map[int]memoryHandler),
+	}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event Event) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrBusClosed
+	}
+	if len(b.published) == memoryHistorySize {
+		b.published = b.published[1:]
+	}
+	b.published = append(b.published, event)
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		if matches(h.eventType, event.Type) {
+			handlers = append(handlers, h.handler)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	b.nextId++
+	b.handlers[b.nextId] = memoryHandler{eventType: eventType, handler: handler}
+	return &memorySubscription{bus: b, id: b.nextId}, nil
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.handlers = make(map[int]memoryHandler)
+	return nil
+}
+
+// Published returns the events published so far, optionally only those of eventType.
+func (b *MemoryBus) Published(eventType string) []Event {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	events := []Event{}
+	for _, e := range b.published {
+		if eventType == "" || matches(eventType, e.Type) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Reset forgets the published events.
+func (b *MemoryBus) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.published = nil
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.handlers, s.id)
+	return nil
+}
@@ -0,0 +1,72 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+// DefaultSubjectPrefix is prepended to the event type to form the NATS subject.
+const DefaultSubjectPrefix = "lineblocs.events."
+
+// NATSBus publishes events to NATS core subjects. The client reconnects on
+// its own; publishing is flushed so errors surface to the caller.
+type NATSBus struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSBus(url string, prefix string) (*NATSBus, error) {
+	if prefix == "" {
+		prefix = DefaultSubjectPrefix
+	}
+	conn, err := nats.Connect(url,
+		nats.Name(Source),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				utils.Log(logrus.WarnLevel, "NATS disconnected: "+err.Error())
+			}
+		}),
+		nats.ReconnectHandler(func(_ *nats.Conn) {
+			utils.Log(logrus.InfoLevel, "NATS reconnected")
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSBus{conn: conn, prefix: prefix}, nil
+}
+
+func (b *NATSBus) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	err = b.conn.Publish(b.prefix+event.Type, body)
+	if err != nil {
+		return err
+	}
+	return b.conn.FlushWithContext(ctx)
+}
+
+func (b *NATSBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	subject := b.prefix + eventType
+	if eventType == AllEvents {
+		subject = b.prefix + ">"
+	}
+	return b.conn.Subscribe(subject, func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			utils.Log(logrus.WarnLevel, "eventbus could not decode event: "+err.Error())
+			return
+		}
+		handler(event)
+	})
+}
+
+func (b *NATSBus) Close() error {
+	return b.conn.Drain()
+}
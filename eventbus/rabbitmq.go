@@ -0,0 +1,114 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/queue"
+	"lineblocs.com/api/utils"
+)
+
+// DefaultExchange is the topic exchange domain events are published to.
+// The routing key is the event type, so consumers can bind to "recording.#".
+const DefaultExchange = "lineblocs.events"
+
+// RabbitMQBus publishes events to a topic exchange through the shared,
+// confirmed AMQP publisher.
+type RabbitMQBus struct {
+	publisher *queue.AMQPPublisher
+	exchange  string
+
+	mu       sync.Mutex
+	channels []*amqp.Channel
+}
+
+func NewRabbitMQBus(publisher *queue.AMQPPublisher, exchange string) *RabbitMQBus {
+	if exchange == "" {
+		exchange = DefaultExchange
+	}
+	return &RabbitMQBus{
+		publisher: publisher,
+		exchange:  exchange,
+	}
+}
+
+func (b *RabbitMQBus) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.publisher.PublishToExchange(ctx, b.exchange, event.Type, body)
+}
+
+// Subscribe binds an exclusive queue to the exchange. The subscription does
+// not survive a lost connection; long-running consumers should live in
+// their own service with a durable queue.
+func (b *RabbitMQBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	ch, err := b.publisher.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ch.ExchangeDeclare(b.exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	q, err := ch.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	key := eventType
+	if eventType == AllEvents {
+		key = "#"
+	}
+	err = ch.QueueBind(q.Name, key, b.exchange, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	deliveries, err := ch.Consume(q.Name, "", true, true, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	go func() {
+		for d := range deliveries {
+			var event Event
+			if err := json.Unmarshal(d.Body, &event); err != nil {
+				utils.Log(logrus.WarnLevel, "eventbus could not decode event: "+err.Error())
+				continue
+			}
+			handler(event)
+		}
+	}()
+
+	b.mu.Lock()
+	b.channels = append(b.channels, ch)
+	b.mu.Unlock()
+	return &rabbitMQSubscription{ch: ch}, nil
+}
+
+// Close stops the subscriptions. The publisher is shared and closed by its owner.
+func (b *RabbitMQBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, ch := range b.channels {
+		ch.Close()
+	}
+	b.channels = nil
+	return nil
+}
+
+type rabbitMQSubscription struct {
+	ch *amqp.Channel
+}
+
+func (s *rabbitMQSubscription) Unsubscribe() error {
+	return s.ch.Close()
+}
@@ -0,0 +1,141 @@
+package eventbus
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+)
+
+//go:embed schemas/*.json
+var schemaFiles embed.FS
+
+// schema is the subset of JSON Schema used by the catalogue: an object
+// with required properties whose values have primitive types.
+type schema struct {
+	Title      string                    `json:"title"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]schemaProperty `json:"properties"`
+}
+
+type schemaProperty struct {
+	Type schemaTypes `json:"type"`
+}
+
+// schemaTypes accepts both "type": "string" and "type": ["string", "null"].
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*t = many
+	return nil
+}
+
+var (
+	schemasMu sync.Mutex
+	schemas   = map[string]*schema{}
+)
+
+// SchemaName returns the file name of the schema for an event type and version.
+func SchemaName(eventType string, version int) string {
+	return fmt.Sprintf("%s.v%d.json", eventType, version)
+}
+
+// Schema returns the raw JSON schema of an event type and version.
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemaFiles.ReadFile("schemas/" + SchemaName(eventType, version))
+}
+
+func loadSchema(eventType string, version int) (*schema, error) {
+	name := SchemaName(eventType, version)
+
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if s, ok := schemas[name]; ok {
+		return s, nil
+	}
+
+	raw, err := Schema(eventType, version)
+	if err != nil {
+		return nil, fmt.Errorf("no schema for event %s version %d", eventType, version)
+	}
+	s := &schema{}
+	err = json.Unmarshal(raw, s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %v", name, err)
+	}
+	schemas[name] = s
+	return s, nil
+}
+
+// Validate checks the payload of event against the schema of its type and version.
+func Validate(event Event) error {
+	s, err := loadSchema(event.Type, event.Version)
+	if err != nil {
+		return err
+	}
+
+	var data map[string]interface{}
+	err = json.Unmarshal(event.Data, &data)
+	if err != nil || data == nil {
+		return fmt.Errorf("event %s: payload must be a JSON object", event.Type)
+	}
+
+	for _, name := range s.Required {
+		if _, ok := data[name]; !ok {
+			return fmt.Errorf("event %s: missing required property %s", event.Type, name)
+		}
+	}
+	for name, value := range data {
+		prop, ok := s.Properties[name]
+		if !ok {
+			continue
+		}
+		if !hasType(value, prop.Type) {
+			return fmt.Errorf("event %s: property %s must be of type %v", event.Type, name, []string(prop.Type))
+		}
+	}
+	return nil
+}
+
+func hasType(value interface{}, types schemaTypes) bool {
+	for _, t := range types {
+		switch v := value.(type) {
+		case nil:
+			if t == "null" {
+				return true
+			}
+		case string:
+			if t == "string" {
+				return true
+			}
+		case bool:
+			if t == "boolean" {
+				return true
+			}
+		case float64:
+			if t == "number" || (t == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case []interface{}:
+			if t == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if t == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
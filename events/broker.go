@@ -0,0 +1,206 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+// Call lifecycle event types pushed to stream subscribers.
+const (
+	TypeCallCreated          = "call.created"
+	TypeCallStatusChanged    = "call.status_changed"
+	TypeCallSIPReport        = "call.sip_report"
+	TypeCallRecordingStarted = "call.recording_started"
+	TypeCallBilled           = "call.billed"
+)
+
+const (
+	DefaultHistorySize = 1024
+	DefaultQueueSize   = 64
+)
+
+// Event is a single call lifecycle event. Ids are assigned by the broker
+// and increase monotonically, so a subscriber can resume from the last id it saw.
+type Event struct {
+	Id          uint64      `json:"id"`
+	Type        string      `json:"type"`
+	WorkspaceId int         `json:"workspace_id"`
+	CallId      int         `json:"call_id,string"`
+	CreatedAt   string      `json:"created_at"`
+	Data        interface{} `json:"data"`
+}
+
+// Subscription receives the events of a single workspace.
+// Events is closed when the subscriber falls too far behind or unsubscribes.
+type Subscription struct {
+	Events      <-chan Event
+	workspaceId int
+	ch          chan Event
+	dropped     bool
+}
+
+// Dropped reports whether the broker closed the subscription because the
+// subscriber could not keep up. The client should reconnect with the id
+// of the last event it processed.
+func (s *Subscription) Dropped() bool {
+	return s.dropped
+}
+
+// Relay shares events between API instances. Publish assigns the id of an
+// event and hands it to Broker.Receive on every instance, the one that
+// published it included.
+type Relay interface {
+	Publish(e Event) (uint64, error)
+}
+
+// Broker fans events out to subscribers and keeps a bounded history for
+// Last-Event-ID resume. Publishing never blocks on subscribers: a subscriber
+// whose queue is full is disconnected instead of slowing down the API.
+//
+// Without a relay ids and events are local to the instance, deployments
+// with several API instances need a relay so every stream sees every event.
+type Broker struct {
+	mu          sync.Mutex
+	lastId      uint64
+	history     []Event
+	historySize int
+	queueSize   int
+	subscribers map[*Subscription]struct{}
+	relay       Relay
+}
+
+func NewBroker(historySize int, queueSize int) *Broker {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	return &Broker{
+		historySize: historySize,
+		queueSize:   queueSize,
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// SetRelay publishes events through relay instead of delivering them locally.
+func (b *Broker) SetRelay(relay Relay) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.relay = relay
+}
+
+// Publish assigns the next id to e and delivers it to every subscriber of its workspace.
+// With a relay the event is delivered once the relay hands it back to Receive,
+// events the relay fails to publish are logged and dropped.
+func (b *Broker) Publish(e Event) Event {
+	if e.CreatedAt == "" {
+		e.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	b.mu.Lock()
+	relay := b.relay
+	if relay == nil {
+		defer b.mu.Unlock()
+		b.lastId++
+		e.Id = b.lastId
+		b.deliver(e)
+		return e
+	}
+	b.mu.Unlock()
+
+	id, err := relay.Publish(e)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not relay event "+e.Type+": "+err.Error())
+		return e
+	}
+	e.Id = id
+	return e
+}
+
+// Receive delivers an event published through the relay. Events are expected
+// in the order of their ids, an id that was already delivered is ignored.
+func (b *Broker) Receive(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e.Id <= b.lastId {
+		return
+	}
+	b.lastId = e.Id
+	b.deliver(e)
+}
+
+func (b *Broker) deliver(e Event) {
+	if len(b.history) == b.historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:len(b.history)-1]
+	}
+	b.history = append(b.history, e)
+
+	for sub := range b.subscribers {
+		if sub.workspaceId != e.WorkspaceId {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			sub.dropped = true
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for workspaceId. Events newer than
+// lastEventId that are still in the history are returned for replay;
+// complete is false when some of them have already been evicted. An id
+// the broker has not reached yet was handed out before a restart, then
+// the whole history is replayed and complete is false.
+func (b *Broker) Subscribe(workspaceId int, lastEventId uint64) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastEventId > b.lastId {
+		complete = false
+		lastEventId = 0
+		for _, e := range b.history {
+			if e.WorkspaceId == workspaceId {
+				replay = append(replay, e)
+			}
+		}
+	} else if lastEventId > 0 && lastEventId < b.lastId {
+		// relayed ids start wherever the instance joined, so the oldest
+		// event remembered is taken from the history
+		oldest := b.history[0].Id
+		complete = lastEventId+1 >= oldest
+		for _, e := range b.history {
+			if e.Id > lastEventId && e.WorkspaceId == workspaceId {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, b.queueSize)
+	sub = &Subscription{Events: ch, workspaceId: workspaceId, ch: ch}
+	b.subscribers[sub] = struct{}{}
+	return sub, replay, complete
+}
+
+// Unsubscribe removes sub and closes its channel. It is safe to call more than once.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(sub)
+}
+
+func (b *Broker) remove(sub *Subscription) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
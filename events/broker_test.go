@@ -0,0 +1,140 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("Should deliver events only to the matching workspace", func(t *testing.T) {
+		b := NewBroker(10, 10)
+		sub, _, _ := b.Subscribe(1, 0)
+		defer b.Unsubscribe(sub)
+
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 2, CallId: 7})
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 1, CallId: 8})
+
+		e := <-sub.Events
+		assert.Equal(t, 8, e.CallId)
+		assert.Equal(t, uint64(2), e.Id)
+		assert.Len(t, sub.Events, 0)
+	})
+
+	t.Run("Should replay events after the last event id", func(t *testing.T) {
+		b := NewBroker(10, 10)
+		for i := 1; i <= 4; i++ {
+			b.Publish(Event{Type: TypeCallStatusChanged, WorkspaceId: 1, CallId: i})
+		}
+
+		sub, replay, complete := b.Subscribe(1, 2)
+		defer b.Unsubscribe(sub)
+		assert.True(t, complete)
+		if assert.Len(t, replay, 2) {
+			assert.Equal(t, uint64(3), replay[0].Id)
+			assert.Equal(t, uint64(4), replay[1].Id)
+		}
+	})
+
+	t.Run("Should report a gap when history was evicted", func(t *testing.T) {
+		b := NewBroker(2, 10)
+		for i := 1; i <= 5; i++ {
+			b.Publish(Event{Type: TypeCallStatusChanged, WorkspaceId: 1, CallId: i})
+		}
+
+		sub, replay, complete := b.Subscribe(1, 1)
+		defer b.Unsubscribe(sub)
+		assert.False(t, complete)
+		assert.Len(t, replay, 2)
+	})
+
+	t.Run("Should report a gap for ids from before a restart", func(t *testing.T) {
+		b := NewBroker(10, 10)
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 1, CallId: 1})
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 2, CallId: 2})
+
+		sub, replay, complete := b.Subscribe(1, 500)
+		defer b.Unsubscribe(sub)
+		assert.False(t, complete)
+		if assert.Len(t, replay, 1) {
+			assert.Equal(t, uint64(1), replay[0].Id)
+		}
+	})
+
+	t.Run("Should disconnect a slow subscriber", func(t *testing.T) {
+		b := NewBroker(10, 1)
+		sub, _, _ := b.Subscribe(1, 0)
+
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 1})
+		b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 1})
+
+		_, ok := <-sub.Events
+		assert.True(t, ok)
+		_, ok = <-sub.Events
+		assert.False(t, ok)
+		assert.True(t, sub.Dropped())
+
+		// unsubscribing after a drop must not panic
+		b.Unsubscribe(sub)
+	})
+
+	t.Run("Should deliver relayed events in the order of their ids", func(t *testing.T) {
+		b := NewBroker(10, 10)
+		relay := &loopRelay{broker: b, nextId: 40}
+		b.SetRelay(relay)
+		sub, _, _ := b.Subscribe(1, 0)
+		defer b.Unsubscribe(sub)
+
+		e := b.Publish(Event{Type: TypeCallCreated, WorkspaceId: 1, CallId: 3})
+		assert.Equal(t, uint64(41), e.Id)
+
+		// an event seen again through the relay is not delivered twice
+		b.Receive(e)
+		b.Receive(Event{Id: 42, Type: TypeCallBilled, WorkspaceId: 1, CallId: 3})
+
+		assert.Equal(t, uint64(41), (<-sub.Events).Id)
+		assert.Equal(t, uint64(42), (<-sub.Events).Id)
+		assert.Len(t, sub.Events, 0)
+	})
+
+	t.Run("Should report a gap for relayed ids from before the instance joined", func(t *testing.T) {
+		b := NewBroker(10, 10)
+		b.Receive(Event{Id: 41, Type: TypeCallCreated, WorkspaceId: 1})
+		b.Receive(Event{Id: 42, Type: TypeCallCreated, WorkspaceId: 1})
+
+		sub, replay, complete := b.Subscribe(1, 30)
+		defer b.Unsubscribe(sub)
+		assert.False(t, complete)
+		assert.Len(t, replay, 2)
+
+		sub, replay, complete = b.Subscribe(1, 41)
+		defer b.Unsubscribe(sub)
+		assert.True(t, complete)
+		assert.Len(t, replay, 1)
+	})
+}
+
+func TestDecodeRelayed(t *testing.T) {
+	e, err := decodeRelayed(`7 {"id":0,"type":"call.created","workspace_id":2,"call_id":"5","created_at":"2024-05-01T10:00:00Z","data":{"status":"RINGING"}}`)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), e.Id)
+	assert.Equal(t, 2, e.WorkspaceId)
+	assert.Equal(t, json.RawMessage(`{"status":"RINGING"}`), e.Data)
+
+	_, err = decodeRelayed("not an event")
+	assert.Error(t, err)
+}
+
+// loopRelay hands published events straight back to its broker.
+type loopRelay struct {
+	broker *Broker
+	nextId uint64
+}
+
+func (r *loopRelay) Publish(e Event) (uint64, error) {
+	r.nextId++
+	e.Id = r.nextId
+	r.broker.Receive(e)
+	return e.Id, nil
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	// RedisChannel carries the events of every workspace between API instances.
+	RedisChannel = "calls:events"
+	// RedisIdKey holds the last event id handed out.
+	RedisIdKey = "calls:events:id"
+)
+
+// publishScript takes the next id and publishes the event in one step, so
+// every instance receives the events in the order of their ids.
+//
+// KEYS[1] is the id counter, ARGV[1] the channel and ARGV[2] the event.
+var publishScript = redis.NewScript(`
+local id = redis.call('INCR', KEYS[1])
+redis.call('PUBLISH', ARGV[1], id .. ' ' .. ARGV[2])
+return id
+`)
+
+// RedisRelay shares call lifecycle events between API instances with Redis
+// pub/sub. Ids come from a counter in Redis so a client can resume its
+// stream on any instance.
+type RedisRelay struct {
+	client *redis.Client
+	broker *Broker
+}
+
+// NewRedisRelay relays the events of broker, Run must be started for the
+// broker to receive them.
+func NewRedisRelay(client *redis.Client, broker *Broker) *RedisRelay {
+	return &RedisRelay{client: client, broker: broker}
+}
+
+func (r *RedisRelay) Publish(e Event) (uint64, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	id, err := publishScript.Run(r.client, []string{RedisIdKey}, RedisChannel, body).Int64()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// Run hands the events published by every instance to the broker until ctx is done.
+func (r *RedisRelay) Run(ctx context.Context) {
+	pubsub := r.client.Subscribe(RedisChannel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			e, err := decodeRelayed(msg.Payload)
+			if err != nil {
+				utils.Log(logrus.WarnLevel, "could not decode relayed event: "+err.Error())
+				continue
+			}
+			r.broker.Receive(e)
+		}
+	}
+}
+
+// decodeRelayed reads a message of publishScript, the id followed by the event.
+func decodeRelayed(payload string) (Event, error) {
+	var e Event
+	idPart, body, _ := strings.Cut(payload, " ")
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return e, err
+	}
+	var data json.RawMessage
+	e.Data = &data
+	err = json.Unmarshal([]byte(body), &e)
+	if err != nil {
+		return e, err
+	}
+	e.Id = id
+	e.Data = data
+	return e, nil
+}
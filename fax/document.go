@@ -0,0 +1,174 @@
+package fax
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// Content types of the documents a fax can hold.
+const (
+	ContentTypePDF  = "application/pdf"
+	ContentTypeTIFF = "image/tiff"
+)
+
+// Resolutions of a fax, by vertical lines per inch.
+const (
+	ResolutionStandard  = "standard"
+	ResolutionFine      = "fine"
+	ResolutionSuperfine = "superfine"
+)
+
+const (
+	// MaxDocumentSize bounds the file of a fax
+	MaxDocumentSize = 20 << 20
+	// MaxPages bounds the pages of a fax
+	MaxPages = 200
+	// maxInflated bounds the object streams inflated while counting PDF pages
+	maxInflated = 64 << 20
+)
+
+var (
+	ErrUnsupportedDocument = errors.New("fax must be a PDF or TIFF document")
+	ErrInvalidDocument     = errors.New("fax document is damaged")
+	ErrEncryptedDocument   = errors.New("fax document is encrypted")
+	ErrDocumentTooLarge    = errors.New("fax document is larger than 20 MB")
+)
+
+// Document describes the file of a fax. Resolution is only known for TIFF,
+// PDF pages are rendered at the resolution the fax is sent with.
+type Document struct {
+	ContentType string
+	Pages       int
+	Resolution  string
+}
+
+// Inspect validates the fax document of size bytes in r and counts its pages.
+func Inspect(r io.ReaderAt, size int64) (*Document, error) {
+	if size > MaxDocumentSize {
+		return nil, ErrDocumentTooLarge
+	}
+	header := make([]byte, 8)
+	if size < int64(len(header)) {
+		return nil, ErrUnsupportedDocument
+	}
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(header, []byte("%PDF-")):
+		data := make([]byte, size)
+		if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return inspectPDF(data)
+	case bytes.HasPrefix(header, []byte("II*\x00")):
+		return inspectTIFF(r, size, binary.LittleEndian)
+	case bytes.HasPrefix(header, []byte("MM\x00*")):
+		return inspectTIFF(r, size, binary.BigEndian)
+	}
+	return nil, ErrUnsupportedDocument
+}
+
+// inspectTIFF counts the image file directories, one per page, and reads the
+// vertical resolution of the first page.
+func inspectTIFF(r io.ReaderAt, size int64, order binary.ByteOrder) (*Document, error) {
+	pages, err := readTIFF(r, size, order)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{
+		ContentType: ContentTypeTIFF,
+		Pages:       len(pages),
+		Resolution:  resolutionFor(pages[0].dpi(tagYResolution)),
+	}, nil
+}
+
+// resolutionFor names a vertical resolution, fax machines use 98, 196 or 391 lines per inch.
+func resolutionFor(dpi float64) string {
+	switch {
+	case dpi <= 0:
+		return ""
+	case dpi <= 100:
+		return ResolutionStandard
+	case dpi <= 200:
+		return ResolutionFine
+	}
+	return ResolutionSuperfine
+}
+
+var (
+	pdfDictionary = regexp.MustCompile(`<<(?:[^<>]|<[0-9A-Fa-f\s]*>|<<(?:[^<>]|<[0-9A-Fa-f\s]*>|<<(?:[^<>]|<[0-9A-Fa-f\s]*>)*>>)*>>)*>>`)
+	pdfStream     = regexp.MustCompile(`>>\s*stream\r?\n`)
+	pdfPagesType  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfPageType   = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfCount      = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfEncrypt    = regexp.MustCompile(`/Encrypt\s*(?:\d+\s+\d+\s+R|<<)`)
+	pdfObjectStm  = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+)
+
+// inspectPDF reads the page count of the root of the page tree. Object
+// streams of PDF 1.5 and later are inflated so compressed page trees count
+// too, without a page tree the page objects are counted.
+func inspectPDF(data []byte) (*Document, error) {
+	tail := data[max(0, len(data)-1024):]
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return nil, ErrInvalidDocument
+	}
+	text := append(data[:len(data):len(data)], inflateObjectStreams(data)...)
+	if pdfEncrypt.Match(text) {
+		return nil, ErrEncryptedDocument
+	}
+
+	pages := 0
+	for _, dictionary := range pdfDictionary.FindAll(text, -1) {
+		if !pdfPagesType.Match(dictionary) {
+			continue
+		}
+		if match := pdfCount.FindSubmatch(dictionary); match != nil {
+			if count, err := strconv.Atoi(string(match[1])); err == nil && count > pages {
+				pages = count
+			}
+		}
+	}
+	if pages == 0 {
+		pages = len(pdfPageType.FindAllIndex(text, -1))
+	}
+	if pages == 0 {
+		return nil, ErrInvalidDocument
+	}
+	return &Document{ContentType: ContentTypePDF, Pages: pages}, nil
+}
+
+// inflateObjectStreams returns the inflated content of the object streams.
+func inflateObjectStreams(data []byte) []byte {
+	inflated := &bytes.Buffer{}
+	for _, match := range pdfStream.FindAllIndex(data, -1) {
+		start := bytes.LastIndex(data[:match[0]], []byte("obj"))
+		if start < 0 {
+			continue
+		}
+		dictionary := data[start:match[0]]
+		if !pdfObjectStm.Match(dictionary) || !bytes.Contains(dictionary, []byte("/FlateDecode")) {
+			continue
+		}
+		end := bytes.Index(data[match[1]:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data[match[1] : match[1]+end]))
+		if err != nil {
+			continue
+		}
+		io.Copy(inflated, io.LimitReader(reader, int64(maxInflated-inflated.Len())))
+		reader.Close()
+		if inflated.Len() >= maxInflated {
+			break
+		}
+	}
+	return inflated.Bytes()
+}
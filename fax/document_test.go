@@ -0,0 +1,143 @@
+package fax
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pdf builds a document with a page tree of pages pages, compressed into an object stream when compressed is set.
+func pdf(pages int, compressed bool) []byte {
+	kids := []string{}
+	objects := []string{"<< /Type /Catalog /Pages 2 0 R >>"}
+	for i := 0; i < pages; i++ {
+		kids = append(kids, fmt.Sprintf("%d 0 R", i+3))
+	}
+	objects = append(objects, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages))
+	for i := 0; i < pages; i++ {
+		objects = append(objects, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 << /Type /Font >> >> >> >>")
+	}
+
+	out := &bytes.Buffer{}
+	out.WriteString("%PDF-1.5\n")
+	if compressed {
+		content := &bytes.Buffer{}
+		w := zlib.NewWriter(content)
+		w.Write([]byte(strings.Join(objects, "\n")))
+		w.Close()
+		fmt.Fprintf(out, "1 0 obj\n<< /Type /ObjStm /N %d /First 0 /Filter /FlateDecode /Length %d >>\nstream\n", len(objects), content.Len())
+		out.Write(content.Bytes())
+		out.WriteString("\nendstream\nendobj\n")
+	} else {
+		for i, object := range objects {
+			fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+		}
+	}
+	out.WriteString("trailer\n<< /Root 1 0 R /ID [<0a1b> <2c3d>] >>\n%%EOF\n")
+	return out.Bytes()
+}
+
+// tiff builds a document of pages image file directories with a vertical resolution of dpi.
+func tiff(order binary.ByteOrder, pages int, dpi uint32) []byte {
+	out := &bytes.Buffer{}
+	if order == binary.LittleEndian {
+		out.WriteString("II")
+	} else {
+		out.WriteString("MM")
+	}
+	binary.Write(out, order, uint16(42))
+	binary.Write(out, order, uint32(8))
+	for i := 0; i < pages; i++ {
+		offset := uint32(out.Len())
+		binary.Write(out, order, uint16(2))
+		// YResolution, a rational stored after the directory
+		binary.Write(out, order, []uint16{tagYResolution, typeRational})
+		binary.Write(out, order, []uint32{1, offset + 2 + 2*12 + 4})
+		// ResolutionUnit inch
+		binary.Write(out, order, []uint16{tagResolutionUnit, 3})
+		binary.Write(out, order, uint32(1))
+		binary.Write(out, order, []uint16{2, 0})
+		next := uint32(0)
+		if i < pages-1 {
+			next = offset + 2 + 2*12 + 4 + 8
+		}
+		binary.Write(out, order, next)
+		binary.Write(out, order, []uint32{dpi, 1})
+	}
+	return out.Bytes()
+}
+
+func inspect(data []byte) (*Document, error) {
+	return Inspect(bytes.NewReader(data), int64(len(data)))
+}
+
+func TestInspect(t *testing.T) {
+	t.Run("Should count the pages of a PDF", func(t *testing.T) {
+		document, err := inspect(pdf(3, false))
+		require.NoError(t, err)
+		assert.Equal(t, &Document{ContentType: ContentTypePDF, Pages: 3}, document)
+	})
+
+	t.Run("Should count the pages of a PDF with object streams", func(t *testing.T) {
+		document, err := inspect(pdf(5, true))
+		require.NoError(t, err)
+		assert.Equal(t, 5, document.Pages)
+	})
+
+	t.Run("Should count page objects of a PDF without a page count", func(t *testing.T) {
+		data := bytes.Replace(pdf(2, false), []byte("/Count 2"), nil, 1)
+		document, err := inspect(data)
+		require.NoError(t, err)
+		assert.Equal(t, 2, document.Pages)
+	})
+
+	t.Run("Should count the pages and read the resolution of a TIFF", func(t *testing.T) {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			document, err := inspect(tiff(order, 4, 196))
+			require.NoError(t, err)
+			assert.Equal(t, &Document{ContentType: ContentTypeTIFF, Pages: 4, Resolution: ResolutionFine}, document)
+		}
+		document, err := inspect(tiff(binary.LittleEndian, 1, 98))
+		require.NoError(t, err)
+		assert.Equal(t, ResolutionStandard, document.Resolution)
+	})
+
+	t.Run("Should stop at a loop in the TIFF directories", func(t *testing.T) {
+		data := tiff(binary.LittleEndian, 1, 196)
+		// point the next directory back at the first one
+		binary.LittleEndian.PutUint32(data[8+2+2*12:], 8)
+		document, err := inspect(data)
+		require.NoError(t, err)
+		assert.Equal(t, 1, document.Pages)
+	})
+
+	t.Run("Should reject other and damaged documents", func(t *testing.T) {
+		_, err := inspect([]byte("GIF89a not a fax document"))
+		assert.ErrorIs(t, err, ErrUnsupportedDocument)
+
+		data := pdf(1, false)
+		_, err = inspect(data[:len(data)-20])
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+
+		data = tiff(binary.BigEndian, 2, 196)
+		_, err = inspect(data[:20])
+		assert.ErrorIs(t, err, ErrInvalidDocument)
+	})
+
+	t.Run("Should reject documents over the size limit", func(t *testing.T) {
+		_, err := Inspect(bytes.NewReader(pdf(1, false)), MaxDocumentSize+1)
+		assert.ErrorIs(t, err, ErrDocumentTooLarge)
+	})
+
+	t.Run("Should reject encrypted PDFs", func(t *testing.T) {
+		data := bytes.Replace(pdf(1, false), []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt 9 0 R"), 1)
+		_, err := inspect(data)
+		assert.ErrorIs(t, err, ErrEncryptedDocument)
+	})
+}
@@ -1,6 +1,13 @@
+// Package fax validates fax documents and follows faxes through their
+// lifecycle. Outbound faxes are queued, handed to the media server and retried
+// on transmission failures, inbound faxes may be delivered by email.
 package fax
 
-import "lineblocs.com/api/model"
+import (
+	"time"
+
+	"lineblocs.com/api/model"
+)
 
 /*
 Interface of Fax Store.
@@ -9,4 +16,14 @@ Implementation of Fax Store is located /store/fax
 type FaxStoreInterface interface {
 	GetFaxCount(int) (*int, error)
 	CreateFax(*model.Fax, string, int64, string, string) (int64, error)
+	GetFaxFromDB(workspaceId int, id int) (*model.Fax, error)
+	SetFaxStatus(fax *model.Fax, from string, nextAttemptAt *time.Time) (bool, error)
+	ListFaxes(filter *model.FaxFilter) (*model.FaxList, error)
+	ClaimDueFaxes(limit int, timeout time.Duration) ([]model.Fax, error)
+	ReleaseFax(faxId int) error
+	ClaimFaxEmails(limit int, lease time.Duration) ([]model.Fax, error)
+	CompleteFaxEmail(faxId int) error
+	FailFaxEmail(faxId int, lastError string, retryAt *time.Time) error
+	GetFaxEmailSettings(workspaceId int) (*model.FaxEmailSettings, error)
+	SaveFaxEmailSettings(settings *model.FaxEmailSettings) error
 }
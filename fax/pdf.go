@@ -0,0 +1,236 @@
+package fax
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// TIFF compressions that convert to PDF.
+const (
+	compressionNone     = 1
+	compressionCCITTRLE = 2
+	compressionCCITTT4  = 3
+	compressionCCITTT6  = 4
+	compressionPackBits = 32773
+)
+
+const (
+	photometricBlackIsZero = 1
+	fillOrderReversed      = 2
+	t4TwoDimensional       = 1
+	t4FillBits             = 4
+	// resolution of pages that do not carry one, fine fax resolution
+	defaultXResolution = 204
+	defaultYResolution = 196
+)
+
+// ToPDF converts a TIFF fax document of size bytes to PDF. Only bilevel
+// pages convert, their CCITT coded strips are embedded without decoding.
+// PDF documents are returned as they are.
+func ToPDF(r io.ReaderAt, size int64) ([]byte, error) {
+	document, err := Inspect(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if document.ContentType == ContentTypePDF {
+		data := make([]byte, size)
+		if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+			return nil, err
+		}
+		return data, nil
+	}
+
+	header := make([]byte, 2)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if string(header) == "MM" {
+		order = binary.BigEndian
+	}
+	pages, err := readTIFF(r, size, order)
+	if err != nil {
+		return nil, err
+	}
+
+	w := newPDFWriter()
+	catalog := w.reserve()
+	tree := w.reserve()
+	kids := []int{}
+	for _, page := range pages {
+		kid, err := w.writePage(r, size, page, tree)
+		if err != nil {
+			return nil, err
+		}
+		kids = append(kids, kid)
+	}
+	references := &bytes.Buffer{}
+	for _, kid := range kids {
+		fmt.Fprintf(references, "%d 0 R ", kid)
+	}
+	w.object(tree, fmt.Sprintf("<< /Type /Pages /Kids [ %s] /Count %d >>", references.String(), len(kids)), nil)
+	w.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", tree), nil)
+	return w.finish(catalog), nil
+}
+
+// pdfWriter writes the objects of a PDF document and its cross-reference table.
+type pdfWriter struct {
+	out     bytes.Buffer
+	offsets []int
+}
+
+func newPDFWriter() *pdfWriter {
+	w := &pdfWriter{}
+	w.out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	return w
+}
+
+// reserve numbers an object written later.
+func (w *pdfWriter) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+// object writes object id with dictionary, followed by stream when it is not nil.
+func (w *pdfWriter) object(id int, dictionary string, stream []byte) {
+	w.offsets[id-1] = w.out.Len()
+	fmt.Fprintf(&w.out, "%d 0 obj\n%s\n", id, dictionary)
+	if stream != nil {
+		w.out.WriteString("stream\n")
+		w.out.Write(stream)
+		w.out.WriteString("\nendstream\n")
+	}
+	w.out.WriteString("endobj\n")
+}
+
+func (w *pdfWriter) finish(root int) []byte {
+	xref := w.out.Len()
+	fmt.Fprintf(&w.out, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, offset := range w.offsets {
+		fmt.Fprintf(&w.out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&w.out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(w.offsets)+1, root, xref)
+	return w.out.Bytes()
+}
+
+// writePage places every strip of a TIFF page as an image on a page sized by its resolution.
+func (w *pdfWriter) writePage(r io.ReaderAt, size int64, page tiffPage, parent int) (int, error) {
+	width := page.value(tagImageWidth, 0)
+	height := page.value(tagImageLength, 0)
+	offsets := page[tagStripOffsets]
+	counts := page[tagStripByteCounts]
+	if width == 0 || height == 0 || len(offsets) == 0 || len(offsets) != len(counts) {
+		return 0, ErrInvalidDocument
+	}
+	if page.value(tagBitsPerSample, 1) != 1 || page.value(tagSamplesPerPixel, 1) != 1 {
+		return 0, ErrUnsupportedDocument
+	}
+	filter, err := stripFilter(page, width)
+	if err != nil {
+		return 0, err
+	}
+
+	xdpi, ydpi := page.dpi(tagXResolution), page.dpi(tagYResolution)
+	if xdpi <= 0 || ydpi <= 0 {
+		xdpi, ydpi = defaultXResolution, defaultYResolution
+	}
+	pageWidth := float64(width) * 72 / xdpi
+	pageHeight := float64(height) * 72 / ydpi
+	rowsPerStrip := page.value(tagRowsPerStrip, height)
+	if rowsPerStrip == 0 || rowsPerStrip > height {
+		rowsPerStrip = height
+	}
+
+	id := w.reserve()
+	content := &bytes.Buffer{}
+	images := &bytes.Buffer{}
+	for i := range offsets {
+		top := uint32(i) * rowsPerStrip
+		if top >= height {
+			break
+		}
+		rows := min(rowsPerStrip, height-top)
+		if int64(offsets[i])+int64(counts[i]) > size {
+			return 0, ErrInvalidDocument
+		}
+		strip := make([]byte, counts[i])
+		if _, err := r.ReadAt(strip, int64(offsets[i])); err != nil && err != io.EOF {
+			return 0, ErrInvalidDocument
+		}
+		if page.value(tagFillOrder, 1) == fillOrderReversed {
+			for j := range strip {
+				strip[j] = bits.Reverse8(strip[j])
+			}
+		}
+		if page.value(tagCompression, compressionNone) == compressionPackBits {
+			strip = unpackBits(strip)
+		}
+
+		image := w.reserve()
+		w.object(image, fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 1 %s /Length %d >>",
+			width, rows, filter, len(strip)), strip)
+		fmt.Fprintf(images, "/Im%d %d 0 R ", i, image)
+		fmt.Fprintf(content, "q %.4f 0 0 %.4f 0 %.4f cm /Im%d Do Q\n",
+			pageWidth, float64(rows)*72/ydpi, pageHeight-float64(top+rows)*72/ydpi, i)
+	}
+
+	contents := w.reserve()
+	w.object(contents, fmt.Sprintf("<< /Length %d >>", content.Len()), content.Bytes())
+	w.object(id, fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.4f %.4f] /Resources << /XObject << %s>> >> /Contents %d 0 R >>",
+		parent, pageWidth, pageHeight, images.String(), contents), nil)
+	return id, nil
+}
+
+// stripFilter returns the filter of the strip images of a page.
+func stripFilter(page tiffPage, width uint32) (string, error) {
+	blackIs1 := page.value(tagPhotometric, 0) == photometricBlackIsZero
+	switch page.value(tagCompression, compressionNone) {
+	case compressionNone, compressionPackBits:
+		// 0 is black in DeviceGray, white is zero in most fax documents
+		if !blackIs1 {
+			return "/Decode [1 0]", nil
+		}
+		return "", nil
+	case compressionCCITTRLE:
+		return ccittFilter(0, width, blackIs1, true, false), nil
+	case compressionCCITTT4:
+		options := page.value(tagT4Options, 0)
+		k := 0
+		if options&t4TwoDimensional != 0 {
+			k = 1
+		}
+		return ccittFilter(k, width, blackIs1, options&t4FillBits != 0, true), nil
+	case compressionCCITTT6:
+		return ccittFilter(-1, width, blackIs1, false, false), nil
+	}
+	return "", ErrUnsupportedDocument
+}
+
+func ccittFilter(k int, width uint32, blackIs1 bool, byteAlign bool, endOfLine bool) string {
+	return fmt.Sprintf("/Filter /CCITTFaxDecode /DecodeParms << /K %d /Columns %d /BlackIs1 %t /EncodedByteAlign %t /EndOfLine %t >>",
+		k, width, blackIs1, byteAlign, endOfLine)
+}
+
+// unpackBits decodes a PackBits strip.
+func unpackBits(data []byte) []byte {
+	out := make([]byte, 0, len(data)*2)
+	for i := 0; i < len(data); {
+		n := int(int8(data[i]))
+		i++
+		switch {
+		case n >= 0:
+			end := min(i+n+1, len(data))
+			out = append(out, data[i:end]...)
+			i = end
+		case n != -128 && i < len(data):
+			for j := 0; j < 1-n; j++ {
+				out = append(out, data[i])
+			}
+			i++
+		}
+	}
+	return out
+}
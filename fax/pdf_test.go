@@ -0,0 +1,120 @@
+package fax
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tiffEntry is a directory entry whose values are stored after the directory.
+type tiffEntry struct {
+	tag    uint16
+	typ    uint16
+	values []uint32
+}
+
+// faxTIFF builds a little endian TIFF of pages pages, each 16x8 pixels in two
+// strips of data with the given compression at 204x196 dpi.
+func faxTIFF(pages int, compression uint32, strip []byte) []byte {
+	order := binary.LittleEndian
+	out := &bytes.Buffer{}
+	out.WriteString("II")
+	binary.Write(out, order, uint16(42))
+	binary.Write(out, order, uint32(8))
+	for i := 0; i < pages; i++ {
+		offset := uint32(out.Len())
+		entries := []tiffEntry{
+			{tagImageWidth, typeShort, []uint32{16}},
+			{tagImageLength, typeShort, []uint32{8}},
+			{tagCompression, typeShort, []uint32{compression}},
+			{tagStripOffsets, typeLong, nil},
+			{tagRowsPerStrip, typeShort, []uint32{4}},
+			{tagStripByteCounts, typeLong, []uint32{uint32(len(strip)), uint32(len(strip))}},
+			{tagXResolution, typeRational, []uint32{204, 1}},
+			{tagYResolution, typeRational, []uint32{196, 1}},
+		}
+		// values after the directory, then the strips
+		values := offset + 2 + uint32(len(entries))*12 + 4
+		data := &bytes.Buffer{}
+		stripsAt := values + 4*(2+2+4)
+		entries[3].values = []uint32{stripsAt, stripsAt + uint32(len(strip))}
+
+		binary.Write(out, order, uint16(len(entries)))
+		for _, entry := range entries {
+			binary.Write(out, order, []uint16{entry.tag, entry.typ})
+			count := uint32(len(entry.values))
+			if entry.typ == typeRational {
+				count /= 2
+			}
+			binary.Write(out, order, count)
+			switch {
+			case entry.typ == typeShort && len(entry.values) == 1:
+				binary.Write(out, order, []uint16{uint16(entry.values[0]), 0})
+			case entry.typ == typeLong && len(entry.values) == 1:
+				binary.Write(out, order, entry.values[0])
+			default:
+				binary.Write(out, order, values+uint32(data.Len()))
+				binary.Write(data, order, entry.values)
+			}
+		}
+		next := uint32(0)
+		if i < pages-1 {
+			next = stripsAt + 2*uint32(len(strip))
+		}
+		binary.Write(out, order, next)
+		out.Write(data.Bytes())
+		// pad the values to where the strips start
+		out.Write(make([]byte, int(stripsAt)-out.Len()))
+		out.Write(strip)
+		out.Write(strip)
+	}
+	return out.Bytes()
+}
+
+func TestToPDF(t *testing.T) {
+	t.Run("Should embed CCITT strips in a PDF of the same pages", func(t *testing.T) {
+		data := faxTIFF(3, compressionCCITTT6, []byte{0x26, 0xa0, 0x00, 0x10})
+		pdf, err := ToPDF(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+
+		document, err := inspect(pdf)
+		require.NoError(t, err)
+		assert.Equal(t, 3, document.Pages)
+		assert.Contains(t, string(pdf), "/CCITTFaxDecode /DecodeParms << /K -1 /Columns 16")
+		assert.Equal(t, 6, bytes.Count(pdf, []byte("/Subtype /Image")))
+		// 16 pixels at 204 dpi by 8 rows at 196 dpi
+		assert.Contains(t, string(pdf), "/MediaBox [0 0 5.6471 2.9388]")
+	})
+
+	t.Run("Should decode PackBits strips", func(t *testing.T) {
+		// four rows of two bytes: a run of 0xff followed by literal bytes
+		strip := []byte{0xfd, 0xff, 0x03, 0x01, 0x02, 0x03, 0x04}
+		data := faxTIFF(1, compressionPackBits, strip)
+		pdf, err := ToPDF(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		assert.Contains(t, string(pdf), "stream\n\xff\xff\xff\xff\x01\x02\x03\x04\nendstream")
+		assert.Contains(t, string(pdf), "/Decode [1 0]")
+	})
+
+	t.Run("Should return PDFs as they are", func(t *testing.T) {
+		data := pdf(2, false)
+		converted, err := ToPDF(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		assert.Equal(t, data, converted)
+	})
+
+	t.Run("Should refuse pages it can not embed", func(t *testing.T) {
+		data := faxTIFF(1, 5, []byte{0})
+		_, err := ToPDF(bytes.NewReader(data), int64(len(data)))
+		assert.ErrorIs(t, err, ErrUnsupportedDocument)
+	})
+}
+
+func TestUnpackBits(t *testing.T) {
+	assert.Equal(t, []byte{0xaa, 0xaa, 0xaa, 0x80, 0x00, 0x2a}, unpackBits([]byte{0xfe, 0xaa, 0x02, 0x80, 0x00, 0x2a}))
+	// -128 is a no-op and truncated runs stop at the end
+	assert.Equal(t, []byte{0x01}, unpackBits([]byte{0x80, 0x00, 0x01, 0x05}))
+}
@@ -0,0 +1,131 @@
+package fax
+
+import (
+	"errors"
+	"time"
+
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+// Directions of a fax.
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+/*
+Fax lifecycle state machine.
+
+	inbound:  RECEIVED
+	outbound: QUEUED -> SENDING -> DELIVERED / FAILED
+	          QUEUED -> FAILED
+
+A failed transmission that can be retried goes back to QUEUED until the
+attempts run out. RECEIVED, DELIVERED and FAILED are terminal.
+*/
+const (
+	StatusReceived  = "RECEIVED"
+	StatusQueued    = "QUEUED"
+	StatusSending   = "SENDING"
+	StatusDelivered = "DELIVERED"
+	StatusFailed    = "FAILED"
+)
+
+// Statuses of the email delivery of an inbound fax.
+const (
+	EmailPending = "PENDING"
+	EmailSent    = "SENT"
+	EmailFailed  = "FAILED"
+)
+
+const (
+	// DefaultMaxAttempts bounds the transmissions of an outbound fax
+	DefaultMaxAttempts = 3
+	minRetryDelay      = time.Minute
+	maxRetryDelay      = 30 * time.Minute
+)
+
+var (
+	ErrUnknownStatus     = errors.New("unknown fax status")
+	ErrInvalidTransition = errors.New("invalid fax status transition")
+)
+
+var transitions = map[string][]string{
+	StatusQueued:  {StatusSending, StatusFailed},
+	StatusSending: {StatusDelivered, StatusFailed},
+}
+
+// ValidStatus reports whether status is a known fax status.
+func ValidStatus(status string) bool {
+	switch status {
+	case StatusReceived, StatusQueued, StatusSending, StatusDelivered, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// CheckTransition returns an error unless a fax may move from one status to the other.
+func CheckTransition(from string, to string) error {
+	if !ValidStatus(from) || !ValidStatus(to) {
+		return ErrUnknownStatus
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return ErrInvalidTransition
+}
+
+// T.30 error codes of the fax engine, numbered as by spandsp, that a retry
+// can not fix: the far end does not take faxes or not this document.
+const (
+	ReasonOK            = 0
+	ReasonIncompatible  = 8
+	ReasonRxIncapable   = 9
+	ReasonNoResSupport  = 11
+	ReasonNoSizeSupport = 12
+)
+
+// Retryable reports whether a transmission that failed with the T.30 error
+// code may succeed when sent again. Call failures such as busy lines carry
+// no T.30 code and are retried.
+func Retryable(reasonCode int) bool {
+	switch reasonCode {
+	case ReasonIncompatible, ReasonRxIncapable, ReasonNoResSupport, ReasonNoSizeSupport:
+		return false
+	}
+	return true
+}
+
+// RetryDelay backs off exponentially from a minute, capped at half an hour.
+func RetryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, minRetryDelay, maxRetryDelay)
+}
+
+// Apply moves fax to the status reported in update and returns when a failed
+// transmission is sent again. A retried fax goes back to QUEUED, every
+// transmission counts as an attempt, including failures before sending.
+func Apply(fax *model.Fax, update *model.FaxStatusUpdate, maxAttempts int, now time.Time) (*time.Time, error) {
+	err := CheckTransition(fax.Status, update.Status)
+	if err != nil {
+		return nil, err
+	}
+	if update.Status == StatusSending || (update.Status == StatusFailed && fax.Status == StatusQueued) {
+		fax.Attempts++
+	}
+	fax.Status = update.Status
+	fax.ReasonCode = update.ReasonCode
+	fax.Reason = update.Reason
+
+	if fax.Status != StatusFailed || fax.Direction != DirectionOutbound {
+		return nil, nil
+	}
+	if !Retryable(fax.ReasonCode) || fax.Attempts >= maxAttempts {
+		return nil, nil
+	}
+	fax.Status = StatusQueued
+	retryAt := now.Add(RetryDelay(fax.Attempts))
+	return &retryAt, nil
+}
@@ -0,0 +1,77 @@
+package fax
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/model"
+)
+
+func TestCheckTransition(t *testing.T) {
+	assert.NoError(t, CheckTransition(StatusQueued, StatusSending))
+	assert.NoError(t, CheckTransition(StatusSending, StatusDelivered))
+	assert.NoError(t, CheckTransition(StatusQueued, StatusFailed))
+	assert.ErrorIs(t, CheckTransition(StatusReceived, StatusSending), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckTransition(StatusDelivered, StatusFailed), ErrInvalidTransition)
+	assert.ErrorIs(t, CheckTransition(StatusQueued, "LOST"), ErrUnknownStatus)
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Should count an attempt for every transmission", func(t *testing.T) {
+		fax := &model.Fax{Direction: DirectionOutbound, Status: StatusQueued}
+		retryAt, err := Apply(fax, &model.FaxStatusUpdate{Status: StatusSending}, DefaultMaxAttempts, now)
+		require.NoError(t, err)
+		assert.Nil(t, retryAt)
+		assert.Equal(t, 1, fax.Attempts)
+
+		_, err = Apply(fax, &model.FaxStatusUpdate{Status: StatusDelivered}, DefaultMaxAttempts, now)
+		require.NoError(t, err)
+		assert.Equal(t, StatusDelivered, fax.Status)
+		assert.Equal(t, 1, fax.Attempts)
+	})
+
+	t.Run("Should queue failed transmissions again until the attempts run out", func(t *testing.T) {
+		fax := &model.Fax{Direction: DirectionOutbound, Status: StatusQueued}
+		for attempt := 1; attempt <= DefaultMaxAttempts; attempt++ {
+			_, err := Apply(fax, &model.FaxStatusUpdate{Status: StatusSending}, DefaultMaxAttempts, now)
+			require.NoError(t, err)
+			retryAt, err := Apply(fax, &model.FaxStatusUpdate{Status: StatusFailed, ReasonCode: 3, Reason: "Timed out waiting for the first message"}, DefaultMaxAttempts, now)
+			require.NoError(t, err)
+			assert.Equal(t, 3, fax.ReasonCode)
+
+			if attempt < DefaultMaxAttempts {
+				assert.Equal(t, StatusQueued, fax.Status)
+				require.NotNil(t, retryAt)
+				assert.Equal(t, now.Add(RetryDelay(attempt)), *retryAt)
+			} else {
+				assert.Equal(t, StatusFailed, fax.Status)
+				assert.Nil(t, retryAt)
+			}
+		}
+	})
+
+	t.Run("Should not retry faxes the far end can not take", func(t *testing.T) {
+		fax := &model.Fax{Direction: DirectionOutbound, Status: StatusSending, Attempts: 1}
+		retryAt, err := Apply(fax, &model.FaxStatusUpdate{Status: StatusFailed, ReasonCode: ReasonRxIncapable}, DefaultMaxAttempts, now)
+		require.NoError(t, err)
+		assert.Nil(t, retryAt)
+		assert.Equal(t, StatusFailed, fax.Status)
+	})
+
+	t.Run("Should reject updates of received faxes", func(t *testing.T) {
+		fax := &model.Fax{Direction: DirectionInbound, Status: StatusReceived}
+		_, err := Apply(fax, &model.FaxStatusUpdate{Status: StatusFailed}, DefaultMaxAttempts, now)
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		assert.Equal(t, StatusReceived, fax.Status)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, RetryDelay(0))
+	assert.Equal(t, 4*time.Minute, RetryDelay(3))
+	assert.Equal(t, 30*time.Minute, RetryDelay(10))
+}
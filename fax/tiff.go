@@ -0,0 +1,150 @@
+package fax
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// TIFF tags read from the image file directories.
+const (
+	tagImageWidth      = 256
+	tagImageLength     = 257
+	tagBitsPerSample   = 258
+	tagCompression     = 259
+	tagPhotometric     = 262
+	tagFillOrder       = 266
+	tagStripOffsets    = 273
+	tagSamplesPerPixel = 277
+	tagRowsPerStrip    = 278
+	tagStripByteCounts = 279
+	tagXResolution     = 282
+	tagYResolution     = 283
+	tagT4Options       = 292
+	tagResolutionUnit  = 296
+)
+
+// TIFF field types.
+const (
+	typeByte     = 1
+	typeShort    = 3
+	typeLong     = 4
+	typeRational = 5
+)
+
+const unitCentimeter = 3
+
+// tiffPage holds the fields of an image file directory, rationals take two values.
+type tiffPage map[uint16][]uint32
+
+func (p tiffPage) value(tag uint16, fallback uint32) uint32 {
+	if values := p[tag]; len(values) > 0 {
+		return values[0]
+	}
+	return fallback
+}
+
+// dpi returns the resolution in dots per inch read from tag, 0 when unknown.
+func (p tiffPage) dpi(tag uint16) float64 {
+	values := p[tag]
+	if len(values) < 2 || values[1] == 0 {
+		return 0
+	}
+	dpi := float64(values[0]) / float64(values[1])
+	if p.value(tagResolutionUnit, 2) == unitCentimeter {
+		dpi *= 2.54
+	}
+	return dpi
+}
+
+// readTIFF reads the image file directories, one per page. A loop in the
+// directory chain ends it, more than MaxPages are not read.
+func readTIFF(r io.ReaderAt, size int64, order binary.ByteOrder) ([]tiffPage, error) {
+	read := func(offset int64, n int64) ([]byte, error) {
+		if offset < 0 || n < 0 || offset+n > size {
+			return nil, ErrInvalidDocument
+		}
+		buf := make([]byte, n)
+		if _, err := r.ReadAt(buf, offset); err != nil {
+			return nil, ErrInvalidDocument
+		}
+		return buf, nil
+	}
+
+	header, err := read(4, 4)
+	if err != nil {
+		return nil, err
+	}
+	pages := []tiffPage{}
+	offset := int64(order.Uint32(header))
+	visited := map[int64]bool{}
+	for offset != 0 && !visited[offset] && len(pages) <= MaxPages {
+		visited[offset] = true
+
+		countBytes, err := read(offset, 2)
+		if err != nil {
+			return nil, err
+		}
+		count := int64(order.Uint16(countBytes))
+		entries, err := read(offset+2, count*12+4)
+		if err != nil {
+			return nil, err
+		}
+		page := tiffPage{}
+		for i := int64(0); i < count; i++ {
+			entry := entries[i*12 : i*12+12]
+			tag, values, err := readTIFFField(entry, order, read)
+			if err != nil {
+				return nil, err
+			}
+			if values != nil {
+				page[tag] = values
+			}
+		}
+		pages = append(pages, page)
+		offset = int64(order.Uint32(entries[count*12:]))
+	}
+	if len(pages) == 0 {
+		return nil, ErrInvalidDocument
+	}
+	return pages, nil
+}
+
+// readTIFFField decodes the values of a directory entry, fields of other types are skipped.
+func readTIFFField(entry []byte, order binary.ByteOrder, read func(int64, int64) ([]byte, error)) (uint16, []uint32, error) {
+	tag := order.Uint16(entry[0:2])
+	count := int64(order.Uint32(entry[4:8]))
+	var width int64
+	switch order.Uint16(entry[2:4]) {
+	case typeByte:
+		width = 1
+	case typeShort:
+		width = 2
+	case typeLong:
+		width = 4
+	case typeRational:
+		width, count = 4, count*2
+	default:
+		return tag, nil, nil
+	}
+
+	data := entry[8:12]
+	if count*width > 4 {
+		var err error
+		data, err = read(int64(order.Uint32(entry[8:12])), count*width)
+		if err != nil {
+			return tag, nil, err
+		}
+	}
+	values := make([]uint32, count)
+	for i := range values {
+		switch width {
+		case 1:
+			values[i] = uint32(data[i])
+		case 2:
+			values[i] = uint32(order.Uint16(data[i*2:]))
+		default:
+			values[i] = order.Uint32(data[i*4:])
+		}
+	}
+	return tag, values, nil
+}
@@ -0,0 +1,251 @@
+package fax
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	// DefaultSender sends fax-to-email messages unless another sender is configured
+	DefaultSender       = "Lineblocs <fax@lineblocs.com>"
+	DefaultPollInterval = 5 * time.Second
+	DefaultBatchSize    = 10
+	// DefaultLease must outlast the delivery of a fax-to-email message
+	DefaultLease = 2 * time.Minute
+	// DefaultRequestTimeout must outlast the time the media server takes to
+	// report a requested transmission, the attempt is requested again after it
+	DefaultRequestTimeout = 15 * time.Minute
+	// DefaultEmailAttempts bounds the deliveries of a fax-to-email message
+	DefaultEmailAttempts = 5
+)
+
+// Worker hands outbound faxes that are due to the media server by publishing
+// fax.send_requested once per attempt, and delivers inbound faxes by email as
+// PDF attachments.
+type Worker struct {
+	store          FaxStoreInterface
+	objects        *objectstore.Manager
+	mailer         mail.Mailer
+	bus            eventbus.EventBus
+	signer         *objectstore.ProxySigner
+	interval       time.Duration
+	batchSize      int
+	lease          time.Duration
+	requestTimeout time.Duration
+	emailAttempts  int
+	now            func() time.Time
+}
+
+func NewWorker(store FaxStoreInterface, objects *objectstore.Manager) *Worker {
+	return &Worker{
+		store:          store,
+		objects:        objects,
+		interval:       DefaultPollInterval,
+		batchSize:      DefaultBatchSize,
+		lease:          DefaultLease,
+		requestTimeout: DefaultRequestTimeout,
+		emailAttempts:  DefaultEmailAttempts,
+		now:            time.Now,
+	}
+}
+
+// SetEventBus requests transmissions of outbound faxes on bus, without it
+// outbound faxes stay queued.
+func (w *Worker) SetEventBus(bus eventbus.EventBus) {
+	w.bus = bus
+}
+
+// SetDownloadSigner signs the download URLs of the documents of outbound
+// faxes kept in stores that can not sign URLs themselves.
+func (w *Worker) SetDownloadSigner(signer *objectstore.ProxySigner) {
+	w.signer = signer
+}
+
+// SetMailer delivers fax-to-email messages through mailer, without it they
+// stay pending. The worker retries failed deliveries itself so mailer should
+// not be a queue.
+func (w *Worker) SetMailer(mailer mail.Mailer) {
+	w.mailer = mailer
+}
+
+// Run processes due faxes until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		// keep going while full batches come back
+		for {
+			n, err := w.ProcessBatch(ctx)
+			if err != nil {
+				utils.Log(logrus.ErrorLevel, "fax worker could not claim faxes: "+err.Error())
+				break
+			}
+			if n < w.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch requests one batch of due transmissions, delivers one batch of
+// emails and returns the size of the larger batch.
+func (w *Worker) ProcessBatch(ctx context.Context) (int, error) {
+	sent, err := w.dispatch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	emailed, err := w.deliver(ctx)
+	if err != nil {
+		return sent, err
+	}
+	return max(sent, emailed), nil
+}
+
+func (w *Worker) dispatch(ctx context.Context) (int, error) {
+	if w.bus == nil {
+		return 0, nil
+	}
+	faxes, err := w.store.ClaimDueFaxes(w.batchSize, w.requestTimeout)
+	if err != nil {
+		return 0, err
+	}
+	for _, fax := range faxes {
+		// the URL is good until the attempt is requested again
+		expiresAt := w.now().Add(w.requestTimeout)
+		url, err := w.downloadURL(&fax, expiresAt)
+		var event eventbus.Event
+		if err == nil {
+			event, err = eventbus.NewEvent(fax.WorkspaceId, eventbus.FaxSendRequested{
+				FaxId:       fax.Id,
+				APIId:       fax.APIId,
+				From:        fax.From,
+				To:          fax.To,
+				Resolution:  fax.Resolution,
+				DownloadURL: url,
+				ExpiresAt:   expiresAt.UTC().Format(time.RFC3339),
+				Attempt:     fax.Attempts + 1,
+			})
+		}
+		if err == nil {
+			err = w.bus.Publish(ctx, event)
+		}
+		if err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("fax worker could not request transmission of fax id = %d: %s", fax.Id, err.Error()))
+			// the transmission is requested again on the next batch
+			if err := w.store.ReleaseFax(fax.Id); err != nil {
+				utils.Log(logrus.ErrorLevel, fmt.Sprintf("fax worker could not release fax id = %d: %s", fax.Id, err.Error()))
+			}
+		}
+	}
+	return len(faxes), nil
+}
+
+// downloadURL signs a URL the media server downloads the document of fax from.
+func (w *Worker) downloadURL(fax *model.Fax, expiresAt time.Time) (string, error) {
+	objects, err := w.objects.ForWorkspace(fax.WorkspaceId)
+	if err != nil {
+		return "", err
+	}
+	key := objectstore.Key(objectstore.FolderFaxes, fax.APIId)
+	return objectstore.DownloadURL(objects, w.signer, fax.WorkspaceId, key, expiresAt)
+}
+
+func (w *Worker) deliver(ctx context.Context) (int, error) {
+	if w.mailer == nil {
+		return 0, nil
+	}
+	faxes, err := w.store.ClaimFaxEmails(w.batchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+	for i := range faxes {
+		if ctx.Err() != nil {
+			// the lease expires and another worker picks the rest up
+			return len(faxes), nil
+		}
+		w.email(ctx, &faxes[i])
+	}
+	return len(faxes), nil
+}
+
+func (w *Worker) email(ctx context.Context, fax *model.Fax) {
+	attachment, err := w.attachment(ctx, fax)
+	var message *notification.Message
+	if err == nil {
+		message, err = notification.Render(notification.TypeFaxReceived, fax.EmailLocale, fax)
+	}
+	if err == nil {
+		email := mail.NewEmail(fax.EmailTo, message)
+		email.Attachments = []mail.Attachment{*attachment}
+		err = w.mailer.Send(ctx, email)
+	}
+	if err == nil {
+		err = w.store.CompleteFaxEmail(fax.Id)
+		if err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("fax worker could not complete email of fax id = %d: %s", fax.Id, err.Error()))
+		}
+		return
+	}
+
+	// a deleted fax or a rejected email can not be delivered
+	var retryAt *time.Time
+	if !errors.Is(err, objectstore.ErrNotFound) && !errors.Is(err, mail.ErrPermanent) && fax.EmailAttempts+1 < w.emailAttempts {
+		at := w.now().Add(RetryDelay(fax.EmailAttempts + 1))
+		retryAt = &at
+	}
+	utils.Log(logrus.WarnLevel, fmt.Sprintf("email of fax id = %d failed on attempt %d: %s", fax.Id, fax.EmailAttempts+1, err.Error()))
+	err = w.store.FailFaxEmail(fax.Id, err.Error(), retryAt)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "fax worker could not record email failure: "+err.Error())
+	}
+}
+
+// attachment reads the fax document as PDF. TIFF pages that do not convert
+// are sent as the original document.
+func (w *Worker) attachment(ctx context.Context, fax *model.Fax) (*mail.Attachment, error) {
+	objects, err := w.objects.ForWorkspace(fax.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	body, err := objects.Get(ctx, objectstore.Key(objectstore.FolderFaxes, fax.APIId))
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, MaxDocumentSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	name := fax.APIId
+	if fax.Name != "" {
+		name = strings.TrimSuffix(fax.Name, filepath.Ext(fax.Name))
+	}
+	pdf, err := ToPDF(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		utils.Log(logrus.WarnLevel, fmt.Sprintf("could not convert fax id = %d to PDF, attaching the original: %s", fax.Id, err.Error()))
+		return &mail.Attachment{Name: name + ".tif", Data: data}, nil
+	}
+	return &mail.Attachment{Name: name + ".pdf", Data: pdf}, nil
+}
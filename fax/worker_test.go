@@ -0,0 +1,169 @@
+package fax
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+)
+
+// failingMailer fails every delivery with err.
+type failingMailer struct {
+	err error
+}
+
+func (m *failingMailer) Send(ctx context.Context, email *mail.Email) error {
+	return m.err
+}
+
+// failingBus fails every publish with err.
+type failingBus struct {
+	eventbus.EventBus
+	err error
+}
+
+func (b *failingBus) Publish(ctx context.Context, event eventbus.Event) error {
+	return b.err
+}
+
+func newObjects(t *testing.T, documents map[string][]byte) *objectstore.Manager {
+	local, err := objectstore.NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	for apiId, data := range documents {
+		key := objectstore.Key(objectstore.FolderFaxes, apiId)
+		require.NoError(t, local.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), ""))
+	}
+	return objectstore.NewStaticManager(local)
+}
+
+func TestWorker(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should request the transmission of due outbound faxes", func(t *testing.T) {
+		store := mocks.NewFaxStoreInterface(t)
+		store.EXPECT().ClaimDueFaxes(DefaultBatchSize, DefaultRequestTimeout).Return([]model.Fax{
+			{Id: 4, WorkspaceId: 7, APIId: "fax-4", To: "+15145550100", Resolution: ResolutionFine, Uri: "https://files/fax-4", Attempts: 1},
+		}, nil)
+		bus := eventbus.NewMemoryBus()
+
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		signer := objectstore.NewProxySigner([]byte("secret"), "https://api.example.com")
+		worker := NewWorker(store, newObjects(t, nil))
+		worker.SetEventBus(bus)
+		worker.SetDownloadSigner(signer)
+		worker.now = func() time.Time { return now }
+		n, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		published := bus.Published(eventbus.TypeFaxSendRequested)
+		require.Len(t, published, 1)
+		var request eventbus.FaxSendRequested
+		require.NoError(t, published[0].Decode(&request))
+		expiresAt := now.Add(DefaultRequestTimeout)
+		assert.Equal(t, eventbus.FaxSendRequested{
+			FaxId:       4,
+			APIId:       "fax-4",
+			To:          "+15145550100",
+			Resolution:  ResolutionFine,
+			DownloadURL: signer.Sign(7, objectstore.Key(objectstore.FolderFaxes, "fax-4"), expiresAt),
+			ExpiresAt:   "2024-06-01T00:15:00Z",
+			Attempt:     2,
+		}, request)
+	})
+
+	t.Run("Should release faxes without a download URL", func(t *testing.T) {
+		store := mocks.NewFaxStoreInterface(t)
+		store.EXPECT().ClaimDueFaxes(DefaultBatchSize, DefaultRequestTimeout).Return([]model.Fax{
+			{Id: 4, WorkspaceId: 7, APIId: "fax-4", To: "+15145550100", Resolution: ResolutionFine, Uri: "https://files/fax-4"},
+		}, nil)
+		store.EXPECT().ReleaseFax(4).Return(nil)
+		bus := eventbus.NewMemoryBus()
+
+		// the local store can not sign URLs itself
+		worker := NewWorker(store, newObjects(t, nil))
+		worker.SetEventBus(bus)
+		_, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, bus.Published(eventbus.TypeFaxSendRequested))
+	})
+
+	t.Run("Should release faxes whose request could not be published", func(t *testing.T) {
+		store := mocks.NewFaxStoreInterface(t)
+		store.EXPECT().ClaimDueFaxes(DefaultBatchSize, DefaultRequestTimeout).Return([]model.Fax{
+			{Id: 4, WorkspaceId: 7, APIId: "fax-4", To: "+15145550100", Resolution: ResolutionFine, Uri: "https://files/fax-4"},
+		}, nil)
+		store.EXPECT().ReleaseFax(4).Return(nil)
+
+		worker := NewWorker(store, newObjects(t, nil))
+		worker.SetEventBus(&failingBus{err: errors.New("broker unavailable")})
+		worker.SetDownloadSigner(objectstore.NewProxySigner([]byte("secret"), ""))
+		_, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("Should email received faxes as PDF", func(t *testing.T) {
+		document := faxTIFF(2, compressionCCITTT6, []byte{0x26, 0xa0})
+		store := mocks.NewFaxStoreInterface(t)
+		store.EXPECT().ClaimFaxEmails(DefaultBatchSize, DefaultLease).Return([]model.Fax{
+			{Id: 5, WorkspaceId: 7, APIId: "fax-5", Name: "invoice.tif", From: "<+1514>", Pages: 2, EmailTo: []string{"ops@example.com"}, EmailLocale: "fr-CA"},
+		}, nil)
+		store.EXPECT().CompleteFaxEmail(5).Return(nil)
+		mailer := mail.NewCaptureMailer("", "")
+
+		worker := NewWorker(store, newObjects(t, map[string][]byte{"fax-5": document}))
+		worker.SetMailer(mailer)
+		_, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, mailer.Sent(), 1)
+		email := mailer.Sent()[0]
+		assert.Equal(t, []string{"ops@example.com"}, email.To)
+		assert.Equal(t, "Nouvelle télécopie de <+1514>", email.Subject)
+		assert.Contains(t, email.Text, "Pages : 2")
+		assert.Contains(t, email.HTML, "&lt;&#43;1514&gt;")
+		assert.NotContains(t, email.HTML, "<+1514>")
+		require.Len(t, email.Attachments, 1)
+		assert.Equal(t, "invoice.pdf", email.Attachments[0].Name)
+		attached, err := Inspect(bytes.NewReader(email.Attachments[0].Data), int64(len(email.Attachments[0].Data)))
+		require.NoError(t, err)
+		assert.Equal(t, &Document{ContentType: ContentTypePDF, Pages: 2}, attached)
+	})
+
+	t.Run("Should retry failed emails until attempts run out", func(t *testing.T) {
+		store := mocks.NewFaxStoreInterface(t)
+		store.EXPECT().ClaimFaxEmails(DefaultBatchSize, DefaultLease).Return([]model.Fax{
+			{Id: 1, WorkspaceId: 7, APIId: "fax-1", EmailTo: []string{"ops@example.com"}, EmailAttempts: 1},
+			{Id: 2, WorkspaceId: 7, APIId: "fax-1", EmailTo: []string{"ops@example.com"}, EmailAttempts: DefaultEmailAttempts - 1},
+			{Id: 3, WorkspaceId: 7, APIId: "missing", EmailTo: []string{"ops@example.com"}},
+		}, nil)
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		retryAt := now.Add(RetryDelay(2))
+		store.EXPECT().FailFaxEmail(1, "mail server unavailable", &retryAt).Return(nil)
+		store.EXPECT().FailFaxEmail(2, "mail server unavailable", (*time.Time)(nil)).Return(nil)
+		store.EXPECT().FailFaxEmail(3, objectstore.ErrNotFound.Error(), (*time.Time)(nil)).Return(nil)
+
+		worker := NewWorker(store, newObjects(t, map[string][]byte{"fax-1": pdf(1, false)}))
+		worker.SetMailer(&failingMailer{err: errors.New("mail server unavailable")})
+		worker.now = func() time.Time { return now }
+		_, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+	})
+
+	t.Run("Should leave faxes alone without a bus or mailer", func(t *testing.T) {
+		worker := NewWorker(mocks.NewFaxStoreInterface(t), newObjects(t, nil))
+		n, err := worker.ProcessBatch(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, n)
+	})
+}
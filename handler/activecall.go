@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/activecall"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+// acquireActiveCall registers a new call against the concurrent call limits
+// of its workspace and trunk. Only ErrLimitReached is returned, a registry
+// outage is logged and lets the call through.
+func (h *Handler) acquireActiveCall(call *model.Call, limits *model.CallLimits) error {
+	if h.activeCalls == nil {
+		return nil
+	}
+	err := h.activeCalls.Acquire(model.ActiveCall{
+		CallId:      call.Id,
+		APIId:       call.APIId,
+		WorkspaceId: call.WorkspaceId,
+		Extension:   call.Extension,
+		TrunkId:     call.TrunkId,
+		From:        call.From,
+		To:          call.To,
+		Direction:   call.Direction,
+		Status:      call.Status,
+		StartedAt:   time.Now().UTC().Format(time.RFC3339),
+	}, *limits)
+	if errors.Is(err, activecall.ErrLimitReached) {
+		return err
+	}
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not register active call "+call.APIId+": "+err.Error())
+	}
+	return nil
+}
+
+// trackActiveCall keeps the registry in line with the call status, terminal
+// calls are released and all others have their expiry extended.
+func (h *Handler) trackActiveCall(call *model.Call) {
+	if h.activeCalls == nil {
+		return
+	}
+	if callstate.IsTerminalStatus(call.Status) {
+		h.releaseActiveCall(call.APIId)
+		return
+	}
+	err := h.activeCalls.Refresh(call.APIId, call.Id, call.Status)
+	if err != nil && !errors.Is(err, activecall.ErrNotFound) {
+		utils.Log(logrus.ErrorLevel, "could not refresh active call "+call.APIId+": "+err.Error())
+	}
+}
+
+func (h *Handler) releaseActiveCall(apiId string) {
+	if h.activeCalls == nil {
+		return
+	}
+	err := h.activeCalls.Release(apiId)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not release active call "+apiId+": "+err.Error())
+	}
+}
+
+// checkCallCapacity reports whether one more call fits in the workspace and
+// trunk limits, without registering it.
+func (h *Handler) checkCallCapacity(workspaceId int, trunkId int) error {
+	if h.activeCalls == nil || workspaceId == 0 {
+		return nil
+	}
+	limits, err := h.callStore.GetConcurrentCallLimits(workspaceId, "", trunkId)
+	if err != nil {
+		return err
+	}
+	return h.activeCalls.Check(workspaceId, trunkId, *limits)
+}
+
+/*
+Input: workspace_id
+Todo : Get the calls currently in progress in a workspace
+Output: If success return ActiveCallSummary model else return err
+*/
+func (h *Handler) GetActiveCalls(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetActiveCalls is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetActiveCalls workspace_id is required", err, c)
+	}
+
+	calls, err := h.activeCalls.List(workspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("GetActiveCalls could not list active calls", err, c)
+	}
+	limits, err := h.callStore.GetConcurrentCallLimits(workspaceId, "", 0)
+	if err != nil {
+		return utils.HandleInternalErr("GetActiveCalls could not get call limits", err, c)
+	}
+
+	return c.JSON(http.StatusOK, &model.ActiveCallSummary{
+		WorkspaceId: workspaceId,
+		Count:       len(calls),
+		Limit:       limits.Workspace,
+		Calls:       calls,
+	})
+}
@@ -1,14 +1,14 @@
 package handler
 
 import (
-	"context"
+	"errors"
 	"net/http"
-	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/mailgun/mailgun-go/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/mail"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
 	"lineblocs.com/api/utils"
 )
 
@@ -41,34 +41,28 @@ func (h *Handler) SendAdminEmail(c echo.Context) error {
 		return utils.HandleInternalErr("SendAdminEmail Could not decode JSON", err, c)
 	}
 
-	mg := mailgun.NewMailgun(utils.Config("MAILGUN_DOMAIN"), utils.Config("MAILGUN_API_KEY"))
-	m := mg.NewMessage(
-		"Lineblocs <monitor@lineblocs.com>",
-		"Admin Error",
-		"Admin Error",
-		"contact@lineblocs.com")
-	body := `<html>
-		<head></head>
-		<body>
-			<h1>Lineblocs Admin Monitor</h1>
-			<p>` + emailInfo.Message + `</p>
-		</body>
-		</html>`
-
-	m.SetHtml(body)
-	//m.AddAttachment("files/test.jpg")
-	//m.AddAttachment("files/test.txt")
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-
-	_, _, err := mg.Send(ctx, m)
+	message, err := notification.Render(notification.TypeAdminError, notification.DefaultLocale, emailInfo)
+	if err != nil {
+		return utils.HandleInternalErr("SendAdminEmail could not render email", err, c)
+	}
+	if h.mailer == nil {
+		return utils.HandleInternalErr("SendAdminEmail error", errors.New("email is not configured"), c)
+	}
+	err = h.mailer.Send(c.Request().Context(), mail.NewEmail([]string{AdminEmail()}, message))
 	if err != nil {
 		return utils.HandleInternalErr("SendAdminEmail error", err, c)
 	}
 	return c.NoContent(http.StatusNoContent)
 }
 
+// AdminEmail receives the admin error emails, ADMIN_EMAIL or contact@lineblocs.com.
+func AdminEmail() string {
+	if address := utils.Config("ADMIN_EMAIL"); address != "" {
+		return address
+	}
+	return "contact@lineblocs.com"
+}
+
 /*
 Input:
 Todo : Choose Best one from rtpproxy_sockets
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/apikey"
+	"lineblocs.com/api/middlewares"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: APIKey model with service, scopes and optional expires_at
+Todo : Create an API key for a service
+Output: If success return APIKey model with the token else return err
+The token is only returned here, a key can not grant scopes the caller does not have
+*/
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "CreateAPIKey is called...")
+
+	var request model.APIKey
+	if err := c.Bind(&request); err != nil {
+		return utils.HandleInternalErr("CreateAPIKey Could not decode JSON", err, c)
+	}
+	request.Service = strings.TrimSpace(request.Service)
+	if request.Service == "" {
+		return utils.HandleBadRequest("CreateAPIKey service is required", errors.New("missing service"), c)
+	}
+	if len(request.Scopes) == 0 {
+		return utils.HandleBadRequest("CreateAPIKey scopes are required", errors.New("missing scopes"), c)
+	}
+	for _, scope := range request.Scopes {
+		if !apikey.ValidScope(scope) {
+			return utils.HandleBadRequest("CreateAPIKey invalid scope", fmt.Errorf("unknown scope %q", scope), c)
+		}
+	}
+	if err := grantable(c, request.Scopes); err != nil {
+		return utils.HandleForbidden("CreateAPIKey scope not granted", err, c)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return utils.HandleBadRequest("CreateAPIKey invalid expires_at", errors.New("expires_at must be in the future"), c)
+	}
+
+	key, err := h.apiKeys.Create(request.Service, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		return utils.HandleInternalErr("CreateAPIKey error occured", err, c)
+	}
+	utils.Log(logrus.InfoLevel, fmt.Sprintf("created API key id = %d %s of service %q", key.Id, key.Prefix, key.Service))
+	return c.JSON(http.StatusOK, key)
+}
+
+/*
+Input: service
+Todo : List the API keys of a service, of every service without it
+Output: If success return list of APIKey model else return err
+*/
+func (h *Handler) ListAPIKeys(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListAPIKeys is called...")
+
+	keys, err := h.apiKeys.List(c.QueryParam("service"))
+	if err != nil {
+		return utils.HandleInternalErr("ListAPIKeys error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, keys)
+}
+
+/*
+Input: APIKeyRotation model with id and optional overlap
+Todo : Replace an API key with a new one, the replaced key stays valid for the overlap, a day by default
+Output: If success return the new APIKey model with the token else return err
+*/
+func (h *Handler) RotateAPIKey(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "RotateAPIKey is called...")
+
+	var rotation model.APIKeyRotation
+	if err := c.Bind(&rotation); err != nil {
+		return utils.HandleInternalErr("RotateAPIKey Could not decode JSON", err, c)
+	}
+	if rotation.Id <= 0 {
+		return utils.HandleBadRequest("RotateAPIKey id is required", errors.New("missing id"), c)
+	}
+	overlap := apikey.DefaultOverlap
+	if rotation.Overlap != "" {
+		parsed, err := time.ParseDuration(rotation.Overlap)
+		if err != nil || parsed < 0 {
+			return utils.HandleBadRequest("RotateAPIKey invalid overlap", fmt.Errorf("invalid overlap %q", rotation.Overlap), c)
+		}
+		overlap = parsed
+	}
+	current, err := h.apiKeys.Get(rotation.Id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("RotateAPIKey error occured", err, c)
+	}
+	if err := grantable(c, current.Scopes); err != nil {
+		return utils.HandleForbidden("RotateAPIKey scope not granted", err, c)
+	}
+
+	key, err := h.apiKeys.Rotate(rotation.Id, overlap)
+	if err == apikey.ErrExpiredKey {
+		return utils.HandleConflict("RotateAPIKey key has expired", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("RotateAPIKey error occured", err, c)
+	}
+	utils.Log(logrus.InfoLevel, fmt.Sprintf("rotated API key id = %d to id = %d %s of service %q", rotation.Id, key.Id, key.Prefix, key.Service))
+	return c.JSON(http.StatusOK, key)
+}
+
+/*
+Input: id
+Todo : Expire an API key now
+Output: If success return NoContent else return err
+Other instances may accept the key until their cache of it expires
+*/
+func (h *Handler) RevokeAPIKey(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "RevokeAPIKey is called...")
+
+	id, err := strconv.Atoi(c.FormValue("id"))
+	if err != nil {
+		return utils.HandleBadRequest("RevokeAPIKey id is required", err, c)
+	}
+	current, err := h.apiKeys.Get(id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("RevokeAPIKey error occured", err, c)
+	}
+	if err := grantable(c, current.Scopes); err != nil {
+		return utils.HandleForbidden("RevokeAPIKey scope not granted", err, c)
+	}
+
+	err = h.apiKeys.Revoke(id)
+	if err != nil {
+		return utils.HandleInternalErr("RevokeAPIKey error occured", err, c)
+	}
+	utils.Log(logrus.InfoLevel, fmt.Sprintf("revoked API key id = %d", id))
+	return c.NoContent(http.StatusOK)
+}
+
+// grantable returns an error when the key of the request does not have every one of scopes.
+func grantable(c echo.Context, scopes []string) error {
+	caller := middlewares.APIKey(c)
+	for _, scope := range scopes {
+		if caller == nil || !apikey.Allows(caller.Scopes, scope) {
+			return fmt.Errorf("scope %q is not granted to the caller", scope)
+		}
+	}
+	return nil
+}
@@ -1,75 +1,22 @@
 package handler
 
 import (
-	"context"
-	"encoding/json"
+	"database/sql"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 	"errors"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/events"
 	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 	helpers "github.com/Lineblocs/go-helpers"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-type CallActivityTask struct {
-	RunID       string `json:"run_id"`
-	EventType   string `json:"event_type"`
-	From        string `json:"from"`
-	To          string `json:"to"`
-	WorkspaceID string `json:"workspace_id"`
-	Description string `json:"description"`
-}
-
-
-func (h *Handler) publishCallEventToQueue(eventType string, call *model.Call) error {
-	conn, err := amqp.Dial(os.Getenv("QUEUE_URL"))
-	if err != nil {
-		utils.Log(logrus.ErrorLevel, "RabbitMQ connection failed: "+err.Error())
-		return err
-	}
-	defer conn.Close()
-
-	ch, err := conn.Channel()
-	if err != nil {
-		return err
-	}
-	defer ch.Close()
-
-	ch.Confirm(false)
-	q, err := ch.QueueDeclare("call_activity", true, false, false, false, nil)
-	if err != nil {
-		return err
-	}
-
-
-	task := CallActivityTask{
-		RunID:       strconv.Itoa(call.Id),
-		EventType:   eventType,
-		From:        call.From,
-		To:          call.To,
-		WorkspaceID: strconv.Itoa(call.WorkspaceId),
-	}
-
-	body, _ := json.Marshal(task)
-	err = ch.PublishWithContext(context.Background(), "", q.Name, false, false, amqp.Publishing{
-		DeliveryMode: amqp.Persistent,
-		ContentType:  "application/json",
-		Body:         body,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 /*
 Input: Call model
 Todo : Create new call and store to db
@@ -95,15 +42,13 @@ func (h *Handler) CreateCall(c echo.Context) error {
 		return utils.HandleInternalErr("CreateCall internal error in processing.", err, c)
 	}
 	if isSuspended {
+		h.emitEvent(call.WorkspaceId, eventbus.SuspensionCallBlocked{From: call.From, To: call.To, Direction: call.Direction})
 		return utils.HandleInternalErr("CreateCall account is suspended", errors.New("account is suspended"), c)
 	}
 
 	call.APIId = utils.CreateAPIID("call")
 
 	if call.Direction == "OUTBOUND" {
-		// Check if this is the first time we are making a call to this destination
-		go h.callStore.ProcessUsersFirstCall(call)
-
 		permitted, err := h.callStore.IsCallerIdPermitted(call.WorkspaceId, call.From, call.To)
 		if err != nil {
 			utils.Log(logrus.ErrorLevel, "Failed to check caller ID permission: " + err.Error())
@@ -112,10 +57,11 @@ func (h *Handler) CreateCall(c echo.Context) error {
 
 		if !permitted {
 			utils.Log(logrus.InfoLevel, "Caller ID " + call.From + " is not permitted for workspace " + strconv.Itoa(call.WorkspaceId))
-			err := h.publishCallEventToQueue("UNAUTHORIZED_CALLER_ID", &call)
+			// the outbox relay delivers the event, a RabbitMQ outage must not change the response.
+			// The call is never created, so the event carries its api id and no call id
+			err := h.callStore.QueueCallActivity("UNAUTHORIZED_CALLER_ID", &call)
 			if err != nil {
-				utils.Log(logrus.ErrorLevel, "Failed to publish call event to queue: " + err.Error())
-				return utils.HandleInternalErr("CreateCall internal error in processing.", err, c)
+				utils.Log(logrus.ErrorLevel, "Failed to queue call event: " + err.Error())
 			}
 
 			err = errors.New("Using this caller ID is not permitted.")
@@ -141,16 +87,39 @@ func (h *Handler) CreateCall(c echo.Context) error {
 		}
 	}
 
+	limits, err := h.callStore.GetConcurrentCallLimits(call.WorkspaceId, call.Extension, call.TrunkId)
 	if err != nil {
-		utils.Log(logrus.ErrorLevel, "Failed to publish call event to queue: " + err.Error())
-		return utils.HandleInternalErr("CreateCall Could not execute query", err, c)
+		return utils.HandleInternalErr("CreateCall could not get call limits", err, c)
+	}
+	err = h.acquireActiveCall(&call, limits)
+	if err != nil {
+		return utils.HandleConflict("CreateCall concurrent call limit reached", err, c)
 	}
 
 	callId, err := h.callStore.CreateCall(&call)
+	if err != nil {
+		h.releaseActiveCall(call.APIId)
+	}
+	if errors.Is(err, callstate.ErrUnknownStatus) {
+		return utils.HandleBadRequest("CreateCall invalid initial status", err, c)
+	}
+	if errors.Is(err, callstate.ErrParentWorkspaceMismatch) || errors.Is(err, callstate.ErrParentCallNotFound) {
+		return utils.HandleBadRequest("CreateCall invalid parent call", err, c)
+	}
+	if errors.Is(err, callstate.ErrSessionNotFound) {
+		return utils.HandleBadRequest("CreateCall invalid session", err, c)
+	}
 	if err != nil {
 		return utils.HandleInternalErr("CreateCall Could not execute query", err, c)
 	}
 
+	h.trackActiveCall(&call)
+	h.publishEvent(events.TypeCallCreated, call.WorkspaceId, call.Id, &call)
+	if call.Direction == "OUTBOUND" {
+		// Check if this is the first time we are making a call to this destination country
+		go h.callStore.ProcessUsersFirstCall(call, h.mailer)
+	}
+
 	c.Response().Writer.Header().Set("X-Call-ID", callId)
 	return c.JSON(http.StatusOK, &call)
 }
@@ -174,6 +143,12 @@ func (h *Handler) UpdateCall(c echo.Context) error {
 	}
 
 	err := h.callStore.UpdateCall(&update)
+	if errors.Is(err, callstate.ErrInvalidTransition) {
+		return utils.HandleConflict("UpdateCall status change is not allowed", err, c)
+	}
+	if errors.Is(err, callstate.ErrUnknownStatus) {
+		return utils.HandleBadRequest("UpdateCall unknown status", err, c)
+	}
 	if err != nil {
 		return utils.HandleInternalErr("UpdateCall Could not execute query..", err, c)
 	}
@@ -182,30 +157,18 @@ func (h *Handler) UpdateCall(c echo.Context) error {
 	if err != nil {
 		return utils.HandleInternalErr("UpdateCall Could not fetch call from DB", err, c)
 	}
+	h.trackActiveCall(call)
+	if callstate.IsTerminalStatus(call.Status) {
+		h.leaveConference(call.Id)
+	}
+	h.publishEvent(events.TypeCallStatusChanged, call.WorkspaceId, call.Id, call)
 
-	// Note: To use variables from the call data structure in your debit processing below, 
-	// you should update the debit initialization inside the if statement to look like this:
-	// debit := model.Debit{
-	// 	UserId:      call.UserId,
-	// 	WorkspaceId: call.WorkspaceId,
-	// 	Status:      "PAID",
-	// 	Number:      call.To, // Using 'to' as the number
-	// }
-	// Only update if status is "ENDED"
-	utils.Log(logrus.InfoLevel, "UpdateCall Processing call ID "+strconv.Itoa(call.Id)+" with status "+update.Status)
-	if update.Status == "ENDED" && enableBillingInCallFlow {
+	// Bill once the call has ended. The store computes the billable duration
+	// from answer time, calls that were never answered are billed with 0 seconds
+	utils.Log(logrus.InfoLevel, "UpdateCall Processing call ID "+strconv.Itoa(call.Id)+" with status "+call.Status)
+	if call.Status == callstate.StatusEnded && enableBillingInCallFlow {
 		utils.Log(logrus.InfoLevel, "UpdateCall Processing billing for call ID "+strconv.Itoa(call.Id))
-		// Get Call Rate depends number and type
-		endedAt, err := utils.ParseDateTime(call.EndedAt)
-		if err != nil {
-			return utils.HandleInternalErr("UpdateCall Could not parse endedAt", err, c)
-		}
-		startedAt, err := utils.ParseDateTime(call.StartedAt)
-		if err != nil {
-			return utils.HandleInternalErr("UpdateCall Could not parse createdAt", err, c)
-		}
-
-		durationInSeconds := int(endedAt.Sub(startedAt).Seconds())
+		durationInSeconds := call.Duration
 		utils.Log(logrus.InfoLevel, "Call duration is "+strconv.Itoa(durationInSeconds)+" seconds for call ID "+strconv.Itoa(call.Id))
 
 		now := time.Now()
@@ -231,6 +194,9 @@ func (h *Handler) UpdateCall(c echo.Context) error {
 		err = h.debitStore.CreateDebit(rate, &debit)
 		if err != nil {
 			utils.Log(logrus.ErrorLevel, "UpdateCall Could not create debit: "+err.Error())
+		} else {
+			h.publishEvent(events.TypeCallBilled, call.WorkspaceId, call.Id, &debit)
+			h.emitEvent(call.WorkspaceId, debitCreatedEvent(&debit))
 		}
 	}
 
@@ -259,6 +225,57 @@ func (h *Handler) FetchCall(c echo.Context) error {
 	return c.JSON(http.StatusOK, &call)
 }
 
+/*
+Input: workspace_id, session_id or call_id
+Todo : Fetch every leg of a call session of the workspace, link them into a tree and add what each leg was charged
+Output: If success return CallSession model else return err
+*/
+func (h *Handler) GetCallSession(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetCallSession is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetCallSession workspace_id is required", err, c)
+	}
+	sessionId := c.QueryParam("session_id")
+	if sessionId == "" {
+		callId, err := strconv.Atoi(c.QueryParam("call_id"))
+		if err != nil {
+			return utils.HandleBadRequest("GetCallSession session_id or call_id is required", err, c)
+		}
+		call, err := h.callStore.GetCallFromDB(callId)
+		if err == sql.ErrNoRows || (err == nil && call.WorkspaceId != workspaceId) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		if err != nil {
+			return utils.HandleInternalErr("GetCallSession error occured", err, c)
+		}
+		sessionId = call.SessionId
+	}
+	if sessionId == "" {
+		return utils.HandleBadRequest("GetCallSession call has no session", errors.New("call has no session"), c)
+	}
+
+	calls, err := h.callStore.GetCallsBySessionId(workspaceId, sessionId)
+	if err != nil {
+		return utils.HandleInternalErr("GetCallSession error occured", err, c)
+	}
+	if len(calls) == 0 {
+		return c.NoContent(http.StatusNotFound)
+	}
+	callIds := make([]int, len(calls))
+	for i, call := range calls {
+		callIds[i] = call.Id
+	}
+	charges, err := h.debitStore.GetCallCharges(workspaceId, callIds)
+	if err != nil {
+		return utils.HandleInternalErr("GetCallSession could not get charges", err, c)
+	}
+	session := callstate.BuildCallTree(sessionId, calls)
+	callstate.AddCharges(session, charges)
+	return c.JSON(http.StatusOK, session)
+}
+
 /*
 Input: callid, apiid
 Todo : Set sip_call_id field with matching id
@@ -318,6 +335,13 @@ func (h *Handler) CreateConference(c echo.Context) error {
 		return utils.HandleInternalErr("CreateConference error occured", err, c)
 	}
 
+	// the conference may already exist, return it as stored
+	stored, err := h.callStore.GetConference(conference.Id)
+	if err != nil {
+		return utils.HandleInternalErr("CreateConference could not get conference", err, c)
+	}
+
+	hideConferencePins(stored)
 	c.Response().Writer.Header().Set("X-Conference-ID", conferenceId)
-	return c.JSON(http.StatusOK, &conference)
+	return c.JSON(http.StatusOK, stored)
 }
@@ -1,18 +1,22 @@
 package handler
 
 import (
+	"database/sql"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/events"
 	"lineblocs.com/api/helpers"
+	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 )
 
 /*
-Input: callid, status
-Todo : Update sip_status of calls with matching sip_call_id
+Input: callid, status, reason, host
+Todo : Store the SIP response in the call's event history and update sip_status of calls with matching sip_call_id
 Output: If success return NoContent else return err
 */
 func (h *Handler) CreateSIPReport(c echo.Context) error {
@@ -21,13 +25,55 @@ func (h *Handler) CreateSIPReport(c echo.Context) error {
 	callid := c.FormValue("callid")
 	status := c.FormValue("status")
 
-	err := h.carrierStore.CreateSIPReport(callid, status)
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return utils.HandleBadRequest("CreateSIPReport invalid status", err, c)
+	}
+
+	report := model.SIPReport{
+		SIPCallId:    callid,
+		Code:         code,
+		Reason:       c.FormValue("reason"),
+		ProviderHost: c.FormValue("host"),
+	}
+
+	err = h.carrierStore.CreateSIPReport(&report)
 	if err != nil {
 		return utils.HandleInternalErr("CreateSIPReport error", err, c)
 	}
+
+	// reports can arrive for calls the API never created, those are not streamed
+	call, err := h.callStore.GetCallBySIPCallId(callid)
+	if err == nil {
+		h.publishEvent(events.TypeCallSIPReport, call.WorkspaceId, call.Id, &report)
+	}
 	return c.NoContent(http.StatusOK)
 }
 
+/*
+Input: callid, workspace_id
+Todo : Get the SIP response history of a call of the workspace with its hangup cause and post dial delay
+Output: If success return SIPReportHistory model else return err
+*/
+func (h *Handler) GetSIPReportHistory(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetSIPReportHistory is called...")
+
+	callid := c.QueryParam("callid")
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetSIPReportHistory workspace_id is required", err, c)
+	}
+
+	history, err := h.carrierStore.GetSIPReportHistory(workspaceId, callid)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetSIPReportHistory error", err, c)
+	}
+	return c.JSON(http.StatusOK, history)
+}
+
 /*
 Input: callto, callfrom, userid
 Todo : Create and Start Router Flow
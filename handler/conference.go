@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: id
+Todo : Get a conference with its current participants
+Output: If success return Conference model else return err
+*/
+func (h *Handler) GetConference(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetConference is called...")
+
+	id, err := strconv.Atoi(c.QueryParam("id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetConference id is required", err, c)
+	}
+	conference, err := h.callStore.GetConference(id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetConference error occured", err, c)
+	}
+	hideConferencePins(conference)
+	return c.JSON(http.StatusOK, conference)
+}
+
+/*
+Input: ConferenceJoin model
+Todo : Add a call to a conference after checking the PIN and the participant limit
+Output: If success return ConferenceParticipant model else return err
+*/
+func (h *Handler) AddConferenceParticipant(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "AddConferenceParticipant is called...")
+
+	var join model.ConferenceJoin
+	if err := c.Bind(&join); err != nil {
+		return utils.HandleInternalErr("AddConferenceParticipant 1 Could not decode JSON", err, c)
+	}
+	if err := c.Validate(&join); err != nil {
+		return utils.HandleInternalErr("AddConferenceParticipant 2 Could not decode JSON", err, c)
+	}
+
+	conference, err := h.callStore.GetConference(join.ConferenceId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("AddConferenceParticipant could not get conference", err, c)
+	}
+	call, err := h.callStore.GetCallFromDB(join.CallId)
+	if err != nil {
+		return utils.HandleInternalErr("AddConferenceParticipant could not get call", err, c)
+	}
+	if call.WorkspaceId != conference.WorkspaceId {
+		return utils.HandleBadRequest("AddConferenceParticipant call is not in the workspace of the conference", errors.New("call belongs to another workspace"), c)
+	}
+
+	moderator, err := callstate.CheckConferencePIN(conference.Pin, conference.ModeratorPin, join.Pin)
+	if err != nil {
+		return utils.HandleForbidden("AddConferenceParticipant PIN rejected", err, c)
+	}
+
+	participant := model.ConferenceParticipant{
+		ConferenceId: join.ConferenceId,
+		CallId:       join.CallId,
+		Muted:        join.Muted,
+		Moderator:    moderator,
+	}
+	err = h.callStore.AddConferenceParticipant(&participant)
+	if errors.Is(err, callstate.ErrConferenceFull) || errors.Is(err, callstate.ErrAlreadyInConference) {
+		return utils.HandleConflict("AddConferenceParticipant could not join conference", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("AddConferenceParticipant error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, &participant)
+}
+
+/*
+Input: ConferenceParticipant model with call_id
+Todo : Remove a call from its conference and bill its conference minutes
+Output: If success return ConferenceParticipant model else return err
+*/
+func (h *Handler) RemoveConferenceParticipant(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "RemoveConferenceParticipant is called...")
+
+	var request model.ConferenceParticipant
+	if err := c.Bind(&request); err != nil {
+		return utils.HandleInternalErr("RemoveConferenceParticipant 1 Could not decode JSON", err, c)
+	}
+
+	participant, err := h.callStore.RemoveConferenceParticipant(request.CallId)
+	if errors.Is(err, callstate.ErrParticipantNotFound) {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("RemoveConferenceParticipant error occured", err, c)
+	}
+	h.billConferenceParticipant(participant)
+	return c.JSON(http.StatusOK, participant)
+}
+
+/*
+Input: ConferenceParticipantUpdate model
+Todo : Mute or unmute a participant and change its moderator flag
+Output: If success return ConferenceParticipant model else return err
+*/
+func (h *Handler) UpdateConferenceParticipant(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "UpdateConferenceParticipant is called...")
+
+	var update model.ConferenceParticipantUpdate
+	if err := c.Bind(&update); err != nil {
+		return utils.HandleInternalErr("UpdateConferenceParticipant 1 Could not decode JSON", err, c)
+	}
+
+	participant, err := h.callStore.UpdateConferenceParticipant(&update)
+	if errors.Is(err, callstate.ErrParticipantNotFound) {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if errors.Is(err, callstate.ErrInvalidPIN) {
+		return utils.HandleForbidden("UpdateConferenceParticipant the moderator PIN is required to change moderator", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("UpdateConferenceParticipant error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, participant)
+}
+
+/*
+Input: Conference model with id
+Todo : End a conference, removing and billing every participant still in it
+Output: If success return the removed ConferenceParticipant models else return err
+*/
+func (h *Handler) EndConference(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "EndConference is called...")
+
+	var request model.Conference
+	if err := c.Bind(&request); err != nil {
+		return utils.HandleInternalErr("EndConference 1 Could not decode JSON", err, c)
+	}
+
+	participants, err := h.callStore.EndConference(request.Id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("EndConference error occured", err, c)
+	}
+	for i := range participants {
+		h.billConferenceParticipant(&participants[i])
+	}
+	return c.JSON(http.StatusOK, participants)
+}
+
+// hideConferencePins blanks the PINs of a conference before it is returned,
+// they are only ever checked server side.
+func hideConferencePins(conference *model.Conference) {
+	conference.Pin = ""
+	conference.ModeratorPin = ""
+}
+
+// leaveConference removes a call that ended from the conference it was in.
+func (h *Handler) leaveConference(callId int) {
+	participant, err := h.callStore.RemoveConferenceParticipant(callId)
+	if errors.Is(err, callstate.ErrParticipantNotFound) {
+		return
+	}
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not remove call id = "+strconv.Itoa(callId)+" from its conference: "+err.Error())
+		return
+	}
+	h.billConferenceParticipant(participant)
+}
+
+// billConferenceParticipant debits the conference minutes of a participant
+// that left. Billing errors are logged, the participant has left regardless.
+func (h *Handler) billConferenceParticipant(participant *model.ConferenceParticipant) {
+	if participant.Duration <= 0 {
+		return
+	}
+	call, err := h.callStore.GetCallFromDB(participant.CallId)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not get call of conference participant id = "+strconv.Itoa(participant.Id)+": "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	debit := model.Debit{
+		UserId:           call.UserId,
+		WorkspaceId:      participant.WorkspaceId,
+		Status:           "PAID",
+		Number:           call.To,
+		Seconds:          participant.Duration,
+		Source:           "CONFERENCE",
+		ModuleId:         participant.Id,
+		DeduplicationKey: helpers.GenerateDeduplicationKey("CONFERENCE", now.Year(), int(now.Month()), now.Day(), participant.WorkspaceId, participant.Id),
+	}
+	// conference minutes are priced by the conference rate deck
+	rate := h.callStore.LookupBestCallRate(call.From, call.To, "CONFERENCE")
+	if rate == nil {
+		utils.Log(logrus.ErrorLevel, "no conference rate for conference participant id = "+strconv.Itoa(participant.Id))
+		return
+	}
+	err = h.debitStore.CreateDebit(rate, &debit)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not bill conference participant id = "+strconv.Itoa(participant.Id)+": "+err.Error())
+		return
+	}
+	h.emitEvent(participant.WorkspaceId, debitCreatedEvent(&debit))
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/eventbus"
 	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 )
@@ -41,6 +42,7 @@ func (h *Handler) CreateDebit(c echo.Context) error {
 	if err != nil {
 		return utils.HandleInternalErr("CreateDebit Could not execute query..", err, c)
 	}
+	h.emitEvent(debit.WorkspaceId, debitCreatedEvent(&debit))
 
 	return c.NoContent(http.StatusNoContent)
 }
@@ -66,11 +68,16 @@ func (h *Handler) CreateAPIUsageDebit(c echo.Context) error {
 		return utils.HandleInternalErr("Could not get workspace..", err, c)
 	}
 
-	err = h.debitStore.CreateAPIUsageDebit(workspace, &debitApi)
+	cents, err := h.debitStore.CreateAPIUsageDebit(workspace, &debitApi)
 
 	if err != nil {
 		return utils.HandleInternalErr("CreateDebit Could not execute query..", err, c)
 	}
+	h.emitEvent(debitApi.WorkspaceId, eventbus.DebitCreated{
+		UserId: debitApi.UserId,
+		Source: "API usage - " + debitApi.Type,
+		Cents:  cents,
+	})
 
 	return c.NoContent(http.StatusNoContent)
 }
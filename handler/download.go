@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/recording"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: recording_id, workspace_id, expires_in (optional, seconds)
+Todo : Create a short-lived download URL for a recording of the workspace
+Output: If success return DownloadURL model else return err
+*/
+func (h *Handler) GetRecordingDownloadURL(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetRecordingDownloadURL is called...")
+
+	recordingId, err := strconv.Atoi(c.QueryParam("recording_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetRecordingDownloadURL recording_id is required", err, c)
+	}
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetRecordingDownloadURL workspace_id is required", err, c)
+	}
+
+	record, err := h.recordingStore.GetRecordingFromDB(recordingId)
+	if err != nil && err != sql.ErrNoRows {
+		return utils.HandleInternalErr("GetRecordingDownloadURL could not get recording", err, c)
+	}
+	access := model.ObjectAccessLog{WorkspaceId: workspaceId, ObjectType: objectstore.FolderRecordings, ObjectId: recordingId}
+	// recordings of other workspaces are reported as missing so ids can not be probed
+	if record == nil || record.WorkspaceId != workspaceId {
+		return h.denyDownload(c, &access)
+	}
+	// the file of a recording removed by its retention policy is gone
+	if record.Status == "DELETED" {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if record.Status == "ARCHIVED" {
+		return utils.HandleConflict("GetRecordingDownloadURL recording is archived", recording.ErrRecordingArchived, c)
+	}
+	access.Key = objectstore.Key(objectstore.FolderRecordings, record.APIId)
+	return h.issueDownloadURL(c, &access)
+}
+
+/*
+Input: fax_id, workspace_id, expires_in (optional, seconds)
+Todo : Create a short-lived download URL for a fax of the workspace
+Output: If success return DownloadURL model else return err
+*/
+func (h *Handler) GetFaxDownloadURL(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetFaxDownloadURL is called...")
+
+	faxId, err := strconv.Atoi(c.QueryParam("fax_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetFaxDownloadURL fax_id is required", err, c)
+	}
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetFaxDownloadURL workspace_id is required", err, c)
+	}
+
+	fax, err := h.faxStore.GetFaxFromDB(workspaceId, faxId)
+	if err != nil && err != sql.ErrNoRows {
+		return utils.HandleInternalErr("GetFaxDownloadURL could not get fax", err, c)
+	}
+	access := model.ObjectAccessLog{WorkspaceId: workspaceId, ObjectType: objectstore.FolderFaxes, ObjectId: faxId}
+	if fax == nil {
+		return h.denyDownload(c, &access)
+	}
+	access.Key = objectstore.Key(objectstore.FolderFaxes, fax.APIId)
+	return h.issueDownloadURL(c, &access)
+}
+
+/*
+Input: workspace_id, key, expires, signature
+Todo : Stream an object for a download URL signed by the API
+Output: If success return the object else return err
+*/
+func (h *Handler) DownloadObject(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "DownloadObject is called...")
+
+	signer, err := h.signer()
+	if err != nil {
+		return utils.HandleInternalErr("DownloadObject could not create URL signer", err, c)
+	}
+	workspaceId, key, err := signer.Verify(c.QueryParams(), time.Now())
+	if err != nil {
+		// the unverified workspace and key are recorded as they were requested
+		requested, _ := strconv.Atoi(c.QueryParam("workspace_id"))
+		h.logObjectAccess(c, &model.ObjectAccessLog{WorkspaceId: requested, Key: c.QueryParam("key")}, objectstore.AccessDenied)
+		return utils.HandleForbidden("DownloadObject rejected URL", err, c)
+	}
+	access := model.ObjectAccessLog{WorkspaceId: workspaceId, ObjectType: path.Dir(key), Key: key}
+
+	objects, err := h.objects.ForWorkspace(workspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("DownloadObject could not open object store", err, c)
+	}
+	body, err := objects.Get(c.Request().Context(), key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("DownloadObject could not read object", err, c)
+	}
+	defer body.Close()
+
+	err = h.logObjectAccess(c, &access, objectstore.AccessDownloaded)
+	if err != nil {
+		return utils.HandleInternalErr("DownloadObject could not log access", err, c)
+	}
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename=\""+path.Base(key)+"\"")
+	c.Response().Header().Set("Cache-Control", "private, no-store")
+	return c.Stream(http.StatusOK, echo.MIMEOctetStream, body)
+}
+
+// issueDownloadURL signs a URL for access.Key with the store of the
+// workspace, or with the API download route when the store can not sign.
+func (h *Handler) issueDownloadURL(c echo.Context, access *model.ObjectAccessLog) error {
+	expiry := objectstore.DefaultURLExpiry
+	if value := c.QueryParam("expires_in"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return utils.HandleBadRequest("GetDownloadURL expires_in must be a positive number of seconds", err, c)
+		}
+		expiry = time.Duration(seconds) * time.Second
+		if expiry > objectstore.MaxURLExpiry {
+			expiry = objectstore.MaxURLExpiry
+		}
+	}
+
+	objects, err := h.objects.ForWorkspace(access.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("GetDownloadURL could not open object store", err, c)
+	}
+
+	proxy, err := h.signer()
+	if err != nil {
+		return utils.HandleInternalErr("GetDownloadURL could not create URL signer", err, c)
+	}
+	expiresAt := time.Now().Add(expiry)
+	url, err := objectstore.DownloadURL(objects, proxy, access.WorkspaceId, access.Key, expiresAt)
+	if err != nil {
+		return utils.HandleInternalErr("GetDownloadURL could not sign URL", err, c)
+	}
+
+	// a URL is only handed out once its issue is on record
+	access.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	err = h.logObjectAccess(c, access, objectstore.AccessURLIssued)
+	if err != nil {
+		return utils.HandleInternalErr("GetDownloadURL could not log access", err, c)
+	}
+	return c.JSON(http.StatusOK, &model.DownloadURL{URL: url, ExpiresAt: access.ExpiresAt})
+}
+
+func (h *Handler) denyDownload(c echo.Context, access *model.ObjectAccessLog) error {
+	h.logObjectAccess(c, access, objectstore.AccessDenied)
+	return c.NoContent(http.StatusNotFound)
+}
+
+// logObjectAccess records an access to a recording or fax. URLs and signatures are never logged.
+func (h *Handler) logObjectAccess(c echo.Context, access *model.ObjectAccessLog, action string) error {
+	access.Action = action
+	access.RemoteIp = c.RealIP()
+	access.UserAgent = c.Request().UserAgent()
+	utils.Log(logrus.InfoLevel, "object access: "+action+" workspace = "+strconv.Itoa(access.WorkspaceId)+" key = "+access.Key+" from "+access.RemoteIp)
+	if h.accessLogStore == nil {
+		return nil
+	}
+	err := h.accessLogStore.CreateAccessLog(access)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not store object access log: "+err.Error())
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/events"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+const eventStreamHeartbeat = 15 * time.Second
+
+// publishEvent pushes a call lifecycle event to the workspace's stream subscribers.
+func (h *Handler) publishEvent(eventType string, workspaceId int, callId int, data interface{}) {
+	if h.events == nil {
+		return
+	}
+	h.events.Publish(events.Event{
+		Type:        eventType,
+		WorkspaceId: workspaceId,
+		CallId:      callId,
+		Data:        data,
+	})
+}
+
+// emitEvent publishes a domain event to the event bus. Publishing errors are
+// logged and never fail the request; brokers are wrapped in an AsyncBus so
+// this does not wait on the network.
+func (h *Handler) emitEvent(workspaceId int, payload eventbus.Payload) {
+	if h.bus == nil {
+		return
+	}
+	event, err := eventbus.NewEvent(workspaceId, payload)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not create event "+payload.EventType()+": "+err.Error())
+		return
+	}
+	err = h.bus.Publish(context.Background(), event)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not publish event "+event.Type+": "+err.Error())
+	}
+}
+
+func debitCreatedEvent(debit *model.Debit) eventbus.DebitCreated {
+	return eventbus.DebitCreated{
+		UserId:   debit.UserId,
+		Source:   debit.Source,
+		ModuleId: debit.ModuleId,
+		Number:   debit.Number,
+		Seconds:  debit.Seconds,
+		Cents:    debit.Cents,
+	}
+}
+
+/*
+Input: workspace_id, Last-Event-ID header or last_event_id
+Todo : Stream call lifecycle events of a workspace as server-sent events
+Output: text/event-stream until the client disconnects or falls behind
+*/
+func (h *Handler) StreamCallEvents(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "StreamCallEvents is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("StreamCallEvents workspace_id is required", err, c)
+	}
+
+	lastEventIdParam := c.Request().Header.Get("Last-Event-ID")
+	if lastEventIdParam == "" {
+		lastEventIdParam = c.QueryParam("last_event_id")
+	}
+	var lastEventId uint64
+	if lastEventIdParam != "" {
+		lastEventId, err = strconv.ParseUint(lastEventIdParam, 10, 64)
+		if err != nil {
+			return utils.HandleBadRequest("StreamCallEvents invalid last event id", err, c)
+		}
+	}
+
+	sub, replay, complete := h.events.Subscribe(workspaceId, lastEventId)
+	defer h.events.Unsubscribe(sub)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	// tell the client some events were evicted or lost in a restart so it can resync from the API
+	if !complete {
+		fmt.Fprintf(res, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		if err := writeEvent(res, e); err != nil {
+			return nil
+		}
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(eventStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	ctx := c.Request().Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprintf(res, ": ping\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case e, ok := <-sub.Events:
+			if !ok {
+				if sub.Dropped() {
+					utils.Log(logrus.WarnLevel, "StreamCallEvents subscriber for workspace "+strconv.Itoa(workspaceId)+" fell behind, disconnecting")
+				}
+				return nil
+			}
+			if err := writeEvent(res, e); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+func writeEvent(res *echo.Response, e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Type, payload)
+	return err
+}
@@ -1,35 +1,38 @@
 package handler
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"net/mail"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/fax"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
+	"lineblocs.com/api/objectstore"
 	"lineblocs.com/api/utils"
 )
 
 /*
-Input: file, user_id, workspace_id, call_id, name
-Todo : Create fax and store to db, also upload file to AWS s3
+Input: file, user_id, workspace_id, call_id, name, resolution, direction, from, to
+Todo : Validate the PDF or TIFF document and count its pages, check the plan limits, upload the file to the object store of the workspace and store the fax to db.
+Received faxes are billed and queued for fax-to-email, outbound faxes are queued for the media server and billed once delivered
 Output: If success return Fax model with fax id in header else return err
+Nothing is stored for documents that are invalid or over the plan limits, call_id is optional for outbound faxes
 */
 func (h *Handler) CreateFax(c echo.Context) error {
 	utils.Log(logrus.InfoLevel, "CreateFax is called...")
 
-	var fax *model.Fax
 	file, err := c.FormFile("file")
-
 	if err != nil {
-		return utils.HandleInternalErr("CreateFax error occured", err, c)
-	}
-
-	workspace, err := h.callStore.GetWorkspaceFromDB(fax.WorkspaceId)
-	if err != nil {
-		return utils.HandleInternalErr("Could not get workspace..", err, c)
+		return utils.HandleBadRequest("CreateFax file is required", err, c)
 	}
 
 	userId := c.FormValue("user_id")
@@ -44,54 +47,332 @@ func (h *Handler) CreateFax(c echo.Context) error {
 		return utils.HandleInternalErr("CreateFax error occured workspace ID", err, c)
 	}
 
+	direction := c.FormValue("direction")
+	if direction == "" {
+		direction = fax.DirectionInbound
+	}
+	if direction != fax.DirectionInbound && direction != fax.DirectionOutbound {
+		return utils.HandleBadRequest("CreateFax direction must be inbound or outbound", nil, c)
+	}
+	from := c.FormValue("from")
+	to := c.FormValue("to")
+	if direction == fax.DirectionOutbound && to == "" {
+		return utils.HandleBadRequest("CreateFax to is required for outbound faxes", nil, c)
+	}
+
 	callId := c.FormValue("call_id")
-	callIdInt, err := strconv.Atoi(callId)
-	if err != nil {
-		return utils.HandleInternalErr("CreateFax error occured call ID", err, c)
+	callIdInt := 0
+	if callId != "" || direction == fax.DirectionInbound {
+		callIdInt, err = strconv.Atoi(callId)
+		if err != nil {
+			return utils.HandleInternalErr("CreateFax error occured call ID", err, c)
+		}
 	}
 
 	name := c.FormValue("name")
 
+	workspace, err := h.callStore.GetWorkspaceFromDB(workspaceIdInt)
+	if err != nil {
+		return utils.HandleInternalErr("Could not get workspace..", err, c)
+	}
+
 	src, err := file.Open()
 	if err != nil {
 		return utils.HandleInternalErr("CreateFax error occured", err, c)
 	}
 	defer src.Close()
 
-	dst, err := os.OpenFile(file.Filename, os.O_WRONLY|os.O_CREATE, 0666)
+	document, err := fax.Inspect(src, file.Size)
+	if errors.Is(err, fax.ErrDocumentTooLarge) {
+		return utils.HandleBadRequest("CreateFax fax document is too large", err, c)
+	}
 	if err != nil {
-		return utils.HandleInternalErr("CreateFax error occured", err, c)
+		return utils.HandleBadRequest("CreateFax invalid fax document", err, c)
 	}
-	defer dst.Close()
-	apiId := utils.CreateAPIID("fax")
-	uri := utils.CreateS3URL("faxes", apiId)
+	if document.Pages > fax.MaxPages {
+		return utils.HandleBadRequest(fmt.Sprintf("CreateFax fax has more than %d pages", fax.MaxPages), nil, c)
+	}
+	resolution := document.Resolution
+	if resolution == "" {
+		resolution, err = faxResolution(c.FormValue("resolution"))
+		if err != nil {
+			return utils.HandleBadRequest("CreateFax invalid resolution", err, c)
+		}
+	}
+
+	// Get fax count limit and check current count is over the limit
 	count, err := h.faxStore.GetFaxCount(workspaceIdInt)
 	if err != nil {
 		return utils.HandleInternalErr("CreateFax error occured", err, c)
 	}
+	limit, err := utils.GetPlanFaxLimit(workspace)
+	if err != nil {
+		return utils.HandleInternalErr("CreateFax error occured", err, c)
+	}
+	// a limit of 0 is an unlimited plan
+	if *limit > 0 && (*count)+1 > *limit {
+		return utils.HandlePaymentRequired("Not saving fax due to limit reached..", nil, c)
+	}
 
-	fax = &model.Fax{UserId: userIdInt, WorkspaceId: workspaceIdInt, CallId: callIdInt, Uri: uri}
+	objects, err := h.objects.ForWorkspace(workspaceIdInt)
+	if err != nil {
+		return utils.HandleInternalErr("CreateFax could not open object store", err, c)
+	}
+	apiId := utils.CreateAPIID("fax")
+	key := objectstore.Key(objectstore.FolderFaxes, apiId)
+	uri := objects.URL(key)
 
-	faxId, err := h.faxStore.CreateFax(fax, name, file.Size, apiId, workspace.Plan)
+	err = objects.Put(c.Request().Context(), key, src, file.Size, document.ContentType)
+	if err != nil {
+		return utils.HandleInternalErr("CreateFax could not store fax", err, c)
+	}
 
+	record := &model.Fax{
+		UserId:      userIdInt,
+		WorkspaceId: workspaceIdInt,
+		CallId:      callIdInt,
+		Uri:         uri,
+		APIId:       apiId,
+		ContentType: document.ContentType,
+		Pages:       document.Pages,
+		Resolution:  resolution,
+		Status:      fax.StatusReceived,
+		Direction:   direction,
+		From:        from,
+		To:          to}
+	if direction == fax.DirectionOutbound {
+		record.Status = fax.StatusQueued
+	} else {
+		settings, err := h.faxStore.GetFaxEmailSettings(workspaceIdInt)
+		if err != nil {
+			return utils.HandleInternalErr("CreateFax could not get fax-to-email settings", err, c)
+		}
+		if settings.Enabled {
+			record.EmailTo = settings.Recipients
+			record.EmailLocale = settings.Locale
+		}
+	}
+	faxId, err := h.faxStore.CreateFax(record, name, file.Size, apiId, workspace.Plan)
 	if err != nil {
+		if err := objects.Delete(c.Request().Context(), key); err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not remove fax object %s: %s", key, err.Error()))
+		}
 		return utils.HandleInternalErr("CreateFax error occured", err, c)
 	}
+	record.Id = int(faxId)
 
-	// Get fax count limit and check current count is over the limit
-	limit, err := utils.GetPlanFaxLimit(workspace)
+	h.emitEvent(record.WorkspaceId, eventbus.FaxCreated{
+		FaxId:  faxId,
+		APIId:  apiId,
+		UserId: record.UserId,
+		CallId: record.CallId,
+		Size:   file.Size,
+		Pages:  record.Pages,
+	})
+
+	c.Response().Writer.Header().Set("X-Fax-ID", strconv.FormatInt(faxId, 10))
+	return c.JSON(http.StatusOK, record)
+}
+
+// faxResolution checks the requested resolution of a fax, faxes are sent in fine resolution by default.
+func faxResolution(resolution string) (string, error) {
+	switch resolution {
+	case "":
+		return fax.ResolutionFine, nil
+	case fax.ResolutionStandard, fax.ResolutionFine, fax.ResolutionSuperfine:
+		return resolution, nil
+	}
+	return "", errors.New("resolution must be standard, fine or superfine")
+}
+
+/*
+Input: FaxStatusUpdate model
+Todo : Move a fax through its lifecycle as reported by the media server, failed transmissions that can be retried are queued again
+Output: If success return Fax model else return err
+Outbound faxes are billed once delivered, reason_code is the T.30 error code of a failed transmission
+*/
+func (h *Handler) UpdateFaxStatus(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "UpdateFaxStatus is called...")
+
+	var update model.FaxStatusUpdate
+	if err := c.Bind(&update); err != nil {
+		return utils.HandleInternalErr("UpdateFaxStatus Could not decode JSON", err, c)
+	}
+	if update.FaxId <= 0 {
+		return utils.HandleBadRequest("UpdateFaxStatus fax_id is required", errors.New("missing fax_id"), c)
+	}
+	if update.WorkspaceId <= 0 {
+		return utils.HandleBadRequest("UpdateFaxStatus workspace_id is required", errors.New("missing workspace_id"), c)
+	}
+	if !fax.ValidStatus(update.Status) {
+		return utils.HandleBadRequest("UpdateFaxStatus invalid status", fax.ErrUnknownStatus, c)
+	}
+
+	record, err := h.faxStore.GetFaxFromDB(update.WorkspaceId, update.FaxId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
 	if err != nil {
-		return utils.HandleInternalErr("CreateFax error occured", err, c)
+		return utils.HandleInternalErr("UpdateFaxStatus could not get fax", err, c)
+	}
+	previous := record.Status
+	retryAt, err := fax.Apply(record, &update, fax.DefaultMaxAttempts, time.Now())
+	if err != nil {
+		return utils.HandleConflict(fmt.Sprintf("UpdateFaxStatus fax is %s", previous), err, c)
 	}
-	newCount := (*count) + 1
-	if newCount > *limit {
-		utils.Log(logrus.WarnLevel, fmt.Sprintf("Not saving fax due to limit reached.."))
-		return c.NoContent(http.StatusNoContent)
+	updated, err := h.faxStore.SetFaxStatus(record, previous, retryAt)
+	if err != nil {
+		return utils.HandleInternalErr("UpdateFaxStatus could not store status", err, c)
+	}
+	if !updated {
+		// another update moved the fax first
+		return utils.HandleConflict("UpdateFaxStatus fax status changed", fax.ErrInvalidTransition, c)
 	}
 
-	// Upload fax file to AWS s3
-	go utils.UploadS3("faxes", apiId, src)
+	h.emitEvent(record.WorkspaceId, eventbus.FaxStatusChanged{
+		FaxId:      record.Id,
+		APIId:      record.APIId,
+		Direction:  record.Direction,
+		Status:     record.Status,
+		ReasonCode: record.ReasonCode,
+		Reason:     record.Reason,
+		Attempts:   record.Attempts,
+	})
+	return c.JSON(http.StatusOK, record)
+}
 
-	c.Response().Writer.Header().Set("X-Fax-ID", strconv.FormatInt(faxId, 10))
-	return c.JSON(http.StatusOK, &fax)
+/*
+Input: id, workspace_id
+Todo : Get a fax of the workspace with its transmission status
+Output: If success return Fax model else return err
+*/
+func (h *Handler) GetFax(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetFax is called...")
+
+	id, err := strconv.Atoi(c.QueryParam("id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetFax id is required", err, c)
+	}
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetFax workspace_id is required", err, c)
+	}
+	record, err := h.faxStore.GetFaxFromDB(workspaceId, id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetFax error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, record)
+}
+
+/*
+Input: workspace_id, direction, status, number, call_id, from, to, cursor, limit
+Todo : List the faxes of a workspace matching the filters, newest first
+Output: If success return FaxList model else return err
+number matches the sending or receiving number, from and to are RFC3339 times or dates
+*/
+func (h *Handler) ListFaxes(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListFaxes is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("ListFaxes workspace_id is required", err, c)
+	}
+	filter := model.FaxFilter{
+		WorkspaceId: workspaceId,
+		Direction:   c.QueryParam("direction"),
+		Status:      c.QueryParam("status"),
+		Number:      strings.TrimSpace(c.QueryParam("number")),
+	}
+	if filter.Direction != "" && filter.Direction != fax.DirectionInbound && filter.Direction != fax.DirectionOutbound {
+		return utils.HandleBadRequest("ListFaxes direction must be inbound or outbound", nil, c)
+	}
+	if filter.Status != "" && !fax.ValidStatus(filter.Status) {
+		return utils.HandleBadRequest("ListFaxes invalid status", fax.ErrUnknownStatus, c)
+	}
+	if value := c.QueryParam("call_id"); value != "" {
+		callId, err := strconv.Atoi(value)
+		if err != nil {
+			return utils.HandleBadRequest("ListFaxes invalid call_id", err, c)
+		}
+		filter.CallId = &callId
+	}
+	if filter.From, err = parseDateParam(c.QueryParam("from"), false); err != nil {
+		return utils.HandleBadRequest("ListFaxes invalid from", err, c)
+	}
+	if filter.To, err = parseDateParam(c.QueryParam("to"), true); err != nil {
+		return utils.HandleBadRequest("ListFaxes invalid to", err, c)
+	}
+	if filter.BeforeId, err = utils.DecodeCursor(c.QueryParam("cursor")); err != nil {
+		return utils.HandleBadRequest("ListFaxes invalid cursor", err, c)
+	}
+	if filter.Limit, err = utils.ParsePageLimit(c.QueryParam("limit")); err != nil {
+		return utils.HandleBadRequest("ListFaxes invalid limit", err, c)
+	}
+
+	list, err := h.faxStore.ListFaxes(&filter)
+	if err != nil {
+		return utils.HandleInternalErr("ListFaxes error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+/*
+Input: workspace_id
+Todo : Get the fax-to-email settings of a workspace
+Output: If success return FaxEmailSettings model else return err
+*/
+func (h *Handler) GetFaxEmailSettings(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetFaxEmailSettings is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetFaxEmailSettings workspace_id is required", err, c)
+	}
+	settings, err := h.faxStore.GetFaxEmailSettings(workspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("GetFaxEmailSettings error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, settings)
+}
+
+/*
+Input: FaxEmailSettings model
+Todo : Create or replace the fax-to-email settings of a workspace, received faxes are emailed to the recipients as PDF
+Output: If success return FaxEmailSettings model else return err
+*/
+func (h *Handler) SetFaxEmailSettings(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "SetFaxEmailSettings is called...")
+
+	var settings model.FaxEmailSettings
+	if err := c.Bind(&settings); err != nil {
+		return utils.HandleInternalErr("SetFaxEmailSettings Could not decode JSON", err, c)
+	}
+	if settings.WorkspaceId == 0 {
+		return utils.HandleBadRequest("SetFaxEmailSettings workspace_id is required", errors.New("missing workspace_id"), c)
+	}
+	recipients := []string{}
+	for _, recipient := range settings.Recipients {
+		address, err := mail.ParseAddress(strings.TrimSpace(recipient))
+		if err != nil {
+			return utils.HandleBadRequest("SetFaxEmailSettings invalid recipient", err, c)
+		}
+		recipients = append(recipients, address.Address)
+	}
+	settings.Recipients = recipients
+	settings.Locale = strings.TrimSpace(settings.Locale)
+	if settings.Locale != "" && !notification.ValidLocale(settings.Locale) {
+		return utils.HandleBadRequest("SetFaxEmailSettings invalid locale", errors.New("invalid locale "+settings.Locale), c)
+	}
+	if settings.Enabled && len(settings.Recipients) == 0 {
+		return utils.HandleBadRequest("SetFaxEmailSettings recipients are required", errors.New("missing recipients"), c)
+	}
+
+	err := h.faxStore.SaveFaxEmailSettings(&settings)
+	if err != nil {
+		return utils.HandleInternalErr("SetFaxEmailSettings error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, &settings)
 }
@@ -1,13 +1,24 @@
 package handler
 
 import (
+	"crypto/rand"
+	"sync"
+
+	"lineblocs.com/api/activecall"
 	"lineblocs.com/api/admin"
+	"lineblocs.com/api/alert"
+	"lineblocs.com/api/apikey"
 	"lineblocs.com/api/call"
 	"lineblocs.com/api/carrier"
 	"lineblocs.com/api/debit"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/events"
 	"lineblocs.com/api/fax"
 	"lineblocs.com/api/logger"
+	"lineblocs.com/api/mail"
+	"lineblocs.com/api/objectstore"
 	"lineblocs.com/api/recording"
+	"lineblocs.com/api/transcription"
 	"lineblocs.com/api/user"
 )
 
@@ -25,6 +36,17 @@ type Handler struct {
 	loggerStore    logger.LoggerStoreInterface
 	recordingStore recording.RecordingStoreInterface
 	userStore      user.UserStoreInterface
+	events         *events.Broker
+	bus            eventbus.EventBus
+	activeCalls    activecall.Registry
+	objects        *objectstore.Manager
+	signerMu       sync.Mutex
+	downloadSigner *objectstore.ProxySigner
+	accessLogStore objectstore.AccessLogStoreInterface
+	transcriptions transcription.TranscriptionStoreInterface
+	alerts         *alert.Dispatcher
+	mailer         mail.Mailer
+	apiKeys        *apikey.Manager
 }
 
 func NewHandler(as admin.AdminStoreInterface, cs call.CallStoreInterface, crs carrier.CarrierStoreInterface, ds debit.DebitStoreInterface, fs fax.FaxStoreInterface, ls logger.LoggerStoreInterface, rs recording.RecordingStoreInterface, us user.UserStoreInterface) *Handler {
@@ -37,5 +59,108 @@ func NewHandler(as admin.AdminStoreInterface, cs call.CallStoreInterface, crs ca
 		loggerStore:    ls,
 		recordingStore: rs,
 		userStore:      us,
+		events:         events.NewBroker(events.DefaultHistorySize, events.DefaultQueueSize),
+		bus:            eventbus.NewMemoryBus(),
+		activeCalls:    activecall.NewMemoryRegistry(activecall.DefaultTTL),
+		objects:        objectstore.NewManager(StorageCredentials(us), objectstore.DefaultRefreshInterval),
+	}
+}
+
+// randomSecret signs download URLs until a shared secret is configured,
+// such URLs only work on the instance that issued them.
+func randomSecret() ([]byte, error) {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// signer returns the signer of download URLs, creating one with a random
+// secret on first use when none was set.
+func (h *Handler) signer() (*objectstore.ProxySigner, error) {
+	h.signerMu.Lock()
+	defer h.signerMu.Unlock()
+	if h.downloadSigner == nil {
+		secret, err := randomSecret()
+		if err != nil {
+			return nil, err
+		}
+		h.downloadSigner = objectstore.NewProxySigner(secret, "")
+	}
+	return h.downloadSigner, nil
+}
+
+// StorageCredentials reads the object store settings from api_credentials_kv_store.
+func StorageCredentials(us user.UserStoreInterface) objectstore.CredentialsLoader {
+	return func() (map[string]string, error) {
+		settings, err := us.GetSettings()
+		if err != nil {
+			return nil, err
+		}
+		return settings.Credentials, nil
 	}
 }
+
+// SetEventBus replaces the in-memory event bus the handler publishes domain events to.
+func (h *Handler) SetEventBus(bus eventbus.EventBus) {
+	h.bus = bus
+}
+
+// SetEventBroker replaces the broker of the call event streams, deployments
+// with several API instances need one with a relay.
+func (h *Handler) SetEventBroker(broker *events.Broker) {
+	h.events = broker
+}
+
+// SetActiveCallRegistry replaces the in-memory registry of active calls,
+// deployments with several API instances need a shared one.
+func (h *Handler) SetActiveCallRegistry(registry activecall.Registry) {
+	h.activeCalls = registry
+}
+
+// SetObjectStores replaces the object stores recordings and faxes are uploaded to.
+func (h *Handler) SetObjectStores(objects *objectstore.Manager) {
+	h.objects = objects
+}
+
+// SetDownloadSigner replaces the signer of download URLs served by the API.
+func (h *Handler) SetDownloadSigner(signer *objectstore.ProxySigner) {
+	h.signerMu.Lock()
+	defer h.signerMu.Unlock()
+	h.downloadSigner = signer
+}
+
+// DownloadSigner returns the signer of download URLs served by the API, for
+// the workers that hand out URLs of objects.
+func (h *Handler) DownloadSigner() (*objectstore.ProxySigner, error) {
+	return h.signer()
+}
+
+// SetAccessLogStore records recording and fax accesses in addition to logging them.
+func (h *Handler) SetAccessLogStore(store objectstore.AccessLogStoreInterface) {
+	h.accessLogStore = store
+}
+
+// SetTranscriptionStore queues recordings for transcription, without it
+// transcriptions can only be pushed with UpdateRecordingTranscription.
+func (h *Handler) SetTranscriptionStore(store transcription.TranscriptionStoreInterface) {
+	h.transcriptions = store
+}
+
+// SetAlertDispatcher notifies workspaces of debugger logs, without it logs are only stored.
+func (h *Handler) SetAlertDispatcher(dispatcher *alert.Dispatcher) {
+	h.alerts = dispatcher
+}
+
+// SetMailer sends the email of the handler, without it none is sent.
+func (h *Handler) SetMailer(mailer mail.Mailer) {
+	h.mailer = mailer
+}
+
+// SetAPIKeys authenticates the API keys of the services calling the API,
+// without it every API request is rejected.
+func (h *Handler) SetAPIKeys(keys *apikey.Manager) {
+	h.apiKeys = keys
+}
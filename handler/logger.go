@@ -1,17 +1,31 @@
 package handler
 
 import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/alert"
+	"lineblocs.com/api/eventbus"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
 	"lineblocs.com/api/utils"
 )
 
 /*
 Input: Log model
-Todo : Create log model and store to db, send log email
+Todo : Create log model and store to db, notify the workspace
 Output: If success return NoContent else return err
 */
 func (h *Handler) CreateLog(c echo.Context) error {
@@ -49,22 +63,39 @@ func (h *Handler) CreateLog(c echo.Context) error {
 		return utils.HandleInternalErr("Could not get workspace..", err, c)
 	}
 
-	_, err = h.loggerStore.StartLogRoutine(workspace, log)
+	logId, err := h.loggerStore.StartLogRoutine(workspace, log)
 	if err != nil {
 		return utils.HandleInternalErr("CreateLog 2 log routine error", err, c)
 	}
+	h.emitLogCreated(logId, log)
+	h.notifyLog(logId, log)
 	return c.NoContent(http.StatusOK)
 }
 
+// logTemplates maps the types of CreateLogSimple to the notification types
+// their title and report are rendered from.
+var logTemplates = map[string]string{
+	"verify-callerid-cailed": notification.TypeCallerIdVerifyFailed,
+	"verify-callerid-failed": notification.TypeCallerIdVerifyFailed,
+}
+
+// defaultLogType is the type of CreateLogSimple when none is given.
+const defaultLogType = "verify-callerid-cailed"
+
 /*
 Input: type, level, domain
-Todo : Create log model and store to db, send log email
+Todo : Create log model and store to db, notify the workspace
 Output: If success return NoContent else return err
+The title and report are rendered in the locale of the workspace creator
 */
 func (h *Handler) CreateLogSimple(c echo.Context) error {
 	utils.Log(logrus.InfoLevel, "CreateLogSimple is called...")
 
-	logType := c.FormValue("type")
+	logType := strings.TrimSpace(c.FormValue("type"))
+	if logType == "" {
+		// callers from before the type was sent only logged failed caller ID verifications
+		logType = defaultLogType
+	}
 	level := c.FormValue("level")
 	domain := c.FormValue("domain")
 	workspace, err := h.callStore.GetWorkspaceByDomain(domain)
@@ -76,25 +107,301 @@ func (h *Handler) CreateLogSimple(c echo.Context) error {
 		level = "infO"
 	}
 
-	var title string
-	var report string
-	switch logType {
-	case "verify-callerid-cailed":
-		title = "Caller ID Verify failed.."
-		report = "Caller ID Verify failed.."
+	locale := notification.DefaultLocale
+	if creator, err := h.callStore.GetUserFromDB(workspace.CreatorId); err == nil {
+		locale = creator.Locale
+	}
+	template, ok := logTemplates[logType]
+	if !ok {
+		template = notification.TypeLogEvent
+	}
+	message, err := notification.Render(template, locale, map[string]interface{}{"Type": logType})
+	if err != nil {
+		return utils.HandleInternalErr("CreateLogSimple could not render log", err, c)
 	}
 	log := &model.LogRoutine{
 		From:        "",
 		To:          "",
 		Level:       level,
-		Title:       title,
-		Report:      report,
+		Title:       message.Subject,
+		Report:      message.Text,
 		UserId:      workspace.CreatorId,
 		WorkspaceId: workspace.Id}
 
-	_, err = h.loggerStore.StartLogRoutine(workspace, log)
+	logId, err := h.loggerStore.StartLogRoutine(workspace, log)
 	if err != nil {
 		return utils.HandleInternalErr("CreateLog log routine error", err, c)
 	}
+	h.emitLogCreated(logId, log)
+	h.notifyLog(logId, log)
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *Handler) emitLogCreated(logId *string, log *model.LogRoutine) {
+	id := ""
+	if logId != nil {
+		id = *logId
+	}
+	h.emitEvent(log.WorkspaceId, eventbus.DebuggerLogCreated{
+		LogId:  id,
+		Level:  log.Level,
+		Title:  log.Title,
+		FlowId: log.FlowId,
+		From:   log.From,
+		To:     log.To,
+	})
+}
+
+// notifyLog queues the alert of a log to the notification channels of its workspace.
+func (h *Handler) notifyLog(logId *string, log *model.LogRoutine) {
+	if h.alerts == nil {
+		return
+	}
+	id := ""
+	if logId != nil {
+		id = *logId
+	}
+	err := h.alerts.Notify(alert.Alert{
+		WorkspaceId: log.WorkspaceId,
+		UserId:      log.UserId,
+		LogId:       id,
+		FlowId:      log.FlowId,
+		Level:       alert.NormalizeLevel(log.Level),
+		Title:       log.Title,
+		Report:      log.Report,
+		From:        log.From,
+		To:          log.To,
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not notify workspace id = %d of log id = %s: %s", log.WorkspaceId, id, err.Error()))
+	}
+}
+
+/*
+Input: workspace_id, level, flow_id, from, to, start, end, cursor, limit
+Todo : List the debugger logs of a workspace matching the filters, newest first
+Output: If success return LogList model else return err
+from and to are the numbers of the logged call, start and end are RFC3339 times or dates
+*/
+func (h *Handler) ListLogs(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListLogs is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("ListLogs workspace_id is required", err, c)
+	}
+	filter := model.LogFilter{
+		WorkspaceId: workspaceId,
+		From:        strings.TrimSpace(c.QueryParam("from")),
+		To:          strings.TrimSpace(c.QueryParam("to")),
+	}
+	if level := c.QueryParam("level"); level != "" {
+		if !alert.ValidLevel(level) {
+			return utils.HandleBadRequest("ListLogs invalid level", errors.New("unknown level "+level), c)
+		}
+		filter.Level = strings.ToLower(strings.TrimSpace(level))
+	}
+	if value := c.QueryParam("flow_id"); value != "" {
+		flowId, err := strconv.Atoi(value)
+		if err != nil {
+			return utils.HandleBadRequest("ListLogs invalid flow_id", err, c)
+		}
+		filter.FlowId = &flowId
+	}
+	if filter.Start, err = parseDateParam(c.QueryParam("start"), false); err != nil {
+		return utils.HandleBadRequest("ListLogs invalid start", err, c)
+	}
+	if filter.End, err = parseDateParam(c.QueryParam("end"), true); err != nil {
+		return utils.HandleBadRequest("ListLogs invalid end", err, c)
+	}
+	if filter.BeforeId, err = utils.DecodeCursor(c.QueryParam("cursor")); err != nil {
+		return utils.HandleBadRequest("ListLogs invalid cursor", err, c)
+	}
+	if filter.Limit, err = utils.ParsePageLimit(c.QueryParam("limit")); err != nil {
+		return utils.HandleBadRequest("ListLogs invalid limit", err, c)
+	}
+
+	list, err := h.loggerStore.ListLogs(&filter)
+	if err != nil {
+		return utils.HandleInternalErr("ListLogs error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+/*
+Input: id, workspace_id
+Todo : Get a debugger log of the workspace with its report
+Output: If success return DebuggerLog model else return err
+*/
+func (h *Handler) GetLog(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetLog is called...")
+
+	id, err := strconv.Atoi(c.QueryParam("id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetLog id is required", err, c)
+	}
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetLog workspace_id is required", err, c)
+	}
+
+	log, err := h.loggerStore.GetLog(workspaceId, id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetLog error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, log)
+}
+
+/*
+Input: workspace_id
+Todo : List the notification channels of a workspace
+Output: If success return list of NotificationChannel model else return err
+Secrets of webhook channels are not returned
+*/
+func (h *Handler) ListNotificationChannels(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListNotificationChannels is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("ListNotificationChannels workspace_id is required", err, c)
+	}
+	channels, err := h.loggerStore.GetNotificationChannels(workspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("ListNotificationChannels error occured", err, c)
+	}
+	for i := range channels {
+		channels[i].Secret = ""
+	}
+	return c.JSON(http.StatusOK, channels)
+}
+
+/*
+Input: NotificationChannel model
+Todo : Create a notification channel, or update it when id is set
+Output: If success return NotificationChannel model else return err
+Webhook channels without a secret get a generated one, it is only returned here
+Slack and webhook targets must be public addresses
+*/
+func (h *Handler) SaveNotificationChannel(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "SaveNotificationChannel is called...")
+
+	var channel model.NotificationChannel
+	if err := c.Bind(&channel); err != nil {
+		return utils.HandleInternalErr("SaveNotificationChannel Could not decode JSON", err, c)
+	}
+	if channel.WorkspaceId == 0 {
+		return utils.HandleBadRequest("SaveNotificationChannel workspace_id is required", errors.New("missing workspace_id"), c)
+	}
+	if channel.MinLevel == "" {
+		channel.MinLevel = alert.LevelInfo
+	}
+	if !alert.ValidLevel(channel.MinLevel) {
+		return utils.HandleBadRequest("SaveNotificationChannel invalid min_level", errors.New("unknown level "+channel.MinLevel), c)
+	}
+	channel.MinLevel = alert.NormalizeLevel(channel.MinLevel)
+
+	target, err := notificationTarget(c.Request().Context(), channel.Type, channel.Target)
+	if err != nil {
+		return utils.HandleBadRequest("SaveNotificationChannel invalid target", err, c)
+	}
+	channel.Target = target
+	saved := ""
+	if channel.Id != 0 {
+		// an empty secret keeps the saved one, channels that were not webhooks have none
+		channels, err := h.loggerStore.GetNotificationChannels(channel.WorkspaceId)
+		if err != nil {
+			return utils.HandleInternalErr("SaveNotificationChannel could not get channels", err, c)
+		}
+		found := false
+		for _, existing := range channels {
+			if existing.Id == channel.Id {
+				saved, found = existing.Secret, true
+			}
+		}
+		if !found {
+			return c.NoContent(http.StatusNotFound)
+		}
+	}
+	if channel.Type != alert.TypeWebhook {
+		channel.Secret = ""
+	} else if channel.Secret == "" && saved == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return utils.HandleInternalErr("SaveNotificationChannel could not generate secret", err, c)
+		}
+		channel.Secret = hex.EncodeToString(secret)
+	}
+
+	err = h.loggerStore.SaveNotificationChannel(&channel)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("SaveNotificationChannel error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, &channel)
+}
+
+// notificationTarget validates the target of a channel type and returns it cleaned up.
+func notificationTarget(ctx context.Context, channelType string, target string) (string, error) {
+	target = strings.TrimSpace(target)
+	switch channelType {
+	case alert.TypeNone:
+		return "", nil
+	case alert.TypeEmail:
+		addresses, err := mail.ParseAddressList(target)
+		if err != nil {
+			return "", err
+		}
+		emails := []string{}
+		for _, address := range addresses {
+			emails = append(emails, address.Address)
+		}
+		return strings.Join(emails, ","), nil
+	case alert.TypeSlack, alert.TypeWebhook:
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return "", err
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "", errors.New("target must be an http or https URL")
+		}
+		if err := alert.CheckTarget(ctx, target); err != nil {
+			return "", err
+		}
+		return target, nil
+	}
+	return "", fmt.Errorf("unknown channel type %q", channelType)
+}
+
+/*
+Input: workspace_id, id
+Todo : Delete a notification channel of a workspace
+Output: If success return NoContent else return err
+Workspaces without channels are notified by email again
+*/
+func (h *Handler) DeleteNotificationChannel(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "DeleteNotificationChannel is called...")
+
+	workspaceId, err := strconv.Atoi(c.FormValue("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("DeleteNotificationChannel workspace_id is required", err, c)
+	}
+	id, err := strconv.Atoi(c.FormValue("id"))
+	if err != nil {
+		return utils.HandleBadRequest("DeleteNotificationChannel id is required", err, c)
+	}
+
+	err = h.loggerStore.DeleteNotificationChannel(workspaceId, id)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("DeleteNotificationChannel error occured", err, c)
+	}
 	return c.NoContent(http.StatusOK)
 }
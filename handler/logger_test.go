@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -47,6 +48,7 @@ func TestCreateLogSimple(t *testing.T) {
 		mockCallStore := mocks.CallStoreInterface{}
 		mockLoggerStore := mocks.LoggerStoreInterface{}
 		mockCallStore.EXPECT().GetWorkspaceByDomain("").Return(mockResponse, nil)
+		mockCallStore.EXPECT().GetUserFromDB(1).Return(&model.User{Id: 1, Locale: "en"}, nil)
 		mockLoggerStore.EXPECT().StartLogRoutine(mock.Anything, mock.Anything).Return(nil, nil)
 		handler := NewHandler(nil, &mockCallStore, nil, nil, nil, &mockLoggerStore, nil, nil)
 		if assert.NoError(t, handler.CreateLogSimple(c)) {
@@ -67,6 +69,7 @@ func TestCreateLogSimple(t *testing.T) {
 		mockCallStore := mocks.CallStoreInterface{}
 		mockLoggerStore := mocks.LoggerStoreInterface{}
 		mockCallStore.EXPECT().GetWorkspaceByDomain("").Return(mockResponse, nil)
+		mockCallStore.EXPECT().GetUserFromDB(1).Return(nil, errors.New("error"))
 		mockLoggerStore.EXPECT().StartLogRoutine(mock.Anything, mock.Anything).Return(nil, errors.New("error"))
 		handler := NewHandler(nil, &mockCallStore, nil, nil, nil, &mockLoggerStore, nil, nil)
 		if assert.NoError(t, handler.CreateLogSimple(c)) {
@@ -212,3 +215,93 @@ func TestCreateLog(t *testing.T) {
 		}
 	})
 }
+
+func TestListLogs(t *testing.T) {
+
+	e := echo.New()
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should require workspace_id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/listLogs", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handler := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+		if assert.NoError(t, handler.ListLogs(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Should reject unknown levels", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/listLogs?workspace_id=1&level=loud", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handler := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+		if assert.NoError(t, handler.ListLogs(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Should list the logs matching the filters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/listLogs?workspace_id=1&level=ERROR&flow_id=3&from=%2B15145550100&limit=2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		flowId := 3
+		mockLoggerStore := mocks.LoggerStoreInterface{}
+		mockLoggerStore.EXPECT().ListLogs(&model.LogFilter{WorkspaceId: 1, Level: "error", FlowId: &flowId, From: "+15145550100", Limit: 2}).
+			Return(&model.LogList{Logs: []model.DebuggerLog{{Id: 9, WorkspaceId: 1, Level: "error"}}}, nil)
+		handler := NewHandler(nil, nil, nil, nil, nil, &mockLoggerStore, nil, nil)
+		if assert.NoError(t, handler.ListLogs(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			var list model.LogList
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+			assert.Len(t, list.Logs, 1)
+		}
+	})
+}
+
+func TestGetLog(t *testing.T) {
+
+	e := echo.New()
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should require workspace_id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/getLog?id=9", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		handler := NewHandler(nil, nil, nil, nil, nil, nil, nil, nil)
+		if assert.NoError(t, handler.GetLog(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Should not find logs of other workspaces", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/getLog?id=9&workspace_id=2", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockLoggerStore := mocks.LoggerStoreInterface{}
+		mockLoggerStore.EXPECT().GetLog(2, 9).Return(nil, sql.ErrNoRows)
+		handler := NewHandler(nil, nil, nil, nil, nil, &mockLoggerStore, nil, nil)
+		if assert.NoError(t, handler.GetLog(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("Should return the log", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debugger/getLog?id=9&workspace_id=1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		mockLoggerStore := mocks.LoggerStoreInterface{}
+		mockLoggerStore.EXPECT().GetLog(1, 9).Return(&model.DebuggerLog{Id: 9, WorkspaceId: 1, Title: "Call failed"}, nil)
+		handler := NewHandler(nil, nil, nil, nil, nil, &mockLoggerStore, nil, nil)
+		if assert.NoError(t, handler.GetLog(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), "Call failed")
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Todo : List the notification types and their locales
+Output: If success return list of NotificationTemplate model
+*/
+func (h *Handler) ListNotificationTemplates(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListNotificationTemplates is called...")
+
+	templates := []model.NotificationTemplate{}
+	for _, notificationType := range notification.Default.Types() {
+		templates = append(templates, model.NotificationTemplate{Type: notificationType, Locales: notification.Default.Locales(notificationType)})
+	}
+	return c.JSON(http.StatusOK, templates)
+}
+
+/*
+Input: type, locale, format
+Todo : Render a notification type with sample data
+Output: If success return NotificationPreview model, or the HTML or text body when format is html or text, else return err
+Locales without a translation fall back to English
+*/
+func (h *Handler) PreviewNotification(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "PreviewNotification is called...")
+
+	notificationType := strings.TrimSpace(c.QueryParam("type"))
+	if notificationType == "" {
+		return utils.HandleBadRequest("PreviewNotification type is required", errors.New("missing type"), c)
+	}
+	locale := strings.TrimSpace(c.QueryParam("locale"))
+	if locale == "" {
+		locale = notification.DefaultLocale
+	}
+	if !notification.ValidLocale(locale) {
+		return utils.HandleBadRequest("PreviewNotification invalid locale", errors.New("invalid locale "+locale), c)
+	}
+
+	message, err := notification.Default.Preview(notificationType, locale)
+	if errors.Is(err, notification.ErrUnknownType) {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("PreviewNotification could not render", err, c)
+	}
+	switch c.QueryParam("format") {
+	case "html":
+		return c.HTML(http.StatusOK, message.HTML)
+	case "text":
+		return c.String(http.StatusOK, message.Text)
+	}
+	return c.JSON(http.StatusOK, model.NotificationPreview{
+		Type:    notificationType,
+		Locale:  locale,
+		Subject: message.Subject,
+		Text:    message.Text,
+		HTML:    message.HTML,
+	})
+}
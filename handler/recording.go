@@ -1,13 +1,23 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/audio"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/events"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
 	"lineblocs.com/api/utils"
 	helpers "github.com/Lineblocs/go-helpers"
 )
@@ -54,22 +64,39 @@ func (h *Handler) CreateRecording(c echo.Context) error {
 	if err != nil {
 		return utils.HandleInternalErr("CreateRecording error.", err, c)
 	}
+	recording.Id = int(recId)
+	h.emitEvent(recording.WorkspaceId, eventbus.RecordingCreated{
+		RecordingId: recording.Id,
+		APIId:       recording.APIId,
+		UserId:      recording.UserId,
+		CallId:      recording.CallId,
+	})
+	if recording.CallId != nil {
+		h.publishEvent(events.TypeCallRecordingStarted, recording.WorkspaceId, *recording.CallId, &recording)
+	}
+
 	c.Response().Writer.Header().Set("X-Recording-ID", strconv.FormatInt(recId, 10))
 	return c.JSON(http.StatusOK, &recording)
 }
 
 /*
 Input: file, status, recording_id
-Todo : Update recordings with matching id and upload file to AWS s3
+Todo : Upload the recording file to the object store of the workspace and update the recording with matching id
 Output: If success return NoContent in header else return err
 */
 func (h *Handler) UpdateRecording(c echo.Context) error {
 	utils.Log(logrus.InfoLevel, "UpdateRecording is called...")
 
 	file, err := c.FormFile("file")
+	if err != nil {
+		return utils.HandleBadRequest("UpdateRecording file is required", err, c)
+	}
 	status := c.FormValue("status")
 	recordingId := c.FormValue("recording_id")
 	recordingIdInt, err := strconv.Atoi(recordingId)
+	if err != nil {
+		return utils.HandleBadRequest("UpdateRecording recording_id is required", err, c)
+	}
 	record, err := h.recordingStore.GetRecordingFromDB(recordingIdInt)
 	if err != nil {
 		return utils.HandleInternalErr("Could not get recording..", err, c)
@@ -87,31 +114,139 @@ func (h *Handler) UpdateRecording(c echo.Context) error {
 	}
 	defer src.Close()
 
-	dst, err := os.OpenFile(file.Filename, os.O_WRONLY|os.O_CREATE, 0666)
+	// Will not save if space is over the limit
+	err = h.storeRecordingAudio(c.Request().Context(), workspace, record, src, file.Size, file.Header.Get("Content-Type"), status)
+	if err == errRecordingSpace {
+		return utils.HandlePaymentRequired("Not saving recording due to space limit reached..", nil, c)
+	}
 	if err != nil {
-		return utils.HandleInternalErr("UpdateRecording error occured", err, c)
+		return utils.HandleInternalErr("UpdateRecording could not store recording", err, c)
 	}
-	defer dst.Close()
-	size, err := h.recordingStore.GetRecordingSpace(workspace.Id)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// errRecordingSpace is returned by storeRecordingAudio when the recording does not fit in the plan.
+var errRecordingSpace = errors.New("recording space limit reached")
+
+// storeRecordingAudio reads the WAV header of a recording and cuts the silence
+// around it when the recording was created with trim. What is left is checked
+// against the plan and put in the object store of the workspace. Audio that is
+// not WAV is stored as is, without a duration.
+func (h *Handler) storeRecordingAudio(ctx context.Context, workspace *model.Workspace, record *model.Recording, src io.ReaderAt, size int64, contentType string, status string) error {
+	var body io.Reader = io.NewSectionReader(src, 0, size)
+	var meta *model.RecordingAudio
+	info, err := audio.ParseWAV(src, size)
 	if err != nil {
-		return utils.HandleInternalErr("Could not get recording space..", err, c)
+		utils.Log(logrus.WarnLevel, fmt.Sprintf("could not read the audio of recording id = %d: %s", record.Id, err.Error()))
+	} else {
+		if record.Trim {
+			if trimmed, trimmedSize, trimmedInfo := trimSilence(src, info, record.Id); trimmed != nil {
+				body, size, info = trimmed, trimmedSize, trimmedInfo
+			}
+		}
+		meta = &model.RecordingAudio{
+			Duration:   int(math.Ceil(info.Duration)),
+			Codec:      info.Codec,
+			SampleRate: info.SampleRate,
+			Channels:   info.Channels,
+		}
 	}
-	apiId := utils.CreateAPIID("rec")
-	err = h.recordingStore.UpdateRecording(apiId, status, file.Size, recordingIdInt)
+
+	duration := 0
+	if meta != nil {
+		duration = meta.Duration
+	}
+	allowed, err := h.hasRecordingSpace(workspace, size, duration)
 	if err != nil {
-		return utils.HandleInternalErr("UpdateRecording error occured", err, c)
+		return err
+	}
+	if !allowed {
+		return errRecordingSpace
 	}
 
-	// Will not save if space is over the limit
-	limit, err := utils.GetPlanRecordingLimit(workspace)
-	newSpace := size + int(file.Size)
-	if newSpace > limit {
-		return utils.HandleInternalErr("Not saving recording due to space limit reached..", err, c)
+	objects, err := h.objects.ForWorkspace(workspace.Id)
+	if err != nil {
+		return err
+	}
+	key := objectstore.Key(objectstore.FolderRecordings, record.APIId)
+	err = objects.Put(ctx, key, body, size, contentType)
+	if err != nil {
+		return err
 	}
+	return h.recordingStored(record, status, size, objects.URL(key), meta)
+}
 
-	// Upload recording file to AWS s3
-	go utils.UploadS3("recordings", apiId, src)
-	return c.NoContent(http.StatusNoContent)
+// trimSilence returns the recording without the silence before and after the
+// sound, or nil when there is nothing to cut. Recordings that are silent
+// throughout or whose codec can not be measured are kept whole.
+func trimSilence(src io.ReaderAt, info *audio.Info, recordingId int) (io.Reader, int64, *audio.Info) {
+	start, end, err := audio.FindSound(src, info, audio.TrimOptions{})
+	if err != nil {
+		utils.Log(logrus.WarnLevel, fmt.Sprintf("could not trim recording id = %d: %s", recordingId, err.Error()))
+		return nil, 0, nil
+	}
+	if start == end || (start == 0 && end == info.DataSize) {
+		return nil, 0, nil
+	}
+	trimmed, size, trimmedInfo, err := audio.Trim(src, info, start, end)
+	if err != nil {
+		utils.Log(logrus.WarnLevel, fmt.Sprintf("could not trim recording id = %d: %s", recordingId, err.Error()))
+		return nil, 0, nil
+	}
+	return trimmed, size, trimmedInfo
+}
+
+// hasRecordingSpace tells whether size more bytes and seconds more audio fit
+// in the recording space and minutes of the plan, plans without a limit
+// have no bound.
+func (h *Handler) hasRecordingSpace(workspace *model.Workspace, size int64, seconds int) (bool, error) {
+	used, err := h.recordingStore.GetRecordingSpace(workspace.Id)
+	if err != nil {
+		return false, err
+	}
+	limit, err := h.recordingStore.GetPlanRecordingSpace(workspace.Id)
+	if err != nil {
+		return false, err
+	}
+	if limit > 0 && int64(used)+size > limit {
+		return false, nil
+	}
+
+	minutes, err := h.recordingStore.GetPlanRecordingMinutes(workspace.Id)
+	if err != nil {
+		return false, err
+	}
+	if minutes == 0 || seconds == 0 {
+		return true, nil
+	}
+	usedSeconds, err := h.recordingStore.GetRecordingDuration(workspace.Id)
+	if err != nil {
+		return false, err
+	}
+	return usedSeconds+seconds <= minutes*60, nil
+}
+
+// recordingStored updates a recording once its audio is in the object store
+// and queues its transcription.
+func (h *Handler) recordingStored(record *model.Recording, status string, size int64, uri string, meta *model.RecordingAudio) error {
+	err := h.recordingStore.UpdateRecording(record.Id, status, size, uri, meta)
+	if err != nil {
+		return err
+	}
+	if meta != nil {
+		record.Duration = meta.Duration
+		record.Codec = meta.Codec
+		record.SampleRate = meta.SampleRate
+		record.Channels = meta.Channels
+	}
+	h.emitEvent(record.WorkspaceId, eventbus.RecordingUploaded{
+		RecordingId: record.Id,
+		APIId:       record.APIId,
+		Status:      status,
+		Size:        size,
+	})
+	h.transcribeCompletedRecording(record, status)
+	return nil
 }
 
 /*
@@ -133,6 +268,15 @@ func (h *Handler) SetRecordingStatus(c echo.Context) error {
 		return utils.HandleInternalErr("SetRecordingStatus error.", err, c)
 	}
 
+	record, err := h.recordingStore.GetRecordingFromDB(statusData.Id)
+	if err == nil {
+		h.emitEvent(record.WorkspaceId, eventbus.RecordingStatusChanged{RecordingId: statusData.Id, Status: statusData.Status})
+		// recordings completed before their audio is stored are queued by UpdateRecording
+		if record.Uri != "" {
+			h.transcribeCompletedRecording(record, statusData.Status)
+		}
+	}
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -152,6 +296,11 @@ func (h *Handler) UpdateRecordingTranscription(c echo.Context) error {
 	if err != nil {
 		return utils.HandleInternalErr("UpdateRecording Could not execute query", err, c)
 	}
+
+	record, err := h.recordingStore.GetRecordingFromDB(update.RecordingId)
+	if err == nil {
+		h.emitEvent(record.WorkspaceId, eventbus.RecordingTranscribed{RecordingId: update.RecordingId, Ready: update.Ready})
+	}
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -174,3 +323,74 @@ func (h *Handler) GetRecording(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, &record)
 }
+
+/*
+Input: workspace_id, tags, call_id, status, from, to, q, cursor, limit
+Todo : List the recordings of a workspace matching the filters, newest first
+Output: If success return RecordingList model else return err
+tags may be repeated or comma separated, from and to are RFC3339 times or dates
+*/
+func (h *Handler) ListRecordings(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "ListRecordings is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("ListRecordings workspace_id is required", err, c)
+	}
+	filter := model.RecordingFilter{
+		WorkspaceId: workspaceId,
+		Status:      c.QueryParam("status"),
+		Text:        strings.TrimSpace(c.QueryParam("q")),
+	}
+	for _, value := range c.QueryParams()["tags"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+	if value := c.QueryParam("call_id"); value != "" {
+		callId, err := strconv.Atoi(value)
+		if err != nil {
+			return utils.HandleBadRequest("ListRecordings invalid call_id", err, c)
+		}
+		filter.CallId = &callId
+	}
+	if filter.From, err = parseDateParam(c.QueryParam("from"), false); err != nil {
+		return utils.HandleBadRequest("ListRecordings invalid from", err, c)
+	}
+	if filter.To, err = parseDateParam(c.QueryParam("to"), true); err != nil {
+		return utils.HandleBadRequest("ListRecordings invalid to", err, c)
+	}
+	if filter.BeforeId, err = utils.DecodeCursor(c.QueryParam("cursor")); err != nil {
+		return utils.HandleBadRequest("ListRecordings invalid cursor", err, c)
+	}
+	if filter.Limit, err = utils.ParsePageLimit(c.QueryParam("limit")); err != nil {
+		return utils.HandleBadRequest("ListRecordings invalid limit", err, c)
+	}
+
+	list, err := h.recordingStore.ListRecordings(&filter)
+	if err != nil {
+		return utils.HandleInternalErr("ListRecordings error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// parseDateParam reads an optional RFC3339 time or YYYY-MM-DD date. With
+// endOfDay a date means the end of that day, so ranges include their last day.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return &parsed, nil
+}
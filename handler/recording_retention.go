@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: workspace_id
+Todo : Get the recording retention policy of a workspace
+Output: If success return RecordingRetentionPolicy model else return err
+*/
+func (h *Handler) GetRecordingRetentionPolicy(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetRecordingRetentionPolicy is called...")
+
+	workspaceId, err := strconv.Atoi(c.QueryParam("workspace_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetRecordingRetentionPolicy workspace_id is required", err, c)
+	}
+	policy, err := h.recordingStore.GetRetentionPolicy(workspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("GetRecordingRetentionPolicy error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, policy)
+}
+
+/*
+Input: RecordingRetentionPolicy model
+Todo : Create or replace the recording retention policy of a workspace, 0 days turns a rule off
+Output: If success return RecordingRetentionPolicy model else return err
+*/
+func (h *Handler) SetRecordingRetentionPolicy(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "SetRecordingRetentionPolicy is called...")
+
+	var policy model.RecordingRetentionPolicy
+	if err := c.Bind(&policy); err != nil {
+		return utils.HandleInternalErr("SetRecordingRetentionPolicy 1 Could not decode JSON", err, c)
+	}
+	if policy.WorkspaceId == 0 {
+		return utils.HandleBadRequest("SetRecordingRetentionPolicy workspace_id is required", errors.New("missing workspace_id"), c)
+	}
+	if policy.ArchiveAfterDays < 0 || policy.DeleteAfterDays < 0 {
+		return utils.HandleBadRequest("SetRecordingRetentionPolicy days can not be negative", errors.New("negative retention days"), c)
+	}
+	if policy.ArchiveAfterDays > 0 && policy.DeleteAfterDays > 0 && policy.ArchiveAfterDays >= policy.DeleteAfterDays {
+		return utils.HandleBadRequest("SetRecordingRetentionPolicy archive_after_days must be less than delete_after_days", errors.New("archive after delete"), c)
+	}
+	if policy.KeepTags == nil {
+		policy.KeepTags = []string{}
+	}
+
+	err := h.recordingStore.SaveRetentionPolicy(&policy)
+	if err != nil {
+		return utils.HandleInternalErr("SetRecordingRetentionPolicy error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, &policy)
+}
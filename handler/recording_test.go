@@ -190,6 +190,7 @@ func TestUpdateRecordingTranscription(t *testing.T) {
 
 		mockRecStore := mocks.RecordingStoreInterface{}
 		mockRecStore.EXPECT().UpdateRecordingTranscription(&recBody).Return(nil)
+		mockRecStore.EXPECT().GetRecordingFromDB(1).Return(&model.Recording{Id: 1, WorkspaceId: 1}, nil)
 		handler := NewHandler(nil, nil, nil, nil, nil, nil, &mockRecStore, nil)
 		if assert.NoError(t, handler.UpdateRecordingTranscription(c)) {
 			assert.Equal(t, http.StatusNoContent, rec.Code)
@@ -241,3 +242,31 @@ func TestUpdateRecordingTranscription(t *testing.T) {
 		}
 	})
 }
+
+func TestHasRecordingSpace(t *testing.T) {
+	helpers.InitLogrus("stdout")
+	workspace := &model.Workspace{Id: 1, Plan: "enterprise"}
+
+	t.Run("Should allow any size on plans without a limit", func(t *testing.T) {
+		mockRecStore := mocks.RecordingStoreInterface{}
+		mockRecStore.EXPECT().GetRecordingSpace(1).Return(5<<30, nil)
+		mockRecStore.EXPECT().GetPlanRecordingSpace(1).Return(0, nil)
+		mockRecStore.EXPECT().GetPlanRecordingMinutes(1).Return(0, nil)
+
+		handler := NewHandler(nil, nil, nil, nil, nil, nil, &mockRecStore, nil)
+		allowed, err := handler.hasRecordingSpace(workspace, 1024, 30)
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	})
+
+	t.Run("Should reject uploads over the plan space", func(t *testing.T) {
+		mockRecStore := mocks.RecordingStoreInterface{}
+		mockRecStore.EXPECT().GetRecordingSpace(1).Return(1<<30-512, nil)
+		mockRecStore.EXPECT().GetPlanRecordingSpace(1).Return(1<<30, nil)
+
+		handler := NewHandler(nil, nil, nil, nil, nil, nil, &mockRecStore, nil)
+		allowed, err := handler.hasRecordingSpace(workspace, 1024, 30)
+		assert.NoError(t, err)
+		assert.False(t, allowed)
+	})
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/recording"
+	"lineblocs.com/api/utils"
+)
+
+// ChunkChecksumHeader carries the hex SHA-256 of a chunk.
+const ChunkChecksumHeader = "X-Chunk-Checksum"
+
+/*
+Input: RecordingUpload model with recording_id, size, checksum, content_type
+Todo : Start a resumable upload of the audio of a recording
+Output: If success return RecordingUpload model with upload_id else return err
+*/
+func (h *Handler) InitRecordingUpload(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "InitRecordingUpload is called...")
+
+	var upload model.RecordingUpload
+	if err := c.Bind(&upload); err != nil {
+		return utils.HandleInternalErr("InitRecordingUpload 1 Could not decode JSON", err, c)
+	}
+	if upload.Size < 0 {
+		return utils.HandleBadRequest("InitRecordingUpload size can not be negative", errors.New("negative size"), c)
+	}
+
+	record, err := h.recordingStore.GetRecordingFromDB(upload.RecordingId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("InitRecordingUpload could not get recording", err, c)
+	}
+	workspace, err := h.callStore.GetWorkspaceFromDB(record.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("InitRecordingUpload could not get workspace", err, c)
+	}
+	// a known size is checked up front so the media server does not send it in vain
+	if upload.Size > 0 {
+		allowed, err := h.hasRecordingSpace(workspace, upload.Size, 0)
+		if err != nil {
+			return utils.HandleInternalErr("InitRecordingUpload could not get recording space", err, c)
+		}
+		if !allowed {
+			return utils.HandlePaymentRequired("InitRecordingUpload recording space limit reached", nil, c)
+		}
+	}
+
+	upload.UploadId = utils.CreateAPIID("upl")
+	upload.WorkspaceId = record.WorkspaceId
+	_, err = h.recordingStore.CreateRecordingUpload(&upload)
+	if err != nil {
+		return utils.HandleInternalErr("InitRecordingUpload error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, &upload)
+}
+
+/*
+Input: upload_id
+Todo : Get the state of an upload, received is the offset to resume from
+Output: If success return RecordingUpload model else return err
+*/
+func (h *Handler) GetRecordingUpload(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetRecordingUpload is called...")
+
+	upload, err := h.recordingStore.GetRecordingUpload(c.QueryParam("upload_id"))
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetRecordingUpload error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, upload)
+}
+
+/*
+Input: upload_id, offset, chunk bytes as the request body, X-Chunk-Checksum header
+Todo : Store the next chunk of an upload, a chunk that was received already is acknowledged again
+Output: If success return RecordingUpload model else return err
+A chunk that does not continue the upload gets 409 with the upload to resume from
+*/
+func (h *Handler) UploadRecordingChunk(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "UploadRecordingChunk is called...")
+
+	offset, err := strconv.ParseInt(c.QueryParam("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return utils.HandleBadRequest("UploadRecordingChunk offset is required", err, c)
+	}
+	checksum := c.Request().Header.Get(ChunkChecksumHeader)
+	if checksum == "" {
+		return utils.HandleBadRequest("UploadRecordingChunk "+ChunkChecksumHeader+" is required", errors.New("missing chunk checksum"), c)
+	}
+
+	upload, err := h.recordingStore.GetRecordingUpload(c.QueryParam("upload_id"))
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("UploadRecordingChunk could not get upload", err, c)
+	}
+	if upload.Status != recording.UploadInProgress {
+		return utils.HandleConflict("UploadRecordingChunk upload is "+upload.Status, recording.ErrUploadClosed, c)
+	}
+	if offset < upload.Received {
+		// a retry of a chunk whose acknowledgement was lost
+		return c.JSON(http.StatusOK, upload)
+	}
+	if offset > upload.Received {
+		return h.resumeUpload(c, upload)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(c.Request().Body, recording.MaxChunkSize+1))
+	if err != nil {
+		return utils.HandleBadRequest("UploadRecordingChunk could not read chunk", err, c)
+	}
+	if len(data) == 0 || len(data) > recording.MaxChunkSize {
+		return utils.HandleBadRequest("UploadRecordingChunk chunks must hold 1 to "+strconv.Itoa(recording.MaxChunkSize)+" bytes", errors.New("invalid chunk size"), c)
+	}
+	if !recording.SameChecksum(recording.Checksum(data), checksum) {
+		return utils.HandleBadRequest("UploadRecordingChunk chunk was corrupted in transit", recording.ErrChecksumMismatch, c)
+	}
+	chunk := model.RecordingUploadChunk{Offset: offset, Size: int64(len(data)), Checksum: checksum}
+	if upload.Size > 0 && chunk.Offset+chunk.Size > upload.Size {
+		return utils.HandleBadRequest("UploadRecordingChunk chunk goes past the size of the upload", errors.New("chunk too large"), c)
+	}
+
+	objects, err := h.objects.ForWorkspace(upload.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("UploadRecordingChunk could not open object store", err, c)
+	}
+	err = objects.Put(c.Request().Context(), recording.ChunkKey(upload.UploadId, offset), bytes.NewReader(data), chunk.Size, echo.MIMEOctetStream)
+	if err != nil {
+		return utils.HandleInternalErr("UploadRecordingChunk could not store chunk", err, c)
+	}
+	err = h.recordingStore.AddRecordingUploadChunk(upload.Id, &chunk)
+	if errors.Is(err, recording.ErrOffsetMismatch) {
+		// another request stored this offset first, the client resumes from the current state
+		upload, err = h.recordingStore.GetRecordingUpload(upload.UploadId)
+		if err != nil {
+			return utils.HandleInternalErr("UploadRecordingChunk could not get upload", err, c)
+		}
+		return h.resumeUpload(c, upload)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("UploadRecordingChunk error occured", err, c)
+	}
+
+	upload.Received += chunk.Size
+	return c.JSON(http.StatusOK, upload)
+}
+
+/*
+Input: RecordingUploadComplete model
+Todo : Assemble the chunks of an upload into the recording and update its size and status
+Output: If success return NoContent else return err
+*/
+func (h *Handler) CompleteRecordingUpload(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "CompleteRecordingUpload is called...")
+
+	var request model.RecordingUploadComplete
+	if err := c.Bind(&request); err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload 1 Could not decode JSON", err, c)
+	}
+
+	upload, err := h.recordingStore.GetRecordingUpload(request.UploadId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not get upload", err, c)
+	}
+	if upload.Status != recording.UploadInProgress {
+		return utils.HandleConflict("CompleteRecordingUpload upload is "+upload.Status, recording.ErrUploadClosed, c)
+	}
+	// concurrent requests for the same upload race on the claim, only one assembles it
+	err = h.recordingStore.ClaimRecordingUpload(upload.Id)
+	if errors.Is(err, recording.ErrUploadClosed) {
+		return utils.HandleConflict("CompleteRecordingUpload upload is no longer in progress", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not claim upload", err, c)
+	}
+	closed := false
+	defer func() {
+		// the upload can be completed again after a failure that did not close it
+		if !closed {
+			h.reopenUpload(upload)
+		}
+	}()
+	if request.Checksum != "" {
+		upload.Checksum = request.Checksum
+	}
+
+	record, err := h.recordingStore.GetRecordingFromDB(upload.RecordingId)
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not get recording", err, c)
+	}
+	workspace, err := h.callStore.GetWorkspaceFromDB(upload.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not get workspace", err, c)
+	}
+
+	chunks, err := h.recordingStore.GetRecordingUploadChunks(upload.Id)
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not get chunks", err, c)
+	}
+	objects, err := h.objects.ForWorkspace(upload.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not open object store", err, c)
+	}
+	// the file is assembled on disk so its audio can be read and trimmed before it is stored
+	assembled, err := os.CreateTemp("", "recording-"+upload.UploadId+"-*")
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not create temporary file", err, c)
+	}
+	defer os.Remove(assembled.Name())
+	defer assembled.Close()
+
+	err = recording.Assemble(c.Request().Context(), objects, upload, chunks, assembled)
+	if errors.Is(err, recording.ErrUploadIncomplete) {
+		return h.resumeUpload(c, upload)
+	}
+	if errors.Is(err, recording.ErrChecksumMismatch) {
+		// the stored bytes are wrong, the media server has to start over
+		closed = true
+		h.closeUpload(objects, upload, chunks, recording.UploadFailed)
+		return utils.HandleBadRequest("CompleteRecordingUpload upload was corrupted", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not assemble recording", err, c)
+	}
+
+	err = h.storeRecordingAudio(c.Request().Context(), workspace, record, assembled, upload.Received, upload.ContentType, request.Status)
+	if err == errRecordingSpace {
+		return utils.HandlePaymentRequired("CompleteRecordingUpload recording space limit reached", nil, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("CompleteRecordingUpload could not store recording", err, c)
+	}
+	closed = true
+	h.closeUpload(objects, upload, chunks, recording.UploadCompleted)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// reopenUpload puts a claimed upload back in progress.
+func (h *Handler) reopenUpload(upload *model.RecordingUpload) {
+	err := h.recordingStore.SetRecordingUploadStatus(upload.Id, recording.UploadInProgress)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not reopen upload "+upload.UploadId+": "+err.Error())
+	}
+}
+
+func (h *Handler) resumeUpload(c echo.Context, upload *model.RecordingUpload) error {
+	utils.Log(logrus.WarnLevel, "upload "+upload.UploadId+" has to resume from offset "+strconv.FormatInt(upload.Received, 10))
+	return c.JSON(http.StatusConflict, upload)
+}
+
+// closeUpload sets the final status of an upload and deletes its chunks.
+func (h *Handler) closeUpload(objects objectstore.ObjectStore, upload *model.RecordingUpload, chunks []model.RecordingUploadChunk, status string) {
+	err := h.recordingStore.SetRecordingUploadStatus(upload.Id, status)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not close upload "+upload.UploadId+": "+err.Error())
+		return
+	}
+	err = recording.DeleteChunks(context.Background(), objects, upload.UploadId, chunks)
+	if err != nil {
+		utils.Log(logrus.WarnLevel, "could not delete chunks of upload "+upload.UploadId+": "+err.Error())
+	}
+}
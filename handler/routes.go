@@ -3,8 +3,10 @@ package handler
 import (
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
-	"lineblocs.com/api/utils"
+	"lineblocs.com/api/apikey"
 	"lineblocs.com/api/middlewares"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/utils"
 )
 
 /*
@@ -22,82 +24,128 @@ func (h *Handler) Register(e *echo.Echo) {
 		g.Use(middlewares.BasicAuthMiddleware(h.userStore))
 	}
 
-	apiTokenValue := utils.Config("LINEBLOCS_KEY")
-
-	utils.Log(logrus.InfoLevel, "registring API auth middleware with Lineblocs key: " + apiTokenValue)
+	if h.apiKeys == nil {
+		utils.Log(logrus.WarnLevel, "API keys are not configured, every API request is rejected")
+		h.apiKeys = apikey.NewManager(nil)
+	}
+	// Every route declares the scope an API key needs to call it
+	scope := middlewares.RequireScope
 
 	// Middleware to check for the x-lineblocs-api-token header
-	e.Use(middlewares.APIAuthMiddleware(apiTokenValue))
+	// Download URLs are authorized by their signature instead
+	e.Use(middlewares.APIAuthMiddleware(h.apiKeys, objectstore.ProxyDownloadPath))
 
 	// For Health Check
 	e.GET("/healthz", h.Healthz)
 
+	// Signed download URLs of recordings and faxes
+	e.GET(objectstore.ProxyDownloadPath, h.DownloadObject)
+	g.POST("/storage/rotateKey", h.RotateStorageKey, scope(apikey.ScopeStorageWrite))
+
 	// Call Related Routing
-	g.POST("/call/createCall", h.CreateCall)
-	g.POST("/call/updateCall", h.UpdateCall)
-	g.GET("/call/fetchCall", h.FetchCall)
-	g.POST("/call/setSIPCallID", h.SetSIPCallID)
-	g.POST("/call/setProviderByIP", h.SetProviderByIP)
-	g.POST("/conference/createConference", h.CreateConference)
+	g.POST("/call/createCall", h.CreateCall, scope(apikey.ScopeCallWrite))
+	g.POST("/call/updateCall", h.UpdateCall, scope(apikey.ScopeCallWrite))
+	g.GET("/call/fetchCall", h.FetchCall, scope(apikey.ScopeCallRead))
+	g.GET("/call/getCallSession", h.GetCallSession, scope(apikey.ScopeCallRead))
+	g.GET("/call/getActiveCalls", h.GetActiveCalls, scope(apikey.ScopeCallRead))
+	g.GET("/call/streamEvents", h.StreamCallEvents, scope(apikey.ScopeCallRead))
+	g.POST("/call/setSIPCallID", h.SetSIPCallID, scope(apikey.ScopeCallWrite))
+	g.POST("/call/setProviderByIP", h.SetProviderByIP, scope(apikey.ScopeCallWrite))
+	g.POST("/conference/createConference", h.CreateConference, scope(apikey.ScopeCallWrite))
+	g.GET("/conference/getConference", h.GetConference, scope(apikey.ScopeCallRead))
+	g.POST("/conference/addParticipant", h.AddConferenceParticipant, scope(apikey.ScopeCallWrite))
+	g.POST("/conference/removeParticipant", h.RemoveConferenceParticipant, scope(apikey.ScopeCallWrite))
+	g.POST("/conference/updateParticipant", h.UpdateConferenceParticipant, scope(apikey.ScopeCallWrite))
+	g.POST("/conference/endConference", h.EndConference, scope(apikey.ScopeCallWrite))
 
 	// Debit Related Routing
-	g.POST("/debit/createDebit", h.CreateDebit)
-	g.POST("/debit/createAPIUsageDebit", h.CreateAPIUsageDebit)
+	g.POST("/debit/createDebit", h.CreateDebit, scope(apikey.ScopeBillingWrite))
+	g.POST("/debit/createAPIUsageDebit", h.CreateAPIUsageDebit, scope(apikey.ScopeBillingWrite))
 
 	// Debugger Log Related Routing
-	g.POST("/debugger/createLog", h.CreateLog)
-	g.POST("/debugger/createLogSimple", h.CreateLogSimple)
+	g.POST("/debugger/createLog", h.CreateLog, scope(apikey.ScopeDebuggerWrite))
+	g.POST("/debugger/createLogSimple", h.CreateLogSimple, scope(apikey.ScopeDebuggerWrite))
+	g.GET("/debugger/listLogs", h.ListLogs, scope(apikey.ScopeDebuggerRead))
+	g.GET("/debugger/getLog", h.GetLog, scope(apikey.ScopeDebuggerRead))
+	g.GET("/debugger/listChannels", h.ListNotificationChannels, scope(apikey.ScopeDebuggerRead))
+	g.POST("/debugger/saveChannel", h.SaveNotificationChannel, scope(apikey.ScopeDebuggerWrite))
+	g.POST("/debugger/deleteChannel", h.DeleteNotificationChannel, scope(apikey.ScopeDebuggerWrite))
+	g.GET("/notification/listTemplates", h.ListNotificationTemplates, scope(apikey.ScopeDebuggerRead))
+	g.GET("/notification/preview", h.PreviewNotification, scope(apikey.ScopeDebuggerRead))
 
 	// Fax Related Routing
-	g.POST("/fax/createFax", h.CreateFax)
+	g.POST("/fax/createFax", h.CreateFax, scope(apikey.ScopeFaxWrite))
+	g.POST("/fax/updateFaxStatus", h.UpdateFaxStatus, scope(apikey.ScopeFaxWrite))
+	g.GET("/fax/getFax", h.GetFax, scope(apikey.ScopeFaxRead))
+	g.GET("/fax/listFaxes", h.ListFaxes, scope(apikey.ScopeFaxRead))
+	g.GET("/fax/getEmailSettings", h.GetFaxEmailSettings, scope(apikey.ScopeFaxRead))
+	g.POST("/fax/setEmailSettings", h.SetFaxEmailSettings, scope(apikey.ScopeFaxWrite))
+	g.GET("/fax/getDownloadURL", h.GetFaxDownloadURL, scope(apikey.ScopeFaxRead))
 
 	// Recording Related Routing
-	g.POST("/recording/createRecording", h.CreateRecording)
-	g.POST("/recording/setRecordingStatus", h.SetRecordingStatus)
-	g.POST("/recording/updateRecording", h.UpdateRecording)
-	g.POST("/recording/updateRecordingTranscription", h.UpdateRecordingTranscription)
-	g.GET("/recording/getRecording", h.GetRecording)
+	g.POST("/recording/createRecording", h.CreateRecording, scope(apikey.ScopeRecordingWrite))
+	g.POST("/recording/setRecordingStatus", h.SetRecordingStatus, scope(apikey.ScopeRecordingWrite))
+	g.POST("/recording/updateRecording", h.UpdateRecording, scope(apikey.ScopeRecordingWrite))
+	g.POST("/recording/initUpload", h.InitRecordingUpload, scope(apikey.ScopeRecordingWrite))
+	g.PUT("/recording/uploadChunk", h.UploadRecordingChunk, scope(apikey.ScopeRecordingWrite))
+	g.GET("/recording/getUpload", h.GetRecordingUpload, scope(apikey.ScopeRecordingRead))
+	g.POST("/recording/completeUpload", h.CompleteRecordingUpload, scope(apikey.ScopeRecordingWrite))
+	g.POST("/recording/updateRecordingTranscription", h.UpdateRecordingTranscription, scope(apikey.ScopeRecordingWrite))
+	g.POST("/recording/transcribe", h.TranscribeRecording, scope(apikey.ScopeRecordingWrite))
+	g.GET("/recording/getTranscription", h.GetTranscription, scope(apikey.ScopeRecordingRead))
+	g.GET("/recording/getRecording", h.GetRecording, scope(apikey.ScopeRecordingRead))
+	g.GET("/recording/listRecordings", h.ListRecordings, scope(apikey.ScopeRecordingRead))
+	g.GET("/recording/getDownloadURL", h.GetRecordingDownloadURL, scope(apikey.ScopeRecordingRead))
+	g.GET("/recording/getRetentionPolicy", h.GetRecordingRetentionPolicy, scope(apikey.ScopeRecordingRead))
+	g.POST("/recording/setRetentionPolicy", h.SetRecordingRetentionPolicy, scope(apikey.ScopeRecordingWrite))
 
 	// Carrier Related Routing
-	g.POST("/carrier/createSIPReport", h.CreateSIPReport)
-	g.GET("/carrier/processRouterFlow", h.ProcessRouterFlow)
+	g.POST("/carrier/createSIPReport", h.CreateSIPReport, scope(apikey.ScopeCarrierWrite))
+	g.GET("/carrier/getSIPReportHistory", h.GetSIPReportHistory, scope(apikey.ScopeCarrierRead))
+	g.GET("/carrier/processRouterFlow", h.ProcessRouterFlow, scope(apikey.ScopeCarrierRead))
 
 	// User Related Routing
-	g.GET("/user/verifyCaller", h.VerifyCaller)
-	g.GET("/user/verifyCallerByDomain", h.VerifyCallerByDomain)
-	g.GET("/user/getUserByDomain", h.GetUserByDomain)
-	g.GET("/user/getUserByDID", h.GetUserByDID)
-	g.GET("/user/getUserByTrunkSourceIp", h.GetUserByTrunkSourceIp)
-	g.GET("/user/getWorkspaceMacros", h.GetWorkspaceMacros)
-	g.GET("/user/getDIDNumberData", h.GetDIDNumberData)
-	g.GET("/user/getPSTNProviderIP", h.GetPSTNProviderIP)
-	g.GET("/user/getPSTNProviderIPForTrunk", h.GetPSTNProviderIPForTrunk)
-	g.GET("/user/ipWhitelistLookup", h.IPWhitelistLookup)
-	g.GET("/user/hostedSIPTrunkLookup", h.HostedSIPTrunkLookup)
-	g.GET("/user/getDIDAcceptOption", h.GetDIDAcceptOption)
-	g.GET("/user/getDIDAssignedIP", h.GetDIDAssignedIP)
-	g.GET("/user/getUserAssignedIP", h.GetUserAssignedIP)
-	g.GET("/user/getTrunkAssignedIP", h.GetTrunkAssignedIP)
-	g.GET("/user/addPSTNProviderTechPrefix", h.AddPSTNProviderTechPrefix)
-	g.GET("/user/getCallerIdToUse", h.GetCallerIdToUse)
-	g.GET("/user/getExtensionFlowInfo", h.GetExtensionFlowInfo)
-	g.GET("/user/getFlowInfo", h.GetFlowInfo)
-	g.GET("/user/getDIDDomain", h.GetDIDDomain)
-	g.GET("/user/getCodeFlowInfo", h.GetCodeFlowInfo)
-	g.GET("/user/incomingDIDValidation", h.IncomingDIDValidation)
-	g.GET("/user/incomingTrunkValidation", h.IncomingTrunkValidation)
-	g.GET("/user/lookupSIPTrunkByDID", h.LookupSIPTrunkByDID)
-	g.GET("/user/incomingMediaServerValidation", h.IncomingMediaServerValidation)
-	g.POST("/user/storeRegistration", h.StoreRegistration)
-	g.GET("/user/getSettings", h.GetSettings)
-	g.GET("/user/processSIPTrunkCall", h.ProcessSIPTrunkCall)
-	g.GET("/user/processDialplan", h.ProcessDialplan)
-	g.POST("/user/processCDRsAndBill", h.ProcessCDRsAndBill)
-	g.GET("/user/captureSIPMessage", h.CaptureSIPMessage)
-	g.GET("/user/logCallInviteEvent", h.LogCallInviteEvent)
+	g.GET("/user/verifyCaller", h.VerifyCaller, scope(apikey.ScopeUserRead))
+	g.GET("/user/verifyCallerByDomain", h.VerifyCallerByDomain, scope(apikey.ScopeUserRead))
+	g.GET("/user/getUserByDomain", h.GetUserByDomain, scope(apikey.ScopeUserRead))
+	g.GET("/user/getUserByDID", h.GetUserByDID, scope(apikey.ScopeUserRead))
+	g.GET("/user/getUserByTrunkSourceIp", h.GetUserByTrunkSourceIp, scope(apikey.ScopeUserRead))
+	g.GET("/user/getWorkspaceMacros", h.GetWorkspaceMacros, scope(apikey.ScopeUserRead))
+	g.GET("/user/getDIDNumberData", h.GetDIDNumberData, scope(apikey.ScopeUserRead))
+	g.GET("/user/getPSTNProviderIP", h.GetPSTNProviderIP, scope(apikey.ScopeUserRead))
+	g.GET("/user/getPSTNProviderIPForTrunk", h.GetPSTNProviderIPForTrunk, scope(apikey.ScopeUserRead))
+	g.GET("/user/ipWhitelistLookup", h.IPWhitelistLookup, scope(apikey.ScopeUserRead))
+	g.GET("/user/hostedSIPTrunkLookup", h.HostedSIPTrunkLookup, scope(apikey.ScopeUserRead))
+	g.GET("/user/getDIDAcceptOption", h.GetDIDAcceptOption, scope(apikey.ScopeUserRead))
+	g.GET("/user/getDIDAssignedIP", h.GetDIDAssignedIP, scope(apikey.ScopeUserRead))
+	g.GET("/user/getUserAssignedIP", h.GetUserAssignedIP, scope(apikey.ScopeUserRead))
+	g.GET("/user/getTrunkAssignedIP", h.GetTrunkAssignedIP, scope(apikey.ScopeUserRead))
+	g.GET("/user/addPSTNProviderTechPrefix", h.AddPSTNProviderTechPrefix, scope(apikey.ScopeUserWrite))
+	g.GET("/user/getCallerIdToUse", h.GetCallerIdToUse, scope(apikey.ScopeUserRead))
+	g.GET("/user/getExtensionFlowInfo", h.GetExtensionFlowInfo, scope(apikey.ScopeUserRead))
+	g.GET("/user/getFlowInfo", h.GetFlowInfo, scope(apikey.ScopeUserRead))
+	g.GET("/user/getDIDDomain", h.GetDIDDomain, scope(apikey.ScopeUserRead))
+	g.GET("/user/getCodeFlowInfo", h.GetCodeFlowInfo, scope(apikey.ScopeUserRead))
+	g.GET("/user/incomingDIDValidation", h.IncomingDIDValidation, scope(apikey.ScopeUserRead))
+	g.GET("/user/incomingTrunkValidation", h.IncomingTrunkValidation, scope(apikey.ScopeUserRead))
+	g.GET("/user/lookupSIPTrunkByDID", h.LookupSIPTrunkByDID, scope(apikey.ScopeUserRead))
+	g.GET("/user/incomingMediaServerValidation", h.IncomingMediaServerValidation, scope(apikey.ScopeUserRead))
+	g.POST("/user/storeRegistration", h.StoreRegistration, scope(apikey.ScopeUserWrite))
+	g.GET("/user/getSettings", h.GetSettings, scope(apikey.ScopeAdminRead))
+	g.GET("/user/processSIPTrunkCall", h.ProcessSIPTrunkCall, scope(apikey.ScopeUserRead))
+	g.GET("/user/processDialplan", h.ProcessDialplan, scope(apikey.ScopeUserRead))
+	g.POST("/user/processCDRsAndBill", h.ProcessCDRsAndBill, scope(apikey.ScopeBillingWrite))
+	g.GET("/user/captureSIPMessage", h.CaptureSIPMessage, scope(apikey.ScopeUserWrite))
+	g.GET("/user/logCallInviteEvent", h.LogCallInviteEvent, scope(apikey.ScopeUserWrite))
+
+	// API Key Related Routing
+	g.POST("/apikey/createKey", h.CreateAPIKey, scope(apikey.ScopeAPIKeyWrite))
+	g.GET("/apikey/listKeys", h.ListAPIKeys, scope(apikey.ScopeAPIKeyRead))
+	g.POST("/apikey/rotateKey", h.RotateAPIKey, scope(apikey.ScopeAPIKeyWrite))
+	g.POST("/apikey/revokeKey", h.RevokeAPIKey, scope(apikey.ScopeAPIKeyWrite))
 
 	// Admin Related Routing
-	g.POST("/admin/sendAdminEmail", h.SendAdminEmail)
-	g.GET("/getBestRTPProxy", h.GetBestRTPProxy)
+	g.POST("/admin/sendAdminEmail", h.SendAdminEmail, scope(apikey.ScopeAdminWrite))
+	g.GET("/getBestRTPProxy", h.GetBestRTPProxy, scope(apikey.ScopeAdminRead))
 
 }
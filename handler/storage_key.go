@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: KeyRotation model with workspace_id
+Todo : Make a new storage key current for the workspace and wrap the data keys of its objects with it
+Output: If success return KeyRotation model with the new key_id and the number of rewrapped data keys else return err
+Objects stay readable during the rotation, calling it again finishes an interrupted rewrap
+*/
+func (h *Handler) RotateStorageKey(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "RotateStorageKey is called...")
+
+	var rotation model.KeyRotation
+	if err := c.Bind(&rotation); err != nil {
+		return utils.HandleInternalErr("RotateStorageKey Could not decode JSON", err, c)
+	}
+	if rotation.WorkspaceId <= 0 {
+		return utils.HandleBadRequest("RotateStorageKey workspace_id is required", errors.New("missing workspace_id"), c)
+	}
+	envelope := h.objects.Envelope()
+	if envelope == nil {
+		return utils.HandleConflict("RotateStorageKey storage encryption is not configured", nil, c)
+	}
+
+	keyId, err := envelope.RotateKey(c.Request().Context(), rotation.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr("RotateStorageKey could not rotate key", err, c)
+	}
+	rotation.KeyId = keyId
+
+	// only data keys are rewrapped, the objects themselves are not read
+	rewrapped, err := envelope.Rewrap(c.Request().Context(), rotation.WorkspaceId)
+	if err != nil {
+		return utils.HandleInternalErr(fmt.Sprintf("RotateStorageKey could not rewrap data keys after %d", rewrapped), err, c)
+	}
+	rotation.Rewrapped = rewrapped
+	utils.Log(logrus.InfoLevel, fmt.Sprintf("rewrapped %d data keys of workspace id = %d", rewrapped, rotation.WorkspaceId))
+
+	return c.JSON(http.StatusOK, &rotation)
+}
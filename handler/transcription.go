@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/transcription"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: TranscriptionRequest model
+Todo : Queue the transcription of a stored recording
+Output: If success return TranscriptionJob model else return err
+*/
+func (h *Handler) TranscribeRecording(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "TranscribeRecording is called...")
+
+	var request model.TranscriptionRequest
+	if err := c.Bind(&request); err != nil {
+		return utils.HandleInternalErr("TranscribeRecording 1 Could not decode JSON", err, c)
+	}
+	if h.transcriptions == nil {
+		return utils.HandleBadRequest("TranscribeRecording transcription is not configured", errors.New("no transcription store"), c)
+	}
+
+	record, err := h.recordingStore.GetRecordingFromDB(request.RecordingId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("TranscribeRecording could not get recording", err, c)
+	}
+	if record.Uri == "" {
+		return utils.HandleBadRequest("TranscribeRecording recording has no stored audio", errors.New("recording not uploaded"), c)
+	}
+
+	job, err := h.queueTranscription(record, request.Language)
+	if errors.Is(err, transcription.ErrJobExists) {
+		return utils.HandleConflict("TranscribeRecording recording is queued or transcribed already", err, c)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("TranscribeRecording error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, job)
+}
+
+/*
+Input: recording_id
+Todo : Get the transcription job of a recording with its text, word timings and language
+Output: If success return Transcription model else return err
+*/
+func (h *Handler) GetTranscription(c echo.Context) error {
+	utils.Log(logrus.InfoLevel, "GetTranscription is called...")
+
+	recordingId, err := strconv.Atoi(c.QueryParam("recording_id"))
+	if err != nil {
+		return utils.HandleBadRequest("GetTranscription recording_id is required", err, c)
+	}
+	if h.transcriptions == nil {
+		return c.NoContent(http.StatusNotFound)
+	}
+	transcript, err := h.transcriptions.GetTranscription(recordingId)
+	if err == sql.ErrNoRows {
+		return c.NoContent(http.StatusNotFound)
+	}
+	if err != nil {
+		return utils.HandleInternalErr("GetTranscription error occured", err, c)
+	}
+	return c.JSON(http.StatusOK, transcript)
+}
+
+func (h *Handler) queueTranscription(record *model.Recording, language string) (*model.TranscriptionJob, error) {
+	job := model.TranscriptionJob{
+		RecordingId: record.Id,
+		WorkspaceId: record.WorkspaceId,
+		UserId:      record.UserId,
+		APIId:       record.APIId,
+		Language:    language,
+	}
+	_, err := h.transcriptions.CreateTranscriptionJob(&job)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// transcribeCompletedRecording queues recordings that asked for a
+// transcription once their audio is stored. Errors are logged, the recording
+// itself is stored regardless.
+func (h *Handler) transcribeCompletedRecording(record *model.Recording, status string) {
+	if h.transcriptions == nil || !record.Transcribe || !strings.EqualFold(status, "completed") {
+		return
+	}
+	_, err := h.queueTranscription(record, "")
+	if err != nil && !errors.Is(err, transcription.ErrJobExists) {
+		utils.Log(logrus.ErrorLevel, "could not queue transcription of recording id = "+strconv.Itoa(record.Id)+": "+err.Error())
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/activecall"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/events"
 	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 )
@@ -350,13 +353,32 @@ func (h *Handler) GetDIDAcceptOption(c echo.Context) error {
 }
 
 /*
-Input:
-Todo : Get DIDAssignedIP
+Input: did (optional)
+Todo : Get DIDAssignedIP, refusing calls to a did whose workspace or trunk has no free channels
 Output: If success return PrivateIpAddress else return err
 */
 func (h *Handler) GetDIDAssignedIP(c echo.Context) error {
 	utils.Log(logrus.InfoLevel, "GetDIDAssignedIP is called...")
 
+	did := c.QueryParam("did")
+	if did != "" {
+		// unknown numbers are rejected later in the call flow, only known ones have limits
+		info, err := h.userStore.IncomingDIDValidation(did)
+		if err != nil && err != sql.ErrNoRows {
+			return utils.HandleInternalErr("GetDIDAssignedIP could not look up did", err, c)
+		}
+		if err == nil {
+			workspaceId, _ := strconv.Atoi(info.DidWorkspaceId)
+			err = h.checkCallCapacity(workspaceId, info.TrunkId)
+			if errors.Is(err, activecall.ErrLimitReached) {
+				return utils.HandleConflict("GetDIDAssignedIP concurrent call limit reached", err, c)
+			}
+			if err != nil {
+				return utils.HandleInternalErr("GetDIDAssignedIP could not check call limits", err, c)
+			}
+		}
+	}
+
 	server, err := utils.GetDIDRoutedServer2(false)
 	if err != nil {
 		return utils.HandleInternalErr("GetUserAssignedIP error occured", err, c)
@@ -364,13 +386,6 @@ func (h *Handler) GetDIDAssignedIP(c echo.Context) error {
 	if server == nil {
 		return utils.HandleInternalErr("GetUserAssignedIP could not get server", err, c)
 	}
-	canPlace, err := utils.CanPlaceAdditionalCalls()
-	if err != nil {
-		return utils.HandleInternalErr("GetUserAssignedIP error occured", err, c)
-	}
-	if !canPlace {
-		return c.NoContent(http.StatusConflict)
-	}
 	return c.JSONBlob(http.StatusOK, []byte(server.PrivateIpAddress))
 }
 
@@ -790,19 +805,34 @@ func (h *Handler) ProcessCDRsAndBill(c echo.Context) error {
 		return utils.HandleInternalErr("ProcessCDRsAndBill error looking up workspace.", err, c)
 	}
 
-	callStartDate, err := utils.ParseDate(call.CreatedAt)
+	// update the calls status to ended. calls that already reached a terminal
+	// status were billed when that status was set
+	update := model.CallUpdate{CallId: call.Id, Status: callstate.StatusEnded}
+	err = h.callStore.UpdateCall(&update)
+	if errors.Is(err, callstate.ErrInvalidTransition) {
+		utils.Log(logrus.InfoLevel, fmt.Sprintf("ProcessCDRsAndBill call %s already ended -- skipping billing", sipCallId))
+		h.releaseActiveCall(call.APIId)
+		return c.NoContent(http.StatusOK)
+	}
 	if err != nil {
-		return utils.HandleInternalErr("ProcessCDRsAndBill error parsing date string.", err, c)
+		return utils.HandleInternalErr("UpdateCall Could not execute query..", err, c)
 	}
+	h.releaseActiveCall(call.APIId)
 
-	seconds := utils.CalculateCallDuration( callStartDate )
+	call, err = h.callStore.GetCallFromDB(call.Id)
+	if err != nil {
+		return utils.HandleInternalErr("ProcessCDRsAndBill error while looking up call.", err, c)
+	}
+	h.publishEvent(events.TypeCallStatusChanged, call.WorkspaceId, call.Id, call)
 
+	// calls that were never answered are still billed, with 0 seconds
 	debit := model.Debit{
 		Source: "CALL",
 		Status: "PAID",
-		Seconds: seconds,
+		Seconds: call.Duration,
 		ModuleId: call.Id,
 		UserId: call.UserId,
+		WorkspaceId: workspace.Id,
 		Type: call.Direction,
 	}
 
@@ -812,19 +842,14 @@ func (h *Handler) ProcessCDRsAndBill(c echo.Context) error {
 		return c.NoContent(http.StatusNotFound)
 	}
 
-	// update the calls status to ended
-	update := model.CallUpdate{CallId: call.Id, Status: "ENDED"}
-	err = h.callStore.UpdateCall(&update)
-	if err != nil {
-		return utils.HandleInternalErr("UpdateCall Could not execute query..", err, c)
-	}
-
 	debit.PlanSnapshot = workspace.Plan
 
 	err = h.debitStore.CreateDebit(rate, &debit)
 	if err != nil {
 		return utils.HandleInternalErr("ProcessCDRsAndBill could not create debit.", err, c)
 	}
+	h.publishEvent(events.TypeCallBilled, call.WorkspaceId, call.Id, &debit)
+	h.emitEvent(debit.WorkspaceId, debitCreatedEvent(&debit))
 
 	// send CDR to any remote locations configured by the user
 	err = utils.CreateCDRs(call)
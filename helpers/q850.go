@@ -0,0 +1,87 @@
+package helpers
+
+// Hangup categories used to tell apart the common ways a call can end.
+const (
+	HangupCategoryAnswered       = "ANSWERED"
+	HangupCategoryBusy           = "BUSY"
+	HangupCategoryNoAnswer       = "NO_ANSWER"
+	HangupCategoryCancelled      = "CANCELLED"
+	HangupCategoryRejected       = "REJECTED"
+	HangupCategoryInvalidNumber  = "INVALID_NUMBER"
+	HangupCategoryCarrierFailure = "CARRIER_FAILURE"
+)
+
+// Q850Cause is a normalized hangup cause derived from a final SIP response.
+type Q850Cause struct {
+	Code     int
+	Name     string
+	Category string
+}
+
+// sip response code -> Q.850 cause, based on the RFC 3398 mapping table
+var sipToQ850 = map[int]Q850Cause{
+	200: {16, "NORMAL_CLEARING", HangupCategoryAnswered},
+	400: {41, "TEMPORARY_FAILURE", HangupCategoryCarrierFailure},
+	401: {21, "CALL_REJECTED", HangupCategoryRejected},
+	402: {21, "CALL_REJECTED", HangupCategoryRejected},
+	403: {21, "CALL_REJECTED", HangupCategoryRejected},
+	404: {1, "UNALLOCATED_NUMBER", HangupCategoryInvalidNumber},
+	405: {63, "SERVICE_UNAVAILABLE", HangupCategoryCarrierFailure},
+	406: {79, "SERVICE_NOT_IMPLEMENTED", HangupCategoryCarrierFailure},
+	407: {21, "CALL_REJECTED", HangupCategoryRejected},
+	408: {102, "RECOVERY_ON_TIMER_EXPIRE", HangupCategoryNoAnswer},
+	410: {22, "NUMBER_CHANGED", HangupCategoryInvalidNumber},
+	413: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	414: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	415: {79, "SERVICE_NOT_IMPLEMENTED", HangupCategoryCarrierFailure},
+	416: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	420: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	421: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	423: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	480: {18, "NO_USER_RESPONSE", HangupCategoryNoAnswer},
+	481: {41, "TEMPORARY_FAILURE", HangupCategoryCarrierFailure},
+	482: {25, "EXCHANGE_ROUTING_ERROR", HangupCategoryCarrierFailure},
+	483: {25, "EXCHANGE_ROUTING_ERROR", HangupCategoryCarrierFailure},
+	484: {28, "INVALID_NUMBER_FORMAT", HangupCategoryInvalidNumber},
+	485: {1, "UNALLOCATED_NUMBER", HangupCategoryInvalidNumber},
+	486: {17, "USER_BUSY", HangupCategoryBusy},
+	487: {127, "INTERWORKING", HangupCategoryCancelled},
+	488: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	500: {41, "TEMPORARY_FAILURE", HangupCategoryCarrierFailure},
+	501: {79, "SERVICE_NOT_IMPLEMENTED", HangupCategoryCarrierFailure},
+	502: {38, "NETWORK_OUT_OF_ORDER", HangupCategoryCarrierFailure},
+	503: {41, "TEMPORARY_FAILURE", HangupCategoryCarrierFailure},
+	504: {102, "RECOVERY_ON_TIMER_EXPIRE", HangupCategoryCarrierFailure},
+	505: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	513: {127, "INTERWORKING", HangupCategoryCarrierFailure},
+	600: {17, "USER_BUSY", HangupCategoryBusy},
+	603: {21, "CALL_REJECTED", HangupCategoryRejected},
+	604: {1, "UNALLOCATED_NUMBER", HangupCategoryInvalidNumber},
+	606: {58, "BEARERCAPABILITY_NOTAVAIL", HangupCategoryCarrierFailure},
+}
+
+// IsFinalSIPResponse reports whether a SIP status code ends the INVITE transaction.
+func IsFinalSIPResponse(code int) bool {
+	return code >= 200 && code < 700
+}
+
+// MapSIPToQ850 returns the Q.850 cause for a final SIP response code.
+// Provisional responses (1xx) have no cause and return false.
+func MapSIPToQ850(code int) (Q850Cause, bool) {
+	if !IsFinalSIPResponse(code) {
+		return Q850Cause{}, false
+	}
+	if cause, ok := sipToQ850[code]; ok {
+		return cause, true
+	}
+
+	// fall back on the response class for codes not in the table
+	switch {
+	case code < 300:
+		return sipToQ850[200], true
+	case code < 500:
+		return Q850Cause{31, "NORMAL_UNSPECIFIED", HangupCategoryRejected}, true
+	default:
+		return Q850Cause{31, "NORMAL_UNSPECIFIED", HangupCategoryCarrierFailure}, true
+	}
+}
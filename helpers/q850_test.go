@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapSIPToQ850(t *testing.T) {
+	t.Run("ProvisionalResponse", func(t *testing.T) {
+		_, ok := MapSIPToQ850(180)
+		assert.False(t, ok)
+	})
+
+	t.Run("BusyAndNoAnswerAreDistinct", func(t *testing.T) {
+		busy, ok := MapSIPToQ850(486)
+		assert.True(t, ok)
+		assert.Equal(t, 17, busy.Code)
+		assert.Equal(t, HangupCategoryBusy, busy.Category)
+
+		noAnswer, ok := MapSIPToQ850(480)
+		assert.True(t, ok)
+		assert.Equal(t, 18, noAnswer.Code)
+		assert.Equal(t, HangupCategoryNoAnswer, noAnswer.Category)
+	})
+
+	t.Run("CarrierFailure", func(t *testing.T) {
+		cause, ok := MapSIPToQ850(503)
+		assert.True(t, ok)
+		assert.Equal(t, 41, cause.Code)
+		assert.Equal(t, HangupCategoryCarrierFailure, cause.Category)
+	})
+
+	t.Run("CancelIsInterworking", func(t *testing.T) {
+		cause, ok := MapSIPToQ850(487)
+		assert.True(t, ok)
+		assert.Equal(t, 127, cause.Code)
+		assert.Equal(t, HangupCategoryCancelled, cause.Category)
+	})
+
+	t.Run("UnknownCodeFallsBackOnClass", func(t *testing.T) {
+		cause, ok := MapSIPToQ850(499)
+		assert.True(t, ok)
+		assert.Equal(t, 31, cause.Code)
+
+		cause, ok = MapSIPToQ850(599)
+		assert.True(t, ok)
+		assert.Equal(t, HangupCategoryCarrierFailure, cause.Category)
+	})
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// HangupCauseFromSIP derives the normalized hangup cause for a final SIP response.
+// Returns nil for provisional responses.
+func HangupCauseFromSIP(code int) *model.HangupCause {
+	cause, ok := MapSIPToQ850(code)
+	if !ok {
+		return nil
+	}
+	return &model.HangupCause{
+		SIPCode:  code,
+		Q850Code: cause.Code,
+		Name:     cause.Name,
+		Category: cause.Category,
+	}
+}
+
+// BuildSIPReportHistory summarizes the SIP responses received for a call.
+// Events must be ordered by the time they were received.
+//
+// Post dial delay is measured from invitedAt, the time the INVITE was sent,
+// to the first 180/183. It is left empty when invitedAt is zero.
+func BuildSIPReportHistory(sipCallId string, invitedAt time.Time, events []model.SIPReport) *model.SIPReportHistory {
+	history := &model.SIPReportHistory{
+		SIPCallId: sipCallId,
+		Events:    events,
+	}
+
+	for _, event := range events {
+		if (event.Code == 180 || event.Code == 183) && history.PDDMillis == nil && !invitedAt.IsZero() {
+			receivedAt, err := time.Parse(time.RFC3339, event.CreatedAt)
+			if err == nil {
+				pdd := receivedAt.Sub(invitedAt).Milliseconds()
+				history.PDDMillis = &pdd
+			}
+		}
+
+		if IsFinalSIPResponse(event.Code) {
+			history.FinalCode = event.Code
+			history.HangupCause = HangupCauseFromSIP(event.Code)
+		}
+	}
+
+	return history
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/model"
+)
+
+func TestBuildSIPReportHistory(t *testing.T) {
+	invitedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("AnsweredCall", func(t *testing.T) {
+		events := []model.SIPReport{
+			{Code: 100, CreatedAt: "2024-05-01T10:00:01Z"},
+			{Code: 183, CreatedAt: "2024-05-01T10:00:02Z"},
+			{Code: 180, CreatedAt: "2024-05-01T10:00:03Z"},
+			{Code: 200, CreatedAt: "2024-05-01T10:00:09Z"},
+		}
+
+		history := BuildSIPReportHistory("abc@host", invitedAt, events)
+		assert.Equal(t, 200, history.FinalCode)
+		assert.Equal(t, HangupCategoryAnswered, history.HangupCause.Category)
+		if assert.NotNil(t, history.PDDMillis) {
+			assert.Equal(t, int64(2000), *history.PDDMillis)
+		}
+	})
+
+	t.Run("FailoverUsesLastFinalResponse", func(t *testing.T) {
+		events := []model.SIPReport{
+			{Code: 503, CreatedAt: "2024-05-01T10:00:00Z"},
+			{Code: 486, CreatedAt: "2024-05-01T10:00:01Z"},
+		}
+
+		history := BuildSIPReportHistory("abc@host", invitedAt, events)
+		assert.Equal(t, 486, history.FinalCode)
+		assert.Equal(t, 17, history.HangupCause.Q850Code)
+		assert.Nil(t, history.PDDMillis)
+	})
+
+	t.Run("NoInviteTime", func(t *testing.T) {
+		events := []model.SIPReport{
+			{Code: 180, CreatedAt: "2024-05-01T10:00:03Z"},
+		}
+
+		history := BuildSIPReportHistory("abc@host", time.Time{}, events)
+		assert.Nil(t, history.PDDMillis)
+	})
+
+	t.Run("NoFinalResponse", func(t *testing.T) {
+		history := BuildSIPReportHistory("abc@host", invitedAt, []model.SIPReport{{Code: 100}})
+		assert.Equal(t, 0, history.FinalCode)
+		assert.Nil(t, history.HangupCause)
+	})
+}
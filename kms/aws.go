@@ -0,0 +1,149 @@
+package kms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// DefaultAliasPrefix names the workspace keys alias/lineblocs-workspace-<id>.
+const DefaultAliasPrefix = "lineblocs"
+
+// AWSConfig configures an AWSKMS, without keys the SDK falls back to the
+// environment and instance roles.
+type AWSConfig struct {
+	Region          string
+	AccessKeyId     string
+	SecretAccessKey string
+	AliasPrefix     string
+}
+
+// AWSKMS keeps a customer managed AWS KMS key per workspace behind an alias.
+// A rotation creates a new key and points the alias at it, the old keys are
+// left enabled so data keys wrapped with them can still be unwrapped.
+type AWSKMS struct {
+	client *kms.KMS
+	prefix string
+}
+
+func NewAWSKMS(config AWSConfig) (*AWSKMS, error) {
+	awsConfig := &aws.Config{Region: aws.String(config.Region)}
+	if config.AccessKeyId != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(config.AccessKeyId, config.SecretAccessKey, "")
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("kms: aws session: %w", err)
+	}
+	prefix := config.AliasPrefix
+	if prefix == "" {
+		prefix = DefaultAliasPrefix
+	}
+	return &AWSKMS{client: kms.New(sess), prefix: prefix}, nil
+}
+
+func (k *AWSKMS) Name() string {
+	return "aws"
+}
+
+func (k *AWSKMS) WrapKey(ctx context.Context, workspaceId int, dataKey []byte) (string, []byte, error) {
+	keyId, err := k.CurrentKeyId(ctx, workspaceId)
+	if err != nil {
+		return "", nil, err
+	}
+	out, err := k.client.EncryptWithContext(ctx, &kms.EncryptInput{
+		KeyId:             aws.String(keyId),
+		Plaintext:         dataKey,
+		EncryptionContext: encryptionContext(workspaceId),
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("kms: encrypt: %w", err)
+	}
+	return aws.StringValue(out.KeyId), out.CiphertextBlob, nil
+}
+
+func (k *AWSKMS) UnwrapKey(ctx context.Context, workspaceId int, keyId string, wrapped []byte) ([]byte, error) {
+	out, err := k.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:             aws.String(keyId),
+		CiphertextBlob:    wrapped,
+		EncryptionContext: encryptionContext(workspaceId),
+	})
+	if isAWSError(err, kms.ErrCodeNotFoundException) {
+		return nil, ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kms: decrypt: %w", err)
+	}
+	return out.Plaintext, nil
+}
+
+func (k *AWSKMS) CurrentKeyId(ctx context.Context, workspaceId int) (string, error) {
+	out, err := k.client.DescribeKeyWithContext(ctx, &kms.DescribeKeyInput{KeyId: aws.String(k.alias(workspaceId))})
+	if isAWSError(err, kms.ErrCodeNotFoundException) {
+		return k.createKey(ctx, workspaceId, false)
+	}
+	if err != nil {
+		return "", fmt.Errorf("kms: describe key: %w", err)
+	}
+	return aws.StringValue(out.KeyMetadata.Arn), nil
+}
+
+func (k *AWSKMS) RotateKey(ctx context.Context, workspaceId int) (string, error) {
+	return k.createKey(ctx, workspaceId, true)
+}
+
+// createKey creates a key for the workspace and points its alias at it,
+// replace moves an existing alias.
+func (k *AWSKMS) createKey(ctx context.Context, workspaceId int, replace bool) (string, error) {
+	created, err := k.client.CreateKeyWithContext(ctx, &kms.CreateKeyInput{
+		Description: aws.String("Lineblocs storage key of workspace " + strconv.Itoa(workspaceId)),
+		KeyUsage:    aws.String(kms.KeyUsageTypeEncryptDecrypt),
+		Tags:        []*kms.Tag{{TagKey: aws.String("workspace_id"), TagValue: aws.String(strconv.Itoa(workspaceId))}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("kms: create key: %w", err)
+	}
+	keyId := aws.StringValue(created.KeyMetadata.Arn)
+
+	alias := aws.String(k.alias(workspaceId))
+	if replace {
+		_, err = k.client.UpdateAliasWithContext(ctx, &kms.UpdateAliasInput{AliasName: alias, TargetKeyId: aws.String(keyId)})
+		if !isAWSError(err, kms.ErrCodeNotFoundException) {
+			return keyId, wrapAWSError("update alias", err)
+		}
+	}
+	_, err = k.client.CreateAliasWithContext(ctx, &kms.CreateAliasInput{AliasName: alias, TargetKeyId: aws.String(keyId)})
+	if isAWSError(err, kms.ErrCodeAlreadyExistsException) && !replace {
+		// another instance created the first key at the same time, use that one
+		k.client.ScheduleKeyDeletionWithContext(ctx, &kms.ScheduleKeyDeletionInput{KeyId: aws.String(keyId)})
+		return k.CurrentKeyId(ctx, workspaceId)
+	}
+	return keyId, wrapAWSError("create alias", err)
+}
+
+func (k *AWSKMS) alias(workspaceId int) string {
+	return "alias/" + k.prefix + "-workspace-" + strconv.Itoa(workspaceId)
+}
+
+func encryptionContext(workspaceId int) map[string]*string {
+	return map[string]*string{"workspace_id": aws.String(strconv.Itoa(workspaceId))}
+}
+
+func isAWSError(err error, code string) bool {
+	var awsErr awserr.Error
+	return errors.As(err, &awsErr) && awsErr.Code() == code
+}
+
+func wrapAWSError(action string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("kms: %s: %w", action, err)
+}
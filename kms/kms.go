@@ -0,0 +1,32 @@
+// Package kms keeps the keys of the workspaces that wrap the data keys of
+// encrypted objects. A rotation makes a new key current while the older keys
+// stay available, so data keys wrapped before it can still be unwrapped until
+// they are wrapped again.
+package kms
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+var ErrKeyNotFound = errors.New("kms: key not found")
+
+// KMS wraps and unwraps data keys with the keys of a workspace. The key id
+// returned with a wrapped key identifies the workspace key that wrapped it.
+type KMS interface {
+	Name() string
+	// WrapKey encrypts dataKey with the current key of the workspace, the key is created on first use.
+	WrapKey(ctx context.Context, workspaceId int, dataKey []byte) (keyId string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped with keyId.
+	UnwrapKey(ctx context.Context, workspaceId int, keyId string, wrapped []byte) ([]byte, error)
+	// CurrentKeyId returns the id of the key WrapKey uses for the workspace.
+	CurrentKeyId(ctx context.Context, workspaceId int) (string, error)
+	// RotateKey makes a new key current for the workspace and returns its id.
+	RotateKey(ctx context.Context, workspaceId int) (string, error)
+}
+
+// context binds a wrapped key to its workspace so it can not be unwrapped for another.
+func workspaceContext(workspaceId int) string {
+	return "workspace_id=" + strconv.Itoa(workspaceId)
+}
@@ -0,0 +1,206 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// LocalKMS keeps AES-256 keys in a JSON key file. It suits tests and single
+// server deployments, the key file must be backed up and kept apart from the
+// stored objects.
+type LocalKMS struct {
+	path string
+
+	mu   sync.Mutex
+	file keyFile
+}
+
+type keyFile struct {
+	Workspaces map[string]*workspaceKeys `json:"workspaces"`
+}
+
+type workspaceKeys struct {
+	Current string            `json:"current"`
+	Keys    map[string][]byte `json:"keys"`
+}
+
+// NewLocalKMS reads the keys at path, the file is created on the first key.
+func NewLocalKMS(path string) (*LocalKMS, error) {
+	k := &LocalKMS{path: path, file: keyFile{Workspaces: map[string]*workspaceKeys{}}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &k.file)
+	if err != nil {
+		return nil, fmt.Errorf("kms: invalid key file %s: %w", path, err)
+	}
+	if k.file.Workspaces == nil {
+		k.file.Workspaces = map[string]*workspaceKeys{}
+	}
+	return k, nil
+}
+
+func (k *LocalKMS) Name() string {
+	return "local"
+}
+
+func (k *LocalKMS) WrapKey(ctx context.Context, workspaceId int, dataKey []byte) (string, []byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys, err := k.workspace(workspaceId)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := newAEAD(keys.Keys[keys.Current])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	wrapped := aead.Seal(nonce, nonce, dataKey, additionalData(workspaceId, keys.Current))
+	return keys.Current, wrapped, nil
+}
+
+func (k *LocalKMS) UnwrapKey(ctx context.Context, workspaceId int, keyId string, wrapped []byte) ([]byte, error) {
+	k.mu.Lock()
+	keys := k.file.Workspaces[strconv.Itoa(workspaceId)]
+	var key []byte
+	if keys != nil {
+		key = keys.Keys[keyId]
+	}
+	k.mu.Unlock()
+	if key == nil {
+		return nil, ErrKeyNotFound
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("kms: wrapped key is too short")
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	dataKey, err := aead.Open(nil, nonce, sealed, additionalData(workspaceId, keyId))
+	if err != nil {
+		return nil, fmt.Errorf("kms: could not unwrap key: %w", err)
+	}
+	return dataKey, nil
+}
+
+func (k *LocalKMS) CurrentKeyId(ctx context.Context, workspaceId int) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys, err := k.workspace(workspaceId)
+	if err != nil {
+		return "", err
+	}
+	return keys.Current, nil
+}
+
+func (k *LocalKMS) RotateKey(ctx context.Context, workspaceId int) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys := k.file.Workspaces[strconv.Itoa(workspaceId)]
+	if keys == nil {
+		keys = &workspaceKeys{Keys: map[string][]byte{}}
+		k.file.Workspaces[strconv.Itoa(workspaceId)] = keys
+	}
+	return k.addKey(workspaceId, keys)
+}
+
+// workspace returns the keys of a workspace, creating its first key. Callers hold mu.
+func (k *LocalKMS) workspace(workspaceId int) (*workspaceKeys, error) {
+	keys := k.file.Workspaces[strconv.Itoa(workspaceId)]
+	if keys != nil && keys.Current != "" {
+		return keys, nil
+	}
+	if keys == nil {
+		keys = &workspaceKeys{Keys: map[string][]byte{}}
+		k.file.Workspaces[strconv.Itoa(workspaceId)] = keys
+	}
+	_, err := k.addKey(workspaceId, keys)
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// addKey makes a new key current and saves the key file. Callers hold mu.
+func (k *LocalKMS) addKey(workspaceId int, keys *workspaceKeys) (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	keyId := fmt.Sprintf("local-%d-v%d", workspaceId, len(keys.Keys)+1)
+	previous := keys.Current
+	keys.Keys[keyId] = key
+	keys.Current = keyId
+
+	err := k.save()
+	if err != nil {
+		// a key that is not on disk must not wrap anything
+		delete(keys.Keys, keyId)
+		keys.Current = previous
+		return "", err
+	}
+	return keyId, nil
+}
+
+// save replaces the key file atomically so a crash never loses keys. Callers hold mu.
+func (k *LocalKMS) save() error {
+	data, err := json.MarshalIndent(&k.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(k.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(k.path), ".keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), k.path)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(workspaceId int, keyId string) []byte {
+	return []byte(workspaceContext(workspaceId) + ";key_id=" + keyId)
+}
@@ -0,0 +1,57 @@
+package kms
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalKMS(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys", "workspaces.json")
+	k, err := NewLocalKMS(path)
+	require.NoError(t, err)
+	dataKey := []byte("0123456789abcdef0123456789abcdef")
+
+	keyId, wrapped, err := k.WrapKey(ctx, 7, dataKey)
+	require.NoError(t, err)
+	assert.NotContains(t, string(wrapped), string(dataKey))
+
+	t.Run("Should unwrap a data key only for its workspace", func(t *testing.T) {
+		unwrapped, err := k.UnwrapKey(ctx, 7, keyId, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+
+		_, err = k.UnwrapKey(ctx, 8, keyId, wrapped)
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("Should keep old keys after a rotation", func(t *testing.T) {
+		rotated, err := k.RotateKey(ctx, 7)
+		require.NoError(t, err)
+		assert.NotEqual(t, keyId, rotated)
+		current, err := k.CurrentKeyId(ctx, 7)
+		require.NoError(t, err)
+		assert.Equal(t, rotated, current)
+
+		unwrapped, err := k.UnwrapKey(ctx, 7, keyId, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+	})
+
+	t.Run("Should read the keys back from the key file", func(t *testing.T) {
+		reopened, err := NewLocalKMS(path)
+		require.NoError(t, err)
+		unwrapped, err := reopened.UnwrapKey(ctx, 7, keyId, wrapped)
+		require.NoError(t, err)
+		assert.Equal(t, dataKey, unwrapped)
+
+		// a wrapped key moved to another key id does not unwrap
+		current, _ := reopened.CurrentKeyId(ctx, 7)
+		_, err = reopened.UnwrapKey(ctx, 7, current, wrapped)
+		assert.Error(t, err)
+	})
+}
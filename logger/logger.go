@@ -8,4 +8,9 @@ Implementation of Logger Store is located /store/logger
 */
 type LoggerStoreInterface interface {
 	StartLogRoutine(*model.Workspace, *model.LogRoutine) (*string, error)
+	GetLog(workspaceId int, id int) (*model.DebuggerLog, error)
+	ListLogs(filter *model.LogFilter) (*model.LogList, error)
+	GetNotificationChannels(workspaceId int) ([]model.NotificationChannel, error)
+	SaveNotificationChannel(channel *model.NotificationChannel) error
+	DeleteNotificationChannel(workspaceId int, id int) error
 }
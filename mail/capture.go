@@ -0,0 +1,60 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CaptureMailer keeps the email it is asked to deliver instead of sending
+// it, for tests and local development. With a directory every email is also
+// written to it as an .eml file that mail clients open.
+type CaptureMailer struct {
+	mu     sync.Mutex
+	dir    string
+	sender string
+	now    func() time.Time
+	sent   []Email
+}
+
+func NewCaptureMailer(dir string, sender string) *CaptureMailer {
+	if sender == "" {
+		sender = DefaultSender
+	}
+	return &CaptureMailer{dir: dir, sender: sender, now: time.Now}
+}
+
+func (m *CaptureMailer) Send(ctx context.Context, email *Email) error {
+	captured := *email
+	if captured.From == "" {
+		captured.From = m.sender
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dir != "" {
+		message, err := Build(&captured, captured.From, m.now())
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(m.dir, 0o755); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s-%04d.eml", m.now().UTC().Format("20060102T150405"), len(m.sent)+1)
+		if err := os.WriteFile(filepath.Join(m.dir, name), message, 0o644); err != nil {
+			return err
+		}
+	}
+	m.sent = append(m.sent, captured)
+	return nil
+}
+
+// Sent returns the captured email in the order it was sent.
+func (m *CaptureMailer) Sent() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Email{}, m.sent...)
+}
@@ -0,0 +1,67 @@
+// Package mail delivers email through SMTP, Mailgun or a capture transport
+// for tests and local development. A Queue delivers email in the background
+// and retries failed deliveries.
+package mail
+
+import (
+	"context"
+	"errors"
+
+	"lineblocs.com/api/notification"
+)
+
+// DefaultSender sends email that does not set a sender when none is configured.
+const DefaultSender = "Lineblocs <monitor@lineblocs.com>"
+
+// ErrPermanent is matched by errors of deliveries that must not be retried.
+var ErrPermanent = errors.New("email rejected")
+
+// Attachment is a file sent with an email.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Email is a message to deliver. An empty From is the sender of the mailer.
+type Email struct {
+	From        string
+	To          []string
+	CC          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// NewEmail addresses a rendered notification to recipients.
+func NewEmail(to []string, message *notification.Message) *Email {
+	return &Email{To: to, Subject: message.Subject, Text: message.Text, HTML: message.HTML}
+}
+
+// Mailer delivers email.
+type Mailer interface {
+	Send(ctx context.Context, email *Email) error
+}
+
+type senderMailer struct {
+	Mailer
+	sender string
+}
+
+func (m *senderMailer) Send(ctx context.Context, email *Email) error {
+	if email.From == "" {
+		copied := *email
+		copied.From = m.sender
+		email = &copied
+	}
+	return m.Mailer.Send(ctx, email)
+}
+
+// WithSender sends the email of mailer that does not set a sender from sender.
+// An empty sender returns mailer.
+func WithSender(mailer Mailer, sender string) Mailer {
+	if sender == "" || mailer == nil {
+		return mailer
+	}
+	return &senderMailer{Mailer: mailer, sender: sender}
+}
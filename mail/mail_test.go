@@ -0,0 +1,104 @@
+package mail
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	t.Run("Should build a text and HTML message with attachments", func(t *testing.T) {
+		email := &Email{
+			To:          []string{"Ops <ops@example.com>"},
+			CC:          []string{"dev@example.com"},
+			Subject:     "Télécopie reçue",
+			Text:        "Pages : 2",
+			HTML:        "<p>Pages : 2</p>",
+			Attachments: []Attachment{{Name: "invoice.pdf", Data: []byte("%PDF-1.4")}},
+		}
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		message, err := Build(email, DefaultSender, now)
+		require.NoError(t, err)
+
+		parsed, err := mail.ReadMessage(strings.NewReader(string(message)))
+		require.NoError(t, err)
+		assert.Equal(t, `"Lineblocs" <monitor@lineblocs.com>`, parsed.Header.Get("From"))
+		assert.Equal(t, `"Ops" <ops@example.com>`, parsed.Header.Get("To"))
+		assert.Equal(t, "<dev@example.com>", parsed.Header.Get("Cc"))
+		subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+		require.NoError(t, err)
+		assert.Equal(t, "Télécopie reçue", subject)
+		date, err := parsed.Header.Date()
+		require.NoError(t, err)
+		assert.True(t, now.Equal(date))
+		assert.True(t, strings.HasSuffix(parsed.Header.Get("Message-Id"), "@lineblocs.com>"))
+
+		mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+		parts := multipart.NewReader(parsed.Body, params["boundary"])
+
+		alternative, err := parts.NextPart()
+		require.NoError(t, err)
+		_, params, err = mime.ParseMediaType(alternative.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		texts := multipart.NewReader(alternative, params["boundary"])
+		for _, expected := range []string{"Pages : 2", "<p>Pages : 2</p>"} {
+			text, err := texts.NextPart()
+			require.NoError(t, err)
+			body, err := io.ReadAll(text)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(body))
+		}
+
+		attachment, err := parts.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "invoice.pdf", attachment.FileName())
+		assert.Equal(t, "application/pdf", attachment.Header.Get("Content-Type"))
+	})
+
+	t.Run("Should reject invalid recipients as permanent", func(t *testing.T) {
+		_, err := Build(&Email{To: []string{"not an address"}}, DefaultSender, time.Now())
+		assert.ErrorIs(t, err, ErrPermanent)
+		_, err = Build(&Email{}, DefaultSender, time.Now())
+		assert.ErrorIs(t, err, ErrPermanent)
+	})
+}
+
+func TestCaptureMailer(t *testing.T) {
+	t.Run("Should keep email and write it to the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		mailer := NewCaptureMailer(dir, "")
+		require.NoError(t, mailer.Send(context.Background(), &Email{To: []string{"ops@example.com"}, Subject: "Hello", Text: "Hi"}))
+
+		require.Len(t, mailer.Sent(), 1)
+		assert.Equal(t, DefaultSender, mailer.Sent()[0].From)
+		files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		data, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Subject: Hello")
+	})
+
+	t.Run("Should fill the sender of email without one", func(t *testing.T) {
+		capture := NewCaptureMailer("", "")
+		mailer := WithSender(capture, "Faxes <fax@example.com>")
+		require.NoError(t, mailer.Send(context.Background(), &Email{To: []string{"ops@example.com"}}))
+		require.NoError(t, mailer.Send(context.Background(), &Email{From: "me@example.com", To: []string{"ops@example.com"}}))
+
+		require.Len(t, capture.Sent(), 2)
+		assert.Equal(t, "Faxes <fax@example.com>", capture.Sent()[0].From)
+		assert.Equal(t, "me@example.com", capture.Sent()[1].From)
+	})
+}
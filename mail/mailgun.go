@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/mailgun/mailgun-go/v4"
+)
+
+// MailgunMailer delivers email through the Mailgun API.
+type MailgunMailer struct {
+	mg     *mailgun.MailgunImpl
+	sender string
+}
+
+func NewMailgunMailer(domain string, apiKey string, sender string) *MailgunMailer {
+	if sender == "" {
+		sender = DefaultSender
+	}
+	return &MailgunMailer{mg: mailgun.NewMailgun(domain, apiKey), sender: sender}
+}
+
+func (m *MailgunMailer) Send(ctx context.Context, email *Email) error {
+	sender := email.From
+	if sender == "" {
+		sender = m.sender
+	}
+	message := m.mg.NewMessage(sender, email.Subject, email.Text, email.To...)
+	for _, address := range email.CC {
+		message.AddCC(address)
+	}
+	if email.HTML != "" {
+		message.SetHtml(email.HTML)
+	}
+	for _, attachment := range email.Attachments {
+		message.AddBufferAttachment(attachment.Name, attachment.Data)
+	}
+	_, _, err := m.mg.Send(ctx, message)
+
+	// client errors other than rate limiting are not retried
+	var response *mailgun.UnexpectedResponseError
+	if errors.As(err, &response) && response.Actual >= 400 && response.Actual < 500 && response.Actual != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %s", ErrPermanent, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,149 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Build writes email as a MIME message from sender: a text and HTML
+// alternative followed by the attachments.
+func Build(email *Email, sender string, now time.Time) ([]byte, error) {
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid sender: %s", ErrPermanent, err.Error())
+	}
+	buf := &bytes.Buffer{}
+	header := func(name string, value string) {
+		fmt.Fprintf(buf, "%s: %s\r\n", name, value)
+	}
+	header("From", from.String())
+	to, err := addressList(email.To)
+	if err != nil {
+		return nil, err
+	}
+	header("To", to)
+	if len(email.CC) > 0 {
+		cc, err := addressList(email.CC)
+		if err != nil {
+			return nil, err
+		}
+		header("Cc", cc)
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-Id", messageId(from.Address))
+	header("MIME-Version", "1.0")
+
+	mixed := multipart.NewWriter(buf)
+	header("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	buf.WriteString("\r\n")
+
+	alternative := &bytes.Buffer{}
+	parts := multipart.NewWriter(alternative)
+	if err := writeText(parts, "text/plain", email.Text); err != nil {
+		return nil, err
+	}
+	if email.HTML != "" {
+		if err := writeText(parts, "text/html", email.HTML); err != nil {
+			return nil, err
+		}
+	}
+	parts.Close()
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`multipart/alternative; boundary="` + parts.Boundary() + `"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(alternative.Bytes())
+
+	for _, attachment := range email.Attachments {
+		contentType := mime.TypeByExtension(filepath.Ext(attachment.Name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+	mixed.Close()
+	return buf.Bytes(), nil
+}
+
+func writeText(w *multipart.Writer, contentType string, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType + "; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(part)
+	encoder.Write([]byte(text))
+	return encoder.Close()
+}
+
+// addressList validates addresses and joins them for a header.
+func addressList(addresses []string) (string, error) {
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("%w: no recipients", ErrPermanent)
+	}
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", fmt.Errorf("%w: invalid recipient %q: %s", ErrPermanent, address, err.Error())
+		}
+		formatted[i] = parsed.String()
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+func messageId(sender string) string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	domain := "localhost"
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		domain = sender[at+1:]
+	}
+	return "<" + hex.EncodeToString(id) + "@" + domain + ">"
+}
+
+// envelope returns the bare addresses of the sender and recipients of email.
+func envelope(email *Email, sender string) (string, []string, error) {
+	from, err := mail.ParseAddress(sender)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: invalid sender: %s", ErrPermanent, err.Error())
+	}
+	recipients := []string{}
+	for _, address := range append(append([]string{}, email.To...), email.CC...) {
+		parsed, err := mail.ParseAddress(address)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: invalid recipient %q: %s", ErrPermanent, address, err.Error())
+		}
+		recipients = append(recipients, parsed.Address)
+	}
+	return from.Address, recipients, nil
+}
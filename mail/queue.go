@@ -0,0 +1,131 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultQueueSize = 256
+	DefaultWorkers   = 2
+	DefaultAttempts  = 5
+	minRetryDelay    = 5 * time.Second
+	maxRetryDelay    = 5 * time.Minute
+	deliveryTimeout  = time.Minute
+)
+
+var ErrQueueFull = errors.New("email queue is full")
+
+type queued struct {
+	email    Email
+	attempts int
+}
+
+// Queue is a Mailer that returns right away and delivers email through
+// another Mailer from background workers. Failed deliveries are retried
+// with backoff unless they are permanent. Email still queued when the
+// process exits is lost.
+type Queue struct {
+	mailer   Mailer
+	queue    chan *queued
+	attempts int
+	delay    func(attempts int) time.Duration
+
+	mu      sync.RWMutex
+	closed  bool
+	pending sync.WaitGroup
+	done    sync.WaitGroup
+}
+
+func NewQueue(mailer Mailer, size int) *Queue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	q := &Queue{
+		mailer:   mailer,
+		queue:    make(chan *queued, size),
+		attempts: DefaultAttempts,
+		delay:    RetryDelay,
+	}
+	for i := 0; i < DefaultWorkers; i++ {
+		q.done.Add(1)
+		go q.run()
+	}
+	return q
+}
+
+// Send queues a copy of email, it fails when the queue is full.
+func (q *Queue) Send(ctx context.Context, email *Email) error {
+	return q.enqueue(&queued{email: *email})
+}
+
+func (q *Queue) enqueue(item *queued) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrQueueFull
+	}
+	q.pending.Add(1)
+	select {
+	case q.queue <- item:
+		return nil
+	default:
+		q.pending.Done()
+		return ErrQueueFull
+	}
+}
+
+// Close waits for the queued email and its retries.
+func (q *Queue) Close() {
+	q.pending.Wait()
+	q.mu.Lock()
+	q.closed = true
+	close(q.queue)
+	q.mu.Unlock()
+	q.done.Wait()
+}
+
+func (q *Queue) run() {
+	defer q.done.Done()
+	for item := range q.queue {
+		q.deliver(item)
+	}
+}
+
+func (q *Queue) deliver(item *queued) {
+	defer q.pending.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+	err := q.mailer.Send(ctx, &item.email)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	item.attempts++
+	recipients := strings.Join(item.email.To, ", ")
+	if errors.Is(err, ErrPermanent) || item.attempts >= q.attempts {
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not deliver email %q to %s: %s", item.email.Subject, recipients, err.Error()))
+		return
+	}
+	utils.Log(logrus.WarnLevel, fmt.Sprintf("delivery of email %q to %s failed on attempt %d: %s", item.email.Subject, recipients, item.attempts, err.Error()))
+	// the retry is pending until it is queued again
+	q.pending.Add(1)
+	time.AfterFunc(q.delay(item.attempts), func() {
+		defer q.pending.Done()
+		if err := q.enqueue(item); err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not retry email %q to %s: %s", item.email.Subject, recipients, err.Error()))
+		}
+	})
+}
+
+// RetryDelay backs off exponentially from 5 seconds, capped at 5 minutes.
+func RetryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, minRetryDelay, maxRetryDelay)
+}
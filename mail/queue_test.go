@@ -0,0 +1,81 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyMailer fails the first failures deliveries with err.
+type flakyMailer struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+}
+
+func (m *flakyMailer) Send(ctx context.Context, email *Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls++
+	if m.calls <= m.failures {
+		return m.err
+	}
+	return nil
+}
+
+func newTestQueue(mailer Mailer) *Queue {
+	q := NewQueue(mailer, 0)
+	q.delay = func(int) time.Duration { return time.Millisecond }
+	return q
+}
+
+func TestQueue(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should deliver a copy of queued email", func(t *testing.T) {
+		capture := NewCaptureMailer("", "")
+		q := newTestQueue(capture)
+		email := &Email{To: []string{"ops@example.com"}, Subject: "Hello"}
+		require.NoError(t, q.Send(context.Background(), email))
+		email.Subject = "Changed"
+		q.Close()
+
+		require.Len(t, capture.Sent(), 1)
+		assert.Equal(t, "Hello", capture.Sent()[0].Subject)
+	})
+
+	t.Run("Should retry failed deliveries until attempts run out", func(t *testing.T) {
+		mailer := &flakyMailer{failures: 2, err: errors.New("connection refused")}
+		q := newTestQueue(mailer)
+		require.NoError(t, q.Send(context.Background(), &Email{To: []string{"ops@example.com"}}))
+		q.Close()
+		assert.Equal(t, 3, mailer.calls)
+
+		mailer = &flakyMailer{failures: 100, err: errors.New("connection refused")}
+		q = newTestQueue(mailer)
+		require.NoError(t, q.Send(context.Background(), &Email{To: []string{"ops@example.com"}}))
+		q.Close()
+		assert.Equal(t, DefaultAttempts, mailer.calls)
+	})
+
+	t.Run("Should not retry permanent failures", func(t *testing.T) {
+		mailer := &flakyMailer{failures: 100, err: ErrPermanent}
+		q := newTestQueue(mailer)
+		require.NoError(t, q.Send(context.Background(), &Email{To: []string{"ops@example.com"}}))
+		q.Close()
+		assert.Equal(t, 1, mailer.calls)
+	})
+
+	t.Run("Should back off exponentially up to the cap", func(t *testing.T) {
+		assert.Equal(t, 5*time.Second, RetryDelay(1))
+		assert.Equal(t, 20*time.Second, RetryDelay(3))
+		assert.Equal(t, 5*time.Minute, RetryDelay(20))
+	})
+}
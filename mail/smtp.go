@@ -0,0 +1,187 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// TLS modes of SMTP servers, from the smtp_tls setting.
+const (
+	// TLSStartTLS upgrades the connection and fails when the server can not
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS, usually on port 465
+	TLSImplicit = "tls"
+	// TLSOff sends in clear text, only for servers on a trusted network
+	TLSOff = "off"
+)
+
+const DefaultSMTPPort = "587"
+
+// SMTPConfig locates and authenticates with an SMTP server.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	TLS      string
+}
+
+// SMTPConfigFromSettings reads the smtp_* settings. smtp_tls is "tls" or
+// "ssl" for implicit TLS, "off" for none and anything else for STARTTLS.
+func SMTPConfigFromSettings(settings *model.Settings) SMTPConfig {
+	config := SMTPConfig{
+		Host:     strings.TrimSpace(settings.SmtpHost),
+		Port:     strings.TrimSpace(settings.SmtpPort),
+		User:     settings.SmtpUser,
+		Password: settings.SmtpPassword,
+		TLS:      TLSStartTLS,
+	}
+	switch strings.ToLower(strings.TrimSpace(settings.SmtpTls)) {
+	case "tls", "ssl", "implicit":
+		config.TLS = TLSImplicit
+	case "off", "none", "false", "0", "no":
+		config.TLS = TLSOff
+	}
+	if config.Port == "" {
+		config.Port = DefaultSMTPPort
+		if config.TLS == TLSImplicit {
+			config.Port = "465"
+		}
+	}
+	return config
+}
+
+// Validate rejects authentication without TLS, passwords are only sent in
+// clear text to a server on localhost.
+func (c SMTPConfig) Validate() error {
+	if c.TLS == TLSOff && c.User != "" && !localHost(c.Host) {
+		return fmt.Errorf("smtp_tls is off, SMTP authentication with %s needs TLS", c.Host)
+	}
+	return nil
+}
+
+// localHost matches the hosts net/smtp sends plain authentication to without TLS.
+func localHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// SMTPMailer delivers email to an SMTP server, one connection per email.
+type SMTPMailer struct {
+	config    SMTPConfig
+	sender    string
+	tlsConfig *tls.Config
+	now       func() time.Time
+}
+
+func NewSMTPMailer(config SMTPConfig, sender string) *SMTPMailer {
+	if sender == "" {
+		sender = DefaultSender
+	}
+	return &SMTPMailer{
+		config:    config,
+		sender:    sender,
+		tlsConfig: &tls.Config{ServerName: config.Host},
+		now:       time.Now,
+	}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, email *Email) error {
+	sender := email.From
+	if sender == "" {
+		sender = m.sender
+	}
+	from, recipients, err := envelope(email, sender)
+	if err != nil {
+		return err
+	}
+	message, err := Build(email, sender, m.now())
+	if err != nil {
+		return err
+	}
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := m.deliver(client, from, recipients, message); err != nil {
+		return smtpError(err)
+	}
+	// the server accepted the message, a failed QUIT must not send it again
+	client.Quit()
+	return nil
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	address := net.JoinHostPort(m.config.Host, m.config.Port)
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if m.config.TLS == TLSImplicit {
+		conn = tls.Client(conn, m.tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if m.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("%w: %s does not support STARTTLS", ErrPermanent, address)
+		}
+		if err := client.StartTLS(m.tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if m.config.User != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.User, m.config.Password, m.config.Host)); err != nil {
+			client.Close()
+			return nil, smtpError(err)
+		}
+	}
+	return client, nil
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, from string, recipients []string, message []byte) error {
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// smtpError marks permanent SMTP replies, 5xx codes, as not retried.
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return fmt.Errorf("%w: %s", ErrPermanent, err.Error())
+	}
+	return err
+}
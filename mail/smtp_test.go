@@ -0,0 +1,149 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/model"
+)
+
+// smtpServer accepts email on a local port and rejects the recipients in
+// reject. With dropQuit it hangs up on QUIT without a reply.
+type smtpServer struct {
+	listener net.Listener
+	reject   string
+	dropQuit bool
+	mu       sync.Mutex
+	from     string
+	to       []string
+	data     string
+}
+
+func newSMTPServer(t *testing.T, reject string) *smtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &smtpServer{listener: listener, reject: reject}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch {
+		case command == "EHLO" || command == "HELO":
+			text.PrintfLine("250 localhost")
+		case command == "MAIL":
+			s.from = line
+			text.PrintfLine("250 OK")
+		case command == "RCPT" && s.reject != "" && strings.Contains(line, s.reject):
+			text.PrintfLine("550 No such user")
+		case command == "RCPT":
+			s.to = append(s.to, line)
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 Go ahead")
+			data, _ := text.ReadDotBytes()
+			s.data = string(data)
+			text.PrintfLine("250 Queued")
+		case command == "QUIT" && s.dropQuit:
+			s.mu.Unlock()
+			return
+		case command == "QUIT":
+			text.PrintfLine("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *smtpServer) config() SMTPConfig {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return SMTPConfig{Host: host, Port: port, TLS: TLSOff}
+}
+
+func TestSMTPMailer(t *testing.T) {
+	t.Run("Should deliver email to every recipient", func(t *testing.T) {
+		server := newSMTPServer(t, "")
+		mailer := NewSMTPMailer(server.config(), "Lineblocs <noreply@example.com>")
+		err := mailer.Send(context.Background(), &Email{
+			To:      []string{"Ops <ops@example.com>"},
+			CC:      []string{"dev@example.com"},
+			Subject: "Hello",
+			Text:    "Hi",
+		})
+		require.NoError(t, err)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		assert.Equal(t, "MAIL FROM:<noreply@example.com>", server.from)
+		assert.Equal(t, []string{"RCPT TO:<ops@example.com>", "RCPT TO:<dev@example.com>"}, server.to)
+		message := bufio.NewReader(strings.NewReader(server.data))
+		headers, err := textproto.NewReader(message).ReadMIMEHeader()
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", headers.Get("Subject"))
+	})
+
+	t.Run("Should mark rejected recipients as permanent", func(t *testing.T) {
+		server := newSMTPServer(t, "gone@example.com")
+		mailer := NewSMTPMailer(server.config(), "")
+		err := mailer.Send(context.Background(), &Email{To: []string{"gone@example.com"}, Text: "Hi"})
+		assert.ErrorIs(t, err, ErrPermanent)
+	})
+
+	t.Run("Should not fail accepted email when QUIT fails", func(t *testing.T) {
+		server := newSMTPServer(t, "")
+		server.dropQuit = true
+		err := NewSMTPMailer(server.config(), "").Send(context.Background(), &Email{To: []string{"ops@example.com"}, Text: "Hi"})
+		require.NoError(t, err)
+	})
+
+	t.Run("Should require STARTTLS unless TLS is off", func(t *testing.T) {
+		server := newSMTPServer(t, "")
+		config := server.config()
+		config.TLS = TLSStartTLS
+		err := NewSMTPMailer(config, "").Send(context.Background(), &Email{To: []string{"ops@example.com"}})
+		assert.ErrorIs(t, err, ErrPermanent)
+	})
+
+	t.Run("Should read the smtp settings", func(t *testing.T) {
+		config := SMTPConfigFromSettings(&model.Settings{SmtpHost: " smtp.example.com ", SmtpUser: "user", SmtpPassword: "secret", SmtpTls: "SSL"})
+		assert.Equal(t, SMTPConfig{Host: "smtp.example.com", Port: "465", User: "user", Password: "secret", TLS: TLSImplicit}, config)
+		config = SMTPConfigFromSettings(&model.Settings{SmtpHost: "smtp.example.com", SmtpPort: "2525"})
+		assert.Equal(t, SMTPConfig{Host: "smtp.example.com", Port: "2525", TLS: TLSStartTLS}, config)
+	})
+
+	t.Run("Should reject authentication in clear text with remote servers", func(t *testing.T) {
+		assert.Error(t, SMTPConfig{Host: "smtp.example.com", User: "user", TLS: TLSOff}.Validate())
+		assert.NoError(t, SMTPConfig{Host: "smtp.example.com", User: "user", TLS: TLSStartTLS}.Validate())
+		assert.NoError(t, SMTPConfig{Host: "smtp.example.com", TLS: TLSOff}.Validate())
+		assert.NoError(t, SMTPConfig{Host: "localhost", User: "user", TLS: TLSOff}.Validate())
+	})
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 	//"errors"
@@ -14,8 +16,22 @@ import (
 	"github.com/mrwaggel/golimiter"
 	"github.com/go-redis/redis"
 	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/activecall"
+	"lineblocs.com/api/alert"
+	"lineblocs.com/api/apikey"
+	"lineblocs.com/api/call"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/events"
+	"lineblocs.com/api/fax"
 	"lineblocs.com/api/handler"
+	"lineblocs.com/api/mail"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/kms"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/outbox"
+	"lineblocs.com/api/recording"
+	"lineblocs.com/api/transcription"
+	"lineblocs.com/api/queue"
 	"lineblocs.com/api/router"
 	"lineblocs.com/api/store"
 	"lineblocs.com/api/utils"
@@ -28,6 +44,7 @@ var cqlCluster *gocql.ClusterConfig
 var cqlSess *gocql.Session
 var data *model.ServerData
 var customizations *helpers.CustomizationSettings
+var bus eventbus.EventBus
 
 func updateCustomizationSettings() (error) {
 	record, err := helpers.GetCustomizationSettings()
@@ -82,19 +99,27 @@ func main() {
 		panic(err)
 	}
 
-	// connect to cassandra
-	/*
-	utils.Log(logrus.InfoLevel, "Connecting to cassandra...")
-	cassandraAddr := utils.Config("CASSANDRA_HOST") + ":9042"
-	cqlCluster = gocql.NewCluster(cassandraAddr)
-	cqlCluster.Keyspace = utils.Config("CASSANDRA_KEYSPACE")
-	cqlCluster.ProtoVersion = 4
-	cqlSess, err = cqlCluster.CreateSession()
-	if err != nil {
-		utils.Log(logrus.PanicLevel, err.Error())
-		panic(err)
+	// connect to cassandra when call records are stored there
+	backend := callStoreBackend()
+	if backend != store.CallStoreBackendMySQL {
+		utils.Log(logrus.InfoLevel, "Connecting to cassandra...")
+		cassandraAddr := utils.Config("CASSANDRA_HOST") + ":9042"
+		cqlCluster = gocql.NewCluster(cassandraAddr)
+		cqlCluster.Keyspace = utils.Config("CASSANDRA_KEYSPACE")
+		cqlCluster.ProtoVersion = 4
+		cqlSess, err = cqlCluster.CreateSession()
+		if err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
+		defer cqlSess.Close()
+
+		err = store.CreateCassandraCallTables(cqlSess)
+		if err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
 	}
-	*/
 
 	utils.Log(logrus.InfoLevel, fmt.Sprintf("got customization settings. billing frequency = %s", customizations.BillingFrequency))
 
@@ -115,6 +140,19 @@ func main() {
         }
     }()
 
+	// publish queued call activity events to RabbitMQ
+	publisher := queue.NewAMQPPublisher(utils.Config("QUEUE_URL"), queue.DefaultPoolSize)
+	defer publisher.Close()
+	relay := outbox.NewRelay(store.NewOutboxStore(dbConn), publisher)
+	go relay.Run(context.Background())
+
+	bus, err = createEventBus(publisher)
+	if err != nil {
+		utils.Log(logrus.PanicLevel, err.Error())
+		panic(err)
+	}
+	defer bus.Close()
+
 	go func() {
 		// Start Internals-API Backend server
 		utils.Log(logrus.InfoLevel, "Starting API...")
@@ -140,7 +178,7 @@ func startServer() {
 
 	// Configure Handler with Global DB
 	as := store.NewAdminStore(dbConn)
-	cs := store.NewCallStore(dbConn)
+	cs := createCallStore(callStoreBackend())
 	crs := store.NewCarrierStore(dbConn)
 	ds := store.NewDebitStore(dbConn)
 	fs := store.NewFaxStore(dbConn)
@@ -148,6 +186,61 @@ func startServer() {
 	rs := store.NewRecordingStore(dbConn)
 	us := store.NewUserStore(dbConn, rdb)
 	h := handler.NewHandler(as, cs, crs, ds, fs, ls, rs, us)
+	h.SetEventBus(bus)
+	h.SetAPIKeys(createAPIKeys())
+	if rdb != nil {
+		h.SetActiveCallRegistry(activecall.NewRedisRegistry(rdb, activeCallTTL()))
+	}
+	h.SetEventBroker(createEventBroker())
+	h.SetAccessLogStore(store.NewObjectAccessStore(dbConn))
+	objects := objectstore.NewManager(handler.StorageCredentials(us), objectstore.DefaultRefreshInterval)
+	if keys := createKMS(); keys != nil {
+		objects.SetEnvelope(objectstore.NewEnvelope(keys, store.NewObjectKeyStore(dbConn)))
+	}
+	h.SetObjectStores(objects)
+	purger := recording.NewPurger(rs, objects, recordingRetentionInterval())
+	if rdb != nil {
+		purger.SetLock(recording.NewRedisLock(rdb))
+	} else {
+		utils.Log(logrus.WarnLevel, "redis is not configured, every instance runs the recording retention purge")
+	}
+	go purger.Run(context.Background())
+	if transcriber := createTranscriber(); transcriber != nil {
+		ts := store.NewTranscriptionStore(dbConn)
+		h.SetTranscriptionStore(ts)
+		worker := transcription.NewWorker(ts, transcriber, objects)
+		worker.SetEventBus(bus)
+		go worker.Run(context.Background())
+	}
+	// every instance must share the secret for download URLs to work behind a load balancer
+	if secret := utils.Config("STORAGE_URL_SECRET"); secret != "" {
+		h.SetDownloadSigner(objectstore.NewProxySigner([]byte(secret), utils.Config("API_PUBLIC_URL")))
+	} else {
+		utils.Log(logrus.WarnLevel, "STORAGE_URL_SECRET is not set, download URLs only work on this instance")
+	}
+	faxes := fax.NewWorker(fs, objects)
+	faxes.SetEventBus(bus)
+	// the media server downloads the documents of outbound faxes with the same URLs as clients
+	if signer, err := h.DownloadSigner(); err == nil {
+		faxes.SetDownloadSigner(signer)
+	} else {
+		utils.Log(logrus.ErrorLevel, "could not create the signer of fax download URLs: "+err.Error())
+	}
+	mailer := createMailer(us)
+	if mailer != nil {
+		faxSender := utils.Config("FAX_EMAIL_SENDER")
+		if faxSender == "" {
+			faxSender = fax.DefaultSender
+		}
+		faxes.SetMailer(mail.WithSender(mailer, faxSender))
+		h.SetMailer(mail.NewQueue(mailer, mail.DefaultQueueSize))
+	}
+	go faxes.Run(context.Background())
+	alerts := alert.NewDispatcher(ls, mail.WithSender(mailer, utils.Config("ALERT_EMAIL_SENDER")), alert.DefaultQueueSize)
+	alerts.SetUserLookup(cs.GetUserFromDB)
+	alerts.SetThrottle(createAlertThrottle())
+	go alerts.Run(context.Background())
+	h.SetAlertDispatcher(alerts)
 
 	// Register Handler for Echo context
 	h.Register(r)
@@ -171,6 +264,207 @@ func startServer() {
 	utils.Log(logrus.InfoLevel, "Started server...")
 }
 
+// Call records are stored in MySQL unless CALL_STORE_BACKEND is cassandra, or dual while migrating
+func callStoreBackend() string {
+	switch backend := utils.Config("CALL_STORE_BACKEND"); backend {
+	case store.CallStoreBackendCassandra, store.CallStoreBackendDual:
+		return backend
+	}
+	return store.CallStoreBackendMySQL
+}
+
+func createCallStore(backend string) call.CallStoreInterface {
+	utils.Log(logrus.InfoLevel, "Using call store backend: "+backend)
+	switch backend {
+	case store.CallStoreBackendCassandra:
+		return store.NewCassandraCallStore(dbConn, cqlSess, callIdNode())
+	case store.CallStoreBackendDual:
+		return store.NewDualCallStore(dbConn, cqlSess)
+	}
+	return store.NewCallStore(dbConn)
+}
+
+// Call ids created in Cassandra embed CALL_ID_NODE, 0 to 1023. Every API instance
+// needs its own, e.g. the ordinal of its pod, or two instances can create the same id
+func callIdNode() int {
+	node, err := strconv.Atoi(utils.Config("CALL_ID_NODE"))
+	if err != nil || node < 0 || node > store.MaxCallIdNode {
+		panic(fmt.Sprintf("CALL_ID_NODE must be a unique number between 0 and %d with the cassandra call store", store.MaxCallIdNode))
+	}
+	return node
+}
+
+// Call event streams are shared between API instances through redis, without
+// it a stream only sees the events of the instance it is connected to
+func createEventBroker() *events.Broker {
+	broker := events.NewBroker(events.DefaultHistorySize, events.DefaultQueueSize)
+	if rdb == nil {
+		utils.Log(logrus.WarnLevel, "redis is not configured, call event streams only see events of their own instance")
+		return broker
+	}
+	relay := events.NewRedisRelay(rdb, broker)
+	broker.SetRelay(relay)
+	go relay.Run(context.Background())
+	return broker
+}
+
+// Active calls expire after ACTIVE_CALL_TTL (a Go duration, e.g. 4h) without a status update
+func activeCallTTL() time.Duration {
+	ttl, err := time.ParseDuration(utils.Config("ACTIVE_CALL_TTL"))
+	if err != nil || ttl <= 0 {
+		return activecall.DefaultTTL
+	}
+	return ttl
+}
+
+// Repeats of a debugger alert within ALERT_DEDUP_WINDOW e.g. "10m" are sent as one digest,
+// a workspace gets at most ALERT_HOURLY_CAP alerts per hour, 0 is unlimited
+func createAlertThrottle() alert.Throttle {
+	window, err := time.ParseDuration(utils.Config("ALERT_DEDUP_WINDOW"))
+	if err != nil || window <= 0 {
+		window = alert.DefaultWindow
+	}
+	hourlyCap, err := strconv.Atoi(utils.Config("ALERT_HOURLY_CAP"))
+	if err != nil || hourlyCap < 0 {
+		hourlyCap = alert.DefaultHourlyCap
+	}
+	if rdb == nil {
+		utils.Log(logrus.WarnLevel, "redis is not configured, debugger alerts are only deduplicated per instance")
+		return alert.NewMemoryThrottle(window, hourlyCap)
+	}
+	return alert.NewRedisThrottle(rdb, window, hourlyCap)
+}
+
+// Services authenticate with their own keys from microservice_api_keys. The shared
+// LINEBLOCS_KEY is still accepted with every scope until the services are moved off it.
+func createAPIKeys() *apikey.Manager {
+	keys := apikey.NewManager(store.NewAPIKeyStore(dbConn))
+	if legacy := utils.Config("LINEBLOCS_KEY"); legacy != "" {
+		utils.Log(logrus.WarnLevel, "LINEBLOCS_KEY is deprecated, create an API key for each service and unset it")
+		keys.SetLegacyToken(legacy)
+	}
+	return keys
+}
+
+// Create the mail transport selected by MAIL_TRANSPORT: smtp with the smtp_* settings,
+// mailgun with MAILGUN_DOMAIN and MAILGUN_API_KEY, or capture to keep emails in
+// MAIL_CAPTURE_DIR. When it is not set smtp is used if smtp_host is set, then mailgun
+// if MAILGUN_DOMAIN is set. MAIL_SENDER is the sender of emails that do not set one.
+func createMailer(us *store.UserStore) mail.Mailer {
+	sender := utils.Config("MAIL_SENDER")
+	settings := model.Settings{}
+	if credentials, err := us.GetSettings(); err != nil {
+		utils.Log(logrus.WarnLevel, "could not read SMTP settings: "+err.Error())
+	} else {
+		settings.SmtpHost = credentials.Credentials["smtp_host"]
+		settings.SmtpPort = credentials.Credentials["smtp_port"]
+		settings.SmtpUser = credentials.Credentials["smtp_user"]
+		settings.SmtpPassword = credentials.Credentials["smtp_password"]
+		settings.SmtpTls = credentials.Credentials["smtp_tls"]
+	}
+	transport := utils.Config("MAIL_TRANSPORT")
+	if transport == "" && settings.SmtpHost != "" {
+		transport = "smtp"
+	} else if transport == "" && utils.Config("MAILGUN_DOMAIN") != "" {
+		transport = "mailgun"
+	}
+	switch transport {
+	case "smtp":
+		config := mail.SMTPConfigFromSettings(&settings)
+		if err := config.Validate(); err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
+		return mail.NewSMTPMailer(config, sender)
+	case "mailgun":
+		return mail.NewMailgunMailer(utils.Config("MAILGUN_DOMAIN"), utils.Config("MAILGUN_API_KEY"), sender)
+	case "capture":
+		return mail.NewCaptureMailer(utils.Config("MAIL_CAPTURE_DIR"), sender)
+	}
+	utils.Log(logrus.WarnLevel, "no mail transport is configured, emails are not sent")
+	return nil
+}
+
+// How often recording retention policies are applied, RECORDING_RETENTION_INTERVAL e.g. "1h"
+func recordingRetentionInterval() time.Duration {
+	interval, err := time.ParseDuration(utils.Config("RECORDING_RETENTION_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return recording.DefaultPurgeInterval
+	}
+	return interval
+}
+
+// Create the KMS selected by KMS_PROVIDER: aws or local. Workspaces with
+// storage_encryption on can not store objects when it is not set.
+func createKMS() kms.KMS {
+	switch utils.Config("KMS_PROVIDER") {
+	case "aws":
+		region := utils.Config("KMS_AWS_REGION")
+		if region == "" {
+			region = objectstore.DefaultRegion
+		}
+		keys, err := kms.NewAWSKMS(kms.AWSConfig{Region: region, AliasPrefix: utils.Config("KMS_ALIAS_PREFIX")})
+		if err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
+		return keys
+	case "local":
+		path := utils.Config("KMS_KEYFILE")
+		if path == "" {
+			path = "storage-keys.json"
+		}
+		keys, err := kms.NewLocalKMS(path)
+		if err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
+		utils.Log(logrus.WarnLevel, "Using the local key file KMS, back up KMS_KEYFILE separately from the stored objects")
+		return keys
+	}
+	return nil
+}
+
+// Create the speech-to-text vendor selected by TRANSCRIPTION_PROVIDER: deepgram
+// or stub. Recordings are not transcribed by the API when it is not set.
+func createTranscriber() transcription.Transcriber {
+	switch utils.Config("TRANSCRIPTION_PROVIDER") {
+	case "deepgram":
+		transcriber, err := transcription.NewDeepgramTranscriber(transcription.DeepgramConfig{
+			APIKey: utils.Config("DEEPGRAM_API_KEY"),
+			Model:  utils.Config("DEEPGRAM_MODEL"),
+		})
+		if err != nil {
+			utils.Log(logrus.PanicLevel, err.Error())
+			panic(err)
+		}
+		return transcriber
+	case "stub":
+		utils.Log(logrus.WarnLevel, "Using the stub transcriber, recordings get placeholder transcriptions")
+		return transcription.NewStubTranscriber("transcription placeholder", "en")
+	}
+	return nil
+}
+
+// Create the domain event bus selected by EVENT_BUS: rabbitmq, nats or memory (default)
+func createEventBus(publisher *queue.AMQPPublisher) (eventbus.EventBus, error) {
+	switch utils.Config("EVENT_BUS") {
+	case "rabbitmq":
+		utils.Log(logrus.InfoLevel, "Publishing domain events to RabbitMQ")
+		inner := eventbus.NewRabbitMQBus(publisher, utils.Config("EVENT_BUS_EXCHANGE"))
+		return eventbus.NewAsyncBus(inner, eventbus.DefaultAsyncQueueSize), nil
+	case "nats":
+		utils.Log(logrus.InfoLevel, "Publishing domain events to NATS")
+		inner, err := eventbus.NewNATSBus(utils.Config("NATS_URL"), utils.Config("EVENT_BUS_SUBJECT_PREFIX"))
+		if err != nil {
+			return nil, err
+		}
+		return eventbus.NewAsyncBus(inner, eventbus.DefaultAsyncQueueSize), nil
+	}
+	utils.Log(logrus.InfoLevel, "Publishing domain events in memory only")
+	return eventbus.NewMemoryBus(), nil
+}
+
 // Configure Limit Handler for Echo context
 func limitHandler(c echo.Context) error {
 	var addr string
@@ -1,28 +1,74 @@
 package middlewares
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-    "github.com/labstack/echo/v4"
-    "github.com/sirupsen/logrus"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/apikey"
+	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 )
 
-func APIAuthMiddleware(expectedValue string) echo.MiddlewareFunc {
-    return func(next echo.HandlerFunc) echo.HandlerFunc {
-        return func(c echo.Context) error {
-            headerKey := "x-lineblocs-api-token"
-            header := c.Request().Header.Get(headerKey)
-            if header == "" {
-	            utils.Log(logrus.InfoLevel, "API token header is missing")
-                return c.String(http.StatusBadRequest, "Missing header: " + headerKey)
-            }
+const (
+	APITokenHeader = "x-lineblocs-api-token"
+	// apiKeyContextKey holds the key a request was authenticated with
+	apiKeyContextKey = "api_key"
+)
+
+// APIAuthMiddleware requires an API key on every route except publicPaths,
+// the scopes of the key are checked by RequireScope on each route. Keys are
+// never logged, only a fingerprint of their hash.
+func APIAuthMiddleware(keys *apikey.Manager, publicPaths ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, path := range publicPaths {
+				if c.Path() == path {
+					return next(c)
+				}
+			}
+			token := c.Request().Header.Get(APITokenHeader)
+			if token == "" {
+				utils.Log(logrus.InfoLevel, "API token header is missing")
+				return c.String(http.StatusBadRequest, "Missing header: "+APITokenHeader)
+			}
+
+			key, err := keys.Authenticate(token)
+			if errors.Is(err, apikey.ErrInvalidKey) || errors.Is(err, apikey.ErrExpiredKey) {
+				utils.Log(logrus.InfoLevel, fmt.Sprintf("rejected API key with fingerprint %s on %s: %s", apikey.Fingerprint(token), c.Path(), err.Error()))
+				return c.String(http.StatusUnauthorized, err.Error()+".")
+			}
+			if err != nil {
+				return utils.HandleInternalErr("APIAuthMiddleware could not authenticate API key", err, c)
+			}
+			c.Set(apiKeyContextKey, key)
+			return next(c)
+		}
+	}
+}
+
+// RequireScope only lets requests authenticated with a key granting scope through.
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := APIKey(c)
+			if key == nil || !apikey.Allows(key.Scopes, scope) {
+				service := ""
+				if key != nil {
+					service = key.Service
+				}
+				utils.Log(logrus.InfoLevel, fmt.Sprintf("API key of service %q is missing scope %s for %s", service, scope, c.Path()))
+				return c.String(http.StatusForbidden, "API key is missing scope "+scope+".")
+			}
+			return next(c)
+		}
+	}
+}
 
-	        utils.Log(logrus.InfoLevel, "received API token: '" + header + "'")
-	        utils.Log(logrus.InfoLevel, "comparing agaisnt configured token: '" + expectedValue + "'")
-            if header != expectedValue {
-                return c.String(http.StatusUnauthorized, "API key is invalid.")
-            }
-            return next(c)
-        }
-    }
-}
\ No newline at end of file
+// APIKey returns the key the request was authenticated with, nil for public routes.
+func APIKey(c echo.Context) *model.APIKey {
+	key, _ := c.Get(apiKeyContextKey).(*model.APIKey)
+	return key
+}
@@ -0,0 +1,105 @@
+package middlewares
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/apikey"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+)
+
+func newContext(e *echo.Echo, path string, token string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set(APITokenHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetPath(path)
+	return c, rec
+}
+
+func TestAPIAuthMiddleware(t *testing.T) {
+	e := echo.New()
+	helpers.InitLogrus("stdout")
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+
+	t.Run("Should let public paths through without a key", func(t *testing.T) {
+		c, rec := newContext(e, "/healthz", "")
+		middleware := APIAuthMiddleware(apikey.NewManager(nil), "/healthz")
+		if assert.NoError(t, middleware(ok)(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Nil(t, APIKey(c))
+		}
+	})
+
+	t.Run("Should require the token header", func(t *testing.T) {
+		c, rec := newContext(e, "/call/createCall", "")
+		if assert.NoError(t, APIAuthMiddleware(apikey.NewManager(nil))(ok)(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+
+	t.Run("Should reject unknown keys", func(t *testing.T) {
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKeyByHash(apikey.Hash("lbk_unknown")).Return(nil, sql.ErrNoRows)
+
+		c, rec := newContext(e, "/call/createCall", "lbk_unknown")
+		if assert.NoError(t, APIAuthMiddleware(apikey.NewManager(store))(ok)(c)) {
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+	})
+
+	t.Run("Should keep the key of authenticated requests", func(t *testing.T) {
+		key := &model.APIKey{Id: 1, Service: "router", Scopes: []string{apikey.ScopeCallWrite}}
+		store := mocks.NewAPIKeyStoreInterface(t)
+		store.EXPECT().GetAPIKeyByHash(apikey.Hash("lbk_router")).Return(key, nil)
+
+		c, rec := newContext(e, "/call/createCall", "lbk_router")
+		if assert.NoError(t, APIAuthMiddleware(apikey.NewManager(store))(ok)(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, key, APIKey(c))
+		}
+	})
+}
+
+func TestRequireScope(t *testing.T) {
+	e := echo.New()
+	helpers.InitLogrus("stdout")
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+
+	t.Run("Should let keys with the scope through", func(t *testing.T) {
+		for _, scopes := range [][]string{{apikey.ScopeCallWrite}, {"call:*"}, {apikey.ScopeAll}} {
+			c, rec := newContext(e, "/call/createCall", "")
+			c.Set(apiKeyContextKey, &model.APIKey{Service: "router", Scopes: scopes})
+			if assert.NoError(t, RequireScope(apikey.ScopeCallWrite)(ok)(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code, scopes)
+			}
+		}
+	})
+
+	t.Run("Should forbid keys without the scope", func(t *testing.T) {
+		c, rec := newContext(e, "/user/getSettings", "")
+		c.Set(apiKeyContextKey, &model.APIKey{Service: "router", Scopes: []string{apikey.ScopeUserRead}})
+		if assert.NoError(t, RequireScope(apikey.ScopeAdminRead)(ok)(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+
+	t.Run("Should forbid requests without a key", func(t *testing.T) {
+		c, rec := newContext(e, "/call/createCall", "")
+		if assert.NoError(t, RequireScope(apikey.ScopeCallWrite)(ok)(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+}
@@ -0,0 +1,4 @@
+-- Concurrent call limits read by CallStore.GetConcurrentCallLimits, 0 is unlimited.
+ALTER TABLE `service_plans` ADD COLUMN `ports` INT UNSIGNED NOT NULL DEFAULT 0;
+ALTER TABLE `extensions` ADD COLUMN `channels` INT UNSIGNED NOT NULL DEFAULT 0;
+ALTER TABLE `sip_trunks` ADD COLUMN `channels` INT UNSIGNED NOT NULL DEFAULT 0;
@@ -4,6 +4,7 @@ package mocks
 
 import (
 	mock "github.com/stretchr/testify/mock"
+	mail "lineblocs.com/api/mail"
 	model "lineblocs.com/api/model"
 )
 
@@ -20,35 +21,44 @@ func (_m *CallStoreInterface) EXPECT() *CallStoreInterface_Expecter {
 	return &CallStoreInterface_Expecter{mock: &_m.Mock}
 }
 
-// ProcessUsersFirstCall provides a mock function with given fields: _a0
-func (_m *CallStoreInterface) ProcessUsersFirstCall(_a0 model.Call) {
-	_m.Called(_a0)
+// AddConferenceParticipant provides a mock function with given fields: participant
+func (_m *CallStoreInterface) AddConferenceParticipant(participant *model.ConferenceParticipant) error {
+	ret := _m.Called(participant)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.ConferenceParticipant) error); ok {
+		r0 = rf(participant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
 }
 
-// CallStoreInterface_ProcessUsersFirstCall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProcessUsersFirstCall'
-type CallStoreInterface_ProcessUsersFirstCall_Call struct {
+// CallStoreInterface_AddConferenceParticipant_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddConferenceParticipant'
+type CallStoreInterface_AddConferenceParticipant_Call struct {
 	*mock.Call
 }
 
-// ProcessUsersFirstCall is a helper method to define mock.On call
-//   - _a0 model.Call
-func (_e *CallStoreInterface_Expecter) ProcessUsersFirstCall(_a0 interface{}) *CallStoreInterface_ProcessUsersFirstCall_Call {
-	return &CallStoreInterface_ProcessUsersFirstCall_Call{Call: _e.mock.On("ProcessUsersFirstCall", _a0)}
+// AddConferenceParticipant is a helper method to define mock.On call
+//   - participant *model.ConferenceParticipant
+func (_e *CallStoreInterface_Expecter) AddConferenceParticipant(participant interface{}) *CallStoreInterface_AddConferenceParticipant_Call {
+	return &CallStoreInterface_AddConferenceParticipant_Call{Call: _e.mock.On("AddConferenceParticipant", participant)}
 }
 
-func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) Run(run func(_a0 model.Call)) *CallStoreInterface_ProcessUsersFirstCall_Call {
+func (_c *CallStoreInterface_AddConferenceParticipant_Call) Run(run func(participant *model.ConferenceParticipant)) *CallStoreInterface_AddConferenceParticipant_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(model.Call))
+		run(args[0].(*model.ConferenceParticipant))
 	})
 	return _c
 }
 
-func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) Return() *CallStoreInterface_ProcessUsersFirstCall_Call {
-	_c.Call.Return()
+func (_c *CallStoreInterface_AddConferenceParticipant_Call) Return(_a0 error) *CallStoreInterface_AddConferenceParticipant_Call {
+	_c.Call.Return(_a0)
 	return _c
 }
 
-func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) RunAndReturn(run func(model.Call)) *CallStoreInterface_ProcessUsersFirstCall_Call {
+func (_c *CallStoreInterface_AddConferenceParticipant_Call) RunAndReturn(run func(*model.ConferenceParticipant) error) *CallStoreInterface_AddConferenceParticipant_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -157,6 +167,114 @@ func (_c *CallStoreInterface_CreateConference_Call) RunAndReturn(run func(*model
 	return _c
 }
 
+// EndConference provides a mock function with given fields: conferenceId
+func (_m *CallStoreInterface) EndConference(conferenceId int) ([]model.ConferenceParticipant, error) {
+	ret := _m.Called(conferenceId)
+
+	var r0 []model.ConferenceParticipant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]model.ConferenceParticipant, error)); ok {
+		return rf(conferenceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) []model.ConferenceParticipant); ok {
+		r0 = rf(conferenceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.ConferenceParticipant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(conferenceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_EndConference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'EndConference'
+type CallStoreInterface_EndConference_Call struct {
+	*mock.Call
+}
+
+// EndConference is a helper method to define mock.On call
+//   - conferenceId int
+func (_e *CallStoreInterface_Expecter) EndConference(conferenceId interface{}) *CallStoreInterface_EndConference_Call {
+	return &CallStoreInterface_EndConference_Call{Call: _e.mock.On("EndConference", conferenceId)}
+}
+
+func (_c *CallStoreInterface_EndConference_Call) Run(run func(conferenceId int)) *CallStoreInterface_EndConference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_EndConference_Call) Return(_a0 []model.ConferenceParticipant, _a1 error) *CallStoreInterface_EndConference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_EndConference_Call) RunAndReturn(run func(int) ([]model.ConferenceParticipant, error)) *CallStoreInterface_EndConference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCallBySIPCallId provides a mock function with given fields: sipCallId
+func (_m *CallStoreInterface) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
+	ret := _m.Called(sipCallId)
+
+	var r0 *model.Call
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.Call, error)); ok {
+		return rf(sipCallId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.Call); ok {
+		r0 = rf(sipCallId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Call)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(sipCallId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_GetCallBySIPCallId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCallBySIPCallId'
+type CallStoreInterface_GetCallBySIPCallId_Call struct {
+	*mock.Call
+}
+
+// GetCallBySIPCallId is a helper method to define mock.On call
+//   - sipCallId string
+func (_e *CallStoreInterface_Expecter) GetCallBySIPCallId(sipCallId interface{}) *CallStoreInterface_GetCallBySIPCallId_Call {
+	return &CallStoreInterface_GetCallBySIPCallId_Call{Call: _e.mock.On("GetCallBySIPCallId", sipCallId)}
+}
+
+func (_c *CallStoreInterface_GetCallBySIPCallId_Call) Run(run func(sipCallId string)) *CallStoreInterface_GetCallBySIPCallId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_GetCallBySIPCallId_Call) Return(_a0 *model.Call, _a1 error) *CallStoreInterface_GetCallBySIPCallId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_GetCallBySIPCallId_Call) RunAndReturn(run func(string) (*model.Call, error)) *CallStoreInterface_GetCallBySIPCallId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetCallFromDB provides a mock function with given fields: _a0
 func (_m *CallStoreInterface) GetCallFromDB(_a0 int) (*model.Call, error) {
 	ret := _m.Called(_a0)
@@ -211,6 +329,171 @@ func (_c *CallStoreInterface_GetCallFromDB_Call) RunAndReturn(run func(int) (*mo
 	return _c
 }
 
+// GetCallsBySessionId provides a mock function with given fields: workspaceId, sessionId
+func (_m *CallStoreInterface) GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error) {
+	ret := _m.Called(workspaceId, sessionId)
+
+	var r0 []model.Call
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string) ([]model.Call, error)); ok {
+		return rf(workspaceId, sessionId)
+	}
+	if rf, ok := ret.Get(0).(func(int, string) []model.Call); ok {
+		r0 = rf(workspaceId, sessionId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Call)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, string) error); ok {
+		r1 = rf(workspaceId, sessionId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_GetCallsBySessionId_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCallsBySessionId'
+type CallStoreInterface_GetCallsBySessionId_Call struct {
+	*mock.Call
+}
+
+// GetCallsBySessionId is a helper method to define mock.On call
+//   - workspaceId int
+//   - sessionId string
+func (_e *CallStoreInterface_Expecter) GetCallsBySessionId(workspaceId interface{}, sessionId interface{}) *CallStoreInterface_GetCallsBySessionId_Call {
+	return &CallStoreInterface_GetCallsBySessionId_Call{Call: _e.mock.On("GetCallsBySessionId", workspaceId, sessionId)}
+}
+
+func (_c *CallStoreInterface_GetCallsBySessionId_Call) Run(run func(workspaceId int, sessionId string)) *CallStoreInterface_GetCallsBySessionId_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_GetCallsBySessionId_Call) Return(_a0 []model.Call, _a1 error) *CallStoreInterface_GetCallsBySessionId_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_GetCallsBySessionId_Call) RunAndReturn(run func(int, string) ([]model.Call, error)) *CallStoreInterface_GetCallsBySessionId_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetConcurrentCallLimits provides a mock function with given fields: workspaceId, extension, trunkId
+func (_m *CallStoreInterface) GetConcurrentCallLimits(workspaceId int, extension string, trunkId int) (*model.CallLimits, error) {
+	ret := _m.Called(workspaceId, extension, trunkId)
+
+	var r0 *model.CallLimits
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string, int) (*model.CallLimits, error)); ok {
+		return rf(workspaceId, extension, trunkId)
+	}
+	if rf, ok := ret.Get(0).(func(int, string, int) *model.CallLimits); ok {
+		r0 = rf(workspaceId, extension, trunkId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CallLimits)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, string, int) error); ok {
+		r1 = rf(workspaceId, extension, trunkId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_GetConcurrentCallLimits_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetConcurrentCallLimits'
+type CallStoreInterface_GetConcurrentCallLimits_Call struct {
+	*mock.Call
+}
+
+// GetConcurrentCallLimits is a helper method to define mock.On call
+//   - workspaceId int
+//   - extension string
+//   - trunkId int
+func (_e *CallStoreInterface_Expecter) GetConcurrentCallLimits(workspaceId interface{}, extension interface{}, trunkId interface{}) *CallStoreInterface_GetConcurrentCallLimits_Call {
+	return &CallStoreInterface_GetConcurrentCallLimits_Call{Call: _e.mock.On("GetConcurrentCallLimits", workspaceId, extension, trunkId)}
+}
+
+func (_c *CallStoreInterface_GetConcurrentCallLimits_Call) Run(run func(workspaceId int, extension string, trunkId int)) *CallStoreInterface_GetConcurrentCallLimits_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string), args[2].(int))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_GetConcurrentCallLimits_Call) Return(_a0 *model.CallLimits, _a1 error) *CallStoreInterface_GetConcurrentCallLimits_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_GetConcurrentCallLimits_Call) RunAndReturn(run func(int, string, int) (*model.CallLimits, error)) *CallStoreInterface_GetConcurrentCallLimits_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetConference provides a mock function with given fields: conferenceId
+func (_m *CallStoreInterface) GetConference(conferenceId int) (*model.Conference, error) {
+	ret := _m.Called(conferenceId)
+
+	var r0 *model.Conference
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.Conference, error)); ok {
+		return rf(conferenceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.Conference); ok {
+		r0 = rf(conferenceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Conference)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(conferenceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_GetConference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetConference'
+type CallStoreInterface_GetConference_Call struct {
+	*mock.Call
+}
+
+// GetConference is a helper method to define mock.On call
+//   - conferenceId int
+func (_e *CallStoreInterface_Expecter) GetConference(conferenceId interface{}) *CallStoreInterface_GetConference_Call {
+	return &CallStoreInterface_GetConference_Call{Call: _e.mock.On("GetConference", conferenceId)}
+}
+
+func (_c *CallStoreInterface_GetConference_Call) Run(run func(conferenceId int)) *CallStoreInterface_GetConference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_GetConference_Call) Return(_a0 *model.Conference, _a1 error) *CallStoreInterface_GetConference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_GetConference_Call) RunAndReturn(run func(int) (*model.Conference, error)) *CallStoreInterface_GetConference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetUserFromDB provides a mock function with given fields: id
 func (_m *CallStoreInterface) GetUserFromDB(id int) (*model.User, error) {
 	ret := _m.Called(id)
@@ -373,6 +656,289 @@ func (_c *CallStoreInterface_GetWorkspaceFromDB_Call) RunAndReturn(run func(int)
 	return _c
 }
 
+// IsCallerIdPermitted provides a mock function with given fields: workspaceId, callerId, toNumber
+func (_m *CallStoreInterface) IsCallerIdPermitted(workspaceId int, callerId string, toNumber string) (bool, error) {
+	ret := _m.Called(workspaceId, callerId, toNumber)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string, string) (bool, error)); ok {
+		return rf(workspaceId, callerId, toNumber)
+	}
+	if rf, ok := ret.Get(0).(func(int, string, string) bool); ok {
+		r0 = rf(workspaceId, callerId, toNumber)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int, string, string) error); ok {
+		r1 = rf(workspaceId, callerId, toNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_IsCallerIdPermitted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsCallerIdPermitted'
+type CallStoreInterface_IsCallerIdPermitted_Call struct {
+	*mock.Call
+}
+
+// IsCallerIdPermitted is a helper method to define mock.On call
+//   - workspaceId int
+//   - callerId string
+//   - toNumber string
+func (_e *CallStoreInterface_Expecter) IsCallerIdPermitted(workspaceId interface{}, callerId interface{}, toNumber interface{}) *CallStoreInterface_IsCallerIdPermitted_Call {
+	return &CallStoreInterface_IsCallerIdPermitted_Call{Call: _e.mock.On("IsCallerIdPermitted", workspaceId, callerId, toNumber)}
+}
+
+func (_c *CallStoreInterface_IsCallerIdPermitted_Call) Run(run func(workspaceId int, callerId string, toNumber string)) *CallStoreInterface_IsCallerIdPermitted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_IsCallerIdPermitted_Call) Return(_a0 bool, _a1 error) *CallStoreInterface_IsCallerIdPermitted_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_IsCallerIdPermitted_Call) RunAndReturn(run func(int, string, string) (bool, error)) *CallStoreInterface_IsCallerIdPermitted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsUserAllowedToMakeCall provides a mock function with given fields: workspaceId
+func (_m *CallStoreInterface) IsUserAllowedToMakeCall(workspaceId int) (bool, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (bool, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) bool); ok {
+		r0 = rf(workspaceId)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_IsUserAllowedToMakeCall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsUserAllowedToMakeCall'
+type CallStoreInterface_IsUserAllowedToMakeCall_Call struct {
+	*mock.Call
+}
+
+// IsUserAllowedToMakeCall is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *CallStoreInterface_Expecter) IsUserAllowedToMakeCall(workspaceId interface{}) *CallStoreInterface_IsUserAllowedToMakeCall_Call {
+	return &CallStoreInterface_IsUserAllowedToMakeCall_Call{Call: _e.mock.On("IsUserAllowedToMakeCall", workspaceId)}
+}
+
+func (_c *CallStoreInterface_IsUserAllowedToMakeCall_Call) Run(run func(workspaceId int)) *CallStoreInterface_IsUserAllowedToMakeCall_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_IsUserAllowedToMakeCall_Call) Return(_a0 bool, _a1 error) *CallStoreInterface_IsUserAllowedToMakeCall_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_IsUserAllowedToMakeCall_Call) RunAndReturn(run func(int) (bool, error)) *CallStoreInterface_IsUserAllowedToMakeCall_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// LookupBestCallRate provides a mock function with given fields: from, to, callDirection
+func (_m *CallStoreInterface) LookupBestCallRate(from string, to string, callDirection string) *model.CallRate {
+	ret := _m.Called(from, to, callDirection)
+
+	var r0 *model.CallRate
+	if rf, ok := ret.Get(0).(func(string, string, string) *model.CallRate); ok {
+		r0 = rf(from, to, callDirection)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CallRate)
+		}
+	}
+
+	return r0
+}
+
+// CallStoreInterface_LookupBestCallRate_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'LookupBestCallRate'
+type CallStoreInterface_LookupBestCallRate_Call struct {
+	*mock.Call
+}
+
+// LookupBestCallRate is a helper method to define mock.On call
+//   - from string
+//   - to string
+//   - callDirection string
+func (_e *CallStoreInterface_Expecter) LookupBestCallRate(from interface{}, to interface{}, callDirection interface{}) *CallStoreInterface_LookupBestCallRate_Call {
+	return &CallStoreInterface_LookupBestCallRate_Call{Call: _e.mock.On("LookupBestCallRate", from, to, callDirection)}
+}
+
+func (_c *CallStoreInterface_LookupBestCallRate_Call) Run(run func(from string, to string, callDirection string)) *CallStoreInterface_LookupBestCallRate_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_LookupBestCallRate_Call) Return(_a0 *model.CallRate) *CallStoreInterface_LookupBestCallRate_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CallStoreInterface_LookupBestCallRate_Call) RunAndReturn(run func(string, string, string) *model.CallRate) *CallStoreInterface_LookupBestCallRate_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ProcessUsersFirstCall provides a mock function with given fields: call, mailer
+func (_m *CallStoreInterface) ProcessUsersFirstCall(call model.Call, mailer mail.Mailer) {
+	_m.Called(call, mailer)
+}
+
+// CallStoreInterface_ProcessUsersFirstCall_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ProcessUsersFirstCall'
+type CallStoreInterface_ProcessUsersFirstCall_Call struct {
+	*mock.Call
+}
+
+// ProcessUsersFirstCall is a helper method to define mock.On call
+//   - call model.Call
+//   - mailer mail.Mailer
+func (_e *CallStoreInterface_Expecter) ProcessUsersFirstCall(call interface{}, mailer interface{}) *CallStoreInterface_ProcessUsersFirstCall_Call {
+	return &CallStoreInterface_ProcessUsersFirstCall_Call{Call: _e.mock.On("ProcessUsersFirstCall", call, mailer)}
+}
+
+func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) Run(run func(call model.Call, mailer mail.Mailer)) *CallStoreInterface_ProcessUsersFirstCall_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(model.Call), args[1].(mail.Mailer))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) Return() *CallStoreInterface_ProcessUsersFirstCall_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *CallStoreInterface_ProcessUsersFirstCall_Call) RunAndReturn(run func(model.Call, mail.Mailer)) *CallStoreInterface_ProcessUsersFirstCall_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// QueueCallActivity provides a mock function with given fields: eventType, call
+func (_m *CallStoreInterface) QueueCallActivity(eventType string, call *model.Call) error {
+	ret := _m.Called(eventType, call)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *model.Call) error); ok {
+		r0 = rf(eventType, call)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CallStoreInterface_QueueCallActivity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'QueueCallActivity'
+type CallStoreInterface_QueueCallActivity_Call struct {
+	*mock.Call
+}
+
+// QueueCallActivity is a helper method to define mock.On call
+//   - eventType string
+//   - call *model.Call
+func (_e *CallStoreInterface_Expecter) QueueCallActivity(eventType interface{}, call interface{}) *CallStoreInterface_QueueCallActivity_Call {
+	return &CallStoreInterface_QueueCallActivity_Call{Call: _e.mock.On("QueueCallActivity", eventType, call)}
+}
+
+func (_c *CallStoreInterface_QueueCallActivity_Call) Run(run func(eventType string, call *model.Call)) *CallStoreInterface_QueueCallActivity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(*model.Call))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_QueueCallActivity_Call) Return(_a0 error) *CallStoreInterface_QueueCallActivity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *CallStoreInterface_QueueCallActivity_Call) RunAndReturn(run func(string, *model.Call) error) *CallStoreInterface_QueueCallActivity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RemoveConferenceParticipant provides a mock function with given fields: callId
+func (_m *CallStoreInterface) RemoveConferenceParticipant(callId int) (*model.ConferenceParticipant, error) {
+	ret := _m.Called(callId)
+
+	var r0 *model.ConferenceParticipant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.ConferenceParticipant, error)); ok {
+		return rf(callId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.ConferenceParticipant); ok {
+		r0 = rf(callId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ConferenceParticipant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(callId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_RemoveConferenceParticipant_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RemoveConferenceParticipant'
+type CallStoreInterface_RemoveConferenceParticipant_Call struct {
+	*mock.Call
+}
+
+// RemoveConferenceParticipant is a helper method to define mock.On call
+//   - callId int
+func (_e *CallStoreInterface_Expecter) RemoveConferenceParticipant(callId interface{}) *CallStoreInterface_RemoveConferenceParticipant_Call {
+	return &CallStoreInterface_RemoveConferenceParticipant_Call{Call: _e.mock.On("RemoveConferenceParticipant", callId)}
+}
+
+func (_c *CallStoreInterface_RemoveConferenceParticipant_Call) Run(run func(callId int)) *CallStoreInterface_RemoveConferenceParticipant_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_RemoveConferenceParticipant_Call) Return(_a0 *model.ConferenceParticipant, _a1 error) *CallStoreInterface_RemoveConferenceParticipant_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_RemoveConferenceParticipant_Call) RunAndReturn(run func(int) (*model.ConferenceParticipant, error)) *CallStoreInterface_RemoveConferenceParticipant_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // SetProviderByIP provides a mock function with given fields: _a0, _a1
 func (_m *CallStoreInterface) SetProviderByIP(_a0 string, _a1 string) error {
 	ret := _m.Called(_a0, _a1)
@@ -501,6 +1067,60 @@ func (_c *CallStoreInterface_UpdateCall_Call) RunAndReturn(run func(*model.CallU
 	return _c
 }
 
+// UpdateConferenceParticipant provides a mock function with given fields: update
+func (_m *CallStoreInterface) UpdateConferenceParticipant(update *model.ConferenceParticipantUpdate) (*model.ConferenceParticipant, error) {
+	ret := _m.Called(update)
+
+	var r0 *model.ConferenceParticipant
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.ConferenceParticipantUpdate) (*model.ConferenceParticipant, error)); ok {
+		return rf(update)
+	}
+	if rf, ok := ret.Get(0).(func(*model.ConferenceParticipantUpdate) *model.ConferenceParticipant); ok {
+		r0 = rf(update)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.ConferenceParticipant)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.ConferenceParticipantUpdate) error); ok {
+		r1 = rf(update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CallStoreInterface_UpdateConferenceParticipant_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateConferenceParticipant'
+type CallStoreInterface_UpdateConferenceParticipant_Call struct {
+	*mock.Call
+}
+
+// UpdateConferenceParticipant is a helper method to define mock.On call
+//   - update *model.ConferenceParticipantUpdate
+func (_e *CallStoreInterface_Expecter) UpdateConferenceParticipant(update interface{}) *CallStoreInterface_UpdateConferenceParticipant_Call {
+	return &CallStoreInterface_UpdateConferenceParticipant_Call{Call: _e.mock.On("UpdateConferenceParticipant", update)}
+}
+
+func (_c *CallStoreInterface_UpdateConferenceParticipant_Call) Run(run func(update *model.ConferenceParticipantUpdate)) *CallStoreInterface_UpdateConferenceParticipant_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.ConferenceParticipantUpdate))
+	})
+	return _c
+}
+
+func (_c *CallStoreInterface_UpdateConferenceParticipant_Call) Return(_a0 *model.ConferenceParticipant, _a1 error) *CallStoreInterface_UpdateConferenceParticipant_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CallStoreInterface_UpdateConferenceParticipant_Call) RunAndReturn(run func(*model.ConferenceParticipantUpdate) (*model.ConferenceParticipant, error)) *CallStoreInterface_UpdateConferenceParticipant_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewCallStoreInterface creates a new instance of CallStoreInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCallStoreInterface(t interface {
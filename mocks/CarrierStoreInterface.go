@@ -5,6 +5,7 @@ package mocks
 import (
 	mock "github.com/stretchr/testify/mock"
 	helpers "lineblocs.com/api/helpers"
+	model "lineblocs.com/api/model"
 )
 
 // CarrierStoreInterface is an autogenerated mock type for the CarrierStoreInterface type
@@ -76,13 +77,13 @@ func (_c *CarrierStoreInterface_CreateRoutingFlow_Call) RunAndReturn(run func(*s
 	return _c
 }
 
-// CreateSIPReport provides a mock function with given fields: _a0, _a1
-func (_m *CarrierStoreInterface) CreateSIPReport(_a0 string, _a1 string) error {
-	ret := _m.Called(_a0, _a1)
+// CreateSIPReport provides a mock function with given fields: _a0
+func (_m *CarrierStoreInterface) CreateSIPReport(_a0 *model.SIPReport) error {
+	ret := _m.Called(_a0)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(_a0, _a1)
+	if rf, ok := ret.Get(0).(func(*model.SIPReport) error); ok {
+		r0 = rf(_a0)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -96,15 +97,14 @@ type CarrierStoreInterface_CreateSIPReport_Call struct {
 }
 
 // CreateSIPReport is a helper method to define mock.On call
-//   - _a0 string
-//   - _a1 string
-func (_e *CarrierStoreInterface_Expecter) CreateSIPReport(_a0 interface{}, _a1 interface{}) *CarrierStoreInterface_CreateSIPReport_Call {
-	return &CarrierStoreInterface_CreateSIPReport_Call{Call: _e.mock.On("CreateSIPReport", _a0, _a1)}
+//   - _a0 *model.SIPReport
+func (_e *CarrierStoreInterface_Expecter) CreateSIPReport(_a0 interface{}) *CarrierStoreInterface_CreateSIPReport_Call {
+	return &CarrierStoreInterface_CreateSIPReport_Call{Call: _e.mock.On("CreateSIPReport", _a0)}
 }
 
-func (_c *CarrierStoreInterface_CreateSIPReport_Call) Run(run func(_a0 string, _a1 string)) *CarrierStoreInterface_CreateSIPReport_Call {
+func (_c *CarrierStoreInterface_CreateSIPReport_Call) Run(run func(_a0 *model.SIPReport)) *CarrierStoreInterface_CreateSIPReport_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string))
+		run(args[0].(*model.SIPReport))
 	})
 	return _c
 }
@@ -114,7 +114,62 @@ func (_c *CarrierStoreInterface_CreateSIPReport_Call) Return(_a0 error) *Carrier
 	return _c
 }
 
-func (_c *CarrierStoreInterface_CreateSIPReport_Call) RunAndReturn(run func(string, string) error) *CarrierStoreInterface_CreateSIPReport_Call {
+func (_c *CarrierStoreInterface_CreateSIPReport_Call) RunAndReturn(run func(*model.SIPReport) error) *CarrierStoreInterface_CreateSIPReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSIPReportHistory provides a mock function with given fields: _a0, _a1
+func (_m *CarrierStoreInterface) GetSIPReportHistory(_a0 int, _a1 string) (*model.SIPReportHistory, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 *model.SIPReportHistory
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, string) (*model.SIPReportHistory, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(int, string) *model.SIPReportHistory); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SIPReportHistory)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, string) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CarrierStoreInterface_GetSIPReportHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSIPReportHistory'
+type CarrierStoreInterface_GetSIPReportHistory_Call struct {
+	*mock.Call
+}
+
+// GetSIPReportHistory is a helper method to define mock.On call
+//   - _a0 int
+//   - _a1 string
+func (_e *CarrierStoreInterface_Expecter) GetSIPReportHistory(_a0 interface{}, _a1 interface{}) *CarrierStoreInterface_GetSIPReportHistory_Call {
+	return &CarrierStoreInterface_GetSIPReportHistory_Call{Call: _e.mock.On("GetSIPReportHistory", _a0, _a1)}
+}
+
+func (_c *CarrierStoreInterface_GetSIPReportHistory_Call) Run(run func(_a0 int, _a1 string)) *CarrierStoreInterface_GetSIPReportHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *CarrierStoreInterface_GetSIPReportHistory_Call) Return(_a0 *model.SIPReportHistory, _a1 error) *CarrierStoreInterface_GetSIPReportHistory_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *CarrierStoreInterface_GetSIPReportHistory_Call) RunAndReturn(run func(int, string) (*model.SIPReportHistory, error)) *CarrierStoreInterface_GetSIPReportHistory_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -21,17 +21,27 @@ func (_m *DebitStoreInterface) EXPECT() *DebitStoreInterface_Expecter {
 }
 
 // CreateAPIUsageDebit provides a mock function with given fields: _a0, _a1
-func (_m *DebitStoreInterface) CreateAPIUsageDebit(_a0 *model.Workspace, _a1 *model.DebitAPI) error {
+func (_m *DebitStoreInterface) CreateAPIUsageDebit(_a0 *model.Workspace, _a1 *model.DebitAPI) (float64, error) {
 	ret := _m.Called(_a0, _a1)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(*model.Workspace, *model.DebitAPI) error); ok {
+	var r0 float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.Workspace, *model.DebitAPI) (float64, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(*model.Workspace, *model.DebitAPI) float64); ok {
 		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(float64)
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(*model.Workspace, *model.DebitAPI) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // DebitStoreInterface_CreateAPIUsageDebit_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateAPIUsageDebit'
@@ -53,12 +63,12 @@ func (_c *DebitStoreInterface_CreateAPIUsageDebit_Call) Run(run func(_a0 *model.
 	return _c
 }
 
-func (_c *DebitStoreInterface_CreateAPIUsageDebit_Call) Return(_a0 error) *DebitStoreInterface_CreateAPIUsageDebit_Call {
-	_c.Call.Return(_a0)
+func (_c *DebitStoreInterface_CreateAPIUsageDebit_Call) Return(_a0 float64, _a1 error) *DebitStoreInterface_CreateAPIUsageDebit_Call {
+	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *DebitStoreInterface_CreateAPIUsageDebit_Call) RunAndReturn(run func(*model.Workspace, *model.DebitAPI) error) *DebitStoreInterface_CreateAPIUsageDebit_Call {
+func (_c *DebitStoreInterface_CreateAPIUsageDebit_Call) RunAndReturn(run func(*model.Workspace, *model.DebitAPI) (float64, error)) *DebitStoreInterface_CreateAPIUsageDebit_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -106,6 +116,61 @@ func (_c *DebitStoreInterface_CreateDebit_Call) RunAndReturn(run func(*model.Cal
 	return _c
 }
 
+// GetCallCharges provides a mock function with given fields: workspaceId, callIds
+func (_m *DebitStoreInterface) GetCallCharges(workspaceId int, callIds []int) (map[int]float64, error) {
+	ret := _m.Called(workspaceId, callIds)
+
+	var r0 map[int]float64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, []int) (map[int]float64, error)); ok {
+		return rf(workspaceId, callIds)
+	}
+	if rf, ok := ret.Get(0).(func(int, []int) map[int]float64); ok {
+		r0 = rf(workspaceId, callIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[int]float64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, []int) error); ok {
+		r1 = rf(workspaceId, callIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DebitStoreInterface_GetCallCharges_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCallCharges'
+type DebitStoreInterface_GetCallCharges_Call struct {
+	*mock.Call
+}
+
+// GetCallCharges is a helper method to define mock.On call
+//   - workspaceId int
+//   - callIds []int
+func (_e *DebitStoreInterface_Expecter) GetCallCharges(workspaceId interface{}, callIds interface{}) *DebitStoreInterface_GetCallCharges_Call {
+	return &DebitStoreInterface_GetCallCharges_Call{Call: _e.mock.On("GetCallCharges", workspaceId, callIds)}
+}
+
+func (_c *DebitStoreInterface_GetCallCharges_Call) Run(run func(workspaceId int, callIds []int)) *DebitStoreInterface_GetCallCharges_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].([]int))
+	})
+	return _c
+}
+
+func (_c *DebitStoreInterface_GetCallCharges_Call) Return(_a0 map[int]float64, _a1 error) *DebitStoreInterface_GetCallCharges_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *DebitStoreInterface_GetCallCharges_Call) RunAndReturn(run func(int, []int) (map[int]float64, error)) *DebitStoreInterface_GetCallCharges_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewDebitStoreInterface creates a new instance of DebitStoreInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewDebitStoreInterface(t interface {
@@ -5,6 +5,7 @@ package mocks
 import (
 	mock "github.com/stretchr/testify/mock"
 	model "lineblocs.com/api/model"
+	time "time"
 )
 
 // FaxStoreInterface is an autogenerated mock type for the FaxStoreInterface type
@@ -20,6 +21,158 @@ func (_m *FaxStoreInterface) EXPECT() *FaxStoreInterface_Expecter {
 	return &FaxStoreInterface_Expecter{mock: &_m.Mock}
 }
 
+// ClaimDueFaxes provides a mock function with given fields: limit, timeout
+func (_m *FaxStoreInterface) ClaimDueFaxes(limit int, timeout time.Duration) ([]model.Fax, error) {
+	ret := _m.Called(limit, timeout)
+
+	var r0 []model.Fax
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) ([]model.Fax, error)); ok {
+		return rf(limit, timeout)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []model.Fax); ok {
+		r0 = rf(limit, timeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Fax)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
+		r1 = rf(limit, timeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_ClaimDueFaxes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimDueFaxes'
+type FaxStoreInterface_ClaimDueFaxes_Call struct {
+	*mock.Call
+}
+
+// ClaimDueFaxes is a helper method to define mock.On call
+//   - limit int
+//   - timeout time.Duration
+func (_e *FaxStoreInterface_Expecter) ClaimDueFaxes(limit interface{}, timeout interface{}) *FaxStoreInterface_ClaimDueFaxes_Call {
+	return &FaxStoreInterface_ClaimDueFaxes_Call{Call: _e.mock.On("ClaimDueFaxes", limit, timeout)}
+}
+
+func (_c *FaxStoreInterface_ClaimDueFaxes_Call) Run(run func(limit int, timeout time.Duration)) *FaxStoreInterface_ClaimDueFaxes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_ClaimDueFaxes_Call) Return(_a0 []model.Fax, _a1 error) *FaxStoreInterface_ClaimDueFaxes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_ClaimDueFaxes_Call) RunAndReturn(run func(int, time.Duration) ([]model.Fax, error)) *FaxStoreInterface_ClaimDueFaxes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimFaxEmails provides a mock function with given fields: limit, lease
+func (_m *FaxStoreInterface) ClaimFaxEmails(limit int, lease time.Duration) ([]model.Fax, error) {
+	ret := _m.Called(limit, lease)
+
+	var r0 []model.Fax
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Duration) ([]model.Fax, error)); ok {
+		return rf(limit, lease)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Duration) []model.Fax); ok {
+		r0 = rf(limit, lease)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Fax)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Duration) error); ok {
+		r1 = rf(limit, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_ClaimFaxEmails_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimFaxEmails'
+type FaxStoreInterface_ClaimFaxEmails_Call struct {
+	*mock.Call
+}
+
+// ClaimFaxEmails is a helper method to define mock.On call
+//   - limit int
+//   - lease time.Duration
+func (_e *FaxStoreInterface_Expecter) ClaimFaxEmails(limit interface{}, lease interface{}) *FaxStoreInterface_ClaimFaxEmails_Call {
+	return &FaxStoreInterface_ClaimFaxEmails_Call{Call: _e.mock.On("ClaimFaxEmails", limit, lease)}
+}
+
+func (_c *FaxStoreInterface_ClaimFaxEmails_Call) Run(run func(limit int, lease time.Duration)) *FaxStoreInterface_ClaimFaxEmails_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_ClaimFaxEmails_Call) Return(_a0 []model.Fax, _a1 error) *FaxStoreInterface_ClaimFaxEmails_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_ClaimFaxEmails_Call) RunAndReturn(run func(int, time.Duration) ([]model.Fax, error)) *FaxStoreInterface_ClaimFaxEmails_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CompleteFaxEmail provides a mock function with given fields: faxId
+func (_m *FaxStoreInterface) CompleteFaxEmail(faxId int) error {
+	ret := _m.Called(faxId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(faxId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FaxStoreInterface_CompleteFaxEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompleteFaxEmail'
+type FaxStoreInterface_CompleteFaxEmail_Call struct {
+	*mock.Call
+}
+
+// CompleteFaxEmail is a helper method to define mock.On call
+//   - faxId int
+func (_e *FaxStoreInterface_Expecter) CompleteFaxEmail(faxId interface{}) *FaxStoreInterface_CompleteFaxEmail_Call {
+	return &FaxStoreInterface_CompleteFaxEmail_Call{Call: _e.mock.On("CompleteFaxEmail", faxId)}
+}
+
+func (_c *FaxStoreInterface_CompleteFaxEmail_Call) Run(run func(faxId int)) *FaxStoreInterface_CompleteFaxEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_CompleteFaxEmail_Call) Return(_a0 error) *FaxStoreInterface_CompleteFaxEmail_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *FaxStoreInterface_CompleteFaxEmail_Call) RunAndReturn(run func(int) error) *FaxStoreInterface_CompleteFaxEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateFax provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
 func (_m *FaxStoreInterface) CreateFax(_a0 *model.Fax, _a1 string, _a2 int64, _a3 string, _a4 string) (int64, error) {
 	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
@@ -76,6 +229,50 @@ func (_c *FaxStoreInterface_CreateFax_Call) RunAndReturn(run func(*model.Fax, st
 	return _c
 }
 
+// FailFaxEmail provides a mock function with given fields: faxId, lastError, retryAt
+func (_m *FaxStoreInterface) FailFaxEmail(faxId int, lastError string, retryAt *time.Time) error {
+	ret := _m.Called(faxId, lastError, retryAt)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string, *time.Time) error); ok {
+		r0 = rf(faxId, lastError, retryAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FaxStoreInterface_FailFaxEmail_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FailFaxEmail'
+type FaxStoreInterface_FailFaxEmail_Call struct {
+	*mock.Call
+}
+
+// FailFaxEmail is a helper method to define mock.On call
+//   - faxId int
+//   - lastError string
+//   - retryAt *time.Time
+func (_e *FaxStoreInterface_Expecter) FailFaxEmail(faxId interface{}, lastError interface{}, retryAt interface{}) *FaxStoreInterface_FailFaxEmail_Call {
+	return &FaxStoreInterface_FailFaxEmail_Call{Call: _e.mock.On("FailFaxEmail", faxId, lastError, retryAt)}
+}
+
+func (_c *FaxStoreInterface_FailFaxEmail_Call) Run(run func(faxId int, lastError string, retryAt *time.Time)) *FaxStoreInterface_FailFaxEmail_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string), args[2].(*time.Time))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_FailFaxEmail_Call) Return(_a0 error) *FaxStoreInterface_FailFaxEmail_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *FaxStoreInterface_FailFaxEmail_Call) RunAndReturn(run func(int, string, *time.Time) error) *FaxStoreInterface_FailFaxEmail_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetFaxCount provides a mock function with given fields: _a0
 func (_m *FaxStoreInterface) GetFaxCount(_a0 int) (*int, error) {
 	ret := _m.Called(_a0)
@@ -130,6 +327,307 @@ func (_c *FaxStoreInterface_GetFaxCount_Call) RunAndReturn(run func(int) (*int,
 	return _c
 }
 
+// GetFaxEmailSettings provides a mock function with given fields: workspaceId
+func (_m *FaxStoreInterface) GetFaxEmailSettings(workspaceId int) (*model.FaxEmailSettings, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 *model.FaxEmailSettings
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.FaxEmailSettings, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.FaxEmailSettings); ok {
+		r0 = rf(workspaceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FaxEmailSettings)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_GetFaxEmailSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFaxEmailSettings'
+type FaxStoreInterface_GetFaxEmailSettings_Call struct {
+	*mock.Call
+}
+
+// GetFaxEmailSettings is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *FaxStoreInterface_Expecter) GetFaxEmailSettings(workspaceId interface{}) *FaxStoreInterface_GetFaxEmailSettings_Call {
+	return &FaxStoreInterface_GetFaxEmailSettings_Call{Call: _e.mock.On("GetFaxEmailSettings", workspaceId)}
+}
+
+func (_c *FaxStoreInterface_GetFaxEmailSettings_Call) Run(run func(workspaceId int)) *FaxStoreInterface_GetFaxEmailSettings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_GetFaxEmailSettings_Call) Return(_a0 *model.FaxEmailSettings, _a1 error) *FaxStoreInterface_GetFaxEmailSettings_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_GetFaxEmailSettings_Call) RunAndReturn(run func(int) (*model.FaxEmailSettings, error)) *FaxStoreInterface_GetFaxEmailSettings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetFaxFromDB provides a mock function with given fields: workspaceId, id
+func (_m *FaxStoreInterface) GetFaxFromDB(workspaceId int, id int) (*model.Fax, error) {
+	ret := _m.Called(workspaceId, id)
+
+	var r0 *model.Fax
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int) (*model.Fax, error)); ok {
+		return rf(workspaceId, id)
+	}
+	if rf, ok := ret.Get(0).(func(int, int) *model.Fax); ok {
+		r0 = rf(workspaceId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Fax)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(workspaceId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_GetFaxFromDB_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetFaxFromDB'
+type FaxStoreInterface_GetFaxFromDB_Call struct {
+	*mock.Call
+}
+
+// GetFaxFromDB is a helper method to define mock.On call
+//   - workspaceId int
+//   - id int
+func (_e *FaxStoreInterface_Expecter) GetFaxFromDB(workspaceId interface{}, id interface{}) *FaxStoreInterface_GetFaxFromDB_Call {
+	return &FaxStoreInterface_GetFaxFromDB_Call{Call: _e.mock.On("GetFaxFromDB", workspaceId, id)}
+}
+
+func (_c *FaxStoreInterface_GetFaxFromDB_Call) Run(run func(workspaceId int, id int)) *FaxStoreInterface_GetFaxFromDB_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_GetFaxFromDB_Call) Return(_a0 *model.Fax, _a1 error) *FaxStoreInterface_GetFaxFromDB_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_GetFaxFromDB_Call) RunAndReturn(run func(int, int) (*model.Fax, error)) *FaxStoreInterface_GetFaxFromDB_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListFaxes provides a mock function with given fields: filter
+func (_m *FaxStoreInterface) ListFaxes(filter *model.FaxFilter) (*model.FaxList, error) {
+	ret := _m.Called(filter)
+
+	var r0 *model.FaxList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.FaxFilter) (*model.FaxList, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(*model.FaxFilter) *model.FaxList); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FaxList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.FaxFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_ListFaxes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListFaxes'
+type FaxStoreInterface_ListFaxes_Call struct {
+	*mock.Call
+}
+
+// ListFaxes is a helper method to define mock.On call
+//   - filter *model.FaxFilter
+func (_e *FaxStoreInterface_Expecter) ListFaxes(filter interface{}) *FaxStoreInterface_ListFaxes_Call {
+	return &FaxStoreInterface_ListFaxes_Call{Call: _e.mock.On("ListFaxes", filter)}
+}
+
+func (_c *FaxStoreInterface_ListFaxes_Call) Run(run func(filter *model.FaxFilter)) *FaxStoreInterface_ListFaxes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.FaxFilter))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_ListFaxes_Call) Return(_a0 *model.FaxList, _a1 error) *FaxStoreInterface_ListFaxes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_ListFaxes_Call) RunAndReturn(run func(*model.FaxFilter) (*model.FaxList, error)) *FaxStoreInterface_ListFaxes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReleaseFax provides a mock function with given fields: faxId
+func (_m *FaxStoreInterface) ReleaseFax(faxId int) error {
+	ret := _m.Called(faxId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(faxId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FaxStoreInterface_ReleaseFax_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReleaseFax'
+type FaxStoreInterface_ReleaseFax_Call struct {
+	*mock.Call
+}
+
+// ReleaseFax is a helper method to define mock.On call
+//   - faxId int
+func (_e *FaxStoreInterface_Expecter) ReleaseFax(faxId interface{}) *FaxStoreInterface_ReleaseFax_Call {
+	return &FaxStoreInterface_ReleaseFax_Call{Call: _e.mock.On("ReleaseFax", faxId)}
+}
+
+func (_c *FaxStoreInterface_ReleaseFax_Call) Run(run func(faxId int)) *FaxStoreInterface_ReleaseFax_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_ReleaseFax_Call) Return(_a0 error) *FaxStoreInterface_ReleaseFax_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *FaxStoreInterface_ReleaseFax_Call) RunAndReturn(run func(int) error) *FaxStoreInterface_ReleaseFax_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveFaxEmailSettings provides a mock function with given fields: settings
+func (_m *FaxStoreInterface) SaveFaxEmailSettings(settings *model.FaxEmailSettings) error {
+	ret := _m.Called(settings)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.FaxEmailSettings) error); ok {
+		r0 = rf(settings)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FaxStoreInterface_SaveFaxEmailSettings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveFaxEmailSettings'
+type FaxStoreInterface_SaveFaxEmailSettings_Call struct {
+	*mock.Call
+}
+
+// SaveFaxEmailSettings is a helper method to define mock.On call
+//   - settings *model.FaxEmailSettings
+func (_e *FaxStoreInterface_Expecter) SaveFaxEmailSettings(settings interface{}) *FaxStoreInterface_SaveFaxEmailSettings_Call {
+	return &FaxStoreInterface_SaveFaxEmailSettings_Call{Call: _e.mock.On("SaveFaxEmailSettings", settings)}
+}
+
+func (_c *FaxStoreInterface_SaveFaxEmailSettings_Call) Run(run func(settings *model.FaxEmailSettings)) *FaxStoreInterface_SaveFaxEmailSettings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.FaxEmailSettings))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_SaveFaxEmailSettings_Call) Return(_a0 error) *FaxStoreInterface_SaveFaxEmailSettings_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *FaxStoreInterface_SaveFaxEmailSettings_Call) RunAndReturn(run func(*model.FaxEmailSettings) error) *FaxStoreInterface_SaveFaxEmailSettings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetFaxStatus provides a mock function with given fields: fax, from, nextAttemptAt
+func (_m *FaxStoreInterface) SetFaxStatus(fax *model.Fax, from string, nextAttemptAt *time.Time) (bool, error) {
+	ret := _m.Called(fax, from, nextAttemptAt)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.Fax, string, *time.Time) (bool, error)); ok {
+		return rf(fax, from, nextAttemptAt)
+	}
+	if rf, ok := ret.Get(0).(func(*model.Fax, string, *time.Time) bool); ok {
+		r0 = rf(fax, from, nextAttemptAt)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.Fax, string, *time.Time) error); ok {
+		r1 = rf(fax, from, nextAttemptAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FaxStoreInterface_SetFaxStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetFaxStatus'
+type FaxStoreInterface_SetFaxStatus_Call struct {
+	*mock.Call
+}
+
+// SetFaxStatus is a helper method to define mock.On call
+//   - fax *model.Fax
+//   - from string
+//   - nextAttemptAt *time.Time
+func (_e *FaxStoreInterface_Expecter) SetFaxStatus(fax interface{}, from interface{}, nextAttemptAt interface{}) *FaxStoreInterface_SetFaxStatus_Call {
+	return &FaxStoreInterface_SetFaxStatus_Call{Call: _e.mock.On("SetFaxStatus", fax, from, nextAttemptAt)}
+}
+
+func (_c *FaxStoreInterface_SetFaxStatus_Call) Run(run func(fax *model.Fax, from string, nextAttemptAt *time.Time)) *FaxStoreInterface_SetFaxStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.Fax), args[1].(string), args[2].(*time.Time))
+	})
+	return _c
+}
+
+func (_c *FaxStoreInterface_SetFaxStatus_Call) Return(_a0 bool, _a1 error) *FaxStoreInterface_SetFaxStatus_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *FaxStoreInterface_SetFaxStatus_Call) RunAndReturn(run func(*model.Fax, string, *time.Time) (bool, error)) *FaxStoreInterface_SetFaxStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewFaxStoreInterface creates a new instance of FaxStoreInterface. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewFaxStoreInterface(t interface {
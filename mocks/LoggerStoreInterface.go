@@ -20,6 +20,254 @@ func (_m *LoggerStoreInterface) EXPECT() *LoggerStoreInterface_Expecter {
 	return &LoggerStoreInterface_Expecter{mock: &_m.Mock}
 }
 
+// DeleteNotificationChannel provides a mock function with given fields: workspaceId, id
+func (_m *LoggerStoreInterface) DeleteNotificationChannel(workspaceId int, id int) error {
+	ret := _m.Called(workspaceId, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, int) error); ok {
+		r0 = rf(workspaceId, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LoggerStoreInterface_DeleteNotificationChannel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteNotificationChannel'
+type LoggerStoreInterface_DeleteNotificationChannel_Call struct {
+	*mock.Call
+}
+
+// DeleteNotificationChannel is a helper method to define mock.On call
+//   - workspaceId int
+//   - id int
+func (_e *LoggerStoreInterface_Expecter) DeleteNotificationChannel(workspaceId interface{}, id interface{}) *LoggerStoreInterface_DeleteNotificationChannel_Call {
+	return &LoggerStoreInterface_DeleteNotificationChannel_Call{Call: _e.mock.On("DeleteNotificationChannel", workspaceId, id)}
+}
+
+func (_c *LoggerStoreInterface_DeleteNotificationChannel_Call) Run(run func(workspaceId int, id int)) *LoggerStoreInterface_DeleteNotificationChannel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *LoggerStoreInterface_DeleteNotificationChannel_Call) Return(_a0 error) *LoggerStoreInterface_DeleteNotificationChannel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LoggerStoreInterface_DeleteNotificationChannel_Call) RunAndReturn(run func(int, int) error) *LoggerStoreInterface_DeleteNotificationChannel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetLog provides a mock function with given fields: workspaceId, id
+func (_m *LoggerStoreInterface) GetLog(workspaceId int, id int) (*model.DebuggerLog, error) {
+	ret := _m.Called(workspaceId, id)
+
+	var r0 *model.DebuggerLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, int) (*model.DebuggerLog, error)); ok {
+		return rf(workspaceId, id)
+	}
+	if rf, ok := ret.Get(0).(func(int, int) *model.DebuggerLog); ok {
+		r0 = rf(workspaceId, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.DebuggerLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, int) error); ok {
+		r1 = rf(workspaceId, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoggerStoreInterface_GetLog_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetLog'
+type LoggerStoreInterface_GetLog_Call struct {
+	*mock.Call
+}
+
+// GetLog is a helper method to define mock.On call
+//   - workspaceId int
+//   - id int
+func (_e *LoggerStoreInterface_Expecter) GetLog(workspaceId interface{}, id interface{}) *LoggerStoreInterface_GetLog_Call {
+	return &LoggerStoreInterface_GetLog_Call{Call: _e.mock.On("GetLog", workspaceId, id)}
+}
+
+func (_c *LoggerStoreInterface_GetLog_Call) Run(run func(workspaceId int, id int)) *LoggerStoreInterface_GetLog_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *LoggerStoreInterface_GetLog_Call) Return(_a0 *model.DebuggerLog, _a1 error) *LoggerStoreInterface_GetLog_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoggerStoreInterface_GetLog_Call) RunAndReturn(run func(int, int) (*model.DebuggerLog, error)) *LoggerStoreInterface_GetLog_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetNotificationChannels provides a mock function with given fields: workspaceId
+func (_m *LoggerStoreInterface) GetNotificationChannels(workspaceId int) ([]model.NotificationChannel, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 []model.NotificationChannel
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]model.NotificationChannel, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) []model.NotificationChannel); ok {
+		r0 = rf(workspaceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.NotificationChannel)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoggerStoreInterface_GetNotificationChannels_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetNotificationChannels'
+type LoggerStoreInterface_GetNotificationChannels_Call struct {
+	*mock.Call
+}
+
+// GetNotificationChannels is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *LoggerStoreInterface_Expecter) GetNotificationChannels(workspaceId interface{}) *LoggerStoreInterface_GetNotificationChannels_Call {
+	return &LoggerStoreInterface_GetNotificationChannels_Call{Call: _e.mock.On("GetNotificationChannels", workspaceId)}
+}
+
+func (_c *LoggerStoreInterface_GetNotificationChannels_Call) Run(run func(workspaceId int)) *LoggerStoreInterface_GetNotificationChannels_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *LoggerStoreInterface_GetNotificationChannels_Call) Return(_a0 []model.NotificationChannel, _a1 error) *LoggerStoreInterface_GetNotificationChannels_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoggerStoreInterface_GetNotificationChannels_Call) RunAndReturn(run func(int) ([]model.NotificationChannel, error)) *LoggerStoreInterface_GetNotificationChannels_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListLogs provides a mock function with given fields: filter
+func (_m *LoggerStoreInterface) ListLogs(filter *model.LogFilter) (*model.LogList, error) {
+	ret := _m.Called(filter)
+
+	var r0 *model.LogList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.LogFilter) (*model.LogList, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(*model.LogFilter) *model.LogList); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.LogList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.LogFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// LoggerStoreInterface_ListLogs_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListLogs'
+type LoggerStoreInterface_ListLogs_Call struct {
+	*mock.Call
+}
+
+// ListLogs is a helper method to define mock.On call
+//   - filter *model.LogFilter
+func (_e *LoggerStoreInterface_Expecter) ListLogs(filter interface{}) *LoggerStoreInterface_ListLogs_Call {
+	return &LoggerStoreInterface_ListLogs_Call{Call: _e.mock.On("ListLogs", filter)}
+}
+
+func (_c *LoggerStoreInterface_ListLogs_Call) Run(run func(filter *model.LogFilter)) *LoggerStoreInterface_ListLogs_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.LogFilter))
+	})
+	return _c
+}
+
+func (_c *LoggerStoreInterface_ListLogs_Call) Return(_a0 *model.LogList, _a1 error) *LoggerStoreInterface_ListLogs_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *LoggerStoreInterface_ListLogs_Call) RunAndReturn(run func(*model.LogFilter) (*model.LogList, error)) *LoggerStoreInterface_ListLogs_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveNotificationChannel provides a mock function with given fields: channel
+func (_m *LoggerStoreInterface) SaveNotificationChannel(channel *model.NotificationChannel) error {
+	ret := _m.Called(channel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.NotificationChannel) error); ok {
+		r0 = rf(channel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// LoggerStoreInterface_SaveNotificationChannel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveNotificationChannel'
+type LoggerStoreInterface_SaveNotificationChannel_Call struct {
+	*mock.Call
+}
+
+// SaveNotificationChannel is a helper method to define mock.On call
+//   - channel *model.NotificationChannel
+func (_e *LoggerStoreInterface_Expecter) SaveNotificationChannel(channel interface{}) *LoggerStoreInterface_SaveNotificationChannel_Call {
+	return &LoggerStoreInterface_SaveNotificationChannel_Call{Call: _e.mock.On("SaveNotificationChannel", channel)}
+}
+
+func (_c *LoggerStoreInterface_SaveNotificationChannel_Call) Run(run func(channel *model.NotificationChannel)) *LoggerStoreInterface_SaveNotificationChannel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.NotificationChannel))
+	})
+	return _c
+}
+
+func (_c *LoggerStoreInterface_SaveNotificationChannel_Call) Return(_a0 error) *LoggerStoreInterface_SaveNotificationChannel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *LoggerStoreInterface_SaveNotificationChannel_Call) RunAndReturn(run func(*model.NotificationChannel) error) *LoggerStoreInterface_SaveNotificationChannel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // StartLogRoutine provides a mock function with given fields: _a0, _a1
 func (_m *LoggerStoreInterface) StartLogRoutine(_a0 *model.Workspace, _a1 *model.LogRoutine) (*string, error) {
 	ret := _m.Called(_a0, _a1)
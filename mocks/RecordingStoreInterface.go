@@ -5,6 +5,7 @@ package mocks
 import (
 	mock "github.com/stretchr/testify/mock"
 	model "lineblocs.com/api/model"
+	time "time"
 )
 
 // RecordingStoreInterface is an autogenerated mock type for the RecordingStoreInterface type
@@ -20,6 +21,91 @@ func (_m *RecordingStoreInterface) EXPECT() *RecordingStoreInterface_Expecter {
 	return &RecordingStoreInterface_Expecter{mock: &_m.Mock}
 }
 
+// AddRecordingUploadChunk provides a mock function with given fields: uploadId, chunk
+func (_m *RecordingStoreInterface) AddRecordingUploadChunk(uploadId int, chunk *model.RecordingUploadChunk) error {
+	ret := _m.Called(uploadId, chunk)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, *model.RecordingUploadChunk) error); ok {
+		r0 = rf(uploadId, chunk)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_AddRecordingUploadChunk_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'AddRecordingUploadChunk'
+type RecordingStoreInterface_AddRecordingUploadChunk_Call struct {
+	*mock.Call
+}
+
+// AddRecordingUploadChunk is a helper method to define mock.On call
+//   - uploadId int
+//   - chunk *model.RecordingUploadChunk
+func (_e *RecordingStoreInterface_Expecter) AddRecordingUploadChunk(uploadId interface{}, chunk interface{}) *RecordingStoreInterface_AddRecordingUploadChunk_Call {
+	return &RecordingStoreInterface_AddRecordingUploadChunk_Call{Call: _e.mock.On("AddRecordingUploadChunk", uploadId, chunk)}
+}
+
+func (_c *RecordingStoreInterface_AddRecordingUploadChunk_Call) Run(run func(uploadId int, chunk *model.RecordingUploadChunk)) *RecordingStoreInterface_AddRecordingUploadChunk_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(*model.RecordingUploadChunk))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_AddRecordingUploadChunk_Call) Return(_a0 error) *RecordingStoreInterface_AddRecordingUploadChunk_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_AddRecordingUploadChunk_Call) RunAndReturn(run func(int, *model.RecordingUploadChunk) error) *RecordingStoreInterface_AddRecordingUploadChunk_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ClaimRecordingUpload provides a mock function with given fields: uploadId
+func (_m *RecordingStoreInterface) ClaimRecordingUpload(uploadId int) error {
+	ret := _m.Called(uploadId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(uploadId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_ClaimRecordingUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClaimRecordingUpload'
+type RecordingStoreInterface_ClaimRecordingUpload_Call struct {
+	*mock.Call
+}
+
+// ClaimRecordingUpload is a helper method to define mock.On call
+//   - uploadId int
+func (_e *RecordingStoreInterface_Expecter) ClaimRecordingUpload(uploadId interface{}) *RecordingStoreInterface_ClaimRecordingUpload_Call {
+	return &RecordingStoreInterface_ClaimRecordingUpload_Call{Call: _e.mock.On("ClaimRecordingUpload", uploadId)}
+}
+
+func (_c *RecordingStoreInterface_ClaimRecordingUpload_Call) Run(run func(uploadId int)) *RecordingStoreInterface_ClaimRecordingUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_ClaimRecordingUpload_Call) Return(_a0 error) *RecordingStoreInterface_ClaimRecordingUpload_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_ClaimRecordingUpload_Call) RunAndReturn(run func(int) error) *RecordingStoreInterface_ClaimRecordingUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateRecording provides a mock function with given fields: _a0, _a1
 func (_m *RecordingStoreInterface) CreateRecording(_a0 *model.Workspace, _a1 *model.Recording) (int64, error) {
 	ret := _m.Called(_a0, _a1)
@@ -73,6 +159,269 @@ func (_c *RecordingStoreInterface_CreateRecording_Call) RunAndReturn(run func(*m
 	return _c
 }
 
+// CreateRecordingUpload provides a mock function with given fields: upload
+func (_m *RecordingStoreInterface) CreateRecordingUpload(upload *model.RecordingUpload) (int64, error) {
+	ret := _m.Called(upload)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.RecordingUpload) (int64, error)); ok {
+		return rf(upload)
+	}
+	if rf, ok := ret.Get(0).(func(*model.RecordingUpload) int64); ok {
+		r0 = rf(upload)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.RecordingUpload) error); ok {
+		r1 = rf(upload)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_CreateRecordingUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateRecordingUpload'
+type RecordingStoreInterface_CreateRecordingUpload_Call struct {
+	*mock.Call
+}
+
+// CreateRecordingUpload is a helper method to define mock.On call
+//   - upload *model.RecordingUpload
+func (_e *RecordingStoreInterface_Expecter) CreateRecordingUpload(upload interface{}) *RecordingStoreInterface_CreateRecordingUpload_Call {
+	return &RecordingStoreInterface_CreateRecordingUpload_Call{Call: _e.mock.On("CreateRecordingUpload", upload)}
+}
+
+func (_c *RecordingStoreInterface_CreateRecordingUpload_Call) Run(run func(upload *model.RecordingUpload)) *RecordingStoreInterface_CreateRecordingUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.RecordingUpload))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_CreateRecordingUpload_Call) Return(_a0 int64, _a1 error) *RecordingStoreInterface_CreateRecordingUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_CreateRecordingUpload_Call) RunAndReturn(run func(*model.RecordingUpload) (int64, error)) *RecordingStoreInterface_CreateRecordingUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetExpiredRecordingUploads provides a mock function with given fields: before, limit
+func (_m *RecordingStoreInterface) GetExpiredRecordingUploads(before time.Time, limit int) ([]model.RecordingUpload, error) {
+	ret := _m.Called(before, limit)
+
+	var r0 []model.RecordingUpload
+	var r1 error
+	if rf, ok := ret.Get(0).(func(time.Time, int) ([]model.RecordingUpload, error)); ok {
+		return rf(before, limit)
+	}
+	if rf, ok := ret.Get(0).(func(time.Time, int) []model.RecordingUpload); ok {
+		r0 = rf(before, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.RecordingUpload)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(time.Time, int) error); ok {
+		r1 = rf(before, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetExpiredRecordingUploads_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetExpiredRecordingUploads'
+type RecordingStoreInterface_GetExpiredRecordingUploads_Call struct {
+	*mock.Call
+}
+
+// GetExpiredRecordingUploads is a helper method to define mock.On call
+//   - before time.Time
+//   - limit int
+func (_e *RecordingStoreInterface_Expecter) GetExpiredRecordingUploads(before interface{}, limit interface{}) *RecordingStoreInterface_GetExpiredRecordingUploads_Call {
+	return &RecordingStoreInterface_GetExpiredRecordingUploads_Call{Call: _e.mock.On("GetExpiredRecordingUploads", before, limit)}
+}
+
+func (_c *RecordingStoreInterface_GetExpiredRecordingUploads_Call) Run(run func(before time.Time, limit int)) *RecordingStoreInterface_GetExpiredRecordingUploads_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(time.Time), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetExpiredRecordingUploads_Call) Return(_a0 []model.RecordingUpload, _a1 error) *RecordingStoreInterface_GetExpiredRecordingUploads_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetExpiredRecordingUploads_Call) RunAndReturn(run func(time.Time, int) ([]model.RecordingUpload, error)) *RecordingStoreInterface_GetExpiredRecordingUploads_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPlanRecordingMinutes provides a mock function with given fields: workspaceId
+func (_m *RecordingStoreInterface) GetPlanRecordingMinutes(workspaceId int) (int, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (int, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) int); ok {
+		r0 = rf(workspaceId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetPlanRecordingMinutes_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlanRecordingMinutes'
+type RecordingStoreInterface_GetPlanRecordingMinutes_Call struct {
+	*mock.Call
+}
+
+// GetPlanRecordingMinutes is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *RecordingStoreInterface_Expecter) GetPlanRecordingMinutes(workspaceId interface{}) *RecordingStoreInterface_GetPlanRecordingMinutes_Call {
+	return &RecordingStoreInterface_GetPlanRecordingMinutes_Call{Call: _e.mock.On("GetPlanRecordingMinutes", workspaceId)}
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingMinutes_Call) Run(run func(workspaceId int)) *RecordingStoreInterface_GetPlanRecordingMinutes_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingMinutes_Call) Return(_a0 int, _a1 error) *RecordingStoreInterface_GetPlanRecordingMinutes_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingMinutes_Call) RunAndReturn(run func(int) (int, error)) *RecordingStoreInterface_GetPlanRecordingMinutes_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetPlanRecordingSpace provides a mock function with given fields: workspaceId
+func (_m *RecordingStoreInterface) GetPlanRecordingSpace(workspaceId int) (int64, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (int64, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) int64); ok {
+		r0 = rf(workspaceId)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetPlanRecordingSpace_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlanRecordingSpace'
+type RecordingStoreInterface_GetPlanRecordingSpace_Call struct {
+	*mock.Call
+}
+
+// GetPlanRecordingSpace is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *RecordingStoreInterface_Expecter) GetPlanRecordingSpace(workspaceId interface{}) *RecordingStoreInterface_GetPlanRecordingSpace_Call {
+	return &RecordingStoreInterface_GetPlanRecordingSpace_Call{Call: _e.mock.On("GetPlanRecordingSpace", workspaceId)}
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingSpace_Call) Run(run func(workspaceId int)) *RecordingStoreInterface_GetPlanRecordingSpace_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingSpace_Call) Return(_a0 int64, _a1 error) *RecordingStoreInterface_GetPlanRecordingSpace_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetPlanRecordingSpace_Call) RunAndReturn(run func(int) (int64, error)) *RecordingStoreInterface_GetPlanRecordingSpace_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRecordingDuration provides a mock function with given fields: workspaceId
+func (_m *RecordingStoreInterface) GetRecordingDuration(workspaceId int) (int, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (int, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) int); ok {
+		r0 = rf(workspaceId)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetRecordingDuration_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRecordingDuration'
+type RecordingStoreInterface_GetRecordingDuration_Call struct {
+	*mock.Call
+}
+
+// GetRecordingDuration is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *RecordingStoreInterface_Expecter) GetRecordingDuration(workspaceId interface{}) *RecordingStoreInterface_GetRecordingDuration_Call {
+	return &RecordingStoreInterface_GetRecordingDuration_Call{Call: _e.mock.On("GetRecordingDuration", workspaceId)}
+}
+
+func (_c *RecordingStoreInterface_GetRecordingDuration_Call) Run(run func(workspaceId int)) *RecordingStoreInterface_GetRecordingDuration_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingDuration_Call) Return(_a0 int, _a1 error) *RecordingStoreInterface_GetRecordingDuration_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingDuration_Call) RunAndReturn(run func(int) (int, error)) *RecordingStoreInterface_GetRecordingDuration_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetRecordingFromDB provides a mock function with given fields: _a0
 func (_m *RecordingStoreInterface) GetRecordingFromDB(_a0 int) (*model.Recording, error) {
 	ret := _m.Called(_a0)
@@ -179,37 +528,630 @@ func (_c *RecordingStoreInterface_GetRecordingSpace_Call) RunAndReturn(run func(
 	return _c
 }
 
-// UpdateRecording provides a mock function with given fields: _a0, _a1, _a2, _a3
-func (_m *RecordingStoreInterface) UpdateRecording(_a0 string, _a1 string, _a2 int64, _a3 int) error {
-	ret := _m.Called(_a0, _a1, _a2, _a3)
+// GetRecordingUpload provides a mock function with given fields: uploadId
+func (_m *RecordingStoreInterface) GetRecordingUpload(uploadId string) (*model.RecordingUpload, error) {
+	ret := _m.Called(uploadId)
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string, int64, int) error); ok {
-		r0 = rf(_a0, _a1, _a2, _a3)
+	var r0 *model.RecordingUpload
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*model.RecordingUpload, error)); ok {
+		return rf(uploadId)
+	}
+	if rf, ok := ret.Get(0).(func(string) *model.RecordingUpload); ok {
+		r0 = rf(uploadId)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.RecordingUpload)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(uploadId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// RecordingStoreInterface_UpdateRecording_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateRecording'
-type RecordingStoreInterface_UpdateRecording_Call struct {
+// RecordingStoreInterface_GetRecordingUpload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRecordingUpload'
+type RecordingStoreInterface_GetRecordingUpload_Call struct {
 	*mock.Call
 }
 
-// UpdateRecording is a helper method to define mock.On call
-//   - _a0 string
+// GetRecordingUpload is a helper method to define mock.On call
+//   - uploadId string
+func (_e *RecordingStoreInterface_Expecter) GetRecordingUpload(uploadId interface{}) *RecordingStoreInterface_GetRecordingUpload_Call {
+	return &RecordingStoreInterface_GetRecordingUpload_Call{Call: _e.mock.On("GetRecordingUpload", uploadId)}
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUpload_Call) Run(run func(uploadId string)) *RecordingStoreInterface_GetRecordingUpload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUpload_Call) Return(_a0 *model.RecordingUpload, _a1 error) *RecordingStoreInterface_GetRecordingUpload_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUpload_Call) RunAndReturn(run func(string) (*model.RecordingUpload, error)) *RecordingStoreInterface_GetRecordingUpload_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRecordingUploadChunks provides a mock function with given fields: uploadId
+func (_m *RecordingStoreInterface) GetRecordingUploadChunks(uploadId int) ([]model.RecordingUploadChunk, error) {
+	ret := _m.Called(uploadId)
+
+	var r0 []model.RecordingUploadChunk
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) ([]model.RecordingUploadChunk, error)); ok {
+		return rf(uploadId)
+	}
+	if rf, ok := ret.Get(0).(func(int) []model.RecordingUploadChunk); ok {
+		r0 = rf(uploadId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.RecordingUploadChunk)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(uploadId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetRecordingUploadChunks_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRecordingUploadChunks'
+type RecordingStoreInterface_GetRecordingUploadChunks_Call struct {
+	*mock.Call
+}
+
+// GetRecordingUploadChunks is a helper method to define mock.On call
+//   - uploadId int
+func (_e *RecordingStoreInterface_Expecter) GetRecordingUploadChunks(uploadId interface{}) *RecordingStoreInterface_GetRecordingUploadChunks_Call {
+	return &RecordingStoreInterface_GetRecordingUploadChunks_Call{Call: _e.mock.On("GetRecordingUploadChunks", uploadId)}
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUploadChunks_Call) Run(run func(uploadId int)) *RecordingStoreInterface_GetRecordingUploadChunks_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUploadChunks_Call) Return(_a0 []model.RecordingUploadChunk, _a1 error) *RecordingStoreInterface_GetRecordingUploadChunks_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingUploadChunks_Call) RunAndReturn(run func(int) ([]model.RecordingUploadChunk, error)) *RecordingStoreInterface_GetRecordingUploadChunks_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRecordingsForRetention provides a mock function with given fields: workspaceId, createdBefore, includeArchived, keepTags, afterId, limit
+func (_m *RecordingStoreInterface) GetRecordingsForRetention(workspaceId int, createdBefore time.Time, includeArchived bool, keepTags []string, afterId int, limit int) ([]model.Recording, error) {
+	ret := _m.Called(workspaceId, createdBefore, includeArchived, keepTags, afterId, limit)
+
+	var r0 []model.Recording
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int, time.Time, bool, []string, int, int) ([]model.Recording, error)); ok {
+		return rf(workspaceId, createdBefore, includeArchived, keepTags, afterId, limit)
+	}
+	if rf, ok := ret.Get(0).(func(int, time.Time, bool, []string, int, int) []model.Recording); ok {
+		r0 = rf(workspaceId, createdBefore, includeArchived, keepTags, afterId, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.Recording)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int, time.Time, bool, []string, int, int) error); ok {
+		r1 = rf(workspaceId, createdBefore, includeArchived, keepTags, afterId, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetRecordingsForRetention_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRecordingsForRetention'
+type RecordingStoreInterface_GetRecordingsForRetention_Call struct {
+	*mock.Call
+}
+
+// GetRecordingsForRetention is a helper method to define mock.On call
+//   - workspaceId int
+//   - createdBefore time.Time
+//   - includeArchived bool
+//   - keepTags []string
+//   - afterId int
+//   - limit int
+func (_e *RecordingStoreInterface_Expecter) GetRecordingsForRetention(workspaceId interface{}, createdBefore interface{}, includeArchived interface{}, keepTags interface{}, afterId interface{}, limit interface{}) *RecordingStoreInterface_GetRecordingsForRetention_Call {
+	return &RecordingStoreInterface_GetRecordingsForRetention_Call{Call: _e.mock.On("GetRecordingsForRetention", workspaceId, createdBefore, includeArchived, keepTags, afterId, limit)}
+}
+
+func (_c *RecordingStoreInterface_GetRecordingsForRetention_Call) Run(run func(workspaceId int, createdBefore time.Time, includeArchived bool, keepTags []string, afterId int, limit int)) *RecordingStoreInterface_GetRecordingsForRetention_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(time.Time), args[2].(bool), args[3].([]string), args[4].(int), args[5].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingsForRetention_Call) Return(_a0 []model.Recording, _a1 error) *RecordingStoreInterface_GetRecordingsForRetention_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRecordingsForRetention_Call) RunAndReturn(run func(int, time.Time, bool, []string, int, int) ([]model.Recording, error)) *RecordingStoreInterface_GetRecordingsForRetention_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRetentionPolicies provides a mock function with given fields:
+func (_m *RecordingStoreInterface) GetRetentionPolicies() ([]model.RecordingRetentionPolicy, error) {
+	ret := _m.Called()
+
+	var r0 []model.RecordingRetentionPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func() ([]model.RecordingRetentionPolicy, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() []model.RecordingRetentionPolicy); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.RecordingRetentionPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetRetentionPolicies_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRetentionPolicies'
+type RecordingStoreInterface_GetRetentionPolicies_Call struct {
+	*mock.Call
+}
+
+// GetRetentionPolicies is a helper method to define mock.On call
+func (_e *RecordingStoreInterface_Expecter) GetRetentionPolicies() *RecordingStoreInterface_GetRetentionPolicies_Call {
+	return &RecordingStoreInterface_GetRetentionPolicies_Call{Call: _e.mock.On("GetRetentionPolicies")}
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicies_Call) Run(run func()) *RecordingStoreInterface_GetRetentionPolicies_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicies_Call) Return(_a0 []model.RecordingRetentionPolicy, _a1 error) *RecordingStoreInterface_GetRetentionPolicies_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicies_Call) RunAndReturn(run func() ([]model.RecordingRetentionPolicy, error)) *RecordingStoreInterface_GetRetentionPolicies_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetRetentionPolicy provides a mock function with given fields: workspaceId
+func (_m *RecordingStoreInterface) GetRetentionPolicy(workspaceId int) (*model.RecordingRetentionPolicy, error) {
+	ret := _m.Called(workspaceId)
+
+	var r0 *model.RecordingRetentionPolicy
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (*model.RecordingRetentionPolicy, error)); ok {
+		return rf(workspaceId)
+	}
+	if rf, ok := ret.Get(0).(func(int) *model.RecordingRetentionPolicy); ok {
+		r0 = rf(workspaceId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.RecordingRetentionPolicy)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(workspaceId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_GetRetentionPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetRetentionPolicy'
+type RecordingStoreInterface_GetRetentionPolicy_Call struct {
+	*mock.Call
+}
+
+// GetRetentionPolicy is a helper method to define mock.On call
+//   - workspaceId int
+func (_e *RecordingStoreInterface_Expecter) GetRetentionPolicy(workspaceId interface{}) *RecordingStoreInterface_GetRetentionPolicy_Call {
+	return &RecordingStoreInterface_GetRetentionPolicy_Call{Call: _e.mock.On("GetRetentionPolicy", workspaceId)}
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicy_Call) Run(run func(workspaceId int)) *RecordingStoreInterface_GetRetentionPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicy_Call) Return(_a0 *model.RecordingRetentionPolicy, _a1 error) *RecordingStoreInterface_GetRetentionPolicy_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_GetRetentionPolicy_Call) RunAndReturn(run func(int) (*model.RecordingRetentionPolicy, error)) *RecordingStoreInterface_GetRetentionPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// IsUserAllowedToRecord provides a mock function with given fields: _a0
+func (_m *RecordingStoreInterface) IsUserAllowedToRecord(_a0 int) (bool, error) {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(int) (bool, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(int) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_IsUserAllowedToRecord_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsUserAllowedToRecord'
+type RecordingStoreInterface_IsUserAllowedToRecord_Call struct {
+	*mock.Call
+}
+
+// IsUserAllowedToRecord is a helper method to define mock.On call
+//   - _a0 int
+func (_e *RecordingStoreInterface_Expecter) IsUserAllowedToRecord(_a0 interface{}) *RecordingStoreInterface_IsUserAllowedToRecord_Call {
+	return &RecordingStoreInterface_IsUserAllowedToRecord_Call{Call: _e.mock.On("IsUserAllowedToRecord", _a0)}
+}
+
+func (_c *RecordingStoreInterface_IsUserAllowedToRecord_Call) Run(run func(_a0 int)) *RecordingStoreInterface_IsUserAllowedToRecord_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_IsUserAllowedToRecord_Call) Return(_a0 bool, _a1 error) *RecordingStoreInterface_IsUserAllowedToRecord_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_IsUserAllowedToRecord_Call) RunAndReturn(run func(int) (bool, error)) *RecordingStoreInterface_IsUserAllowedToRecord_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListRecordings provides a mock function with given fields: filter
+func (_m *RecordingStoreInterface) ListRecordings(filter *model.RecordingFilter) (*model.RecordingList, error) {
+	ret := _m.Called(filter)
+
+	var r0 *model.RecordingList
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*model.RecordingFilter) (*model.RecordingList, error)); ok {
+		return rf(filter)
+	}
+	if rf, ok := ret.Get(0).(func(*model.RecordingFilter) *model.RecordingList); ok {
+		r0 = rf(filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.RecordingList)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*model.RecordingFilter) error); ok {
+		r1 = rf(filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordingStoreInterface_ListRecordings_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListRecordings'
+type RecordingStoreInterface_ListRecordings_Call struct {
+	*mock.Call
+}
+
+// ListRecordings is a helper method to define mock.On call
+//   - filter *model.RecordingFilter
+func (_e *RecordingStoreInterface_Expecter) ListRecordings(filter interface{}) *RecordingStoreInterface_ListRecordings_Call {
+	return &RecordingStoreInterface_ListRecordings_Call{Call: _e.mock.On("ListRecordings", filter)}
+}
+
+func (_c *RecordingStoreInterface_ListRecordings_Call) Run(run func(filter *model.RecordingFilter)) *RecordingStoreInterface_ListRecordings_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.RecordingFilter))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_ListRecordings_Call) Return(_a0 *model.RecordingList, _a1 error) *RecordingStoreInterface_ListRecordings_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_ListRecordings_Call) RunAndReturn(run func(*model.RecordingFilter) (*model.RecordingList, error)) *RecordingStoreInterface_ListRecordings_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkRecordingArchived provides a mock function with given fields: recordingId
+func (_m *RecordingStoreInterface) MarkRecordingArchived(recordingId int) error {
+	ret := _m.Called(recordingId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(recordingId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_MarkRecordingArchived_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkRecordingArchived'
+type RecordingStoreInterface_MarkRecordingArchived_Call struct {
+	*mock.Call
+}
+
+// MarkRecordingArchived is a helper method to define mock.On call
+//   - recordingId int
+func (_e *RecordingStoreInterface_Expecter) MarkRecordingArchived(recordingId interface{}) *RecordingStoreInterface_MarkRecordingArchived_Call {
+	return &RecordingStoreInterface_MarkRecordingArchived_Call{Call: _e.mock.On("MarkRecordingArchived", recordingId)}
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingArchived_Call) Run(run func(recordingId int)) *RecordingStoreInterface_MarkRecordingArchived_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingArchived_Call) Return(_a0 error) *RecordingStoreInterface_MarkRecordingArchived_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingArchived_Call) RunAndReturn(run func(int) error) *RecordingStoreInterface_MarkRecordingArchived_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// MarkRecordingDeleted provides a mock function with given fields: recordingId
+func (_m *RecordingStoreInterface) MarkRecordingDeleted(recordingId int) error {
+	ret := _m.Called(recordingId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(recordingId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_MarkRecordingDeleted_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'MarkRecordingDeleted'
+type RecordingStoreInterface_MarkRecordingDeleted_Call struct {
+	*mock.Call
+}
+
+// MarkRecordingDeleted is a helper method to define mock.On call
+//   - recordingId int
+func (_e *RecordingStoreInterface_Expecter) MarkRecordingDeleted(recordingId interface{}) *RecordingStoreInterface_MarkRecordingDeleted_Call {
+	return &RecordingStoreInterface_MarkRecordingDeleted_Call{Call: _e.mock.On("MarkRecordingDeleted", recordingId)}
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingDeleted_Call) Run(run func(recordingId int)) *RecordingStoreInterface_MarkRecordingDeleted_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingDeleted_Call) Return(_a0 error) *RecordingStoreInterface_MarkRecordingDeleted_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_MarkRecordingDeleted_Call) RunAndReturn(run func(int) error) *RecordingStoreInterface_MarkRecordingDeleted_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SaveRetentionPolicy provides a mock function with given fields: policy
+func (_m *RecordingStoreInterface) SaveRetentionPolicy(policy *model.RecordingRetentionPolicy) error {
+	ret := _m.Called(policy)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*model.RecordingRetentionPolicy) error); ok {
+		r0 = rf(policy)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_SaveRetentionPolicy_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SaveRetentionPolicy'
+type RecordingStoreInterface_SaveRetentionPolicy_Call struct {
+	*mock.Call
+}
+
+// SaveRetentionPolicy is a helper method to define mock.On call
+//   - policy *model.RecordingRetentionPolicy
+func (_e *RecordingStoreInterface_Expecter) SaveRetentionPolicy(policy interface{}) *RecordingStoreInterface_SaveRetentionPolicy_Call {
+	return &RecordingStoreInterface_SaveRetentionPolicy_Call{Call: _e.mock.On("SaveRetentionPolicy", policy)}
+}
+
+func (_c *RecordingStoreInterface_SaveRetentionPolicy_Call) Run(run func(policy *model.RecordingRetentionPolicy)) *RecordingStoreInterface_SaveRetentionPolicy_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(*model.RecordingRetentionPolicy))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SaveRetentionPolicy_Call) Return(_a0 error) *RecordingStoreInterface_SaveRetentionPolicy_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SaveRetentionPolicy_Call) RunAndReturn(run func(*model.RecordingRetentionPolicy) error) *RecordingStoreInterface_SaveRetentionPolicy_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetRecordingStatus provides a mock function with given fields: _a0, _a1
+func (_m *RecordingStoreInterface) SetRecordingStatus(_a0 int, _a1 string) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_SetRecordingStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetRecordingStatus'
+type RecordingStoreInterface_SetRecordingStatus_Call struct {
+	*mock.Call
+}
+
+// SetRecordingStatus is a helper method to define mock.On call
+//   - _a0 int
+//   - _a1 string
+func (_e *RecordingStoreInterface_Expecter) SetRecordingStatus(_a0 interface{}, _a1 interface{}) *RecordingStoreInterface_SetRecordingStatus_Call {
+	return &RecordingStoreInterface_SetRecordingStatus_Call{Call: _e.mock.On("SetRecordingStatus", _a0, _a1)}
+}
+
+func (_c *RecordingStoreInterface_SetRecordingStatus_Call) Run(run func(_a0 int, _a1 string)) *RecordingStoreInterface_SetRecordingStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SetRecordingStatus_Call) Return(_a0 error) *RecordingStoreInterface_SetRecordingStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SetRecordingStatus_Call) RunAndReturn(run func(int, string) error) *RecordingStoreInterface_SetRecordingStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetRecordingUploadStatus provides a mock function with given fields: uploadId, status
+func (_m *RecordingStoreInterface) SetRecordingUploadStatus(uploadId int, status string) error {
+	ret := _m.Called(uploadId, status)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string) error); ok {
+		r0 = rf(uploadId, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_SetRecordingUploadStatus_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetRecordingUploadStatus'
+type RecordingStoreInterface_SetRecordingUploadStatus_Call struct {
+	*mock.Call
+}
+
+// SetRecordingUploadStatus is a helper method to define mock.On call
+//   - uploadId int
+//   - status string
+func (_e *RecordingStoreInterface_Expecter) SetRecordingUploadStatus(uploadId interface{}, status interface{}) *RecordingStoreInterface_SetRecordingUploadStatus_Call {
+	return &RecordingStoreInterface_SetRecordingUploadStatus_Call{Call: _e.mock.On("SetRecordingUploadStatus", uploadId, status)}
+}
+
+func (_c *RecordingStoreInterface_SetRecordingUploadStatus_Call) Run(run func(uploadId int, status string)) *RecordingStoreInterface_SetRecordingUploadStatus_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SetRecordingUploadStatus_Call) Return(_a0 error) *RecordingStoreInterface_SetRecordingUploadStatus_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *RecordingStoreInterface_SetRecordingUploadStatus_Call) RunAndReturn(run func(int, string) error) *RecordingStoreInterface_SetRecordingUploadStatus_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateRecording provides a mock function with given fields: _a0, _a1, _a2, _a3, _a4
+func (_m *RecordingStoreInterface) UpdateRecording(_a0 int, _a1 string, _a2 int64, _a3 string, _a4 *model.RecordingAudio) error {
+	ret := _m.Called(_a0, _a1, _a2, _a3, _a4)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, string, int64, string, *model.RecordingAudio) error); ok {
+		r0 = rf(_a0, _a1, _a2, _a3, _a4)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RecordingStoreInterface_UpdateRecording_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateRecording'
+type RecordingStoreInterface_UpdateRecording_Call struct {
+	*mock.Call
+}
+
+// UpdateRecording is a helper method to define mock.On call
+//   - _a0 int
 //   - _a1 string
 //   - _a2 int64
-//   - _a3 int
-func (_e *RecordingStoreInterface_Expecter) UpdateRecording(_a0 interface{}, _a1 interface{}, _a2 interface{}, _a3 interface{}) *RecordingStoreInterface_UpdateRecording_Call {
-	return &RecordingStoreInterface_UpdateRecording_Call{Call: _e.mock.On("UpdateRecording", _a0, _a1, _a2, _a3)}
+//   - _a3 string
+//   - _a4 *model.RecordingAudio
+func (_e *RecordingStoreInterface_Expecter) UpdateRecording(_a0 interface{}, _a1 interface{}, _a2 interface{}, _a3 interface{}, _a4 interface{}) *RecordingStoreInterface_UpdateRecording_Call {
+	return &RecordingStoreInterface_UpdateRecording_Call{Call: _e.mock.On("UpdateRecording", _a0, _a1, _a2, _a3, _a4)}
 }
 
-func (_c *RecordingStoreInterface_UpdateRecording_Call) Run(run func(_a0 string, _a1 string, _a2 int64, _a3 int)) *RecordingStoreInterface_UpdateRecording_Call {
+func (_c *RecordingStoreInterface_UpdateRecording_Call) Run(run func(_a0 int, _a1 string, _a2 int64, _a3 string, _a4 *model.RecordingAudio)) *RecordingStoreInterface_UpdateRecording_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(string), args[1].(string), args[2].(int64), args[3].(int))
+		run(args[0].(int), args[1].(string), args[2].(int64), args[3].(string), args[4].(*model.RecordingAudio))
 	})
 	return _c
 }
@@ -219,7 +1161,7 @@ func (_c *RecordingStoreInterface_UpdateRecording_Call) Return(_a0 error) *Recor
 	return _c
 }
 
-func (_c *RecordingStoreInterface_UpdateRecording_Call) RunAndReturn(run func(string, string, int64, int) error) *RecordingStoreInterface_UpdateRecording_Call {
+func (_c *RecordingStoreInterface_UpdateRecording_Call) RunAndReturn(run func(int, string, int64, string, *model.RecordingAudio) error) *RecordingStoreInterface_UpdateRecording_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -3,12 +3,10 @@
 package mocks
 
 import (
+	sql "database/sql"
 	lineblocs "github.com/Lineblocs/go-helpers"
 	mock "github.com/stretchr/testify/mock"
-
 	model "lineblocs.com/api/model"
-
-	sql "database/sql"
 )
 
 // UserStoreInterface is an autogenerated mock type for the UserStoreInterface type
@@ -797,19 +795,19 @@ func (_c *UserStoreInterface_GetFlowInfo_Call) RunAndReturn(run func(string, str
 }
 
 // GetSettings provides a mock function with given fields:
-func (_m *UserStoreInterface) GetSettings() (*model.Settings, error) {
+func (_m *UserStoreInterface) GetSettings() (*model.APICredentials, error) {
 	ret := _m.Called()
 
-	var r0 *model.Settings
+	var r0 *model.APICredentials
 	var r1 error
-	if rf, ok := ret.Get(0).(func() (*model.Settings, error)); ok {
+	if rf, ok := ret.Get(0).(func() (*model.APICredentials, error)); ok {
 		return rf()
 	}
-	if rf, ok := ret.Get(0).(func() *model.Settings); ok {
+	if rf, ok := ret.Get(0).(func() *model.APICredentials); ok {
 		r0 = rf()
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*model.Settings)
+			r0 = ret.Get(0).(*model.APICredentials)
 		}
 	}
 
@@ -839,12 +837,12 @@ func (_c *UserStoreInterface_GetSettings_Call) Run(run func()) *UserStoreInterfa
 	return _c
 }
 
-func (_c *UserStoreInterface_GetSettings_Call) Return(_a0 *model.Settings, _a1 error) *UserStoreInterface_GetSettings_Call {
+func (_c *UserStoreInterface_GetSettings_Call) Return(_a0 *model.APICredentials, _a1 error) *UserStoreInterface_GetSettings_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *UserStoreInterface_GetSettings_Call) RunAndReturn(run func() (*model.Settings, error)) *UserStoreInterface_GetSettings_Call {
+func (_c *UserStoreInterface_GetSettings_Call) RunAndReturn(run func() (*model.APICredentials, error)) *UserStoreInterface_GetSettings_Call {
 	_c.Call.Return(run)
 	return _c
 }
@@ -1437,6 +1435,58 @@ func (_c *UserStoreInterface_IncomingTrunkValidation_Call) RunAndReturn(run func
 	return _c
 }
 
+// IsAccountSuspended provides a mock function with given fields: _a0
+func (_m *UserStoreInterface) IsAccountSuspended(_a0 string) (bool, error) {
+	ret := _m.Called(_a0)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (bool, error)); ok {
+		return rf(_a0)
+	}
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserStoreInterface_IsAccountSuspended_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'IsAccountSuspended'
+type UserStoreInterface_IsAccountSuspended_Call struct {
+	*mock.Call
+}
+
+// IsAccountSuspended is a helper method to define mock.On call
+//   - _a0 string
+func (_e *UserStoreInterface_Expecter) IsAccountSuspended(_a0 interface{}) *UserStoreInterface_IsAccountSuspended_Call {
+	return &UserStoreInterface_IsAccountSuspended_Call{Call: _e.mock.On("IsAccountSuspended", _a0)}
+}
+
+func (_c *UserStoreInterface_IsAccountSuspended_Call) Run(run func(_a0 string)) *UserStoreInterface_IsAccountSuspended_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *UserStoreInterface_IsAccountSuspended_Call) Return(_a0 bool, _a1 error) *UserStoreInterface_IsAccountSuspended_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *UserStoreInterface_IsAccountSuspended_Call) RunAndReturn(run func(string) (bool, error)) *UserStoreInterface_IsAccountSuspended_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // LogCallByeEvent provides a mock function with given fields: _a0
 func (_m *UserStoreInterface) LogCallByeEvent(_a0 string) error {
 	ret := _m.Called(_a0)
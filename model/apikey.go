@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// APIKey authenticates a microservice with the scopes it is granted. Only the
+// SHA-256 Hash of the key is stored, Prefix identifies it in listings and logs.
+type APIKey struct {
+	Id      int      `json:"id"`
+	Service string   `json:"service"`
+	Prefix  string   `json:"prefix"`
+	Hash    string   `json:"-"`
+	Scopes  []string `json:"scopes"`
+	// ExpiresAt ends the validity of the key, keys without it do not expire
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	// Token is the key itself, it is only returned when the key is created
+	Token string `json:"token,omitempty"`
+}
+
+// APIKeyRotation replaces the key Id with a new key, the replaced key stays
+// valid for Overlap e.g. "24h", or is revoked right away when it is "0s".
+type APIKeyRotation struct {
+	Id      int    `json:"id"`
+	Overlap string `json:"overlap"`
+}
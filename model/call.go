@@ -1,5 +1,10 @@
 package model
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 type Call struct {
 	From         string `json:"from"`
 	To           string `json:"to"`
@@ -13,11 +18,19 @@ type Call struct {
 	SourceIp     string `json:"source_ip"`
 	ChannelId    string `json:"channel_id"`
 	SIPCallId    string `json:"sip_call_id"`
+	ParentCallId int    `json:"parent_call_id"`
+	SessionId    string `json:"session_id"`
 	StartedAt    string `json:"started_at"`
+	RingingAt    string `json:"ringing_at"`
+	AnsweredAt   string `json:"answered_at"`
 	CreatedAt    string `json:"created_at"`
 	UpdatedAt    string `json:"updated_at"`
 	EndedAt      string `json:"ended_at"`
 	PlanSnapshot string `json:"plan_snapshot"`
+	// Extension and TrunkId are not stored with the call, they only scope
+	// the concurrent call limits while the call is active
+	Extension    string `json:"extension,omitempty"`
+	TrunkId      int    `json:"trunk_id,omitempty"`
 }
 
 type CallUpdate struct {
@@ -27,6 +40,131 @@ type CallUpdate struct {
 	SourceIp string `json:"source_ip"`
 }
 
+type CallLeg struct {
+	Call
+	// Cents charged for the leg, every leg is billed at its own rate
+	Cents float64    `json:"cents"`
+	Legs  []*CallLeg `json:"legs"`
+}
+
+type CallSession struct {
+	SessionId       string     `json:"session_id"`
+	WorkspaceId     int        `json:"workspace_id"`
+	LegCount        int        `json:"leg_count"`
+	BillableSeconds int        `json:"billable_seconds"`
+	Cents           float64    `json:"cents"`
+	StartedAt       string     `json:"started_at"`
+	EndedAt         string     `json:"ended_at"`
+	Legs            []*CallLeg `json:"legs"`
+}
+
+type ActiveCall struct {
+	CallId      int    `json:"call_id,string"`
+	APIId       string `json:"api_id"`
+	WorkspaceId int    `json:"workspace_id"`
+	Extension   string `json:"extension"`
+	TrunkId     int    `json:"trunk_id"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Direction   string `json:"direction"`
+	Status      string `json:"status"`
+	StartedAt   string `json:"started_at"`
+}
+
+type ActiveCallSummary struct {
+	WorkspaceId int          `json:"workspace_id"`
+	Count       int          `json:"count"`
+	Limit       int          `json:"limit"`
+	Calls       []ActiveCall `json:"calls"`
+}
+
+// CallLimits holds the maximum number of concurrent calls, 0 means unlimited.
+type CallLimits struct {
+	Workspace int `json:"workspace"`
+	Extension int `json:"extension"`
+	Trunk     int `json:"trunk"`
+}
+
 type CallRate struct {
 	CallRate float64
 }
+
+// Ids of calls stored in Cassandra do not fit in a JavaScript number, so
+// call ids are written to JSON as strings. Numbers are still read.
+
+type callFields Call
+
+type callJSON struct {
+	callFields
+	Id           string `json:"id"`
+	ParentCallId string `json:"parent_call_id"`
+}
+
+func newCallJSON(c Call) callJSON {
+	return callJSON{
+		callFields:   callFields(c),
+		Id:           strconv.Itoa(c.Id),
+		ParentCallId: strconv.Itoa(c.ParentCallId),
+	}
+}
+
+func (c Call) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newCallJSON(c))
+}
+
+func (c *Call) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*callFields
+		Id           json.Number `json:"id"`
+		ParentCallId json.Number `json:"parent_call_id"`
+	}{callFields: (*callFields)(c)}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	c.Id, err = jsonId(aux.Id)
+	if err != nil {
+		return err
+	}
+	c.ParentCallId, err = jsonId(aux.ParentCallId)
+	return err
+}
+
+func (l CallLeg) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		callJSON
+		Cents float64    `json:"cents"`
+		Legs  []*CallLeg `json:"legs"`
+	}{newCallJSON(l.Call), l.Cents, l.Legs})
+}
+
+func (u CallUpdate) MarshalJSON() ([]byte, error) {
+	type update CallUpdate
+	return json.Marshal(struct {
+		update
+		CallId string `json:"call_id"`
+	}{update(u), strconv.Itoa(u.CallId)})
+}
+
+func (u *CallUpdate) UnmarshalJSON(data []byte) error {
+	type update CallUpdate
+	aux := struct {
+		*update
+		CallId json.Number `json:"call_id"`
+	}{update: (*update)(u)}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	u.CallId, err = jsonId(aux.CallId)
+	return err
+}
+
+// jsonId reads a call id written as a number or a string, missing ids are 0.
+func jsonId(n json.Number) (int, error) {
+	if n == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(string(n), 10, 64)
+	return int(id), err
+}
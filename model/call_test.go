@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallJSON(t *testing.T) {
+	t.Run("Should write call ids as strings", func(t *testing.T) {
+		// larger than a JavaScript number can hold exactly
+		call := Call{Id: 9007199254740993, ParentCallId: 7, From: "+15550100"}
+
+		body, err := json.Marshal(call)
+		assert.NoError(t, err)
+
+		var fields map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &fields))
+		assert.Equal(t, "9007199254740993", fields["id"])
+		assert.Equal(t, "7", fields["parent_call_id"])
+		assert.Equal(t, "+15550100", fields["from"])
+	})
+
+	t.Run("Should read call ids written as numbers or strings", func(t *testing.T) {
+		var call Call
+		err := json.Unmarshal([]byte(`{"id":"9007199254740993","parent_call_id":7,"to":"+15550199"}`), &call)
+		assert.NoError(t, err)
+		assert.Equal(t, 9007199254740993, call.Id)
+		assert.Equal(t, 7, call.ParentCallId)
+		assert.Equal(t, "+15550199", call.To)
+
+		var update CallUpdate
+		err = json.Unmarshal([]byte(`{"call_id":"12","status":"ENDED"}`), &update)
+		assert.NoError(t, err)
+		assert.Equal(t, 12, update.CallId)
+		assert.Equal(t, "ENDED", update.Status)
+	})
+
+	t.Run("Should keep the legs of a call leg", func(t *testing.T) {
+		leg := CallLeg{Call: Call{Id: 1}, Cents: 1.5, Legs: []*CallLeg{{Call: Call{Id: 2, ParentCallId: 1}}}}
+
+		body, err := json.Marshal(leg)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `"1"`, string(mustField(t, body, "id")))
+		assert.JSONEq(t, `1.5`, string(mustField(t, body, "cents")))
+		assert.Contains(t, string(body), `"parent_call_id":"1"`)
+	})
+}
+
+func mustField(t *testing.T, body []byte, name string) json.RawMessage {
+	var fields map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(body, &fields))
+	return fields[name]
+}
@@ -1,7 +1,43 @@
 package model
 
 type Conference struct {
-	Name        string `json:"name"`
-	WorkspaceId int    `json:"workspace_id"`
-	APIId       string `json:"api_id"`
+	Id           int                     `json:"id"`
+	Name         string                  `json:"name"`
+	WorkspaceId  int                     `json:"workspace_id"`
+	APIId        string                  `json:"api_id"`
+	Pin          string                  `json:"pin"`
+	ModeratorPin string                  `json:"moderator_pin"`
+	Status       string                  `json:"status"`
+	StartedAt    string                  `json:"started_at"`
+	EndedAt      string                  `json:"ended_at"`
+	Participants []ConferenceParticipant `json:"participants"`
+}
+
+type ConferenceParticipant struct {
+	Id           int    `json:"id"`
+	ConferenceId int    `json:"conference_id"`
+	CallId       int    `json:"call_id"`
+	WorkspaceId  int    `json:"workspace_id"`
+	Muted        bool   `json:"muted"`
+	Moderator    bool   `json:"moderator"`
+	Status       string `json:"status"`
+	JoinedAt     string `json:"joined_at"`
+	LeftAt       string `json:"left_at"`
+	Duration     int    `json:"duration"`
+}
+
+type ConferenceJoin struct {
+	ConferenceId int    `json:"conference_id"`
+	CallId       int    `json:"call_id"`
+	Pin          string `json:"pin"`
+	Muted        bool   `json:"muted"`
+}
+
+// ConferenceParticipantUpdate changes the flags of a participant, Pin must
+// be the moderator PIN of the conference to change Moderator.
+type ConferenceParticipantUpdate struct {
+	CallId    int    `json:"call_id"`
+	Muted     *bool  `json:"muted"`
+	Moderator *bool  `json:"moderator"`
+	Pin       string `json:"pin"`
 }
@@ -22,6 +22,7 @@ type Debit struct {
 type DebitAPIParams struct {
 	Length          int     `json:"length"`
 	RecordingLength float64 `json:"recording_length"`
+	Pages           int     `json:"pages"`
 }
 type DebitAPI struct {
 	UserId      int            `json:"user_id"`
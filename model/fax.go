@@ -1,9 +1,71 @@
 package model
 
+import "time"
+
 type Fax struct {
+	Id          int    `json:"id"`
 	UserId      int    `json:"user_id"`
 	WorkspaceId int    `json:"workspace_id"`
 	CallId      int    `json:"call_id"`
 	Uri         string `json:"uri"`
 	APIId       string `json:"api_id"`
+	ContentType string `json:"content_type"`
+	Pages       int    `json:"pages"`
+	Resolution  string `json:"resolution"`
+	Status      string `json:"status"`
+	Direction   string `json:"direction"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	// ReasonCode is the T.30 error code of a failed transmission, Reason its description
+	ReasonCode int    `json:"reason_code"`
+	Reason     string `json:"reason"`
+	Attempts   int    `json:"attempts"`
+	// EmailTo receives an inbound fax as a PDF attachment, EmailStatus tracks the delivery
+	EmailTo     []string `json:"email_to,omitempty"`
+	EmailStatus string   `json:"email_status,omitempty"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+
+	// set for claimed email deliveries
+	Name          string `json:"-"`
+	EmailAttempts int    `json:"-"`
+	EmailLocale   string `json:"-"`
+}
+
+// FaxStatusUpdate is reported by the media server while a fax is transmitted.
+type FaxStatusUpdate struct {
+	WorkspaceId int    `json:"workspace_id"`
+	FaxId       int    `json:"fax_id"`
+	Status      string `json:"status"`
+	ReasonCode  int    `json:"reason_code"`
+	Reason      string `json:"reason"`
+}
+
+// FaxFilter selects the faxes of a workspace listed by ListFaxes. Unset
+// fields do not filter, Number matches the sending or receiving number.
+type FaxFilter struct {
+	WorkspaceId int
+	Direction   string
+	Status      string
+	Number      string
+	CallId      *int
+	From        *time.Time
+	To          *time.Time
+	// BeforeId and Limit select the page, faxes are listed newest first
+	BeforeId int
+	Limit    int
+}
+
+type FaxList struct {
+	Faxes      []Fax  `json:"faxes"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// FaxEmailSettings turn on fax-to-email for the inbound faxes of a workspace.
+type FaxEmailSettings struct {
+	WorkspaceId int      `json:"workspace_id"`
+	Enabled     bool     `json:"enabled"`
+	Recipients  []string `json:"recipients"`
+	// Locale of the emails, e.g. "fr-CA", empty is English
+	Locale string `json:"locale"`
 }
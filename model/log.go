@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Log struct {
 	UserId      int     `json:"user_id"`
 	WorkspaceId int     `json:"workspace_id"`
@@ -21,3 +23,37 @@ type LogRoutine struct {
 	From        string
 	To          string
 }
+
+// DebuggerLog is a stored debugger log of a workspace, FlowId is 0 for logs without a flow.
+type DebuggerLog struct {
+	Id          int    `json:"id"`
+	APIId       string `json:"api_id"`
+	WorkspaceId int    `json:"workspace_id"`
+	FlowId      int    `json:"flow_id"`
+	Level       string `json:"level"`
+	Title       string `json:"title"`
+	Report      string `json:"report"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// LogFilter selects the debugger logs listed by ListLogs. Unset fields do
+// not filter, From and To match the numbers of the call that was logged.
+type LogFilter struct {
+	WorkspaceId int
+	Level       string
+	FlowId      *int
+	From        string
+	To          string
+	Start       *time.Time
+	End         *time.Time
+	// BeforeId and Limit select the page, logs are listed newest first
+	BeforeId int
+	Limit    int
+}
+
+type LogList struct {
+	Logs       []DebuggerLog `json:"logs"`
+	NextCursor string        `json:"next_cursor"`
+}
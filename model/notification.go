@@ -0,0 +1,28 @@
+package model
+
+// NotificationChannel delivers the debugger alerts of a workspace at or above MinLevel.
+type NotificationChannel struct {
+	Id          int    `json:"id"`
+	WorkspaceId int    `json:"workspace_id"`
+	Type        string `json:"type"`
+	MinLevel    string `json:"min_level"`
+	// Target holds the comma separated addresses of email channels and the URL of slack and webhook channels
+	Target string `json:"target"`
+	// Secret signs webhook deliveries, it is only returned when the channel is saved
+	Secret string `json:"secret,omitempty"`
+}
+
+// NotificationTemplate lists the locales a notification type is translated to.
+type NotificationTemplate struct {
+	Type    string   `json:"type"`
+	Locales []string `json:"locales"`
+}
+
+// NotificationPreview is a notification rendered with sample data.
+type NotificationPreview struct {
+	Type    string `json:"type"`
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
@@ -0,0 +1,36 @@
+package model
+
+type ObjectAccessLog struct {
+	WorkspaceId int    `json:"workspace_id"`
+	ObjectType  string `json:"object_type"`
+	ObjectId    int    `json:"object_id"`
+	Key         string `json:"key"`
+	Action      string `json:"action"`
+	RemoteIp    string `json:"remote_ip"`
+	UserAgent   string `json:"user_agent"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+type DownloadURL struct {
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ObjectDataKey is the data key an encrypted object was encrypted with,
+// wrapped by the workspace key KeyId.
+type ObjectDataKey struct {
+	Id          int
+	WorkspaceId int
+	Key         string
+	KeyId       string
+	Algorithm   string
+	WrappedKey  []byte
+}
+
+// KeyRotation reports the new storage key of a workspace and how many data
+// keys were wrapped with it.
+type KeyRotation struct {
+	WorkspaceId int    `json:"workspace_id"`
+	KeyId       string `json:"key_id"`
+	Rewrapped   int    `json:"rewrapped"`
+}
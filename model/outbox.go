@@ -0,0 +1,24 @@
+package model
+
+// OutboxEvent is a message waiting in the event_outbox table to be published to the queue.
+type OutboxEvent struct {
+	Id        int64  `json:"id"`
+	Queue     string `json:"queue"`
+	EventType string `json:"event_type"`
+	Payload   []byte `json:"payload"`
+	Attempts  int    `json:"attempts"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CallActivityTask is the payload published to the call_activity queue.
+// APIID identifies the call when it was rejected before being created,
+// RunID is then "0".
+type CallActivityTask struct {
+	RunID       string `json:"run_id"`
+	APIID       string `json:"api_id"`
+	EventType   string `json:"event_type"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	WorkspaceID string `json:"workspace_id"`
+	Description string `json:"description"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Recording struct {
 	Id                 int       `json:"id"`
 	UserId             int       `json:"user_id"`
@@ -7,12 +9,20 @@ type Recording struct {
 	Size               int       `json:"size"`
 	WorkspaceId        int       `json:"workspace_id"`
 	APIId              string    `json:"api_id"`
+	Status             string    `json:"status"`
+	Uri                string    `json:"uri"`
 	Tags               *[]string `json:"tags"`
 	Trim               bool      `json:"trim"`
+	Transcribe         bool      `json:"transcribe"`
 	TranscriptionReady bool      `json:"transcription_ready"`
 	TranscriptionText  string    `json:"transcription_text"`
 	StorageId          string    `json:"storage_id"`
 	StorageServerIp    string    `json:"storage_server_ip"`
+	Duration           int       `json:"duration"`
+	Codec              string    `json:"codec"`
+	SampleRate         int       `json:"sample_rate"`
+	Channels           int       `json:"channels"`
+	CreatedAt          string    `json:"created_at,omitempty"`
 }
 
 type RecordingStatus struct {
@@ -20,8 +30,75 @@ type RecordingStatus struct {
 	Status    string    `json:"status"`
 }
 
+// RecordingAudio is read from the header of a stored recording, Duration is
+// in whole seconds rounded up.
+type RecordingAudio struct {
+	Duration   int
+	Codec      string
+	SampleRate int
+	Channels   int
+}
+
 type RecordingTranscription struct {
 	RecordingId int    `json:"recording_id"`
 	Ready       bool   `json:"ready"`
 	Text        string `json:"text"`
 }
+
+// RecordingRetentionPolicy holds the retention rules of a workspace, a
+// rule is disabled when its number of days is 0.
+type RecordingRetentionPolicy struct {
+	WorkspaceId      int      `json:"workspace_id"`
+	ArchiveAfterDays int      `json:"archive_after_days"`
+	DeleteAfterDays  int      `json:"delete_after_days"`
+	KeepTags         []string `json:"keep_tags"`
+}
+
+// RecordingFilter selects the recordings of a workspace listed by
+// ListRecordings. Unset fields do not filter, a recording must carry every tag.
+type RecordingFilter struct {
+	WorkspaceId int
+	Tags        []string
+	CallId      *int
+	Status      string
+	From        *time.Time
+	To          *time.Time
+	// Text is searched for in the transcriptions
+	Text string
+	// BeforeId and Limit select the page, recordings are listed newest first
+	BeforeId int
+	Limit    int
+}
+
+type RecordingList struct {
+	Recordings []Recording `json:"recordings"`
+	NextCursor string      `json:"next_cursor"`
+}
+
+// RecordingUpload tracks a resumable upload of the audio of a recording.
+// Size and Checksum, the hex SHA-256 of the whole file, are optional.
+type RecordingUpload struct {
+	Id          int    `json:"-"`
+	UploadId    string `json:"upload_id"`
+	RecordingId int    `json:"recording_id"`
+	WorkspaceId int    `json:"workspace_id"`
+	Size        int64  `json:"size"`
+	Received    int64  `json:"received"`
+	Checksum    string `json:"checksum"`
+	ContentType string `json:"content_type"`
+	Status      string `json:"status"`
+	ExpiresAt   string `json:"expires_at"`
+}
+
+// RecordingUploadChunk is a received part of an upload, Checksum is its hex SHA-256.
+type RecordingUploadChunk struct {
+	Offset   int64  `json:"offset"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+type RecordingUploadComplete struct {
+	UploadId string `json:"upload_id"`
+	Status   string `json:"status"`
+	Checksum string `json:"checksum"`
+}
@@ -0,0 +1,24 @@
+package model
+
+type SIPReport struct {
+	SIPCallId    string `json:"sip_call_id"`
+	Code         int    `json:"code"`
+	Reason       string `json:"reason"`
+	ProviderHost string `json:"provider_host"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type HangupCause struct {
+	SIPCode  int    `json:"sip_code"`
+	Q850Code int    `json:"q850_code"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+}
+
+type SIPReportHistory struct {
+	SIPCallId   string       `json:"sip_call_id"`
+	Events      []SIPReport  `json:"events"`
+	FinalCode   int          `json:"final_code"`
+	HangupCause *HangupCause `json:"hangup_cause"`
+	PDDMillis   *int64       `json:"pdd_ms"`
+}
@@ -0,0 +1,70 @@
+package model
+
+// TranscriptionJob tracks the transcription of a recording. Plan is the plan
+// of the workspace and Duration the length of the recording in seconds, they
+// are needed to bill the job.
+type TranscriptionJob struct {
+	Id          int     `json:"id"`
+	RecordingId int     `json:"recording_id"`
+	WorkspaceId int     `json:"workspace_id"`
+	UserId      int     `json:"user_id"`
+	APIId       string  `json:"api_id"`
+	Provider    string  `json:"provider"`
+	Status      string  `json:"status"`
+	Attempts    int     `json:"attempts"`
+	LastError   string  `json:"last_error,omitempty"`
+	Language    string  `json:"language,omitempty"`
+	Duration    float64 `json:"duration"`
+	Plan        string  `json:"-"`
+	CreatedAt   string  `json:"created_at,omitempty"`
+	CompletedAt string  `json:"completed_at,omitempty"`
+}
+
+// TranscriptionRequest queues a recording, an empty language is detected.
+type TranscriptionRequest struct {
+	RecordingId int    `json:"recording_id"`
+	Language    string `json:"language"`
+}
+
+// TranscriptionWord is a recognised word with its offsets in seconds.
+type TranscriptionWord struct {
+	Word       string  `json:"word"`
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Confidence float64 `json:"confidence"`
+}
+
+// TranscriptionResult is returned by a Transcriber. Duration is the length
+// of the audio in seconds as reported by the vendor, it is only billed for
+// recordings whose length was not measured when they were stored.
+type TranscriptionResult struct {
+	Text     string              `json:"text"`
+	Language string              `json:"language"`
+	Duration float64             `json:"duration"`
+	Words    []TranscriptionWord `json:"words"`
+}
+
+// Transcription is a job with the result of a completed transcription.
+type Transcription struct {
+	Job    TranscriptionJob     `json:"job"`
+	Result *TranscriptionResult `json:"result"`
+}
+
+// TranscriptionWebhookTarget is a transcription webhook of a workspace,
+// deliveries are signed with the API secret of the workspace.
+type TranscriptionWebhookTarget struct {
+	URL    string `json:"url"`
+	Secret string `json:"-"`
+}
+
+// TranscriptionWebhook is posted to the transcription webhooks of a workspace.
+type TranscriptionWebhook struct {
+	Event       string  `json:"event"`
+	WorkspaceId int     `json:"workspace_id"`
+	RecordingId int     `json:"recording_id"`
+	APIId       string  `json:"api_id"`
+	JobId       int     `json:"job_id"`
+	Language    string  `json:"language"`
+	Duration    float64 `json:"duration"`
+	Text        string  `json:"text"`
+}
@@ -6,6 +6,8 @@ type User struct {
 	FirstName string
 	LastName  string
 	Email     string
+	// Locale of the notifications of the user, e.g. "fr-CA", empty is English
+	Locale string
 }
 
 type PSTNInfo struct {
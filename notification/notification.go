@@ -0,0 +1,233 @@
+// Package notification renders the messages Lineblocs sends to users from
+// templates. Every notification type has a subject, a text body and an
+// optional HTML body per locale, the HTML body escapes its data.
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Notification types.
+const (
+	TypeDebuggerAlert        = "debugger_alert"
+	TypeAdminError           = "admin_error"
+	TypeFirstCall            = "first_call"
+	TypeFaxReceived          = "fax_received"
+	TypeCallerIdVerifyFailed = "callerid_verify_failed"
+	// TypeLogEvent describes debugger logs of types without their own template
+	TypeLogEvent = "log_event"
+)
+
+// DefaultLocale is used for users without a locale and locales without templates.
+const DefaultLocale = "en"
+
+var ErrUnknownType = errors.New("unknown notification type")
+
+// Message is a rendered notification.
+type Message struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html,omitempty"`
+}
+
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Registry holds the templates of notification types by locale and the
+// sample data their previews are rendered with.
+type Registry struct {
+	templates map[string]map[string]*localized
+	samples   map[string]interface{}
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		templates: make(map[string]map[string]*localized),
+		samples:   make(map[string]interface{}),
+	}
+}
+
+// Parse adds the template of a type in a locale. source defines the
+// "subject" and "text" templates and optionally an "html" template.
+func (r *Registry) Parse(notificationType string, locale string, source string) error {
+	name := notificationType + "." + locale
+	text, err := texttemplate.New(name).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return err
+	}
+	for _, required := range []string{"subject", "text"} {
+		if text.Lookup(required) == nil {
+			return fmt.Errorf("template %s does not define %q", name, required)
+		}
+	}
+	template := &localized{text: text}
+	if text.Lookup("html") != nil {
+		template.html, err = htmltemplate.New(name).Option("missingkey=error").Parse(source)
+		if err != nil {
+			return err
+		}
+	}
+	if r.templates[notificationType] == nil {
+		r.templates[notificationType] = make(map[string]*localized)
+	}
+	r.templates[notificationType][normalizeLocale(locale)] = template
+	return nil
+}
+
+// SetSample sets the data previews of a type are rendered with.
+func (r *Registry) SetSample(notificationType string, data interface{}) {
+	r.samples[notificationType] = data
+}
+
+// Types returns the registered notification types.
+func (r *Registry) Types() []string {
+	types := make([]string, 0, len(r.templates))
+	for notificationType := range r.templates {
+		types = append(types, notificationType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Locales returns the locales a type has templates for.
+func (r *Registry) Locales(notificationType string) []string {
+	locales := []string{}
+	for locale := range r.templates[notificationType] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render renders a type in the closest locale available: "fr-CA" falls back
+// to "fr" and then to the default locale.
+func (r *Registry) Render(notificationType string, locale string, data interface{}) (*Message, error) {
+	locales, ok := r.templates[notificationType]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, notificationType)
+	}
+	template := locales[DefaultLocale]
+	locale = normalizeLocale(locale)
+	if found, ok := locales[locale]; ok {
+		template = found
+	} else if found, ok := locales[strings.SplitN(locale, "-", 2)[0]]; ok {
+		template = found
+	}
+	if template == nil {
+		return nil, fmt.Errorf("%w: %s has no %s template", ErrUnknownType, notificationType, DefaultLocale)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := template.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := template.text.ExecuteTemplate(&text, "text", data); err != nil {
+		return nil, err
+	}
+	if template.html != nil {
+		if err := template.html.ExecuteTemplate(&html, "html", data); err != nil {
+			return nil, err
+		}
+	}
+	return &Message{
+		// subjects are a single line
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    strings.TrimSpace(html.String()),
+	}, nil
+}
+
+// Preview renders a type with its sample data.
+func (r *Registry) Preview(notificationType string, locale string) (*Message, error) {
+	return r.Render(notificationType, locale, r.samples[notificationType])
+}
+
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+// ValidLocale reports whether locale is a language tag such as "fr" or "fr-CA".
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// normalizeLocale turns "fr_CA" and "FR-ca" into "fr-ca".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// Default holds the templates shipped with the API, named <type>.<locale>.tmpl.
+var Default = load()
+
+func load() *Registry {
+	r := NewRegistry()
+	files, err := fs.Glob(templateFiles, "templates/*.tmpl")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(path.Base(file), ".tmpl")
+		dot := strings.LastIndex(name, ".")
+		if dot < 0 {
+			panic("notification template " + file + " is not named <type>.<locale>.tmpl")
+		}
+		source, err := templateFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		if err := r.Parse(name[:dot], name[dot+1:], string(source)); err != nil {
+			panic(err)
+		}
+	}
+	for notificationType, data := range samples {
+		r.SetSample(notificationType, data)
+	}
+	return r
+}
+
+// Render renders a type with the default registry.
+func Render(notificationType string, locale string, data interface{}) (*Message, error) {
+	return Default.Render(notificationType, locale, data)
+}
+
+// samples fill the previews of the default templates.
+var samples = map[string]interface{}{
+	TypeDebuggerAlert: map[string]interface{}{
+		"Level":  "error",
+		"Title":  "Flow <Support line> failed",
+		"Report": "The Dial widget timed out after 30 seconds.",
+		"From":   "+15145550100",
+		"To":     "+15145550101",
+		"Count":  0,
+	},
+	TypeAdminError: map[string]interface{}{
+		"Message": "SIP router 10.0.0.4 stopped responding to OPTIONS.",
+	},
+	TypeFirstCall: map[string]interface{}{
+		"FirstName": "Ada",
+		"To":        "+442071838750",
+	},
+	TypeFaxReceived: map[string]interface{}{
+		"From":      "+15145550100",
+		"To":        "+15145550101",
+		"Pages":     3,
+		"CreatedAt": "2024-03-01T10:00:00Z",
+	},
+	TypeCallerIdVerifyFailed: map[string]interface{}{},
+	TypeLogEvent: map[string]interface{}{
+		"Type": "sip-registration-failed",
+	},
+}
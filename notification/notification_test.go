@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, r.Parse("greeting", "en", `{{define "subject"}}Hello
+	{{.Name}}{{end}}{{define "text"}} Hello {{.Name}} {{end}}{{define "html"}}<p>Hello {{.Name}}</p>{{end}}`))
+	require.NoError(t, r.Parse("greeting", "fr", `{{define "subject"}}Bonjour {{.Name}}{{end}}{{define "text"}}Bonjour {{.Name}}{{end}}`))
+	data := map[string]interface{}{"Name": "<Ada & Bob>"}
+
+	t.Run("Should escape the HTML body only", func(t *testing.T) {
+		message, err := r.Render("greeting", "en", data)
+		require.NoError(t, err)
+		assert.Equal(t, &Message{
+			Subject: "Hello <Ada & Bob>",
+			Text:    "Hello <Ada & Bob>",
+			HTML:    "<p>Hello &lt;Ada &amp; Bob&gt;</p>",
+		}, message)
+	})
+
+	t.Run("Should fall back to the language and then the default locale", func(t *testing.T) {
+		message, err := r.Render("greeting", "fr_CA", data)
+		require.NoError(t, err)
+		assert.Equal(t, "Bonjour <Ada & Bob>", message.Subject)
+		assert.Empty(t, message.HTML)
+
+		message, err = r.Render("greeting", "de", data)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello <Ada & Bob>", message.Subject)
+	})
+
+	t.Run("Should fail on unknown types and missing data", func(t *testing.T) {
+		_, err := r.Render("farewell", "en", data)
+		assert.ErrorIs(t, err, ErrUnknownType)
+		_, err = r.Render("greeting", "en", map[string]interface{}{})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should require a subject and a text body", func(t *testing.T) {
+		assert.Error(t, r.Parse("farewell", "en", `{{define "subject"}}Bye{{end}}`))
+	})
+}
+
+func TestDefault(t *testing.T) {
+	for _, notificationType := range []string{TypeDebuggerAlert, TypeAdminError, TypeFirstCall, TypeFaxReceived, TypeCallerIdVerifyFailed, TypeLogEvent} {
+		locales := Default.Locales(notificationType)
+		require.Contains(t, locales, DefaultLocale, notificationType)
+		for _, locale := range locales {
+			message, err := Default.Preview(notificationType, locale)
+			require.NoError(t, err, notificationType+"."+locale)
+			assert.NotEmpty(t, message.Subject)
+			assert.NotEmpty(t, message.Text)
+		}
+	}
+
+	message, err := Default.Preview(TypeDebuggerAlert, "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Debug Monitor: Flow <Support line> failed", message.Subject)
+	assert.Contains(t, message.HTML, "Flow &lt;Support line&gt; failed")
+}
+
+func TestValidLocale(t *testing.T) {
+	assert.True(t, ValidLocale("fr"))
+	assert.True(t, ValidLocale("fr-CA"))
+	assert.True(t, ValidLocale("zh_Hant_TW"))
+	assert.False(t, ValidLocale("f"))
+	assert.False(t, ValidLocale("fr-CA<script>"))
+}
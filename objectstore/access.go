@@ -0,0 +1,19 @@
+package objectstore
+
+import "lineblocs.com/api/model"
+
+// Access log actions.
+const (
+	AccessURLIssued  = "URL_ISSUED"
+	AccessDownloaded = "DOWNLOADED"
+	AccessDenied     = "DENIED"
+)
+
+/*
+Interface of the object access log, every download URL handed out and
+every download through the API is recorded.
+Implementation of Object Access Store is located /store/object_access
+*/
+type AccessLogStoreInterface interface {
+	CreateAccessLog(*model.ObjectAccessLog) error
+}
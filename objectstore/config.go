@@ -0,0 +1,109 @@
+package objectstore
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Backends selected by the storage_backend credential.
+const (
+	BackendS3    = "s3"
+	BackendMinIO = "minio"
+	BackendLocal = "local"
+)
+
+// Defaults of deployments that predate the storage settings.
+const (
+	DefaultBucket    = "lineblocs"
+	DefaultRegion    = "ca-central-1"
+	DefaultLocalPath = "storage"
+)
+
+// Config selects and configures the backend of a deployment or workspace.
+type Config struct {
+	Backend   string
+	S3        S3Config
+	LocalPath string
+	LocalURL  string
+	// Encrypt stores objects with envelope encryption
+	Encrypt bool
+}
+
+// Credential keys read from api_credentials_kv_store. A workspace uses its
+// own storage when it has a storage_backend key prefixed with
+// "workspace.<id>.", e.g. workspace.12.storage_backend, and then only reads
+// keys with that prefix.
+const (
+	KeyBackend         = "storage_backend"
+	KeyBucket          = "s3_bucket"
+	KeyRegion          = "aws_region"
+	KeyAccessKeyId     = "aws_access_key_id"
+	KeySecretAccessKey = "aws_secret_access_key"
+	KeyEndpoint        = "s3_endpoint"
+	KeyPublicURL       = "s3_public_url"
+	KeyArchiveClass    = "s3_archive_storage_class"
+	KeyLocalPath       = "storage_local_path"
+	KeyLocalURL        = "storage_local_url"
+	KeyEncryption      = "storage_encryption"
+)
+
+// WorkspacePrefix returns the prefix of the credential keys of a workspace.
+func WorkspacePrefix(workspaceId int) string {
+	return "workspace." + strconv.Itoa(workspaceId) + "."
+}
+
+// ConfigFromCredentials builds the storage config of a workspace, falling
+// back to the deployment config when the workspace has none. Pass 0 for the
+// deployment config. Encryption is "on" or "off" and, unlike the other keys,
+// can be set for a workspace that uses the deployment storage.
+func ConfigFromCredentials(credentials map[string]string, workspaceId int) Config {
+	prefix := ""
+	if workspaceId != 0 && credentials[WorkspacePrefix(workspaceId)+KeyBackend] != "" {
+		prefix = WorkspacePrefix(workspaceId)
+	}
+	get := func(key string, fallback string) string {
+		if value := credentials[prefix+key]; value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	encrypt := credentials[KeyEncryption]
+	if value := credentials[WorkspacePrefix(workspaceId)+KeyEncryption]; workspaceId != 0 && value != "" {
+		encrypt = value
+	}
+
+	backend := get(KeyBackend, BackendS3)
+	return Config{
+		Backend: backend,
+		S3: S3Config{
+			Bucket:              get(KeyBucket, DefaultBucket),
+			Region:              get(KeyRegion, DefaultRegion),
+			Endpoint:            get(KeyEndpoint, ""),
+			AccessKeyId:         get(KeyAccessKeyId, ""),
+			SecretAccessKey:     get(KeySecretAccessKey, ""),
+			ForcePathStyle:      backend == BackendMinIO,
+			PublicURL:           get(KeyPublicURL, ""),
+			ArchiveStorageClass: get(KeyArchiveClass, ""),
+		},
+		LocalPath: get(KeyLocalPath, DefaultLocalPath),
+		LocalURL:  get(KeyLocalURL, ""),
+		Encrypt:   encrypt == "on",
+	}
+}
+
+// Open creates the object store described by config.
+func Open(config Config) (ObjectStore, error) {
+	switch config.Backend {
+	case BackendS3:
+		return NewS3Store(config.S3)
+	case BackendMinIO:
+		if config.S3.Endpoint == "" {
+			return nil, fmt.Errorf("objectstore: %s is required for the minio backend", KeyEndpoint)
+		}
+		return NewS3Store(config.S3)
+	case BackendLocal:
+		return NewLocalStore(config.LocalPath, config.LocalURL)
+	}
+	return nil, fmt.Errorf("objectstore: unknown backend %q", config.Backend)
+}
@@ -0,0 +1,329 @@
+package objectstore
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"lineblocs.com/api/kms"
+	"lineblocs.com/api/model"
+)
+
+// Objects are encrypted in segments so they can be streamed, each segment
+// is sealed with AES-256-GCM under the data key of the object. The nonce
+// holds the segment number and marks the last segment, so segments can not
+// be reordered, dropped or cut off.
+const (
+	AlgorithmSegmentedGCM = "AES256-GCM-SEG64K"
+	SegmentSize           = 64 << 10
+	dataKeySize           = 32
+	tagSize               = 16
+)
+
+var ErrDecrypt = errors.New("objectstore: could not decrypt object")
+
+/*
+Interface of the data key store, the wrapped data key of every encrypted
+object is kept next to the workspace and key of the object.
+Implementation of Object Key Store is located /store/object_key
+*/
+type DataKeyStoreInterface interface {
+	SaveDataKey(*model.ObjectDataKey) error
+	GetDataKey(workspaceId int, key string) (*model.ObjectDataKey, error)
+	DeleteDataKey(workspaceId int, key string) error
+	GetDataKeysToRewrap(workspaceId int, keyId string, afterId int, limit int) ([]model.ObjectDataKey, error)
+}
+
+// Envelope encrypts the objects of workspaces with a data key per object,
+// the data keys are wrapped by the workspace key in the KMS.
+type Envelope struct {
+	kms  kms.KMS
+	keys DataKeyStoreInterface
+}
+
+func NewEnvelope(k kms.KMS, keys DataKeyStoreInterface) *Envelope {
+	return &Envelope{kms: k, keys: keys}
+}
+
+// Wrap returns store with the objects of the workspace encrypted at rest.
+// Objects stored before encryption was enabled have no data key and are
+// read as they are. The wrapped store never signs URLs, downloads go
+// through the API so they can be decrypted.
+func (e *Envelope) Wrap(store ObjectStore, workspaceId int) ObjectStore {
+	return e.wrap(store, workspaceId, true)
+}
+
+// Decrypting returns store with new objects of the workspace stored in the
+// clear. Objects that have a data key, stored while encryption was on, are
+// still decrypted when they are read.
+func (e *Envelope) Decrypting(store ObjectStore, workspaceId int) ObjectStore {
+	return e.wrap(store, workspaceId, false)
+}
+
+func (e *Envelope) wrap(store ObjectStore, workspaceId int, encrypt bool) ObjectStore {
+	encrypted := &encryptedStore{inner: store, envelope: e, workspaceId: workspaceId, encrypt: encrypt}
+	if archiver, ok := store.(Archiver); ok {
+		return &encryptedArchiver{encryptedStore: encrypted, archiver: archiver}
+	}
+	return encrypted
+}
+
+// RotateKey makes a new workspace key current. Data keys wrapped with the
+// older keys keep working until Rewrap wraps them with the new one.
+func (e *Envelope) RotateKey(ctx context.Context, workspaceId int) (string, error) {
+	return e.kms.RotateKey(ctx, workspaceId)
+}
+
+// Rewrap wraps every data key of the workspace that is not wrapped with the
+// current workspace key again and returns how many it rewrapped. Objects are
+// not touched.
+func (e *Envelope) Rewrap(ctx context.Context, workspaceId int) (int, error) {
+	current, err := e.kms.CurrentKeyId(ctx, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+	rewrapped := 0
+	afterId := 0
+	for ctx.Err() == nil {
+		dataKeys, err := e.keys.GetDataKeysToRewrap(workspaceId, current, afterId, DefaultRewrapBatch)
+		if err != nil {
+			return rewrapped, err
+		}
+		for i := range dataKeys {
+			afterId = dataKeys[i].Id
+			plain, err := e.kms.UnwrapKey(ctx, workspaceId, dataKeys[i].KeyId, dataKeys[i].WrappedKey)
+			if err != nil {
+				return rewrapped, fmt.Errorf("could not unwrap data key of %s: %w", dataKeys[i].Key, err)
+			}
+			keyId, wrapped, err := e.kms.WrapKey(ctx, workspaceId, plain)
+			if err != nil {
+				return rewrapped, err
+			}
+			dataKeys[i].KeyId = keyId
+			dataKeys[i].WrappedKey = wrapped
+			if err := e.keys.SaveDataKey(&dataKeys[i]); err != nil {
+				return rewrapped, err
+			}
+			rewrapped++
+		}
+		if len(dataKeys) < DefaultRewrapBatch {
+			break
+		}
+	}
+	return rewrapped, ctx.Err()
+}
+
+// DefaultRewrapBatch is how many data keys Rewrap loads at a time.
+const DefaultRewrapBatch = 100
+
+type encryptedStore struct {
+	inner       ObjectStore
+	envelope    *Envelope
+	workspaceId int
+	encrypt     bool
+}
+
+func (s *encryptedStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if !s.encrypt {
+		err := s.inner.Put(ctx, key, body, size, contentType)
+		if err != nil {
+			return err
+		}
+		// a data key left from an encrypted object under the same key would garble the new one
+		return s.envelope.keys.DeleteDataKey(s.workspaceId, key)
+	}
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return err
+	}
+	keyId, wrapped, err := s.envelope.kms.WrapKey(ctx, s.workspaceId, dataKey)
+	if err != nil {
+		return err
+	}
+	aead, err := segmentAEAD(dataKey)
+	if err != nil {
+		return err
+	}
+
+	err = s.inner.Put(ctx, key, newSealReader(aead, body), EncryptedSize(size), contentType)
+	if err != nil {
+		return err
+	}
+	err = s.envelope.keys.SaveDataKey(&model.ObjectDataKey{
+		WorkspaceId: s.workspaceId,
+		Key:         key,
+		KeyId:       keyId,
+		Algorithm:   AlgorithmSegmentedGCM,
+		WrappedKey:  wrapped,
+	})
+	if err != nil {
+		// without its data key the object can never be read
+		s.inner.Delete(context.Background(), key)
+		return err
+	}
+	return nil
+}
+
+func (s *encryptedStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	dataKey, err := s.envelope.keys.GetDataKey(s.workspaceId, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// stored before encryption was enabled
+		return s.inner.Get(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dataKey.Algorithm != AlgorithmSegmentedGCM {
+		return nil, fmt.Errorf("%w: unknown algorithm %q", ErrDecrypt, dataKey.Algorithm)
+	}
+	plain, err := s.envelope.kms.UnwrapKey(ctx, s.workspaceId, dataKey.KeyId, dataKey.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := segmentAEAD(plain)
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.inner.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &openReader{segmentReader: newSegmentReader(body, SegmentSize+tagSize, aead.Open), body: body}, nil
+}
+
+func (s *encryptedStore) Delete(ctx context.Context, key string) error {
+	err := s.inner.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	return s.envelope.keys.DeleteDataKey(s.workspaceId, key)
+}
+
+func (s *encryptedStore) URL(key string) string {
+	return s.inner.URL(key)
+}
+
+type encryptedArchiver struct {
+	*encryptedStore
+	archiver Archiver
+}
+
+// Archive moves the ciphertext, the data key stays where it is.
+func (s *encryptedArchiver) Archive(ctx context.Context, key string) error {
+	return s.archiver.Archive(ctx, key)
+}
+
+// EncryptedSize returns the stored size of size bytes of plaintext, -1 stays unknown.
+func EncryptedSize(size int64) int64 {
+	if size < 0 {
+		return -1
+	}
+	segments := (size + SegmentSize - 1) / SegmentSize
+	if segments == 0 {
+		segments = 1
+	}
+	return size + segments*tagSize
+}
+
+func segmentAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// segmentNonce numbers a segment, the last byte marks the final one.
+func segmentNonce(counter uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[3:11], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// segmentReader reads src in segments of size and passes each through
+// transform, a segment is final when nothing follows it.
+type segmentReader struct {
+	src       *bufio.Reader
+	segment   []byte
+	buf       []byte
+	out       []byte
+	counter   uint64
+	done      bool
+	transform func(dst, nonce, data, additional []byte) ([]byte, error)
+}
+
+func newSegmentReader(src io.Reader, size int, transform func(dst, nonce, data, additional []byte) ([]byte, error)) *segmentReader {
+	return &segmentReader{
+		src:       bufio.NewReaderSize(src, size),
+		segment:   make([]byte, size),
+		transform: transform,
+	}
+}
+
+func newSealReader(aead cipher.AEAD, src io.Reader) *segmentReader {
+	seal := func(dst, nonce, data, additional []byte) ([]byte, error) {
+		return aead.Seal(dst, nonce, data, additional), nil
+	}
+	return newSegmentReader(src, SegmentSize, seal)
+}
+
+func (r *segmentReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *segmentReader) next() error {
+	n, err := io.ReadFull(r.src, r.segment)
+	final := false
+	switch err {
+	case nil:
+		if _, err := r.src.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		return err
+	}
+
+	out, err := r.transform(r.buf[:0], segmentNonce(r.counter, final), r.segment[:n], nil)
+	if err != nil {
+		return ErrDecrypt
+	}
+	r.buf = out
+	r.out = out
+	r.counter++
+	r.done = final
+	return nil
+}
+
+// openReader decrypts an object and closes its body.
+type openReader struct {
+	*segmentReader
+	body io.Closer
+}
+
+func (r *openReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,213 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/kms"
+	"lineblocs.com/api/model"
+)
+
+// memoryKeys keeps data keys in memory.
+type memoryKeys struct {
+	nextId int
+	keys   map[string]*model.ObjectDataKey
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: map[string]*model.ObjectDataKey{}}
+}
+
+func (m *memoryKeys) SaveDataKey(key *model.ObjectDataKey) error {
+	if existing, ok := m.keys[key.Key]; ok {
+		key.Id = existing.Id
+	} else {
+		m.nextId++
+		key.Id = m.nextId
+	}
+	saved := *key
+	m.keys[key.Key] = &saved
+	return nil
+}
+
+func (m *memoryKeys) GetDataKey(workspaceId int, key string) (*model.ObjectDataKey, error) {
+	found, ok := m.keys[key]
+	if !ok || found.WorkspaceId != workspaceId {
+		return nil, sql.ErrNoRows
+	}
+	copied := *found
+	return &copied, nil
+}
+
+func (m *memoryKeys) DeleteDataKey(workspaceId int, key string) error {
+	delete(m.keys, key)
+	return nil
+}
+
+func (m *memoryKeys) GetDataKeysToRewrap(workspaceId int, keyId string, afterId int, limit int) ([]model.ObjectDataKey, error) {
+	keys := []model.ObjectDataKey{}
+	for _, key := range m.keys {
+		if key.WorkspaceId == workspaceId && key.KeyId != keyId && key.Id > afterId {
+			keys = append(keys, *key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Id < keys[j].Id })
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys, nil
+}
+
+func readAll(t *testing.T, store ObjectStore, key string) []byte {
+	body, err := store.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	return data
+}
+
+func TestEnvelope(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocalStore(t.TempDir(), "")
+	require.NoError(t, err)
+	keyring, err := kms.NewLocalKMS(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+	keys := newMemoryKeys()
+	envelope := NewEnvelope(keyring, keys)
+	store := envelope.Wrap(local, 7)
+
+	t.Run("Should store ciphertext and read back plaintext", func(t *testing.T) {
+		for _, size := range []int{0, 1, SegmentSize - 1, SegmentSize, 3*SegmentSize + 17} {
+			data := make([]byte, size)
+			rand.Read(data)
+			key := Key(FolderRecordings, "rec-size")
+
+			require.NoError(t, store.Put(ctx, key, bytes.NewReader(data), int64(size), "audio/wav"))
+			stored := readAll(t, local, key)
+			assert.Equal(t, EncryptedSize(int64(size)), int64(len(stored)), size)
+			if size > 16 {
+				assert.NotEqual(t, data, stored[:size])
+			}
+			assert.Equal(t, data, readAll(t, store, key), size)
+		}
+	})
+
+	t.Run("Should reject tampered and truncated objects", func(t *testing.T) {
+		key := Key(FolderFaxes, "fax-1")
+		data := bytes.Repeat([]byte("page"), SegmentSize)
+		require.NoError(t, store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "application/pdf"))
+		stored := readAll(t, local, key)
+
+		tampered := append([]byte{}, stored...)
+		tampered[10] ^= 1
+		require.NoError(t, local.Put(ctx, key, bytes.NewReader(tampered), -1, ""))
+		body, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		_, err = io.ReadAll(body)
+		body.Close()
+		assert.ErrorIs(t, err, ErrDecrypt)
+
+		// dropping the last segment must not go unnoticed
+		truncated := stored[:2*(SegmentSize+tagSize)]
+		require.NoError(t, local.Put(ctx, key, bytes.NewReader(truncated), -1, ""))
+		body, err = store.Get(ctx, key)
+		require.NoError(t, err)
+		_, err = io.ReadAll(body)
+		body.Close()
+		assert.ErrorIs(t, err, ErrDecrypt)
+	})
+
+	t.Run("Should read objects stored before encryption was turned on", func(t *testing.T) {
+		key := Key(FolderRecordings, "rec-old")
+		require.NoError(t, local.Put(ctx, key, strings.NewReader("clear audio"), -1, ""))
+		assert.Equal(t, "clear audio", string(readAll(t, store, key)))
+	})
+
+	t.Run("Should keep objects readable across a key rotation", func(t *testing.T) {
+		key := Key(FolderRecordings, "rec-rotated")
+		require.NoError(t, store.Put(ctx, key, strings.NewReader("before rotation"), -1, ""))
+		before, _ := keys.GetDataKey(7, key)
+
+		newKeyId, err := envelope.RotateKey(ctx, 7)
+		require.NoError(t, err)
+		assert.NotEqual(t, before.KeyId, newKeyId)
+		assert.Equal(t, "before rotation", string(readAll(t, store, key)))
+
+		rewrapped, err := envelope.Rewrap(ctx, 7)
+		require.NoError(t, err)
+		assert.Positive(t, rewrapped)
+		after, _ := keys.GetDataKey(7, key)
+		assert.Equal(t, newKeyId, after.KeyId)
+		assert.Equal(t, "before rotation", string(readAll(t, store, key)))
+
+		rewrapped, err = envelope.Rewrap(ctx, 7)
+		require.NoError(t, err)
+		assert.Zero(t, rewrapped)
+	})
+
+	t.Run("Should delete the data key with the object", func(t *testing.T) {
+		key := Key(FolderRecordings, "rec-deleted")
+		require.NoError(t, store.Put(ctx, key, strings.NewReader("x"), 1, ""))
+		require.NoError(t, store.Delete(ctx, key))
+		_, err := keys.GetDataKey(7, key)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = local.Get(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("Should read encrypted objects after encryption was turned off", func(t *testing.T) {
+		encryptedKey := Key(FolderRecordings, "rec-encrypted")
+		require.NoError(t, store.Put(ctx, encryptedKey, strings.NewReader("sealed audio"), -1, ""))
+
+		plain := envelope.Decrypting(local, 7)
+		assert.Equal(t, "sealed audio", string(readAll(t, plain, encryptedKey)))
+
+		// objects stored afterwards are kept in the clear, also under a key that was encrypted
+		require.NoError(t, plain.Put(ctx, encryptedKey, strings.NewReader("clear audio"), -1, ""))
+		assert.Equal(t, "clear audio", string(readAll(t, local, encryptedKey)))
+		assert.Equal(t, "clear audio", string(readAll(t, plain, encryptedKey)))
+		_, err := keys.GetDataKey(7, encryptedKey)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Should never sign URLs for encrypted objects", func(t *testing.T) {
+		_, ok := store.(URLSigner)
+		assert.False(t, ok)
+	})
+}
+
+func TestManagerEncryption(t *testing.T) {
+	root := t.TempDir()
+	manager := NewManager(func() (map[string]string, error) {
+		return map[string]string{KeyBackend: BackendLocal, KeyLocalPath: root, WorkspacePrefix(3) + KeyEncryption: "on"}, nil
+	}, 0)
+
+	_, err := manager.ForWorkspace(3)
+	assert.Error(t, err, "encrypted workspaces must not fall back to plaintext")
+
+	keyring, err := kms.NewLocalKMS(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+	manager.SetEnvelope(NewEnvelope(keyring, newMemoryKeys()))
+	store, err := manager.ForWorkspace(3)
+	require.NoError(t, err)
+	_, ok := store.(*encryptedStore)
+	assert.True(t, ok)
+
+	// workspaces without encryption still read the objects that have a data key
+	store, err = manager.ForWorkspace(4)
+	require.NoError(t, err)
+	decrypting, ok := store.(*encryptedStore)
+	if assert.True(t, ok) {
+		assert.False(t, decrypting.encrypt)
+	}
+}
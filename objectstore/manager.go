@@ -0,0 +1,131 @@
+package objectstore
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultRefreshInterval is how long opened stores are reused before the
+// credentials are read again, so storage changes apply without a restart.
+const DefaultRefreshInterval = 5 * time.Minute
+
+// CredentialsLoader reads the api_credentials_kv_store pairs.
+type CredentialsLoader func() (map[string]string, error)
+
+// Manager opens and caches the object store of every workspace.
+type Manager struct {
+	load     CredentialsLoader
+	interval time.Duration
+	envelope *Envelope
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	stores   map[int]ObjectStore
+	// generation changes every time stores is reset, stores opened before
+	// the reset are not cached
+	generation int
+
+	// opening collapses concurrent opens of the store of a workspace
+	opening singleflight.Group
+}
+
+func NewManager(load CredentialsLoader, interval time.Duration) *Manager {
+	return &Manager{
+		load:     load,
+		interval: interval,
+		stores:   make(map[int]ObjectStore),
+	}
+}
+
+// NewStaticManager serves store to every workspace, for tests and single store deployments.
+func NewStaticManager(store ObjectStore) *Manager {
+	m := NewManager(nil, 0)
+	m.stores[0] = store
+	return m
+}
+
+// SetEnvelope encrypts the objects of workspaces with storage encryption on.
+func (m *Manager) SetEnvelope(envelope *Envelope) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.envelope = envelope
+	m.stores = make(map[int]ObjectStore)
+	m.generation++
+}
+
+// Envelope returns the envelope of the manager, nil when no KMS is configured.
+func (m *Manager) Envelope() *Envelope {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.envelope
+}
+
+// ForWorkspace returns the store of a workspace, the deployment store when
+// the workspace has no storage of its own. Stores are opened without holding
+// the lock so a slow storage provider only delays its own workspace.
+func (m *Manager) ForWorkspace(workspaceId int) (ObjectStore, error) {
+	m.mu.Lock()
+	if m.load == nil {
+		defer m.mu.Unlock()
+		return m.stores[0], nil
+	}
+	if time.Since(m.loadedAt) > m.interval {
+		m.stores = make(map[int]ObjectStore)
+		m.loadedAt = time.Now()
+		m.generation++
+	}
+	if store, ok := m.stores[workspaceId]; ok {
+		m.mu.Unlock()
+		return store, nil
+	}
+	m.mu.Unlock()
+
+	key := strconv.Itoa(workspaceId)
+	store, err, _ := m.opening.Do(key, func() (interface{}, error) {
+		return m.open(workspaceId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return store.(ObjectStore), nil
+}
+
+// open opens the store of a workspace and caches it unless the stores were
+// reset in the meantime.
+func (m *Manager) open(workspaceId int) (ObjectStore, error) {
+	m.mu.Lock()
+	envelope := m.envelope
+	generation := m.generation
+	m.mu.Unlock()
+
+	credentials, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+	config := ConfigFromCredentials(credentials, workspaceId)
+	store, err := Open(config)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case config.Encrypt && envelope == nil:
+		// objects must never be stored in the clear when encryption is on
+		return nil, fmt.Errorf("objectstore: %s is on for workspace id = %d but no KMS is configured", KeyEncryption, workspaceId)
+	case config.Encrypt:
+		store = envelope.Wrap(store, workspaceId)
+	case envelope != nil:
+		// objects stored while encryption was on stay readable after it was turned off
+		store = envelope.Decrypting(store, workspaceId)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.generation == generation {
+		m.stores[workspaceId] = store
+	}
+	return store, nil
+}
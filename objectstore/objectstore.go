@@ -0,0 +1,57 @@
+// Package objectstore stores recordings and faxes in S3, an S3 compatible
+// server such as MinIO, or on the local disk.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// Key prefixes of the stored objects.
+const (
+	FolderRecordings = "recordings"
+	FolderFaxes      = "faxes"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectStore is a flat namespace of objects addressed by slash separated keys.
+type ObjectStore interface {
+	// Put stores body under key, size may be -1 when it is not known.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key, it returns ErrNotFound for unknown keys.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key, deleting an unknown key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the location of the object that is stored with the record it belongs to.
+	URL(key string) string
+}
+
+// Archiver is implemented by stores that can move an object to cheaper cold
+// storage while keeping it under the same key.
+type Archiver interface {
+	Archive(ctx context.Context, key string) error
+}
+
+// Key builds the key of an object from its folder and API id.
+func Key(folder string, apiId string) string {
+	return folder + "/" + apiId
+}
+
+// cleanKey rejects keys that are empty or would escape the store root.
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
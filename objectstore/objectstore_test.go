@@ -0,0 +1,229 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir(), "https://files.example.com/")
+	require.NoError(t, err)
+
+	key := Key(FolderRecordings, "rec-1")
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("audio"), 5, "audio/wav"))
+
+	t.Run("Should read back a stored object", func(t *testing.T) {
+		body, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "audio", string(data))
+	})
+
+	t.Run("Should replace an existing object", func(t *testing.T) {
+		require.NoError(t, store.Put(ctx, key, strings.NewReader("other"), -1, ""))
+		body, err := store.Get(ctx, key)
+		require.NoError(t, err)
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "other", string(data))
+	})
+
+	t.Run("Should build URLs below the base URL", func(t *testing.T) {
+		assert.Equal(t, "https://files.example.com/recordings/rec-1", store.URL(key))
+	})
+
+	t.Run("Should reject keys outside the root", func(t *testing.T) {
+		for _, bad := range []string{"", "/etc/passwd", "../secret", "faxes/../../secret", "faxes//fax-1"} {
+			assert.ErrorIs(t, store.Put(ctx, bad, strings.NewReader("x"), 1, ""), ErrInvalidKey, bad)
+		}
+	})
+
+	t.Run("Should delete objects", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, key))
+		_, err := store.Get(ctx, key)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, key))
+	})
+
+	t.Run("Should stop when the context is cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.ErrorIs(t, store.Put(cancelled, Key(FolderFaxes, "fax-1"), strings.NewReader("x"), 1, ""), context.Canceled)
+		_, err := store.Get(ctx, Key(FolderFaxes, "fax-1"))
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestConfigFromCredentials(t *testing.T) {
+	credentials := map[string]string{
+		KeyBucket:                        "deployment",
+		KeyAccessKeyId:                   "deployment-key",
+		WorkspacePrefix(7) + KeyBackend:  BackendMinIO,
+		WorkspacePrefix(7) + KeyBucket:   "customer",
+		WorkspacePrefix(7) + KeyEndpoint: "https://minio.customer.test",
+		WorkspacePrefix(9) + KeyBucket:   "ignored without a backend",
+	}
+
+	t.Run("Should keep the historical defaults", func(t *testing.T) {
+		config := ConfigFromCredentials(map[string]string{}, 0)
+		assert.Equal(t, BackendS3, config.Backend)
+		assert.Equal(t, DefaultBucket, config.S3.Bucket)
+		assert.Equal(t, DefaultRegion, config.S3.Region)
+	})
+
+	t.Run("Should use the workspace storage when it has a backend", func(t *testing.T) {
+		config := ConfigFromCredentials(credentials, 7)
+		assert.Equal(t, BackendMinIO, config.Backend)
+		assert.Equal(t, "customer", config.S3.Bucket)
+		assert.True(t, config.S3.ForcePathStyle)
+		// deployment keys are never used for a workspace bucket
+		assert.Empty(t, config.S3.AccessKeyId)
+	})
+
+	t.Run("Should fall back to the deployment storage", func(t *testing.T) {
+		config := ConfigFromCredentials(credentials, 9)
+		assert.Equal(t, "deployment", config.S3.Bucket)
+		assert.Equal(t, "deployment-key", config.S3.AccessKeyId)
+	})
+
+	t.Run("Should let a workspace on the deployment storage turn on encryption", func(t *testing.T) {
+		credentials := map[string]string{KeyEncryption: "off", WorkspacePrefix(9) + KeyEncryption: "on"}
+		assert.True(t, ConfigFromCredentials(credentials, 9).Encrypt)
+		assert.False(t, ConfigFromCredentials(credentials, 7).Encrypt)
+		assert.False(t, ConfigFromCredentials(credentials, 0).Encrypt)
+	})
+
+	t.Run("Should require an endpoint for minio", func(t *testing.T) {
+		_, err := Open(Config{Backend: BackendMinIO, S3: S3Config{Bucket: "b"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestManager(t *testing.T) {
+	loads := 0
+	root := t.TempDir()
+	manager := NewManager(func() (map[string]string, error) {
+		loads++
+		return map[string]string{KeyBackend: BackendLocal, KeyLocalPath: root}, nil
+	}, time.Hour)
+
+	first, err := manager.ForWorkspace(3)
+	require.NoError(t, err)
+	second, err := manager.ForWorkspace(3)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, loads)
+
+	_, ok := first.(*LocalStore)
+	assert.True(t, ok)
+}
+
+func TestManagerConcurrentOpen(t *testing.T) {
+	var loads int32
+	release := make(chan struct{})
+	root := t.TempDir()
+	manager := NewManager(func() (map[string]string, error) {
+		if atomic.AddInt32(&loads, 1) > 1 {
+			<-release
+		}
+		return map[string]string{KeyBackend: BackendLocal, KeyLocalPath: root}, nil
+	}, time.Hour)
+
+	cached, err := manager.ForWorkspace(3)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	stores := make([]ObjectStore, 5)
+	for i := range stores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stores[i], _ = manager.ForWorkspace(4)
+		}(i)
+	}
+
+	// a workspace that is still opening does not hold up the others
+	for atomic.LoadInt32(&loads) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	store, err := manager.ForWorkspace(3)
+	require.NoError(t, err)
+	assert.Same(t, cached, store)
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&loads))
+	for _, store := range stores {
+		assert.Same(t, stores[0], store)
+	}
+}
+
+func TestS3StoreURL(t *testing.T) {
+	t.Run("Should keep the historical bucket URL", func(t *testing.T) {
+		store, err := NewS3Store(ConfigFromCredentials(map[string]string{}, 0).S3)
+		require.NoError(t, err)
+		assert.Equal(t, "https://lineblocs.s3.ca-central-1.amazonaws.com/faxes/fax-1", store.URL(Key(FolderFaxes, "fax-1")))
+	})
+
+	t.Run("Should address MinIO buckets by path", func(t *testing.T) {
+		store, err := NewS3Store(S3Config{Bucket: "media", Region: "us-east-1", Endpoint: "https://minio.test/", ForcePathStyle: true})
+		require.NoError(t, err)
+		assert.Equal(t, "https://minio.test/media/recordings/rec-1", store.URL(Key(FolderRecordings, "rec-1")))
+	})
+}
+
+func TestProxySigner(t *testing.T) {
+	signer := NewProxySigner([]byte("secret"), "https://api.example.com/")
+	now := time.Now()
+	signed := signer.Sign(4, Key(FolderRecordings, "rec-1"), now.Add(time.Minute))
+	require.True(t, strings.HasPrefix(signed, "https://api.example.com"+ProxyDownloadPath+"?"))
+
+	query := func(rawURL string) url.Values {
+		parsed, err := url.Parse(rawURL)
+		require.NoError(t, err)
+		return parsed.Query()
+	}
+
+	t.Run("Should grant the signed key to its workspace", func(t *testing.T) {
+		workspaceId, key, err := signer.Verify(query(signed), now)
+		require.NoError(t, err)
+		assert.Equal(t, 4, workspaceId)
+		assert.Equal(t, "recordings/rec-1", key)
+	})
+
+	t.Run("Should reject expired URLs", func(t *testing.T) {
+		_, _, err := signer.Verify(query(signed), now.Add(2*time.Minute))
+		assert.ErrorIs(t, err, ErrURLExpired)
+	})
+
+	t.Run("Should reject tampered URLs", func(t *testing.T) {
+		for _, param := range []string{"workspace_id", "key", "expires"} {
+			values := query(signed)
+			values.Set(param, values.Get(param)+"1")
+			_, _, err := signer.Verify(values, now)
+			assert.ErrorIs(t, err, ErrInvalidSignature, param)
+		}
+		_, _, err := NewProxySigner([]byte("other"), "").Verify(query(signed), now)
+		assert.ErrorIs(t, err, ErrInvalidSignature)
+	})
+
+	t.Run("Should presign S3 URLs", func(t *testing.T) {
+		store, err := NewS3Store(S3Config{Bucket: "media", Region: "us-east-1", AccessKeyId: "key", SecretAccessKey: "secret"})
+		require.NoError(t, err)
+		presigned, err := store.SignedURL(Key(FolderFaxes, "fax-1"), 5*time.Minute)
+		require.NoError(t, err)
+		assert.Contains(t, presigned, "X-Amz-Expires=300")
+		assert.Contains(t, presigned, "X-Amz-Signature=")
+	})
+}
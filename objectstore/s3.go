@@ -0,0 +1,178 @@
+package objectstore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// S3Config configures an S3Store. Endpoint is only set for S3 compatible
+// servers such as MinIO, which also need path style addressing.
+type S3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string
+	AccessKeyId     string
+	SecretAccessKey string
+	ForcePathStyle  bool
+	// PublicURL replaces the bucket location in URL, e.g. a CDN in front of the bucket
+	PublicURL string
+	// ArchiveStorageClass is the storage class objects are moved to by Archive
+	ArchiveStorageClass string
+}
+
+// DefaultArchiveStorageClass is used by Archive when no storage class is configured.
+const DefaultArchiveStorageClass = s3.StorageClassGlacier
+
+// S3Store keeps objects in an S3 bucket.
+type S3Store struct {
+	config   S3Config
+	client   *s3.S3
+	uploader *s3manager.Uploader
+}
+
+func NewS3Store(config S3Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("objectstore: s3 bucket is not configured")
+	}
+	awsConfig := &aws.Config{Region: aws.String(config.Region)}
+	if config.Endpoint != "" {
+		awsConfig.Endpoint = aws.String(config.Endpoint)
+		awsConfig.S3ForcePathStyle = aws.Bool(config.ForcePathStyle)
+	}
+	// without keys the SDK falls back to the environment and instance roles
+	if config.AccessKeyId != "" {
+		awsConfig.Credentials = credentials.NewStaticCredentials(config.AccessKeyId, config.SecretAccessKey, "")
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("objectstore: s3 session: %w", err)
+	}
+	return &S3Store{
+		config:   config,
+		client:   s3.New(sess),
+		uploader: s3manager.NewUploader(sess),
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+		Body:   body,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err = s.uploader.UploadWithContext(ctx, input)
+	if err != nil {
+		return fmt.Errorf("objectstore: upload of %s failed: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *S3Store) URL(key string) string {
+	escaped := (&url.URL{Path: key}).EscapedPath()
+	switch {
+	case s.config.PublicURL != "":
+		return strings.TrimSuffix(s.config.PublicURL, "/") + "/" + escaped
+	case s.config.Endpoint != "":
+		return strings.TrimSuffix(s.config.Endpoint, "/") + "/" + s.config.Bucket + "/" + escaped
+	}
+	return "https://" + s.config.Bucket + ".s3." + s.config.Region + ".amazonaws.com/" + escaped
+}
+
+// SignedURL presigns a GET of key that expires after expiry.
+func (s *S3Store) SignedURL(key string, expiry time.Duration) (string, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(key),
+	})
+	return req.Presign(expiry)
+}
+
+// Archive copies the object onto itself with the archive storage class.
+func (s *S3Store) Archive(ctx context.Context, key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	storageClass := s.config.ArchiveStorageClass
+	if storageClass == "" {
+		storageClass = DefaultArchiveStorageClass
+	}
+	_, err = s.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:       aws.String(s.config.Bucket),
+		Key:          aws.String(key),
+		CopySource:   aws.String(url.PathEscape(s.config.Bucket) + "/" + (&url.URL{Path: key}).EscapedPath()),
+		StorageClass: aws.String(storageClass),
+	})
+	if isNotFound(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("objectstore: archive of %s failed: %w", key, err)
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case s3.ErrCodeNoSuchKey, "NotFound":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package objectstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lifetime of download URLs.
+const (
+	DefaultURLExpiry = 15 * time.Minute
+	MaxURLExpiry     = time.Hour
+)
+
+// ProxyDownloadPath is the API route that serves objects for ProxySigner URLs.
+const ProxyDownloadPath = "/storage/download"
+
+var (
+	ErrInvalidSignature = errors.New("invalid download signature")
+	ErrURLExpired       = errors.New("download URL has expired")
+	ErrNoSigner         = errors.New("no signer for download URLs")
+)
+
+// URLSigner is implemented by stores that hand out time-limited URLs themselves.
+type URLSigner interface {
+	SignedURL(key string, expiry time.Duration) (string, error)
+}
+
+// DownloadURL signs a URL for key that expires at expiresAt with store, or
+// with proxy when the store can not sign. Encrypted stores do not sign, their
+// objects are decrypted by the API download route.
+func DownloadURL(store ObjectStore, proxy *ProxySigner, workspaceId int, key string, expiresAt time.Time) (string, error) {
+	if signer, ok := store.(URLSigner); ok {
+		return signer.SignedURL(key, time.Until(expiresAt))
+	}
+	if proxy == nil {
+		return "", ErrNoSigner
+	}
+	return proxy.Sign(workspaceId, key, expiresAt), nil
+}
+
+// ProxySigner signs URLs of the API download route, for stores that can not
+// sign URLs or are not reachable by clients. The signature covers the
+// workspace, the key and the expiry time.
+type ProxySigner struct {
+	secret  []byte
+	baseURL string
+}
+
+// NewProxySigner signs with secret, baseURL is the public address of the API
+// and may be empty for URLs relative to it.
+func NewProxySigner(secret []byte, baseURL string) *ProxySigner {
+	return &ProxySigner{secret: secret, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Sign returns a download URL for key that is valid until expiresAt.
+func (p *ProxySigner) Sign(workspaceId int, key string, expiresAt time.Time) string {
+	expires := expiresAt.Unix()
+	query := url.Values{}
+	query.Set("workspace_id", strconv.Itoa(workspaceId))
+	query.Set("key", key)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", p.signature(workspaceId, key, expires))
+	return p.baseURL + ProxyDownloadPath + "?" + query.Encode()
+}
+
+// Verify checks the query of a download URL and returns the workspace and key it grants.
+func (p *ProxySigner) Verify(query url.Values, now time.Time) (int, string, error) {
+	workspaceId, err := strconv.Atoi(query.Get("workspace_id"))
+	if err != nil {
+		return 0, "", ErrInvalidSignature
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidSignature
+	}
+	key := query.Get("key")
+	expected := p.signature(workspaceId, key, expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		return 0, "", ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return 0, "", ErrURLExpired
+	}
+	return workspaceId, key, nil
+}
+
+func (p *ProxySigner) signature(workspaceId int, key string, expires int64) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(strconv.Itoa(workspaceId) + "\n" + key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
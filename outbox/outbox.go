@@ -0,0 +1,18 @@
+package outbox
+
+import (
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+/*
+Interface of Outbox Store.
+Implementation of Outbox Store is located /store/outbox
+*/
+type OutboxStoreInterface interface {
+	Enqueue(*model.OutboxEvent) error
+	ClaimPending(limit int, lease time.Duration) ([]model.OutboxEvent, error)
+	MarkPublished(id int64) error
+	MarkFailed(id int64, lastError string, retryAt time.Time) error
+}
@@ -0,0 +1,105 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+// Publisher delivers a message to a queue and returns once the broker has confirmed it.
+type Publisher interface {
+	Publish(ctx context.Context, queue string, body []byte) error
+}
+
+const (
+	DefaultPollInterval = time.Second
+	DefaultBatchSize    = 100
+	DefaultLease        = 30 * time.Second
+	maxRetryDelay       = 5 * time.Minute
+)
+
+// Relay moves events from the outbox table to the queue. An event is only
+// marked published after the broker confirms it, so delivery is at-least-once
+// and consumers must tolerate duplicates.
+type Relay struct {
+	store     OutboxStoreInterface
+	publisher Publisher
+	interval  time.Duration
+	batchSize int
+	lease     time.Duration
+}
+
+func NewRelay(store OutboxStoreInterface, publisher Publisher) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		interval:  DefaultPollInterval,
+		batchSize: DefaultBatchSize,
+		lease:     DefaultLease,
+	}
+}
+
+// Run polls the outbox until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		// keep draining while full batches come back
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				utils.Log(logrus.ErrorLevel, "outbox relay could not claim events: "+err.Error())
+				break
+			}
+			if n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes one batch of pending events and returns how many were claimed.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	events, err := r.store.ClaimPending(r.batchSize, r.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// the lease expires and another relay picks the rest up
+			return len(events), nil
+		}
+
+		err := r.publisher.Publish(ctx, event.Queue, event.Payload)
+		if err != nil {
+			utils.Log(logrus.WarnLevel, fmt.Sprintf("outbox relay could not publish event id = %d type = %s: %s", event.Id, event.EventType, err.Error()))
+			retryAt := time.Now().Add(RetryDelay(event.Attempts + 1))
+			if err := r.store.MarkFailed(event.Id, err.Error(), retryAt); err != nil {
+				utils.Log(logrus.ErrorLevel, "outbox relay could not record failure: "+err.Error())
+			}
+			continue
+		}
+
+		if err := r.store.MarkPublished(event.Id); err != nil {
+			// the event is published again once its lease runs out
+			utils.Log(logrus.ErrorLevel, "outbox relay could not mark event published: "+err.Error())
+		}
+	}
+	return len(events), nil
+}
+
+// RetryDelay backs off exponentially from one second, capped at five minutes.
+func RetryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, time.Second, maxRetryDelay)
+}
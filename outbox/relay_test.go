@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+)
+
+type fakePublisher struct {
+	failQueue string
+	published []string
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, queue string, body []byte) error {
+	if queue == p.failQueue {
+		return errors.New("connection refused")
+	}
+	p.published = append(p.published, string(body))
+	return nil
+}
+
+func TestRelayBatch(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should mark confirmed events published and reschedule failures", func(t *testing.T) {
+		store := mocks.NewOutboxStoreInterface(t)
+		publisher := &fakePublisher{failQueue: "down"}
+
+		store.EXPECT().ClaimPending(DefaultBatchSize, DefaultLease).Return([]model.OutboxEvent{
+			{Id: 1, Queue: "call_activity", EventType: "CALL_CREATED", Payload: []byte(`{"run_id":"1"}`)},
+			{Id: 2, Queue: "down", EventType: "CALL_ENDED", Payload: []byte(`{}`), Attempts: 2},
+		}, nil)
+		store.EXPECT().MarkPublished(int64(1)).Return(nil)
+		store.EXPECT().MarkFailed(int64(2), "connection refused", mock.AnythingOfType("time.Time")).Return(nil)
+
+		n, err := NewRelay(store, publisher).RelayBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []string{`{"run_id":"1"}`}, publisher.published)
+	})
+
+	t.Run("Should return claim errors", func(t *testing.T) {
+		store := mocks.NewOutboxStoreInterface(t)
+		store.EXPECT().ClaimPending(DefaultBatchSize, DefaultLease).Return(nil, errors.New("db down"))
+
+		_, err := NewRelay(store, &fakePublisher{}).RelayBatch(context.Background())
+		assert.Error(t, err)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, RetryDelay(0))
+	assert.Equal(t, time.Second, RetryDelay(1))
+	assert.Equal(t, 8*time.Second, RetryDelay(4))
+	assert.Equal(t, 5*time.Minute, RetryDelay(20))
+}
@@ -0,0 +1,240 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultPoolSize       = 4
+	DefaultConfirmTimeout = 10 * time.Second
+	maxReconnectDelay     = 30 * time.Second
+)
+
+var (
+	ErrPublisherClosed = errors.New("publisher is closed")
+	ErrNacked          = errors.New("message was not confirmed by the broker")
+)
+
+// AMQPPublisher keeps one long-lived connection to RabbitMQ and a pool of
+// channels in confirm mode. Publish only returns nil once the broker has
+// acknowledged the message. A dropped connection is re-dialed on the next
+// publish with exponential backoff.
+type AMQPPublisher struct {
+	url            string
+	confirmTimeout time.Duration
+
+	mu           sync.Mutex
+	conn         *amqp.Connection
+	declared     map[string]bool
+	nextDial     time.Time
+	dialFailures int
+	closed       bool
+
+	channels chan *amqp.Channel
+}
+
+func NewAMQPPublisher(url string, poolSize int) *AMQPPublisher {
+	if poolSize <= 0 {
+		poolSize = DefaultPoolSize
+	}
+	return &AMQPPublisher{
+		url:            url,
+		confirmTimeout: DefaultConfirmTimeout,
+		declared:       make(map[string]bool),
+		channels:       make(chan *amqp.Channel, poolSize),
+	}
+}
+
+// Publish sends body as a persistent message to the durable queue and waits for the broker confirm.
+func (p *AMQPPublisher) Publish(ctx context.Context, queue string, body []byte) error {
+	return p.publish(ctx, "", queue, body, func(ch *amqp.Channel) error {
+		return p.declare("queue:"+queue, func() error {
+			_, err := ch.QueueDeclare(queue, true, false, false, false, nil)
+			return err
+		})
+	})
+}
+
+// PublishToExchange sends body as a persistent message to a durable topic
+// exchange with routingKey and waits for the broker confirm.
+func (p *AMQPPublisher) PublishToExchange(ctx context.Context, exchange string, routingKey string, body []byte) error {
+	return p.publish(ctx, exchange, routingKey, body, func(ch *amqp.Channel) error {
+		return p.declare("exchange:"+exchange, func() error {
+			return ch.ExchangeDeclare(exchange, amqp.ExchangeTopic, true, false, false, false, nil)
+		})
+	})
+}
+
+// Channel opens a channel on the shared connection for consumers. The caller owns and closes it.
+func (p *AMQPPublisher) Channel() (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPublisherClosed
+	}
+	conn, err := p.connection()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Channel()
+}
+
+func (p *AMQPPublisher) publish(ctx context.Context, exchange string, key string, body []byte, declare func(*amqp.Channel) error) error {
+	ch, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	err = declare(ch)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.confirmTimeout)
+	defer cancel()
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, false, false, amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  "application/json",
+		Body:         body,
+	})
+	if err != nil {
+		ch.Close()
+		return err
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		// the confirm may still arrive, the channel cannot be reused safely
+		ch.Close()
+		return err
+	}
+	p.release(ch)
+	if !acked {
+		return ErrNacked
+	}
+	return nil
+}
+
+// Close releases the pooled channels and the connection.
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	for {
+		select {
+		case ch := <-p.channels:
+			ch.Close()
+		default:
+			if p.conn != nil && !p.conn.IsClosed() {
+				return p.conn.Close()
+			}
+			return nil
+		}
+	}
+}
+
+func (p *AMQPPublisher) acquire() (*amqp.Channel, error) {
+	for {
+		select {
+		case ch := <-p.channels:
+			if ch.IsClosed() {
+				continue
+			}
+			return ch, nil
+		default:
+			return p.openChannel()
+		}
+	}
+}
+
+func (p *AMQPPublisher) release(ch *amqp.Channel) {
+	if ch.IsClosed() {
+		return
+	}
+	select {
+	case p.channels <- ch:
+	default:
+		ch.Close()
+	}
+}
+
+func (p *AMQPPublisher) openChannel() (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, ErrPublisherClosed
+	}
+
+	conn, err := p.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	err = ch.Confirm(false)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return ch, nil
+}
+
+// declare runs fn once per connection for each queue or exchange, unroutable
+// messages are confirmed by the broker and silently dropped.
+func (p *AMQPPublisher) declare(name string, fn func() error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.declared[name] {
+		return nil
+	}
+	err := fn()
+	if err != nil {
+		return err
+	}
+	p.declared[name] = true
+	return nil
+}
+
+// connection must be called with p.mu held.
+func (p *AMQPPublisher) connection() (*amqp.Connection, error) {
+	if p.conn != nil && !p.conn.IsClosed() {
+		return p.conn, nil
+	}
+	if time.Now().Before(p.nextDial) {
+		return nil, errors.New("rabbitmq is unavailable, waiting to reconnect")
+	}
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		p.dialFailures++
+		delay := time.Duration(1<<uint(min(p.dialFailures, 5))) * time.Second
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+		p.nextDial = time.Now().Add(delay)
+		utils.Log(logrus.ErrorLevel, "RabbitMQ connection failed: "+err.Error())
+		return nil, err
+	}
+
+	utils.Log(logrus.InfoLevel, "connected to RabbitMQ")
+	p.conn = conn
+	p.dialFailures = 0
+	// queues and exchanges are declared again on the new connection in case the broker lost them
+	p.declared = make(map[string]bool)
+	return conn, nil
+}
@@ -0,0 +1,35 @@
+package recording
+
+import (
+	"os"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// PurgeLockKey is held by the API instance running the retention purge.
+const PurgeLockKey = "recordings:purge:leader"
+
+// Lock elects the API instance that runs a purge when several share a database.
+type Lock interface {
+	// TryLock reports whether key was taken, it is held until ttl passes.
+	TryLock(key string, ttl time.Duration) (bool, error)
+}
+
+// RedisLock takes locks with SET NX, the holder is recorded for debugging.
+type RedisLock struct {
+	client *redis.Client
+	holder string
+}
+
+func NewRedisLock(client *redis.Client) *RedisLock {
+	holder, err := os.Hostname()
+	if err != nil {
+		holder = "unknown"
+	}
+	return &RedisLock{client: client, holder: holder}
+}
+
+func (l *RedisLock) TryLock(key string, ttl time.Duration) (bool, error) {
+	return l.client.SetNX(key, l.holder, ttl).Result()
+}
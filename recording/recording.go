@@ -1,6 +1,10 @@
 package recording
 
-import "lineblocs.com/api/model"
+import (
+	"time"
+
+	"lineblocs.com/api/model"
+)
 
 /*
 Interface of Recording Store.
@@ -11,7 +15,24 @@ type RecordingStoreInterface interface {
 	SetRecordingStatus(int, string) (error)
 	GetRecordingFromDB(int) (*model.Recording, error)
 	GetRecordingSpace(int) (int, error)
-	UpdateRecording(string, string, int64, int) error
+	GetRecordingDuration(workspaceId int) (int, error)
+	GetPlanRecordingMinutes(workspaceId int) (int, error)
+	GetPlanRecordingSpace(workspaceId int) (int64, error)
+	UpdateRecording(int, string, int64, string, *model.RecordingAudio) error
 	UpdateRecordingTranscription(*model.RecordingTranscription) error
 	IsUserAllowedToRecord(int) (bool, error)
+	GetRetentionPolicy(workspaceId int) (*model.RecordingRetentionPolicy, error)
+	SaveRetentionPolicy(policy *model.RecordingRetentionPolicy) error
+	GetRetentionPolicies() ([]model.RecordingRetentionPolicy, error)
+	GetRecordingsForRetention(workspaceId int, createdBefore time.Time, includeArchived bool, keepTags []string, afterId int, limit int) ([]model.Recording, error)
+	MarkRecordingArchived(recordingId int) error
+	MarkRecordingDeleted(recordingId int) error
+	ListRecordings(filter *model.RecordingFilter) (*model.RecordingList, error)
+	CreateRecordingUpload(upload *model.RecordingUpload) (int64, error)
+	GetRecordingUpload(uploadId string) (*model.RecordingUpload, error)
+	AddRecordingUploadChunk(uploadId int, chunk *model.RecordingUploadChunk) error
+	GetRecordingUploadChunks(uploadId int) ([]model.RecordingUploadChunk, error)
+	SetRecordingUploadStatus(uploadId int, status string) error
+	ClaimRecordingUpload(uploadId int) error
+	GetExpiredRecordingUploads(before time.Time, limit int) ([]model.RecordingUpload, error)
 }
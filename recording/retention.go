@@ -0,0 +1,210 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultPurgeInterval = time.Hour
+	DefaultPurgeBatch    = 100
+)
+
+// ErrRecordingArchived is returned for downloads of recordings moved to cold storage.
+var ErrRecordingArchived = errors.New("recording is archived in cold storage, restore it before downloading")
+
+// Purger applies the retention policies of the workspaces. Recordings older
+// than the archive age are moved to cold storage and recordings older than the
+// delete age are removed. Either way they stop counting towards the recording
+// space of the plan. Recordings tagged with one of the keep tags are left alone.
+type Purger struct {
+	store     RecordingStoreInterface
+	objects   *objectstore.Manager
+	interval  time.Duration
+	batchSize int
+	lock      Lock
+	now       func() time.Time
+}
+
+func NewPurger(store RecordingStoreInterface, objects *objectstore.Manager, interval time.Duration) *Purger {
+	if interval <= 0 {
+		interval = DefaultPurgeInterval
+	}
+	return &Purger{
+		store:     store,
+		objects:   objects,
+		interval:  interval,
+		batchSize: DefaultPurgeBatch,
+		now:       time.Now,
+	}
+}
+
+// SetLock makes instances sharing the lock take turns, only the instance
+// holding it purges and it keeps it for one interval.
+func (p *Purger) SetLock(lock Lock) {
+	p.lock = lock
+}
+
+// Run applies the policies on every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if p.leader() {
+			if err := p.PurgeOnce(ctx); err != nil {
+				utils.Log(logrus.ErrorLevel, "recording retention could not load policies: "+err.Error())
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leader reports whether this instance runs the purge of the current interval.
+// The lock is not released, it expires with the interval so the purge runs
+// once per interval across all instances.
+func (p *Purger) leader() bool {
+	if p.lock == nil {
+		return true
+	}
+	locked, err := p.lock.TryLock(PurgeLockKey, p.interval)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "recording retention could not take the purge lock: "+err.Error())
+		return false
+	}
+	return locked
+}
+
+// PurgeOnce applies every retention policy once and removes the chunks of
+// abandoned uploads. Failures of single recordings are logged and retried on
+// the next run.
+func (p *Purger) PurgeOnce(ctx context.Context) error {
+	p.purgeUploads(ctx)
+
+	policies, err := p.store.GetRetentionPolicies()
+	if err != nil {
+		return err
+	}
+	for i := range policies {
+		if ctx.Err() != nil {
+			return nil
+		}
+		p.apply(ctx, &policies[i])
+	}
+	return nil
+}
+
+// purgeUploads deletes the staged chunks of uploads that expired before they were completed.
+func (p *Purger) purgeUploads(ctx context.Context) {
+	for ctx.Err() == nil {
+		uploads, err := p.store.GetExpiredRecordingUploads(p.now(), p.batchSize)
+		if err != nil {
+			utils.Log(logrus.ErrorLevel, "recording retention could not get expired uploads: "+err.Error())
+			return
+		}
+		expired := 0
+		for i := range uploads {
+			if err := p.expireUpload(ctx, &uploads[i]); err != nil {
+				// the upload stays in the next batch and is retried on the next run
+				utils.Log(logrus.WarnLevel, fmt.Sprintf("recording retention could not expire upload %s: %s", uploads[i].UploadId, err.Error()))
+				continue
+			}
+			expired++
+		}
+		// a batch of failed uploads would come back unchanged
+		if len(uploads) < p.batchSize || expired == 0 {
+			return
+		}
+	}
+}
+
+func (p *Purger) expireUpload(ctx context.Context, upload *model.RecordingUpload) error {
+	objects, err := p.objects.ForWorkspace(upload.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	chunks, err := p.store.GetRecordingUploadChunks(upload.Id)
+	if err != nil {
+		return err
+	}
+	err = DeleteChunks(ctx, objects, upload.UploadId, chunks)
+	if err != nil {
+		return err
+	}
+	return p.store.SetRecordingUploadStatus(upload.Id, UploadExpired)
+}
+
+func (p *Purger) apply(ctx context.Context, policy *model.RecordingRetentionPolicy) {
+	objects, err := p.objects.ForWorkspace(policy.WorkspaceId)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("recording retention could not open object store of workspace id = %d: %s", policy.WorkspaceId, err.Error()))
+		return
+	}
+
+	// deleting first keeps recordings past both ages from being archived for nothing
+	if policy.DeleteAfterDays > 0 {
+		p.each(ctx, policy, policy.DeleteAfterDays, true, func(recording *model.Recording) error {
+			err := objects.Delete(ctx, objectstore.Key(objectstore.FolderRecordings, recording.APIId))
+			if err != nil {
+				return err
+			}
+			return p.store.MarkRecordingDeleted(recording.Id)
+		})
+	}
+
+	if policy.ArchiveAfterDays > 0 && (policy.DeleteAfterDays == 0 || policy.ArchiveAfterDays < policy.DeleteAfterDays) {
+		archiver, ok := objects.(objectstore.Archiver)
+		if !ok {
+			utils.Log(logrus.WarnLevel, fmt.Sprintf("recording retention can not archive recordings of workspace id = %d, its object store has no cold storage", policy.WorkspaceId))
+			return
+		}
+		p.each(ctx, policy, policy.ArchiveAfterDays, false, func(recording *model.Recording) error {
+			err := archiver.Archive(ctx, objectstore.Key(objectstore.FolderRecordings, recording.APIId))
+			if errors.Is(err, objectstore.ErrNotFound) {
+				// nothing left to archive, the recording only takes up a row
+				return p.store.MarkRecordingDeleted(recording.Id)
+			}
+			if err != nil {
+				return err
+			}
+			return p.store.MarkRecordingArchived(recording.Id)
+		})
+	}
+}
+
+// each calls fn for every recording of the policy workspace older than days.
+func (p *Purger) each(ctx context.Context, policy *model.RecordingRetentionPolicy, days int, includeArchived bool, fn func(*model.Recording) error) {
+	createdBefore := p.now().AddDate(0, 0, -days)
+	afterId := 0
+	for {
+		recordings, err := p.store.GetRecordingsForRetention(policy.WorkspaceId, createdBefore, includeArchived, policy.KeepTags, afterId, p.batchSize)
+		if err != nil {
+			utils.Log(logrus.ErrorLevel, fmt.Sprintf("recording retention could not get recordings of workspace id = %d: %s", policy.WorkspaceId, err.Error()))
+			return
+		}
+		for i := range recordings {
+			if ctx.Err() != nil {
+				return
+			}
+			afterId = recordings[i].Id
+			if err := fn(&recordings[i]); err != nil {
+				utils.Log(logrus.WarnLevel, fmt.Sprintf("recording retention could not process recording id = %d: %s", recordings[i].Id, err.Error()))
+			}
+		}
+		if len(recordings) < p.batchSize {
+			return
+		}
+	}
+}
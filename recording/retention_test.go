@@ -0,0 +1,167 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+)
+
+// archivingStore is a local store with cold storage.
+type archivingStore struct {
+	*objectstore.LocalStore
+	archived []string
+}
+
+func (s *archivingStore) Archive(ctx context.Context, key string) error {
+	body, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	body.Close()
+	s.archived = append(s.archived, key)
+	return nil
+}
+
+func put(t *testing.T, store objectstore.ObjectStore, apiId string) {
+	key := objectstore.Key(objectstore.FolderRecordings, apiId)
+	err := store.Put(context.Background(), key, strings.NewReader("audio"), 5, "audio/wav")
+	assert.NoError(t, err)
+}
+
+func TestPurgeOnce(t *testing.T) {
+	helpers.InitLogrus("stdout")
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Should delete expired recordings and archive aging ones", func(t *testing.T) {
+		local, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+		objects := &archivingStore{LocalStore: local}
+		put(t, objects, "old")
+		put(t, objects, "aging")
+
+		store := mocks.NewRecordingStoreInterface(t)
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, mock.Anything).Return(nil, nil)
+		policy := model.RecordingRetentionPolicy{WorkspaceId: 7, ArchiveAfterDays: 30, DeleteAfterDays: 90, KeepTags: []string{"legal"}}
+		store.EXPECT().GetRetentionPolicies().Return([]model.RecordingRetentionPolicy{policy}, nil)
+		store.EXPECT().GetRecordingsForRetention(7, now.AddDate(0, 0, -90), true, []string{"legal"}, 0, DefaultPurgeBatch).
+			Return([]model.Recording{{Id: 1, APIId: "old"}}, nil)
+		store.EXPECT().MarkRecordingDeleted(1).Return(nil)
+		store.EXPECT().GetRecordingsForRetention(7, now.AddDate(0, 0, -30), false, []string{"legal"}, 0, DefaultPurgeBatch).
+			Return([]model.Recording{{Id: 2, APIId: "aging"}, {Id: 3, APIId: "missing"}}, nil)
+		store.EXPECT().MarkRecordingArchived(2).Return(nil)
+		store.EXPECT().MarkRecordingDeleted(3).Return(nil)
+
+		purger := NewPurger(store, objectstore.NewStaticManager(objects), time.Hour)
+		purger.now = func() time.Time { return now }
+		assert.NoError(t, purger.PurgeOnce(context.Background()))
+
+		_, err = objects.Get(context.Background(), "recordings/old")
+		assert.ErrorIs(t, err, objectstore.ErrNotFound)
+		assert.Equal(t, []string{"recordings/aging"}, objects.archived)
+	})
+
+	t.Run("Should page through batches and keep going after failures", func(t *testing.T) {
+		local, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+
+		store := mocks.NewRecordingStoreInterface(t)
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, mock.Anything).Return(nil, nil)
+		store.EXPECT().GetRetentionPolicies().Return([]model.RecordingRetentionPolicy{{WorkspaceId: 7, DeleteAfterDays: 10}}, nil)
+		store.EXPECT().GetRecordingsForRetention(7, mock.Anything, true, []string(nil), 0, 2).
+			Return([]model.Recording{{Id: 4, APIId: "a"}, {Id: 5, APIId: "b"}}, nil)
+		store.EXPECT().GetRecordingsForRetention(7, mock.Anything, true, []string(nil), 5, 2).
+			Return([]model.Recording{{Id: 6, APIId: "c"}}, nil)
+		store.EXPECT().MarkRecordingDeleted(4).Return(errors.New("deadlock"))
+		store.EXPECT().MarkRecordingDeleted(5).Return(nil)
+		store.EXPECT().MarkRecordingDeleted(6).Return(nil)
+
+		purger := NewPurger(store, objectstore.NewStaticManager(local), time.Hour)
+		purger.batchSize = 2
+		assert.NoError(t, purger.PurgeOnce(context.Background()))
+	})
+
+	t.Run("Should skip archiving when the store has no cold storage", func(t *testing.T) {
+		local, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+
+		store := mocks.NewRecordingStoreInterface(t)
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, mock.Anything).Return(nil, nil)
+		store.EXPECT().GetRetentionPolicies().Return([]model.RecordingRetentionPolicy{{WorkspaceId: 7, ArchiveAfterDays: 30}}, nil)
+
+		purger := NewPurger(store, objectstore.NewStaticManager(local), time.Hour)
+		assert.NoError(t, purger.PurgeOnce(context.Background()))
+	})
+
+	t.Run("Should keep expiring uploads after a failure", func(t *testing.T) {
+		local, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+
+		store := mocks.NewRecordingStoreInterface(t)
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, 2).Return([]model.RecordingUpload{
+			{Id: 1, UploadId: "up-1", WorkspaceId: 7},
+			{Id: 2, UploadId: "up-2", WorkspaceId: 7},
+		}, nil).Once()
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, 2).Return([]model.RecordingUpload{
+			{Id: 1, UploadId: "up-1", WorkspaceId: 7},
+		}, nil).Once()
+		store.EXPECT().GetRecordingUploadChunks(1).Return(nil, errors.New("deadlock"))
+		store.EXPECT().GetRecordingUploadChunks(2).Return(nil, nil)
+		store.EXPECT().SetRecordingUploadStatus(2, UploadExpired).Return(nil)
+		store.EXPECT().GetRetentionPolicies().Return(nil, nil)
+
+		purger := NewPurger(store, objectstore.NewStaticManager(local), time.Hour)
+		purger.batchSize = 2
+		assert.NoError(t, purger.PurgeOnce(context.Background()))
+	})
+
+	t.Run("Should return policy errors", func(t *testing.T) {
+		store := mocks.NewRecordingStoreInterface(t)
+		store.EXPECT().GetExpiredRecordingUploads(mock.Anything, mock.Anything).Return(nil, nil)
+		store.EXPECT().GetRetentionPolicies().Return(nil, errors.New("connection refused"))
+
+		purger := NewPurger(store, objectstore.NewStaticManager(nil), time.Hour)
+		assert.Error(t, purger.PurgeOnce(context.Background()))
+	})
+}
+
+// heldLock hands out each key once, like a lock held by another instance afterwards.
+type heldLock struct {
+	held map[string]time.Duration
+}
+
+func (l *heldLock) TryLock(key string, ttl time.Duration) (bool, error) {
+	if _, ok := l.held[key]; ok {
+		return false, nil
+	}
+	l.held[key] = ttl
+	return true, nil
+}
+
+func TestPurgerLeader(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should only let the lock holder purge in an interval", func(t *testing.T) {
+		lock := &heldLock{held: map[string]time.Duration{}}
+		first := NewPurger(nil, nil, time.Hour)
+		first.SetLock(lock)
+		second := NewPurger(nil, nil, time.Hour)
+		second.SetLock(lock)
+
+		assert.True(t, first.leader())
+		assert.False(t, second.leader())
+		assert.Equal(t, time.Hour, lock.held[PurgeLockKey])
+	})
+
+	t.Run("Should purge on every instance without a lock", func(t *testing.T) {
+		assert.True(t, NewPurger(nil, nil, time.Hour).leader())
+	})
+}
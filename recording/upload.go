@@ -0,0 +1,166 @@
+package recording
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+)
+
+// Statuses of a resumable upload.
+const (
+	UploadInProgress = "UPLOADING"
+	// UploadCompleting is held by the request assembling the upload
+	UploadCompleting = "COMPLETING"
+	UploadCompleted  = "COMPLETED"
+	UploadFailed     = "FAILED"
+	UploadExpired    = "EXPIRED"
+)
+
+const (
+	// MaxChunkSize bounds the body of a single chunk request
+	MaxChunkSize = 16 << 20
+	// UploadExpiry is how long an upload can be resumed after it was started
+	UploadExpiry = 24 * time.Hour
+	// uploadFolder holds the chunks until they are assembled
+	uploadFolder = "uploads"
+)
+
+var (
+	ErrOffsetMismatch   = errors.New("chunk offset does not match the received size")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrUploadIncomplete = errors.New("upload is incomplete")
+	ErrUploadClosed     = errors.New("upload is no longer in progress")
+)
+
+// ChunkKey is the object key a chunk is staged under. Chunks live in the
+// object store so any API instance can receive the next one.
+func ChunkKey(uploadId string, offset int64) string {
+	return fmt.Sprintf("%s/%s/%020d", uploadFolder, uploadId, offset)
+}
+
+// Checksum returns the hex SHA-256 of data.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SameChecksum compares hex checksums regardless of case.
+func SameChecksum(a string, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// CheckChunks verifies the chunks cover the received bytes without gaps.
+func CheckChunks(upload *model.RecordingUpload, chunks []model.RecordingUploadChunk) error {
+	var offset int64
+	for _, chunk := range chunks {
+		if chunk.Offset != offset {
+			return fmt.Errorf("%w: missing bytes at offset %d", ErrUploadIncomplete, offset)
+		}
+		offset += chunk.Size
+	}
+	if offset != upload.Received || offset == 0 || (upload.Size > 0 && offset != upload.Size) {
+		return fmt.Errorf("%w: received %d of %d bytes", ErrUploadIncomplete, offset, upload.Size)
+	}
+	return nil
+}
+
+// Assemble streams the chunks of an upload into w. Every chunk is checked
+// against its checksum while it is copied and so is the whole file when the
+// upload has a checksum. The file is written to w rather than the object store
+// so it can be inspected before it is stored.
+func Assemble(ctx context.Context, objects objectstore.ObjectStore, upload *model.RecordingUpload, chunks []model.RecordingUploadChunk, w io.Writer) error {
+	if err := CheckChunks(upload, chunks); err != nil {
+		return err
+	}
+	reader := &chunkReader{ctx: ctx, objects: objects, uploadId: upload.UploadId, chunks: chunks}
+	defer reader.close()
+	total := sha256.New()
+
+	_, err := io.Copy(io.MultiWriter(w, total), reader)
+	if err != nil {
+		return err
+	}
+	if upload.Checksum != "" && !SameChecksum(hex.EncodeToString(total.Sum(nil)), upload.Checksum) {
+		return fmt.Errorf("%w: assembled file", ErrChecksumMismatch)
+	}
+	return nil
+}
+
+// DeleteChunks removes the staged chunks of an upload.
+func DeleteChunks(ctx context.Context, objects objectstore.ObjectStore, uploadId string, chunks []model.RecordingUploadChunk) error {
+	var failed error
+	for _, chunk := range chunks {
+		if err := objects.Delete(ctx, ChunkKey(uploadId, chunk.Offset)); err != nil {
+			failed = err
+		}
+	}
+	return failed
+}
+
+// chunkReader reads the chunks of an upload one after the other.
+type chunkReader struct {
+	ctx      context.Context
+	objects  objectstore.ObjectStore
+	uploadId string
+	chunks   []model.RecordingUploadChunk
+	current  io.ReadCloser
+	hash     hash.Hash
+	read     int64
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.chunks) == 0 {
+				return 0, io.EOF
+			}
+			body, err := r.objects.Get(r.ctx, ChunkKey(r.uploadId, r.chunks[0].Offset))
+			if err != nil {
+				return 0, err
+			}
+			r.current = body
+			r.hash = sha256.New()
+			r.read = 0
+		}
+
+		n, err := r.current.Read(p)
+		r.hash.Write(p[:n])
+		r.read += int64(n)
+		if err == io.EOF {
+			if err := r.finishChunk(); err != nil {
+				return n, err
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) finishChunk() error {
+	chunk := r.chunks[0]
+	r.current.Close()
+	r.current = nil
+	r.chunks = r.chunks[1:]
+	if r.read != chunk.Size || !SameChecksum(hex.EncodeToString(r.hash.Sum(nil)), chunk.Checksum) {
+		return fmt.Errorf("%w: chunk at offset %d", ErrChecksumMismatch, chunk.Offset)
+	}
+	return nil
+}
+
+func (r *chunkReader) close() {
+	if r.current != nil {
+		r.current.Close()
+	}
+}
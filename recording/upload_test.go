@@ -0,0 +1,79 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+)
+
+// stage puts data into the store in chunks of size and returns them.
+func stage(t *testing.T, objects objectstore.ObjectStore, uploadId string, data []byte, size int) []model.RecordingUploadChunk {
+	chunks := []model.RecordingUploadChunk{}
+	for offset := 0; offset < len(data); offset += size {
+		end := offset + size
+		if end > len(data) {
+			end = len(data)
+		}
+		part := data[offset:end]
+		err := objects.Put(context.Background(), ChunkKey(uploadId, int64(offset)), bytes.NewReader(part), int64(len(part)), "")
+		assert.NoError(t, err)
+		chunks = append(chunks, model.RecordingUploadChunk{Offset: int64(offset), Size: int64(len(part)), Checksum: Checksum(part)})
+	}
+	return chunks
+}
+
+func TestAssemble(t *testing.T) {
+	data := bytes.Repeat([]byte("RIFF audio "), 1000)
+
+	t.Run("Should assemble the chunks in order and verify the checksums", func(t *testing.T) {
+		objects, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+		chunks := stage(t, objects, "upl-1", data, 4096)
+		upload := model.RecordingUpload{UploadId: "upl-1", Size: int64(len(data)), Received: int64(len(data)), Checksum: Checksum(data)}
+
+		assembled := &bytes.Buffer{}
+		err = Assemble(context.Background(), objects, &upload, chunks, assembled)
+		assert.NoError(t, err)
+		assert.Equal(t, data, assembled.Bytes())
+
+		assert.NoError(t, DeleteChunks(context.Background(), objects, "upl-1", chunks))
+		_, err = objects.Get(context.Background(), ChunkKey("upl-1", 0))
+		assert.ErrorIs(t, err, objectstore.ErrNotFound)
+	})
+
+	t.Run("Should reject a corrupted chunk", func(t *testing.T) {
+		objects, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+		chunks := stage(t, objects, "upl-2", data, 4096)
+		chunks[1].Checksum = Checksum([]byte("something else"))
+		upload := model.RecordingUpload{UploadId: "upl-2", Received: int64(len(data))}
+
+		err = Assemble(context.Background(), objects, &upload, chunks, io.Discard)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+
+	t.Run("Should reject a file that does not match the upload checksum", func(t *testing.T) {
+		objects, err := objectstore.NewLocalStore(t.TempDir(), "")
+		assert.NoError(t, err)
+		chunks := stage(t, objects, "upl-3", data, 4096)
+		upload := model.RecordingUpload{UploadId: "upl-3", Received: int64(len(data)), Checksum: Checksum([]byte("other file"))}
+
+		err = Assemble(context.Background(), objects, &upload, chunks, io.Discard)
+		assert.ErrorIs(t, err, ErrChecksumMismatch)
+	})
+}
+
+func TestCheckChunks(t *testing.T) {
+	chunks := []model.RecordingUploadChunk{{Offset: 0, Size: 10}, {Offset: 10, Size: 5}}
+
+	assert.NoError(t, CheckChunks(&model.RecordingUpload{Received: 15}, chunks))
+	assert.NoError(t, CheckChunks(&model.RecordingUpload{Received: 15, Size: 15}, chunks))
+	assert.ErrorIs(t, CheckChunks(&model.RecordingUpload{Received: 15, Size: 20}, chunks), ErrUploadIncomplete)
+	assert.ErrorIs(t, CheckChunks(&model.RecordingUpload{Received: 15}, chunks[1:]), ErrUploadIncomplete)
+	assert.ErrorIs(t, CheckChunks(&model.RecordingUpload{}, nil), ErrUploadIncomplete)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"lineblocs.com/api/database"
 )
 
 func Test_Healthz(t *testing.T) {
@@ -16,7 +17,7 @@ func Test_Healthz(t *testing.T) {
 	}
 	defer mockDB.Close()
 
-	adminStore := NewAdminStore(mockDB)
+	adminStore := NewAdminStore(database.NewMySQLConn(mockDB))
 
 	t.Run("Should return nil when the query is successful", func(t *testing.T) {
 
@@ -43,8 +44,8 @@ func Test_Healthz(t *testing.T) {
 
 		err := adminStore.Healthz()
 
-		assert.Error(t, err)
-		assert.Equal(t, expectedError, err)
+		// the connection wraps the error of the driver
+		assert.ErrorContains(t, err, expectedError.Error())
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("There were unfulfilled expectations: %s", err)
@@ -60,7 +61,7 @@ func Test_GetBestRTPProxy(t *testing.T) {
 	}
 	defer mockDB.Close()
 
-	adminStore := NewAdminStore(mockDB)
+	adminStore := NewAdminStore(database.NewMySQLConn(mockDB))
 
 	t.Run("Should return the best RTP proxy host", func(t *testing.T) {
 
@@ -0,0 +1,178 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+)
+
+/*
+Implementation of API Key Store
+*/
+
+type APIKeyStore struct {
+	db *database.MySQLConn
+}
+
+func NewAPIKeyStore(db *database.MySQLConn) *APIKeyStore {
+	return &APIKeyStore{
+		db: db,
+	}
+}
+
+const apiKeyColumns = "`id`, `service`, `prefix`, `key_hash`, `scopes`, `expires_at`, `created_at`"
+
+func scanAPIKey(scan func(dest ...interface{}) error) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes string
+	var expiresAt sql.NullTime
+	err := scan(&key.Id, &key.Service, &key.Prefix, &key.Hash, &scopes, &expiresAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	key.Scopes = []string{}
+	if scopes != "" {
+		err = json.Unmarshal([]byte(scopes), &key.Scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &key, nil
+}
+
+/*
+Input: APIKey model
+Todo : Store the hash of a new API key
+Output: First Value: id of the key, Second Value: error
+*/
+func (ks *APIKeyStore) CreateAPIKey(key *model.APIKey) (int, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return 0, err
+	}
+	res, err := ks.db.Exec("INSERT INTO microservice_api_keys (`service`, `prefix`, `key_hash`, `scopes`, `expires_at`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ? )",
+		key.Service, key.Prefix, key.Hash, string(scopes), key.ExpiresAt, key.CreatedAt, key.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+/*
+Input: id
+Todo : Get an API key
+Output: First Value: APIKey model, Second Value: error
+Returns sql.ErrNoRows for unknown keys
+*/
+func (ks *APIKeyStore) GetAPIKey(id int) (*model.APIKey, error) {
+	row := ks.db.QueryRow("SELECT "+apiKeyColumns+" FROM microservice_api_keys WHERE id = ?", id)
+	return scanAPIKey(row.Scan)
+}
+
+/*
+Input: hash
+Todo : Get the API key with the SHA-256 hash of a key presented by a service
+Output: First Value: APIKey model, Second Value: error
+Returns sql.ErrNoRows for unknown keys
+*/
+func (ks *APIKeyStore) GetAPIKeyByHash(hash string) (*model.APIKey, error) {
+	row := ks.db.QueryRow("SELECT "+apiKeyColumns+" FROM microservice_api_keys WHERE key_hash = ?", hash)
+	return scanAPIKey(row.Scan)
+}
+
+/*
+Input: service
+Todo : Get the API keys of a service, of every service when it is empty, newest first
+Output: First Value: list of APIKey model, Second Value: error
+*/
+func (ks *APIKeyStore) ListAPIKeys(service string) ([]model.APIKey, error) {
+	results, err := ks.db.Query("SELECT "+apiKeyColumns+" FROM microservice_api_keys WHERE ? = '' OR service = ? ORDER BY id DESC", service, service)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	keys := []model.APIKey{}
+	for results.Next() {
+		key, err := scanAPIKey(results.Scan)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, results.Err()
+}
+
+/*
+Input: APIKey model, replacedId, expiresAt
+Todo : Store the hash of a new API key and end the validity of the key it replaces at expiresAt, in one transaction
+Output: First Value: id of the new key, Second Value: error
+Returns sql.ErrNoRows when the replaced key is unknown, the new key is not stored then
+*/
+func (ks *APIKeyStore) RotateAPIKey(key *model.APIKey, replacedId int, expiresAt time.Time) (int, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := ks.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE microservice_api_keys SET `expires_at` = ?, `updated_at` = ? WHERE id = ?", expiresAt, time.Now(), replacedId)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, sql.ErrNoRows
+	}
+	res, err = tx.Exec("INSERT INTO microservice_api_keys (`service`, `prefix`, `key_hash`, `scopes`, `expires_at`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ? )",
+		key.Service, key.Prefix, key.Hash, string(scopes), key.ExpiresAt, key.CreatedAt, key.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+/*
+Input: id, expiresAt
+Todo : End the validity of an API key at expiresAt
+Output: If success return nil else return err
+Returns sql.ErrNoRows for unknown keys
+*/
+func (ks *APIKeyStore) ExpireAPIKey(id int, expiresAt time.Time) error {
+	res, err := ks.db.Exec("UPDATE microservice_api_keys SET `expires_at` = ?, `updated_at` = ? WHERE id = ?", expiresAt, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -8,10 +9,12 @@ import (
 	"time"
 	"database/sql"
 
-	"github.com/gocql/gocql"
-
 	"github.com/sirupsen/logrus"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/helpers"
+	"lineblocs.com/api/mail"
 	"lineblocs.com/api/model"
+	"lineblocs.com/api/notification"
 	"lineblocs.com/api/utils"
 	"lineblocs.com/api/database"
 )
@@ -22,7 +25,6 @@ Implementation of Call Store
 
 type CallStore struct {
 	db *database.MySQLConn
-	cqlSess *gocql.Session
 }
 
 func NewCallStore(db *database.MySQLConn) *CallStore {
@@ -38,23 +40,77 @@ Output: First Value: callId, Second Value:error
 If success return (callid, nil) else return (nil, err)
 */
 func (cs *CallStore) CreateCall(call *model.Call) (string, error) {
+	status, err := initialCallStatus(call.Status)
+	if err != nil {
+		return "-1", err
+	}
+
 	now := time.Now()
-	call.StartedAt = now.Format("MM/DD/YYYY")
-	call.CreatedAt = now.Format("MM/DD/YYYY")
-	call.UpdatedAt = now.Format("MM/DD/YYYY")
+	call.Status = status
+	call.Duration = 0
+	call.StartedAt = now.Format(time.RFC3339)
+	call.CreatedAt = now.Format(time.RFC3339)
+	call.UpdatedAt = now.Format(time.RFC3339)
+
+	var ringingAt, answeredAt sql.NullTime
+	if status == callstate.StatusRinging {
+		ringingAt = sql.NullTime{Time: now, Valid: true}
+		call.RingingAt = call.StartedAt
+	}
+	if status == callstate.StatusAnswered {
+		answeredAt = sql.NullTime{Time: now, Valid: true}
+		call.AnsweredAt = call.StartedAt
+	}
+
 	workspace, err := cs.GetWorkspaceFromDB(call.WorkspaceId)
 
 	if err != nil {
 		return "-1", err
 	}
 
-	stmt, err := cs.db.Prepare("INSERT INTO calls ( `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `sip_call_id`, `user_id`, `workspace_id`, `started_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot`, `notes`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '' )")
+	// child legs (transfers, forwards, forks) join the session of the leg that spawned them
+	var parentCallId sql.NullInt64
+	if call.ParentCallId != 0 {
+		var sessionId sql.NullString
+		var parentWorkspaceId int
+		row := cs.db.QueryRow("SELECT `session_id`, `workspace_id` FROM calls WHERE `id` = ?", call.ParentCallId)
+		err = row.Scan(&sessionId, &parentWorkspaceId)
+		if err == sql.ErrNoRows {
+			return "-1", callstate.ErrParentCallNotFound
+		}
+		if err != nil {
+			return "-1", err
+		}
+		if parentWorkspaceId != call.WorkspaceId {
+			return "-1", callstate.ErrParentWorkspaceMismatch
+		}
+		parentCallId = sql.NullInt64{Int64: int64(call.ParentCallId), Valid: true}
+		call.SessionId = sessionId.String
+	} else if call.SessionId != "" {
+		// legs without a parent can only join a session of their own workspace
+		var sessionWorkspaceId int
+		row := cs.db.QueryRow("SELECT `workspace_id` FROM calls WHERE `session_id` = ? AND `workspace_id` = ? LIMIT 1", call.SessionId, call.WorkspaceId)
+		err = row.Scan(&sessionWorkspaceId)
+		if err == sql.ErrNoRows {
+			return "-1", callstate.ErrSessionNotFound
+		}
+		if err != nil {
+			return "-1", err
+		}
+	}
+	if call.SessionId == "" {
+		call.SessionId = utils.CreateAPIID("session")
+	}
+
+	// the call and its outbox event are committed together so the event is never lost or sent for a call that does not exist
+	tx, err := cs.db.Begin()
 	if err != nil {
 		return "-1", err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	res, err := stmt.Exec(call.From, call.To, call.ChannelId, call.Status, call.Direction, "8", call.SIPCallId, call.UserId, call.WorkspaceId, now, now, now, call.APIId, workspace.Plan)
+	res, err := tx.Exec("INSERT INTO calls ( `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `sip_call_id`, `user_id`, `workspace_id`, `parent_call_id`, `session_id`, `started_at`, `ringing_at`, `answered_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot`, `notes`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '' )",
+		call.From, call.To, call.ChannelId, call.Status, call.Direction, call.Duration, call.SIPCallId, call.UserId, call.WorkspaceId, parentCallId, call.SessionId, now, ringingAt, answeredAt, now, now, call.APIId, workspace.Plan)
 
 	if err != nil {
 		return "-1", err
@@ -64,73 +120,118 @@ func (cs *CallStore) CreateCall(call *model.Call) (string, error) {
 	if err != nil {
 		return "-1", err
 	}
-	return strconv.FormatInt(callId, 10), err
+	call.Id = int(callId)
+
+	err = enqueueCallActivity(tx, "CALL_CREATED", call)
+	if err != nil {
+		return "-1", err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return "-1", err
+	}
+	return strconv.FormatInt(callId, 10), nil
 }
 
-/*
-Input: Call model
-Todo : creates a call and inserts it into Cassandra DB
-Output: First Value: callId, Second Value:error
-If success return (callid, nil) else return (nil, err)
-*/
-func (cs *CallStore) CreateCallv2(call *model.Call) (string, error) {
-	now := time.Now()
-	call.StartedAt = now.Format("MM/DD/YYYY")
-	call.CreatedAt = now.Format("MM/DD/YYYY")
-	call.UpdatedAt = now.Format("MM/DD/YYYY")
-	//plan:="testing123"
-	workspaceId := call.WorkspaceId
-	var callId int64 = 123
-
-	results := make(chan error, 2)
-	go func() {
-		results <- cs.cqlSess.Query(`INSERT INTO call2(callId, workspaceId) VALUES (?, ?);`, callId, workspaceId).Exec()
-	}()
-
-	var err error = nil
-	return strconv.FormatInt(callId, 10), err
+// calls are created as INITIATED unless the media server already knows the call is further along
+func initialCallStatus(status string) (string, error) {
+	status = callstate.NormalizeStatus(status)
+	if status == "" {
+		return callstate.StatusInitiated, nil
+	}
+	if !callstate.IsKnownStatus(status) || callstate.IsTerminalStatus(status) {
+		return "", callstate.ErrUnknownStatus
+	}
+	return status, nil
 }
 
 /*
 Input: CallUpdate model
-Todo : Update existing call with matching id
+Todo : Move existing call with matching id to a new status, recording ringing_at, answered_at and ended_at.
+Terminal statuses also store the billable duration, counted from answer time
 Output: If success return nil else return err
+If the status change is not allowed return call.ErrInvalidTransition
 */
 func (cs *CallStore) UpdateCall(update *model.CallUpdate) error {
-	// Perform a db.Query insert
-	var err error
-	var stmt *sql.Stmt
-	updatedAt := time.Now()
+	var currentStatus string
+	var answeredAt sql.NullTime
+	call := model.Call{Id: update.CallId}
 
-	if update.Status == "ENDED" {
-		stmt, err = cs.db.Prepare("UPDATE calls SET `status` = ?, `ended_at` = ?, `updated_at` = ? WHERE `id` = ?")
-		if err != nil {
-			utils.Log(logrus.InfoLevel, "UpdateCall 2 Could not execute query..")
-			utils.Log(logrus.InfoLevel, err.Error())
-			return err
-		}
-		defer stmt.Close()
+	status := callstate.NormalizeStatus(update.Status)
+	row := cs.db.QueryRow("SELECT `status`, `answered_at`, `from`, `to`, `workspace_id` FROM calls WHERE `id` = ?", update.CallId)
+	err := row.Scan(&currentStatus, &answeredAt, &call.From, &call.To, &call.WorkspaceId)
+	if err != nil {
+		utils.Log(logrus.InfoLevel, "UpdateCall 1 Could not find call..")
+		return err
+	}
 
-		endedAt := time.Now()
-		utils.Log(logrus.InfoLevel, "updating call id = " + strconv.Itoa( update.CallId ))
-		_, err = stmt.Exec(update.Status, endedAt, updatedAt, update.CallId)
-	} else {
-		stmt, err = cs.db.Prepare("UPDATE calls SET `status` = ?, `updated_at` = ? WHERE `id` = ?")
-		if err != nil {
-			utils.Log(logrus.InfoLevel, "UpdateCall 2 Could not execute query..")
-			utils.Log(logrus.InfoLevel, err.Error())
-			return err
+	err = callstate.ValidateTransition(currentStatus, status)
+	if err != nil {
+		utils.Log(logrus.InfoLevel, fmt.Sprintf("UpdateCall rejected status change for call id = %d from %s to %s", update.CallId, currentStatus, status))
+		return err
+	}
+
+	now := time.Now()
+	var res sql.Result
+	utils.Log(logrus.InfoLevel, "updating call id = " + strconv.Itoa( update.CallId ))
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the status guard makes concurrent updates of the same call fail instead of overwriting each other
+	switch {
+	case status == callstate.StatusRinging:
+		res, err = tx.Exec("UPDATE calls SET `status` = ?, `ringing_at` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?", status, now, now, update.CallId, currentStatus)
+	case status == callstate.StatusAnswered:
+		res, err = tx.Exec("UPDATE calls SET `status` = ?, `answered_at` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?", status, now, now, update.CallId, currentStatus)
+	default:
+		duration := 0
+		if answeredAt.Valid {
+			duration = int(now.Sub(answeredAt.Time).Seconds())
 		}
-		defer stmt.Close()
+		res, err = tx.Exec("UPDATE calls SET `status` = ?, `ended_at` = ?, `duration` = ?, `updated_at` = ? WHERE `id` = ? AND `status` = ?", status, now, duration, now, update.CallId, currentStatus)
+	}
+	if err != nil {
+		utils.Log(logrus.InfoLevel, "UpdateCall 2 Could not execute query..")
+		utils.Log(logrus.InfoLevel, err.Error())
+		return err
+	}
 
-		utils.Log(logrus.InfoLevel, "updating call id = " + strconv.Itoa( update.CallId ))
-		_, err = stmt.Exec(update.Status, updatedAt, update.CallId)
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return callstate.ErrInvalidTransition
 	}
 
+	err = enqueueCallActivity(tx, "CALL_"+status, &call)
 	if err != nil {
 		return err
 	}
-	return nil
+	return tx.Commit()
+}
+
+/*
+Input: eventType, Call model
+Todo : Store a call_activity event in the outbox for calls that did not change state, e.g. rejected calls
+Output: If success return nil else return err
+*/
+func (cs *CallStore) QueueCallActivity(eventType string, call *model.Call) error {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = enqueueCallActivity(tx, eventType, call)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 /*
@@ -160,13 +261,22 @@ func (cs *CallStore) UpdateCallBySIPId(update *model.CallUpdate) error {
 }
 
 /*
-Input: Call model
-Todo : Check first call and send email
+Input: Call model, mailer
+Todo : Check first call to the destination country and email the user through mailer
 */
-func (cs *CallStore) ProcessUsersFirstCall(call model.Call) {
+func (cs *CallStore) ProcessUsersFirstCall(call model.Call, mailer mail.Mailer) {
+	if mailer == nil {
+		return
+	}
+	countryCode, err := helpers.ParseCountryCode(call.To)
+	if err != nil {
+		utils.Log(logrus.WarnLevel, fmt.Sprintf("could not get destination country of %s: %s", call.To, err.Error()))
+		return
+	}
 	var id string
-	row := cs.db.QueryRow("SELECT id FROM `calls` WHERE `workspace_id` = ? AND `from` LIKE '?%s' AND `direction = 'outbound'", call.WorkspaceId, call.From, call.Direction)
-	err := row.Scan(&id)
+	row := cs.db.QueryRow("SELECT id FROM `calls` WHERE `workspace_id` = ? AND (`to` LIKE ? OR `to` LIKE ?) AND `direction` = 'outbound' AND `api_id` <> ? LIMIT 1",
+		call.WorkspaceId, "+"+countryCode+"%", countryCode+"%", call.APIId)
+	err = row.Scan(&id)
 	if err != sql.ErrNoRows {
 		// All ok
 		return
@@ -174,10 +284,21 @@ func (cs *CallStore) ProcessUsersFirstCall(call model.Call) {
 	//Send notification
 	user, err := cs.GetUserFromDB(call.UserId)
 	if err != nil {
-		panic(err)
+		utils.Log(logrus.ErrorLevel, "could not get user for first call email: "+err.Error())
+		return
+	}
+	message, err := notification.Render(notification.TypeFirstCall, user.Locale, map[string]interface{}{
+		"FirstName": user.FirstName,
+		"To":        call.To,
+	})
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not render first call email: "+err.Error())
+		return
+	}
+	err = mailer.Send(context.Background(), mail.NewEmail([]string{user.Email}, message))
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not send first call email: "+err.Error())
 	}
-	body := `A call was made to ` + call.To + ` for the first time on your account.`
-	sendEmail(user, "First call to destination country", body)
 }
 
 /*
@@ -187,7 +308,9 @@ Output: First Value: Call model,Second Value: error
 If success return Call model else return err
 */
 func (cs *CallStore) GetCallFromDB(id int) (*model.Call, error) {
-	row := cs.db.QueryRow("SELECT `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `user_id`, `workspace_id`, `started_at`, `ended_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot` FROM calls WHERE id = ?", id)
+	var ringingAt, answeredAt, endedAt, sessionId, sipCallId sql.NullString
+	var parentCallId sql.NullInt64
+	row := cs.db.QueryRow("SELECT `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `sip_call_id`, `user_id`, `workspace_id`, `parent_call_id`, `session_id`, `started_at`, `ringing_at`, `answered_at`, `ended_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot` FROM calls WHERE id = ?", id)
 	call := model.Call{Id: id}
 	err := row.Scan(
 		&call.From,
@@ -196,10 +319,15 @@ func (cs *CallStore) GetCallFromDB(id int) (*model.Call, error) {
 		&call.Status,
 		&call.Direction,
 		&call.Duration,
+		&sipCallId,
 		&call.UserId,
 		&call.WorkspaceId,
+		&parentCallId,
+		&sessionId,
 		&call.StartedAt,
-		&call.EndedAt,
+		&ringingAt,
+		&answeredAt,
+		&endedAt,
 		&call.CreatedAt,
 		&call.UpdatedAt,
 		&call.APIId,
@@ -207,9 +335,69 @@ func (cs *CallStore) GetCallFromDB(id int) (*model.Call, error) {
 	if err != nil {
 		return nil, err
 	}
+	call.SIPCallId = sipCallId.String
+	call.ParentCallId = int(parentCallId.Int64)
+	call.SessionId = sessionId.String
+	call.RingingAt = ringingAt.String
+	call.AnsweredAt = answeredAt.String
+	call.EndedAt = endedAt.String
 	return &call, nil
 }
 
+/*
+Input: workspaceId, session_id
+Todo : Fetch every leg of a call session of the workspace
+Output: First Value: list of Call model, Second Value: error
+If success return (calls, nil) else return (nil, err)
+*/
+func (cs *CallStore) GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error) {
+	results, err := cs.db.Query("SELECT `id`, `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `user_id`, `workspace_id`, `parent_call_id`, `sip_call_id`, `started_at`, `ringing_at`, `answered_at`, `ended_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot` FROM calls WHERE session_id = ? AND workspace_id = ? ORDER BY id ASC", sessionId, workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	calls := []model.Call{}
+	for results.Next() {
+		var ringingAt, answeredAt, endedAt, sipCallId sql.NullString
+		var parentCallId sql.NullInt64
+		call := model.Call{SessionId: sessionId}
+		err = results.Scan(
+			&call.Id,
+			&call.From,
+			&call.To,
+			&call.ChannelId,
+			&call.Status,
+			&call.Direction,
+			&call.Duration,
+			&call.UserId,
+			&call.WorkspaceId,
+			&parentCallId,
+			&sipCallId,
+			&call.StartedAt,
+			&ringingAt,
+			&answeredAt,
+			&endedAt,
+			&call.CreatedAt,
+			&call.UpdatedAt,
+			&call.APIId,
+			&call.PlanSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		call.ParentCallId = int(parentCallId.Int64)
+		call.SIPCallId = sipCallId.String
+		call.RingingAt = ringingAt.String
+		call.AnsweredAt = answeredAt.String
+		call.EndedAt = endedAt.String
+		calls = append(calls, call)
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+	return calls, nil
+}
+
 /*
 Input: id
 Todo : Fetch a call with sip_call_id
@@ -217,8 +405,10 @@ Output: First Value: Call model,Second Value: error
 If success return Call model else return err
 */
 func (cs *CallStore) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
-	row := cs.db.QueryRow("SELECT `id`, `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `user_id`, `workspace_id`, `started_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot` FROM calls WHERE sip_call_id = ?", sipCallId)
-	call := model.Call{}
+	var parentCallId sql.NullInt64
+	var sessionId sql.NullString
+	row := cs.db.QueryRow("SELECT `id`, `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `user_id`, `workspace_id`, `parent_call_id`, `session_id`, `started_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot` FROM calls WHERE sip_call_id = ?", sipCallId)
+	call := model.Call{SIPCallId: sipCallId}
 	err := row.Scan(
 		&call.Id,
 		&call.From,
@@ -229,6 +419,8 @@ func (cs *CallStore) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
 		&call.Duration,
 		&call.UserId,
 		&call.WorkspaceId,
+		&parentCallId,
+		&sessionId,
 		&call.StartedAt,
 		&call.CreatedAt,
 		&call.UpdatedAt,
@@ -237,6 +429,8 @@ func (cs *CallStore) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
 	if err != nil {
 		return nil, err
 	}
+	call.ParentCallId = int(parentCallId.Int64)
+	call.SessionId = sessionId.String
 	return &call, nil
 }
 
@@ -300,14 +494,15 @@ func (cs *CallStore) CreateConference(conference *model.Conference) (string, err
 	err := row.Scan(&id, &name)
 	if err == sql.ErrNoRows {
 		conference.APIId = utils.CreateAPIID("conf")
+		conference.Status = callstate.ConferenceCreated
 		// perform a db.Query insert
 		now := time.Now()
-		stmt, err := cs.db.Prepare("INSERT INTO conferences (`name`, `workspace_id`, `api_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ? )")
+		stmt, err := cs.db.Prepare("INSERT INTO conferences (`name`, `workspace_id`, `api_id`, `pin`, `moderator_pin`, `status`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ? )")
 		if err != nil {
 			return "-1", err
 		}
 		defer stmt.Close()
-		res, err := stmt.Exec(conference.Name, conference.WorkspaceId, conference.APIId, now, now)
+		res, err := stmt.Exec(conference.Name, conference.WorkspaceId, conference.APIId, conference.Pin, conference.ModeratorPin, conference.Status, now, now)
 
 		if err != nil {
 			return "-1", err
@@ -316,14 +511,16 @@ func (cs *CallStore) CreateConference(conference *model.Conference) (string, err
 		if err != nil {
 			return "-1", err
 		}
+		conference.Id = int(conferenceId)
 		return strconv.FormatInt(conferenceId, 10), nil
 	}
+	if err != nil {
+		return "-1", err
+	}
+	conference.Id = id
 	return strconv.Itoa(id), nil
 }
 
-func sendEmail(user *model.User, subject string, body string) {
-}
-
 /*
 Input: id
 Todo : Create new conference and store to db
@@ -376,7 +573,7 @@ func (cs *CallStore) GetWorkspaceByDomain(domain string) (*model.Workspace, erro
 
 /*
 Input: id
-Todo : Get User with matching id
+Todo : Get User with matching id and its locale
 Output: First Value: User model, Second Value: error
 If success return (User model, nil) else return (nil, err)
 */
@@ -397,7 +594,22 @@ func (cs *CallStore) GetUserFromDB(id int) (*model.User, error) {
 		return nil, err
 	}
 
-	return &model.User{Id: userId, Username: username, FirstName: fname, LastName: lname, Email: email}, nil
+	return &model.User{Id: userId, Username: username, FirstName: fname, LastName: lname, Email: email, Locale: cs.getUserLocale(userId)}, nil
+}
+
+// getUserLocale reads the locale of a user on its own, databases without the
+// users.locale column and users without a locale get the default one.
+func (cs *CallStore) getUserLocale(id int) string {
+	var locale sql.NullString
+	err := cs.db.QueryRow(`SELECT locale FROM users WHERE id=?`, id).Scan(&locale)
+	if err != nil {
+		utils.Log(logrus.DebugLevel, fmt.Sprintf("could not read locale of user %d: %s", id, err.Error()))
+		return notification.DefaultLocale
+	}
+	if locale.String == "" {
+		return notification.DefaultLocale
+	}
+	return locale.String
 }
 
 func (cs *CallStore) IsUserAllowedToMakeCall(workspaceId int) (bool, error) {
@@ -438,6 +650,41 @@ func (cs *CallStore) IsUserAllowedToMakeCall(workspaceId int) (bool, error) {
 	return true, nil
 }
 
+/*
+Input: workspaceId, extension, trunkId
+Todo : Get the concurrent call limits from the workspace plan ports, the extension channels and the trunk channels.
+The columns are added by migrations/20261019_concurrent_call_limits.sql
+Output: First Value: CallLimits model, Second Value: error
+*/
+func (cs *CallStore) GetConcurrentCallLimits(workspaceId int, extension string, trunkId int) (*model.CallLimits, error) {
+	var limits model.CallLimits
+
+	row := cs.db.QueryRow(`SELECT COALESCE(service_plans.ports, 0) FROM subscriptions
+		INNER JOIN service_plans ON service_plans.id = subscriptions.current_plan_id
+		WHERE subscriptions.workspace_id = ?`, workspaceId)
+	err := row.Scan(&limits.Workspace)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	if extension != "" {
+		row = cs.db.QueryRow("SELECT COALESCE(channels, 0) FROM extensions WHERE workspace_id = ? AND username = ?", workspaceId, extension)
+		err = row.Scan(&limits.Extension)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	if trunkId != 0 {
+		row = cs.db.QueryRow("SELECT COALESCE(channels, 0) FROM sip_trunks WHERE id = ? AND workspace_id = ?", trunkId, workspaceId)
+		err = row.Scan(&limits.Trunk)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+	return &limits, nil
+}
+
 /*
 Input: workspaceId, callerId
 Todo : Check if caller id is permitted to be used with a workspace
@@ -508,8 +755,8 @@ func (cs *CallStore) LookupBestCallRate(from string, to string, callDirection st
 
 	utils.Log(logrus.DebugLevel, fmt.Sprintf("LookupBestCallRate - to: %s, from: %s", to, from))
 
-	// Query call_rates table
-	if callDirection != "OUTBOUND" && callDirection != "INBOUND" {
+	// Query call_rates table, conference minutes are priced by the rate deck of type conference
+	if callDirection != "OUTBOUND" && callDirection != "INBOUND" && callDirection != "CONFERENCE" {
 		return nil
 	}
 
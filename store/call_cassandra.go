@@ -0,0 +1,558 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Implementation of Call Store on Cassandra
+
+Call records (create, update, fetch by id, SIP call id and session) are kept
+in Cassandra for write throughput. Workspaces, users, rates, conferences and
+the outbox stay in MySQL, so CassandraCallStore embeds the MySQL CallStore.
+Every call also keeps a reference row in the MySQL calls table under its
+Cassandra id, the tables that join or reference calls keep working.
+*/
+
+const (
+	CallStoreBackendMySQL     = "mysql"
+	CallStoreBackendCassandra = "cassandra"
+	CallStoreBackendDual      = "dual"
+)
+
+// CassandraCallSchema creates the tables used by CassandraCallStore.
+var CassandraCallSchema = []string{
+	`CREATE TABLE IF NOT EXISTS calls_by_id (
+		id bigint PRIMARY KEY,
+		from_number text,
+		to_number text,
+		channel_id text,
+		status text,
+		direction text,
+		duration int,
+		sip_call_id text,
+		provider_id int,
+		user_id int,
+		workspace_id int,
+		parent_call_id bigint,
+		session_id text,
+		started_at timestamp,
+		ringing_at timestamp,
+		answered_at timestamp,
+		ended_at timestamp,
+		created_at timestamp,
+		updated_at timestamp,
+		api_id text,
+		plan_snapshot text
+	)`,
+	`CREATE TABLE IF NOT EXISTS calls_by_sip_call_id (
+		sip_call_id text PRIMARY KEY,
+		id bigint
+	)`,
+	`CREATE TABLE IF NOT EXISTS calls_by_session (
+		session_id text,
+		id bigint,
+		PRIMARY KEY (session_id, id)
+	)`,
+}
+
+const cassandraCallColumns = "id, from_number, to_number, channel_id, status, direction, duration, sip_call_id, user_id, workspace_id, parent_call_id, session_id, started_at, ringing_at, answered_at, ended_at, created_at, updated_at, api_id, plan_snapshot"
+
+type CassandraCallStore struct {
+	*CallStore
+	session *gocql.Session
+	ids     *callIdGenerator
+}
+
+// NewCassandraCallStore creates call ids with node, which must be unique
+// among the API instances and at most MaxCallIdNode.
+func NewCassandraCallStore(db *database.MySQLConn, session *gocql.Session, node int) *CassandraCallStore {
+	return &CassandraCallStore{
+		CallStore: NewCallStore(db),
+		session:   session,
+		ids:       newCallIdGenerator(node),
+	}
+}
+
+/*
+Input: Cassandra session
+Todo : Create the call tables if they do not exist yet
+Output: If success return nil else return err
+*/
+func CreateCassandraCallTables(session *gocql.Session) error {
+	for _, stmt := range CassandraCallSchema {
+		err := session.Query(stmt).Exec()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Input: Call model
+Todo : Create new call and store it in Cassandra
+Output: First Value: callId, Second Value:error
+If success return (callid, nil) else return (nil, err)
+*/
+func (cs *CassandraCallStore) CreateCall(call *model.Call) (string, error) {
+	status, err := initialCallStatus(call.Status)
+	if err != nil {
+		return "-1", err
+	}
+
+	now := time.Now().UTC()
+	call.Status = status
+	call.Duration = 0
+	call.StartedAt = now.Format(time.RFC3339)
+	call.CreatedAt = now.Format(time.RFC3339)
+	call.UpdatedAt = now.Format(time.RFC3339)
+	if status == callstate.StatusRinging {
+		call.RingingAt = call.StartedAt
+	}
+	if status == callstate.StatusAnswered {
+		call.AnsweredAt = call.StartedAt
+	}
+
+	workspace, err := cs.GetWorkspaceFromDB(call.WorkspaceId)
+	if err != nil {
+		return "-1", err
+	}
+	call.PlanSnapshot = workspace.Plan
+
+	if call.ParentCallId != 0 {
+		parent, err := cs.GetCallFromDB(call.ParentCallId)
+		if err == sql.ErrNoRows {
+			return "-1", callstate.ErrParentCallNotFound
+		}
+		if err != nil {
+			return "-1", err
+		}
+		if parent.WorkspaceId != call.WorkspaceId {
+			return "-1", callstate.ErrParentWorkspaceMismatch
+		}
+		call.SessionId = parent.SessionId
+	} else if call.SessionId != "" {
+		// legs without a parent can only join a session of their own workspace
+		legs, err := cs.GetCallsBySessionId(call.WorkspaceId, call.SessionId)
+		if err != nil {
+			return "-1", err
+		}
+		if len(legs) == 0 {
+			return "-1", callstate.ErrSessionNotFound
+		}
+	}
+	if call.SessionId == "" {
+		call.SessionId = utils.CreateAPIID("session")
+	}
+
+	call.Id = cs.ids.Next()
+	err = cs.writeCall(call)
+	if err != nil {
+		return "-1", err
+	}
+
+	// Cassandra and MySQL cannot share a transaction, the call is written to Cassandra
+	// first and only handed out once its MySQL reference row and event are committed
+	err = cs.createCallReference(call)
+	if err != nil {
+		return "-1", err
+	}
+	return strconv.Itoa(call.Id), nil
+}
+
+/*
+Input: CallUpdate model
+Todo : Move existing call with matching id to a new status, using a lightweight transaction as the status guard
+Output: If success return nil else return err
+If the status change is not allowed return call.ErrInvalidTransition
+*/
+func (cs *CassandraCallStore) UpdateCall(update *model.CallUpdate) error {
+	call, err := cs.GetCallFromDB(update.CallId)
+	if err != nil {
+		utils.Log(logrus.InfoLevel, "UpdateCall 1 Could not find call..")
+		return err
+	}
+
+	status := callstate.NormalizeStatus(update.Status)
+	err = callstate.ValidateTransition(call.Status, status)
+	if err != nil {
+		utils.Log(logrus.InfoLevel, fmt.Sprintf("UpdateCall rejected status change for call id = %d from %s to %s", update.CallId, call.Status, status))
+		return err
+	}
+
+	now := time.Now().UTC()
+	var query *gocql.Query
+	switch {
+	case status == callstate.StatusRinging:
+		query = cs.session.Query("UPDATE calls_by_id SET status = ?, ringing_at = ?, updated_at = ? WHERE id = ? IF status = ?", status, now, now, call.Id, call.Status)
+	case status == callstate.StatusAnswered:
+		query = cs.session.Query("UPDATE calls_by_id SET status = ?, answered_at = ?, updated_at = ? WHERE id = ? IF status = ?", status, now, now, call.Id, call.Status)
+	default:
+		duration := 0
+		if answeredAt, err := time.Parse(time.RFC3339, call.AnsweredAt); err == nil {
+			duration = int(now.Sub(answeredAt).Seconds())
+		}
+		query = cs.session.Query("UPDATE calls_by_id SET status = ?, ended_at = ?, duration = ?, updated_at = ? WHERE id = ? IF status = ?", status, now, duration, now, call.Id, call.Status)
+	}
+
+	var currentStatus string
+	applied, err := query.ScanCAS(&currentStatus)
+	if err != nil {
+		utils.Log(logrus.InfoLevel, "UpdateCall 2 Could not execute query..")
+		return err
+	}
+	if !applied {
+		return callstate.ErrInvalidTransition
+	}
+
+	call, err = cs.GetCallFromDB(update.CallId)
+	if err != nil {
+		return err
+	}
+	return cs.updateCallReference(call, "CALL_"+status)
+}
+
+/*
+Input: id
+Todo : Fetch a call with call_id from Cassandra
+Output: First Value: Call model,Second Value: error
+If success return Call model else return err
+*/
+func (cs *CassandraCallStore) GetCallFromDB(id int) (*model.Call, error) {
+	query := cs.session.Query("SELECT "+cassandraCallColumns+" FROM calls_by_id WHERE id = ?", int64(id))
+	call, err := scanCassandraCall(query.Iter().Scanner())
+	if err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+/*
+Input: sipCallId
+Todo : Fetch a call with its SIP call id from Cassandra
+Output: First Value: Call model,Second Value: error
+*/
+func (cs *CassandraCallStore) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
+	var id int64
+	err := cs.session.Query("SELECT id FROM calls_by_sip_call_id WHERE sip_call_id = ?", sipCallId).Scan(&id)
+	if err == gocql.ErrNotFound {
+		return nil, sql.ErrNoRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cs.GetCallFromDB(int(id))
+}
+
+/*
+Input: workspaceId, session_id
+Todo : Fetch every leg of a call session of the workspace from Cassandra
+Output: First Value: list of Call model, Second Value: error
+*/
+func (cs *CassandraCallStore) GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error) {
+	iter := cs.session.Query("SELECT id FROM calls_by_session WHERE session_id = ?", sessionId).Iter()
+	var ids []int64
+	var id int64
+	for iter.Scan(&id) {
+		ids = append(ids, id)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	calls := []model.Call{}
+	for _, id := range ids {
+		call, err := cs.GetCallFromDB(int(id))
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if call.WorkspaceId != workspaceId {
+			continue
+		}
+		calls = append(calls, *call)
+	}
+	return calls, nil
+}
+
+/*
+Input: callid, apiid
+Todo : Set sip_call_id of the call and index it for lookups by SIP call id
+Output: If success return nil else return err
+*/
+func (cs *CassandraCallStore) SetSIPCallID(callid string, apiid string) error {
+	id, err := strconv.ParseInt(apiid, 10, 64)
+	if err != nil {
+		return err
+	}
+	batch := cs.session.NewBatch(gocql.LoggedBatch)
+	batch.Query("UPDATE calls_by_id SET sip_call_id = ? WHERE id = ?", callid, id)
+	batch.Query("INSERT INTO calls_by_sip_call_id (sip_call_id, id) VALUES (?, ?)", callid, id)
+	err = cs.session.ExecuteBatch(batch)
+	if err != nil {
+		return err
+	}
+	return cs.CallStore.SetSIPCallID(callid, apiid)
+}
+
+/*
+Input: ip, apiid
+Todo : Update provider_id of the call with the provider owning the ip address
+Output: If success return nil else return err
+*/
+func (cs *CassandraCallStore) SetProviderByIP(ip string, apiid string) error {
+	id, err := strconv.ParseInt(apiid, 10, 64)
+	if err != nil {
+		return err
+	}
+	var providerId int
+	err = cs.db.QueryRow(`SELECT sip_providers_hosts.provider_id FROM sip_providers_hosts WHERE sip_providers_hosts.ip_address = ?`, ip).Scan(&providerId)
+	if err != nil {
+		return err
+	}
+	err = cs.session.Query("UPDATE calls_by_id SET provider_id = ? WHERE id = ?", providerId, id).Exec()
+	if err != nil {
+		return err
+	}
+	_, err = cs.db.Exec("UPDATE calls SET provider_id = ? WHERE id = ?", providerId, id)
+	return err
+}
+
+// createCallReference stores the MySQL reference row of a Cassandra call under the
+// same id together with its outbox event. Recordings, debits, faxes and SIP reports
+// join and reference calls in MySQL, so calls.id has to be a BIGINT for snowflake ids.
+func (cs *CassandraCallStore) createCallReference(call *model.Call) error {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	_, err = tx.Exec("INSERT INTO calls ( `id`, `from`, `to`, `channel_id`, `status`, `direction`, `duration`, `sip_call_id`, `user_id`, `workspace_id`, `parent_call_id`, `session_id`, `started_at`, `ringing_at`, `answered_at`, `created_at`, `updated_at`, `api_id`, `plan_snapshot`, `notes`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '' )",
+		int64(call.Id), call.From, call.To, call.ChannelId, call.Status, call.Direction, call.Duration, call.SIPCallId, call.UserId, call.WorkspaceId, nullableBigint(call.ParentCallId), call.SessionId,
+		mysqlTime(call.StartedAt), mysqlTime(call.RingingAt), mysqlTime(call.AnsweredAt), now, now, call.APIId, call.PlanSnapshot)
+	if err != nil {
+		return err
+	}
+	err = enqueueCallActivity(tx, "CALL_CREATED", call)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// updateCallReference copies the status, timestamps and duration of a Cassandra call to its
+// MySQL reference row together with the outbox event of the transition.
+func (cs *CassandraCallStore) updateCallReference(call *model.Call, eventType string) error {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE calls SET `status` = ?, `duration` = ?, `ringing_at` = ?, `answered_at` = ?, `ended_at` = ?, `updated_at` = ? WHERE `id` = ?",
+		call.Status, call.Duration, mysqlTime(call.RingingAt), mysqlTime(call.AnsweredAt), mysqlTime(call.EndedAt), time.Now().UTC(), int64(call.Id))
+	if err != nil {
+		return err
+	}
+	err = enqueueCallActivity(tx, eventType, call)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// writeCall stores the full call row and its lookup rows in one logged batch.
+// It is an upsert, dual-write mode uses it to mirror MySQL rows.
+func (cs *CassandraCallStore) writeCall(call *model.Call) error {
+	batch := cs.session.NewBatch(gocql.LoggedBatch)
+	batch.Query("INSERT INTO calls_by_id ("+cassandraCallColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		int64(call.Id),
+		call.From,
+		call.To,
+		call.ChannelId,
+		call.Status,
+		call.Direction,
+		call.Duration,
+		nullableText(call.SIPCallId),
+		call.UserId,
+		call.WorkspaceId,
+		nullableBigint(call.ParentCallId),
+		call.SessionId,
+		cassandraTime(call.StartedAt),
+		cassandraTime(call.RingingAt),
+		cassandraTime(call.AnsweredAt),
+		cassandraTime(call.EndedAt),
+		cassandraTime(call.CreatedAt),
+		cassandraTime(call.UpdatedAt),
+		call.APIId,
+		call.PlanSnapshot)
+	if call.SessionId != "" {
+		batch.Query("INSERT INTO calls_by_session (session_id, id) VALUES (?, ?)", call.SessionId, int64(call.Id))
+	}
+	if call.SIPCallId != "" {
+		batch.Query("INSERT INTO calls_by_sip_call_id (sip_call_id, id) VALUES (?, ?)", call.SIPCallId, int64(call.Id))
+	}
+	return cs.session.ExecuteBatch(batch)
+}
+
+func scanCassandraCall(scanner gocql.Scanner) (*model.Call, error) {
+	if !scanner.Next() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		// unknown calls fail like they do in MySQL
+		return nil, sql.ErrNoRows
+	}
+
+	var id int64
+	var parentCallId *int64
+	var sipCallId *string
+	var startedAt, ringingAt, answeredAt, endedAt, createdAt, updatedAt time.Time
+	call := model.Call{}
+	err := scanner.Scan(
+		&id,
+		&call.From,
+		&call.To,
+		&call.ChannelId,
+		&call.Status,
+		&call.Direction,
+		&call.Duration,
+		&sipCallId,
+		&call.UserId,
+		&call.WorkspaceId,
+		&parentCallId,
+		&call.SessionId,
+		&startedAt,
+		&ringingAt,
+		&answeredAt,
+		&endedAt,
+		&createdAt,
+		&updatedAt,
+		&call.APIId,
+		&call.PlanSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	call.Id = int(id)
+	if parentCallId != nil {
+		call.ParentCallId = int(*parentCallId)
+	}
+	if sipCallId != nil {
+		call.SIPCallId = *sipCallId
+	}
+	call.StartedAt = formatCassandraTime(startedAt)
+	call.RingingAt = formatCassandraTime(ringingAt)
+	call.AnsweredAt = formatCassandraTime(answeredAt)
+	call.EndedAt = formatCassandraTime(endedAt)
+	call.CreatedAt = formatCassandraTime(createdAt)
+	call.UpdatedAt = formatCassandraTime(updatedAt)
+	return &call, nil
+}
+
+// cassandraTime converts the RFC3339 dates of model.Call, empty dates are stored as null.
+func cassandraTime(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// mysqlTime converts the RFC3339 dates of model.Call for the MySQL reference row.
+func mysqlTime(value string) sql.NullTime {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t, Valid: true}
+}
+
+func formatCassandraTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func nullableText(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func nullableBigint(value int) interface{} {
+	if value == 0 {
+		return nil
+	}
+	return int64(value)
+}
+
+// callIdGenerator hands out time ordered 63 bit ids: milliseconds since
+// 2024-01-01 in the high bits, then a 10 bit node id and a 12 bit sequence.
+// They are far above MySQL auto increment ids, so both can coexist while migrating.
+// Two instances sharing a node id can hand out the same id, so the node id
+// is configured per instance and never derived.
+type callIdGenerator struct {
+	mu       sync.Mutex
+	node     int64
+	lastTime int64
+	sequence int64
+}
+
+const (
+	callIdEpoch = int64(1704067200000)
+	// MaxCallIdNode is the largest node id of the call id generator.
+	MaxCallIdNode = 0x3ff
+)
+
+func newCallIdGenerator(node int) *callIdGenerator {
+	return &callIdGenerator{node: int64(node)}
+}
+
+func (g *callIdGenerator) Next() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now().UnixMilli() - callIdEpoch
+	if now < g.lastTime {
+		// clock went backwards, keep counting from the last timestamp
+		now = g.lastTime
+	}
+	if now == g.lastTime {
+		g.sequence = (g.sequence + 1) & 0xfff
+		if g.sequence == 0 {
+			for now <= g.lastTime {
+				time.Sleep(time.Millisecond)
+				now = time.Now().UnixMilli() - callIdEpoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastTime = now
+	return int(now<<22 | g.node<<12 | g.sequence)
+}
@@ -0,0 +1,90 @@
+package store
+
+import (
+	"strconv"
+
+	"github.com/gocql/gocql"
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Implementation of Call Store for the MySQL to Cassandra migration
+
+MySQL stays the source of truth: every call write goes to MySQL first and
+the resulting row is then mirrored to Cassandra, reads are served by MySQL.
+A failed mirror is logged and never fails the request; the next write of
+the same call copies the full row again.
+*/
+
+type DualCallStore struct {
+	*CallStore
+	secondary *CassandraCallStore
+}
+
+func NewDualCallStore(db *database.MySQLConn, session *gocql.Session) *DualCallStore {
+	return &DualCallStore{
+		CallStore: NewCallStore(db),
+		// ids come from MySQL, the Cassandra store never creates them
+		secondary: &CassandraCallStore{CallStore: NewCallStore(db), session: session},
+	}
+}
+
+/*
+Input: Call model
+Todo : Create new call in MySQL and mirror it to Cassandra
+Output: First Value: callId, Second Value:error
+*/
+func (cs *DualCallStore) CreateCall(call *model.Call) (string, error) {
+	callId, err := cs.CallStore.CreateCall(call)
+	if err != nil {
+		return callId, err
+	}
+	cs.mirror(call.Id)
+	return callId, nil
+}
+
+/*
+Input: CallUpdate model
+Todo : Update call in MySQL and mirror it to Cassandra
+Output: If success return nil else return err
+*/
+func (cs *DualCallStore) UpdateCall(update *model.CallUpdate) error {
+	err := cs.CallStore.UpdateCall(update)
+	if err != nil {
+		return err
+	}
+	cs.mirror(update.CallId)
+	return nil
+}
+
+/*
+Input: callid, apiid
+Todo : Set sip_call_id in MySQL and mirror it to Cassandra
+Output: If success return nil else return err
+*/
+func (cs *DualCallStore) SetSIPCallID(callid string, apiid string) error {
+	err := cs.CallStore.SetSIPCallID(callid, apiid)
+	if err != nil {
+		return err
+	}
+	if id, err := strconv.Atoi(apiid); err == nil {
+		cs.mirror(id)
+	}
+	return nil
+}
+
+// mirror copies the current MySQL row of a call to Cassandra.
+func (cs *DualCallStore) mirror(id int) {
+	call, err := cs.CallStore.GetCallFromDB(id)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "dual write could not read call id = "+strconv.Itoa(id)+": "+err.Error())
+		return
+	}
+	err = cs.secondary.writeCall(call)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "dual write could not mirror call id = "+strconv.Itoa(id)+" to Cassandra: "+err.Error())
+	}
+}
@@ -2,10 +2,15 @@ package store
 
 import (
 	"database/sql"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	helpers "github.com/Lineblocs/go-helpers"
 	"github.com/stretchr/testify/assert"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/database"
 	"lineblocs.com/api/model"
 )
 
@@ -16,7 +21,7 @@ func TestCreateCall_Failure(t *testing.T) {
 	}
 	defer db.Close()
 
-	callStore := NewCallStore(db)
+	callStore := NewCallStore(database.NewMySQLConn(db))
 
 	mock.ExpectPrepare("INSERT INTO calls").ExpectExec().
 		WillReturnError(sql.ErrNoRows)
@@ -38,3 +43,194 @@ func TestCreateCall_Failure(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, callID, "-1")
 }
+
+func TestGetUserFromDB_Locale(t *testing.T) {
+	helpers.InitLogrus("stdout")
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	callStore := NewCallStore(database.NewMySQLConn(db))
+	columns := []string{"id", "username", "first_name", "last_name", "email"}
+
+	mock.ExpectQuery("SELECT id, username, first_name, last_name, email FROM users").WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "owner", "Ada", "Lovelace", "owner@example.com"))
+	mock.ExpectQuery("SELECT locale FROM users").WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"locale"}).AddRow("fr"))
+	mock.ExpectQuery("SELECT id, username, first_name, last_name, email FROM users").WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(3, "owner", "Ada", "Lovelace", "owner@example.com"))
+	mock.ExpectQuery("SELECT locale FROM users").WithArgs(3).
+		WillReturnError(errors.New("Error 1054: Unknown column 'locale' in 'field list'"))
+
+	user, err := callStore.GetUserFromDB(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "fr", user.Locale)
+
+	user, err = callStore.GetUserFromDB(3)
+	assert.NoError(t, err)
+	assert.Equal(t, "owner@example.com", user.Email)
+	assert.Equal(t, "en", user.Locale)
+}
+
+func TestQueueCallActivity_APIId(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Error creating mock database: %v", err)
+	}
+	defer db.Close()
+
+	callStore := NewCallStore(database.NewMySQLConn(db))
+
+	// a rejected call was never created, the event names it by its api id
+	payload := `{"run_id":"0","api_id":"call-abc","event_type":"UNAUTHORIZED_CALLER_ID","from":"+15550100","to":"+15550199","workspace_id":"2","description":""}`
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO event_outbox").
+		WithArgs("call_activity", "UNAUTHORIZED_CALLER_ID", []byte(payload), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	call := &model.Call{From: "+15550100", To: "+15550199", WorkspaceId: 2, APIId: "call-abc"}
+	err = callStore.QueueCallActivity("UNAUTHORIZED_CALLER_ID", call)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// The call store contract in store/storetest needs a database, these run the
+// MySQL store against the queries it is expected to send.
+func TestCallStore_MySQL(t *testing.T) {
+	helpers.InitLogrus("stdout")
+	callColumns := []string{"id", "from", "to", "channel_id", "status", "direction", "duration", "user_id", "workspace_id", "parent_call_id", "sip_call_id", "started_at", "ringing_at", "answered_at", "ended_at", "created_at", "updated_at", "api_id", "plan_snapshot"}
+
+	newStore := func(t *testing.T) (*CallStore, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Error creating mock database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return NewCallStore(database.NewMySQLConn(db)), mock
+	}
+
+	expectWorkspace := func(mock sqlmock.Sqlmock, id int) {
+		mock.ExpectQuery("SELECT id, name, creator_id, outbound_macro_id, plan FROM workspaces").WithArgs(id).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "creator_id", "outbound_macro_id", "plan"}).AddRow(id, "acme", 3, nil, "pro"))
+	}
+
+	t.Run("CreateCall stores the call as initiated with its outbox event", func(t *testing.T) {
+		cs, mock := newStore(t)
+		expectWorkspace(mock, 2)
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO calls").
+			WithArgs("+15550100", "+15550199", "chan", callstate.StatusInitiated, "OUTBOUND", 0, "", 1, 2, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), "call-abc", "pro").
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectExec("INSERT INTO event_outbox").
+			WithArgs("call_activity", "CALL_CREATED", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		call := &model.Call{From: "+15550100", To: "+15550199", ChannelId: "chan", Direction: "OUTBOUND", UserId: 1, WorkspaceId: 2, APIId: "call-abc"}
+		id, err := cs.CreateCall(call)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "7", id)
+		assert.Equal(t, 7, call.Id)
+		assert.Equal(t, callstate.StatusInitiated, call.Status)
+		assert.NotEmpty(t, call.SessionId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateCall joins the session of the parent leg", func(t *testing.T) {
+		cs, mock := newStore(t)
+		expectWorkspace(mock, 2)
+		mock.ExpectQuery("SELECT `session_id`, `workspace_id` FROM calls").WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"session_id", "workspace_id"}).AddRow("session-1", 2))
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO calls").WillReturnResult(sqlmock.NewResult(8, 1))
+		mock.ExpectExec("INSERT INTO event_outbox").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		call := &model.Call{WorkspaceId: 2, ParentCallId: 5}
+		_, err := cs.CreateCall(call)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "session-1", call.SessionId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("CreateCall rejects a parent leg of another workspace", func(t *testing.T) {
+		cs, mock := newStore(t)
+		expectWorkspace(mock, 2)
+		mock.ExpectQuery("SELECT `session_id`, `workspace_id` FROM calls").WithArgs(5).
+			WillReturnRows(sqlmock.NewRows([]string{"session_id", "workspace_id"}).AddRow("session-1", 9))
+
+		_, err := cs.CreateCall(&model.Call{WorkspaceId: 2, ParentCallId: 5})
+
+		assert.ErrorIs(t, err, callstate.ErrParentWorkspaceMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateCall ends the call with the duration from answer time", func(t *testing.T) {
+		cs, mock := newStore(t)
+		answeredAt := time.Now().Add(-30 * time.Second)
+		mock.ExpectQuery("SELECT `status`, `answered_at`, `from`, `to`, `workspace_id` FROM calls").WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "answered_at", "from", "to", "workspace_id"}).AddRow(callstate.StatusAnswered, answeredAt, "+15550100", "+15550199", 2))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE calls SET `status` = \\?, `ended_at` = \\?, `duration` = \\?").
+			WithArgs(callstate.StatusEnded, sqlmock.AnyArg(), 30, sqlmock.AnyArg(), 7, callstate.StatusAnswered).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO event_outbox").
+			WithArgs("call_activity", "CALL_ENDED", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectCommit()
+
+		err := cs.UpdateCall(&model.CallUpdate{CallId: 7, Status: callstate.StatusEnded})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateCall rejects leaving a terminal status", func(t *testing.T) {
+		cs, mock := newStore(t)
+		mock.ExpectQuery("SELECT `status`, `answered_at`, `from`, `to`, `workspace_id` FROM calls").WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "answered_at", "from", "to", "workspace_id"}).AddRow(callstate.StatusBusy, nil, "+15550100", "+15550199", 2))
+
+		err := cs.UpdateCall(&model.CallUpdate{CallId: 7, Status: callstate.StatusAnswered})
+
+		assert.ErrorIs(t, err, callstate.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UpdateCall loses a concurrent status change", func(t *testing.T) {
+		cs, mock := newStore(t)
+		mock.ExpectQuery("SELECT `status`, `answered_at`, `from`, `to`, `workspace_id` FROM calls").WithArgs(7).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "answered_at", "from", "to", "workspace_id"}).AddRow(callstate.StatusInitiated, nil, "+15550100", "+15550199", 2))
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE calls SET `status` = \\?, `ringing_at` = \\?").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := cs.UpdateCall(&model.CallUpdate{CallId: 7, Status: callstate.StatusRinging})
+
+		assert.ErrorIs(t, err, callstate.ErrInvalidTransition)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetCallsBySessionId only returns legs of the workspace", func(t *testing.T) {
+		cs, mock := newStore(t)
+		now := time.Now()
+		mock.ExpectQuery("FROM calls WHERE session_id = \\? AND workspace_id = \\?").WithArgs("session-1", 2).
+			WillReturnRows(sqlmock.NewRows(callColumns).
+				AddRow(7, "+15550100", "+15550199", "chan", callstate.StatusEnded, "OUTBOUND", 30, 1, 2, nil, "abc@host", now, now, now, now, now, now, "call-abc", "pro").
+				AddRow(8, "+15550199", "+15550142", "chan", callstate.StatusEnded, "OUTBOUND", 10, 1, 2, 7, "def@host", now, nil, now, now, now, now, "call-def", "pro"))
+
+		legs, err := cs.GetCallsBySessionId(2, "session-1")
+
+		assert.NoError(t, err)
+		if assert.Len(t, legs, 2) {
+			assert.Equal(t, 7, legs[0].Id)
+			assert.Equal(t, 7, legs[1].ParentCallId)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -3,11 +3,12 @@ package store
 import (
 	"encoding/json"
 	"errors"
-	"strconv"
+	"time"
 
 	"database/sql"
 	"github.com/sirupsen/logrus"
 	"lineblocs.com/api/helpers"
+	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
 	"lineblocs.com/api/database"
 )
@@ -27,25 +28,77 @@ func NewCarrierStore(db *database.MySQLConn) *CarrierStore {
 }
 
 /*
-Input: callid, status
-Todo : Update sip_status of calls with matching sip_call_id
+Input: SIPReport model
+Todo : Store the SIP response in the call's event history and update sip_status of calls with matching sip_call_id.
+Final responses also set the normalized Q.850 hangup cause on the call
 Output: If success return nil else return err
 */
-func (crs *CarrierStore) CreateSIPReport(callid string, status string) error {
-	stmt, err := crs.db.Prepare("UPDATE `calls` SET sip_status = ? WHERE sip_call_id = ?")
+func (crs *CarrierStore) CreateSIPReport(report *model.SIPReport) error {
+	now := time.Now()
+	// the history and the status of the call are updated together
+	tx, err := crs.db.Begin()
 	if err != nil {
-		utils.Log(logrus.ErrorLevel, "CreateSIPReport 2 Could not execute query..")
 		return err
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
+
+	_, err = tx.Exec("INSERT INTO `sip_call_events` (`sip_call_id`, `code`, `reason`, `provider_host`, `created_at`) VALUES ( ?, ?, ?, ?, ? )", report.SIPCallId, report.Code, report.Reason, report.ProviderHost, now)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "CreateSIPReport 1 Could not execute query..")
+		return err
+	}
+	report.CreatedAt = now.Format(time.RFC3339)
 
-	statusAsInt, err := strconv.Atoi(status)
+	cause := helpers.HangupCauseFromSIP(report.Code)
+	if cause == nil {
+		_, err = tx.Exec("UPDATE `calls` SET sip_status = ? WHERE sip_call_id = ?", report.Code, report.SIPCallId)
+	} else {
+		_, err = tx.Exec("UPDATE `calls` SET sip_status = ?, q850_cause = ?, hangup_category = ? WHERE sip_call_id = ?", report.Code, cause.Q850Code, cause.Category, report.SIPCallId)
+	}
 	if err != nil {
-		utils.Log(logrus.ErrorLevel, "CreateSIPReport 3 error in convert...")
+		utils.Log(logrus.ErrorLevel, "CreateSIPReport 2 Could not execute query..")
 		return err
 	}
-	_, err = stmt.Exec(statusAsInt, callid)
-	return err
+	return tx.Commit()
+}
+
+/*
+Input: workspace_id, sip_call_id
+Todo : Get every SIP response received for a call of the workspace with the derived hangup cause and post dial delay.
+Post dial delay is measured from started_at of the call, set when the INVITE is sent
+Output: First value: SIPReportHistory model, Second Value: error
+If success return (SIPReportHistory model, nil) else (nil, err)
+*/
+func (crs *CarrierStore) GetSIPReportHistory(workspaceId int, sipCallId string) (*model.SIPReportHistory, error) {
+	var invitedAt sql.NullTime
+	row := crs.db.QueryRow("SELECT `started_at` FROM `calls` WHERE `sip_call_id` = ? AND `workspace_id` = ?", sipCallId, workspaceId)
+	err := row.Scan(&invitedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := crs.db.Query("SELECT `code`, `reason`, `provider_host`, `created_at` FROM `sip_call_events` WHERE `sip_call_id` = ? ORDER BY `created_at` ASC, `id` ASC", sipCallId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	events := []model.SIPReport{}
+	for results.Next() {
+		var createdAt time.Time
+		event := model.SIPReport{SIPCallId: sipCallId}
+		err = results.Scan(&event.Code, &event.Reason, &event.ProviderHost, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+		event.CreatedAt = createdAt.Format(time.RFC3339Nano)
+		events = append(events, event)
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	return helpers.BuildSIPReportHistory(sipCallId, invitedAt.Time, events), nil
 }
 
 /*
@@ -0,0 +1,345 @@
+package store
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Conference participants are stored in conference_participants, one row per
+time a call joins a conference. Every change of the participants locks the
+conference row first so the participant limit and the start and end times
+stay consistent when calls join and leave at the same time.
+*/
+
+/*
+Input: conferenceId
+Todo : Get conference with matching id and the participants of its current or last run
+Output: First Value: Conference model, Second Value: error
+If success return (Conference, nil) else return (nil, err)
+*/
+func (cs *CallStore) GetConference(conferenceId int) (*model.Conference, error) {
+	var status, pin, moderatorPin sql.NullString
+	var startedAt, endedAt sql.NullTime
+	conference := model.Conference{Id: conferenceId}
+	row := cs.db.QueryRow("SELECT `name`, `workspace_id`, `api_id`, `pin`, `moderator_pin`, `status`, `started_at`, `ended_at` FROM conferences WHERE id = ?", conferenceId)
+	err := row.Scan(&conference.Name, &conference.WorkspaceId, &conference.APIId, &pin, &moderatorPin, &status, &startedAt, &endedAt)
+	if err != nil {
+		return nil, err
+	}
+	conference.Pin = pin.String
+	conference.ModeratorPin = moderatorPin.String
+	conference.Status = status.String
+	if conference.Status == "" {
+		conference.Status = callstate.ConferenceCreated
+	}
+	if startedAt.Valid {
+		conference.StartedAt = startedAt.Time.Format(time.RFC3339)
+	}
+	if endedAt.Valid {
+		conference.EndedAt = endedAt.Time.Format(time.RFC3339)
+	}
+
+	conference.Participants = []model.ConferenceParticipant{}
+	if !startedAt.Valid {
+		return &conference, nil
+	}
+	results, err := cs.db.Query("SELECT `id`, `call_id`, `workspace_id`, `muted`, `moderator`, `status`, `joined_at`, `left_at`, `duration` FROM conference_participants WHERE conference_id = ? AND joined_at >= ? ORDER BY joined_at ASC, id ASC", conferenceId, startedAt.Time)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	for results.Next() {
+		var leftAt sql.NullString
+		participant := model.ConferenceParticipant{ConferenceId: conferenceId}
+		err = results.Scan(&participant.Id, &participant.CallId, &participant.WorkspaceId, &participant.Muted, &participant.Moderator, &participant.Status, &participant.JoinedAt, &leftAt, &participant.Duration)
+		if err != nil {
+			return nil, err
+		}
+		participant.LeftAt = leftAt.String
+		conference.Participants = append(conference.Participants, participant)
+	}
+	return &conference, results.Err()
+}
+
+/*
+Input: ConferenceParticipant model
+Todo : Add a call to a conference, starting the conference if needed, within the participant limit of the workspace plan
+Output: If success return nil else return err
+*/
+func (cs *CallStore) AddConferenceParticipant(participant *model.ConferenceParticipant) error {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status sql.NullString
+	row := tx.QueryRow("SELECT `workspace_id`, `status` FROM conferences WHERE id = ? FOR UPDATE", participant.ConferenceId)
+	err = row.Scan(&participant.WorkspaceId, &status)
+	if err != nil {
+		return err
+	}
+
+	// the call row is locked too, joins of the same call to two conferences
+	// lock different conferences and would both pass the check below
+	var callId int
+	row = tx.QueryRow("SELECT id FROM calls WHERE id = ? FOR UPDATE", participant.CallId)
+	err = row.Scan(&callId)
+	if err != nil {
+		return err
+	}
+
+	var inConference int
+	row = tx.QueryRow("SELECT COUNT(*) FROM conference_participants WHERE call_id = ? AND status = ?", participant.CallId, callstate.ParticipantJoined)
+	err = row.Scan(&inConference)
+	if err != nil {
+		return err
+	}
+	if inConference > 0 {
+		return callstate.ErrAlreadyInConference
+	}
+
+	limit, err := conferenceParticipantLimit(tx, participant.WorkspaceId)
+	if err != nil {
+		return err
+	}
+	var joined int
+	row = tx.QueryRow("SELECT COUNT(*) FROM conference_participants WHERE conference_id = ? AND status = ?", participant.ConferenceId, callstate.ParticipantJoined)
+	err = row.Scan(&joined)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && joined >= limit {
+		return callstate.ErrConferenceFull
+	}
+
+	now := time.Now()
+	res, err := tx.Exec("INSERT INTO conference_participants (`conference_id`, `call_id`, `workspace_id`, `muted`, `moderator`, `status`, `joined_at`, `duration`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, 0, ?, ? )",
+		participant.ConferenceId, participant.CallId, participant.WorkspaceId, participant.Muted, participant.Moderator, callstate.ParticipantJoined, now, now, now)
+	if err != nil {
+		return err
+	}
+	participantId, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if status.String != callstate.ConferenceInProgress {
+		utils.Log(logrus.InfoLevel, "starting conference id = "+strconv.Itoa(participant.ConferenceId))
+		_, err = tx.Exec("UPDATE conferences SET `status` = ?, `started_at` = ?, `ended_at` = NULL, `updated_at` = ? WHERE id = ?", callstate.ConferenceInProgress, now, now, participant.ConferenceId)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	participant.Id = int(participantId)
+	participant.Status = callstate.ParticipantJoined
+	participant.JoinedAt = now.Format(time.RFC3339)
+	return nil
+}
+
+/*
+Input: callId
+Todo : Remove a call from its conference and end the conference when it was the last participant
+Output: First Value: ConferenceParticipant model with the billable duration, Second Value: error
+*/
+func (cs *CallStore) RemoveConferenceParticipant(callId int) (*model.ConferenceParticipant, error) {
+	var conferenceId int
+	row := cs.db.QueryRow("SELECT `conference_id` FROM conference_participants WHERE call_id = ? AND status = ?", callId, callstate.ParticipantJoined)
+	err := row.Scan(&conferenceId)
+	if err == sql.ErrNoRows {
+		return nil, callstate.ErrParticipantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	row = tx.QueryRow("SELECT id FROM conferences WHERE id = ? FOR UPDATE", conferenceId)
+	err = row.Scan(&conferenceId)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := leaveConference(tx, conferenceId, callId)
+	if err != nil {
+		return nil, err
+	}
+	if len(participants) == 0 {
+		// another request removed the call after it was looked up
+		return nil, callstate.ErrParticipantNotFound
+	}
+
+	var remaining int
+	row = tx.QueryRow("SELECT COUNT(*) FROM conference_participants WHERE conference_id = ? AND status = ?", conferenceId, callstate.ParticipantJoined)
+	err = row.Scan(&remaining)
+	if err != nil {
+		return nil, err
+	}
+	if remaining == 0 {
+		err = endConference(tx, conferenceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &participants[0], nil
+}
+
+/*
+Input: ConferenceParticipantUpdate model
+Todo : Change the mute and moderator flags of the participant of a call, the moderator flag only with the moderator PIN
+Output: First Value: updated ConferenceParticipant model, Second Value: error
+*/
+func (cs *CallStore) UpdateConferenceParticipant(update *model.ConferenceParticipantUpdate) (*model.ConferenceParticipant, error) {
+	var leftAt, moderatorPin sql.NullString
+	participant := model.ConferenceParticipant{CallId: update.CallId}
+	row := cs.db.QueryRow(`SELECT conference_participants.id, conference_participants.conference_id, conference_participants.workspace_id,
+		conference_participants.muted, conference_participants.moderator, conference_participants.status,
+		conference_participants.joined_at, conference_participants.left_at, conference_participants.duration, conferences.moderator_pin
+		FROM conference_participants
+		INNER JOIN conferences ON conferences.id = conference_participants.conference_id
+		WHERE conference_participants.call_id = ? AND conference_participants.status = ?`, update.CallId, callstate.ParticipantJoined)
+	err := row.Scan(&participant.Id, &participant.ConferenceId, &participant.WorkspaceId, &participant.Muted, &participant.Moderator, &participant.Status, &participant.JoinedAt, &leftAt, &participant.Duration, &moderatorPin)
+	if err == sql.ErrNoRows {
+		return nil, callstate.ErrParticipantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	participant.LeftAt = leftAt.String
+
+	if update.Moderator != nil {
+		err = callstate.CheckModeratorPIN(moderatorPin.String, update.Pin)
+		if err != nil {
+			return nil, err
+		}
+		participant.Moderator = *update.Moderator
+	}
+	if update.Muted != nil {
+		participant.Muted = *update.Muted
+	}
+	_, err = cs.db.Exec("UPDATE conference_participants SET `muted` = ?, `moderator` = ?, `updated_at` = ? WHERE id = ?", participant.Muted, participant.Moderator, time.Now(), participant.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &participant, nil
+}
+
+/*
+Input: conferenceId
+Todo : End a conference, removing every participant still in it
+Output: First Value: list of removed ConferenceParticipant models with their billable duration, Second Value: error
+*/
+func (cs *CallStore) EndConference(conferenceId int) ([]model.ConferenceParticipant, error) {
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status sql.NullString
+	row := tx.QueryRow("SELECT `status` FROM conferences WHERE id = ? FOR UPDATE", conferenceId)
+	err = row.Scan(&status)
+	if err != nil {
+		return nil, err
+	}
+	participants, err := leaveConference(tx, conferenceId, 0)
+	if err != nil {
+		return nil, err
+	}
+	if status.String == callstate.ConferenceInProgress {
+		err = endConference(tx, conferenceId)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return participants, tx.Commit()
+}
+
+// leaveConference marks the joined participants of a conference as left,
+// only the participant of callId unless it is 0. The conference row must be locked.
+func leaveConference(tx *sql.Tx, conferenceId int, callId int) ([]model.ConferenceParticipant, error) {
+	query := "SELECT `id`, `call_id`, `workspace_id`, `muted`, `moderator`, `joined_at` FROM conference_participants WHERE conference_id = ? AND status = ?"
+	args := []interface{}{conferenceId, callstate.ParticipantJoined}
+	if callId != 0 {
+		query += " AND call_id = ?"
+		args = append(args, callId)
+	}
+	results, err := tx.Query(query+" FOR UPDATE", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	participants := []model.ConferenceParticipant{}
+	joinedAt := []time.Time{}
+	for results.Next() {
+		var joined time.Time
+		participant := model.ConferenceParticipant{ConferenceId: conferenceId}
+		err = results.Scan(&participant.Id, &participant.CallId, &participant.WorkspaceId, &participant.Muted, &participant.Moderator, &joined)
+		if err != nil {
+			results.Close()
+			return nil, err
+		}
+		participants = append(participants, participant)
+		joinedAt = append(joinedAt, joined)
+	}
+	results.Close()
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range participants {
+		participant := &participants[i]
+		participant.Status = callstate.ParticipantLeft
+		participant.JoinedAt = joinedAt[i].Format(time.RFC3339)
+		participant.LeftAt = now.Format(time.RFC3339)
+		participant.Duration = int(now.Sub(joinedAt[i]).Seconds())
+		_, err = tx.Exec("UPDATE conference_participants SET `status` = ?, `left_at` = ?, `duration` = ?, `updated_at` = ? WHERE id = ?", participant.Status, now, participant.Duration, now, participant.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return participants, nil
+}
+
+func endConference(tx *sql.Tx, conferenceId int) error {
+	utils.Log(logrus.InfoLevel, "ending conference id = "+strconv.Itoa(conferenceId))
+	now := time.Now()
+	_, err := tx.Exec("UPDATE conferences SET `status` = ?, `ended_at` = ?, `updated_at` = ? WHERE id = ?", callstate.ConferenceEnded, now, now, conferenceId)
+	return err
+}
+
+// conferenceParticipantLimit reads the participant limit of the workspace
+// plan, 0 means unlimited.
+func conferenceParticipantLimit(tx *sql.Tx, workspaceId int) (int, error) {
+	var limit int
+	row := tx.QueryRow(`SELECT COALESCE(service_plans.max_conference_participants, 0) FROM subscriptions
+		INNER JOIN service_plans ON service_plans.id = subscriptions.current_plan_id
+		WHERE subscriptions.workspace_id = ?`, workspaceId)
+	err := row.Scan(&limit)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return limit, err
+}
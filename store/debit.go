@@ -1,8 +1,10 @@
 package store
 
 import (
+	"database/sql"
 	"fmt"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -71,44 +73,90 @@ func (ds *DebitStore) CreateDebit(rate *model.CallRate, debit *model.Debit) erro
 	if err != nil {
 		return err
 	}
+	debit.Cents = float64(cents)
 	return nil
 }
 
 /*
 Input: Workspace model, DebitAPI model
 Todo : Calculate cents based on debit type and create user_debit
-Output: If success return nil else return err
+Output: First Value: debited cents, Second Value: error
 */
-func (ds *DebitStore) CreateAPIUsageDebit(workspace *model.Workspace, debitApi *model.DebitAPI) error {
-	// Check DebitType and calcaulte cents individually
-	if debitApi.Type == "TTS" {
-		dollars := utils.CalculateTTSCosts(debitApi.Params.Length)
-		cents := utils.ToCents(dollars)
-		source := fmt.Sprintf("API usage - %s", debitApi.Type)
-		now := time.Now()
-		stmt, err := ds.db.Prepare("INSERT INTO users_debits (`user_id`, `cents`, `source`, `plan_snapshot`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ? )")
-		if err != nil {
-			return err
-		}
-		defer stmt.Close()
-		_, err = stmt.Exec(debitApi.UserId, cents, source, workspace.Plan, now, now)
-		if err != nil {
-			return err
-		}
-	} else if debitApi.Type == "STT" {
-		dollars := utils.CalculateSTTCosts(debitApi.Params.RecordingLength)
-		cents := utils.ToCents(dollars)
-		source := fmt.Sprintf("API usage - %s", debitApi.Type)
-		now := time.Now()
-		stmt, err := ds.db.Prepare("INSERT INTO users_debits (`user_id`, `cents`, `source`, `plan_snapshot`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ? )")
-		if err != nil {
-			return err
-		}
-		defer stmt.Close()
-		_, err = stmt.Exec(debitApi.UserId, cents, source, workspace.Plan, now, now)
+func (ds *DebitStore) CreateAPIUsageDebit(workspace *model.Workspace, debitApi *model.DebitAPI) (float64, error) {
+	cents, err := insertAPIUsageDebit(ds.db, workspace.Plan, debitApi, 0)
+	if err != nil {
+		return 0, err
+	}
+	return float64(cents), nil
+}
+
+// execer runs statements on a connection or in a transaction.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertAPIUsageDebit calculates the cents of debitApi by its type and
+// creates the user_debit with db, usage that is stored in a transaction is
+// debited in the same transaction by passing it as db. moduleId is the fax or
+// transcription job that is charged, 0 when there is none. Unknown types are
+// not debited.
+func insertAPIUsageDebit(db execer, plan string, debitApi *model.DebitAPI, moduleId int) (int, error) {
+	var dollars float64
+	switch debitApi.Type {
+	case "TTS":
+		dollars = utils.CalculateTTSCosts(debitApi.Params.Length)
+	case "STT":
+		dollars = utils.CalculateSTTCosts(debitApi.Params.RecordingLength)
+	case "FAX":
+		dollars = utils.CalculateFaxCosts(debitApi.Params.Pages)
+	default:
+		return 0, nil
+	}
+	cents := utils.ToCents(dollars)
+	source := fmt.Sprintf("API usage - %s", debitApi.Type)
+	var module *int
+	if moduleId != 0 {
+		module = &moduleId
+	}
+	now := time.Now()
+	_, err := db.Exec("INSERT INTO users_debits (`workspace_id`, `user_id`, `cents`, `source`, `module_id`, `plan_snapshot`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ? )",
+		debitApi.WorkspaceId, debitApi.UserId, cents, source, module, plan, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return cents, nil
+}
+
+/*
+Input: workspaceId, callIds
+Todo : Sum the cents debited for each call of the workspace
+Output: First Value: cents by call id, Second Value: error
+Calls that were not billed are left out
+*/
+func (ds *DebitStore) GetCallCharges(workspaceId int, callIds []int) (map[int]float64, error) {
+	charges := map[int]float64{}
+	if len(callIds) == 0 {
+		return charges, nil
+	}
+	args := []interface{}{workspaceId}
+	for _, id := range callIds {
+		args = append(args, id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(callIds)), ", ")
+	results, err := ds.db.Query("SELECT `module_id`, SUM(`cents`) FROM users_debits WHERE `workspace_id` = ? AND `source` = 'CALL' AND `module_id` IN ("+placeholders+") GROUP BY `module_id`", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	for results.Next() {
+		var callId int
+		var cents float64
+		err = results.Scan(&callId, &cents)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		charges[callId] = cents
 	}
-	return nil
-}
\ No newline at end of file
+	return charges, results.Err()
+}
@@ -1,11 +1,15 @@
 package store
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
 	"database/sql"
-	"lineblocs.com/api/model"
 	"lineblocs.com/api/database"
+	"lineblocs.com/api/fax"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
 )
 
 /*
@@ -22,27 +26,130 @@ func NewFaxStore(db *database.MySQLConn) *FaxStore {
 	}
 }
 
+// faxColumns are read by scanFax.
+const faxColumns = "`id`, `user_id`, `workspace_id`, `call_id`, `uri`, `api_id`, `name`, `content_type`, COALESCE(`pages`, 0), `resolution`, `status`, `direction`, `from_number`, `to_number`, COALESCE(`reason_code`, 0), `reason`, COALESCE(`attempts`, 0), `email_to`, `email_status`, COALESCE(`email_attempts`, 0), `email_locale`, `created_at`, `updated_at`"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanFax(row rowScanner) (*model.Fax, error) {
+	var callId sql.NullInt64
+	var name, contentType, resolution, status, direction, from, to, reason, emailTo, emailStatus, emailLocale sql.NullString
+	var createdAt, updatedAt time.Time
+	var record model.Fax
+	err := row.Scan(
+		&record.Id,
+		&record.UserId,
+		&record.WorkspaceId,
+		&callId,
+		&record.Uri,
+		&record.APIId,
+		&name,
+		&contentType,
+		&record.Pages,
+		&resolution,
+		&status,
+		&direction,
+		&from,
+		&to,
+		&record.ReasonCode,
+		&reason,
+		&record.Attempts,
+		&emailTo,
+		&emailStatus,
+		&record.EmailAttempts,
+		&emailLocale,
+		&createdAt,
+		&updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	record.CallId = int(callId.Int64)
+	record.Name = name.String
+	record.ContentType = contentType.String
+	record.Resolution = resolution.String
+	record.Status = status.String
+	record.Direction = direction.String
+	if record.Direction == "" {
+		// faxes stored before outbound faxes were queued here
+		record.Direction = fax.DirectionInbound
+	}
+	record.From = from.String
+	record.To = to.String
+	record.Reason = reason.String
+	if emailTo.String != "" {
+		record.EmailTo = strings.Split(emailTo.String, ",")
+	}
+	record.EmailStatus = emailStatus.String
+	record.EmailLocale = emailLocale.String
+	record.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	record.UpdatedAt = updatedAt.UTC().Format(time.RFC3339)
+	return &record, nil
+}
+
 /*
 Input: Fax model, name, size, apiId, plan
-Todo : Create fax and store to db,
+Todo : Create fax and store to db with its page count, resolution and status,
+outbound faxes are due right away and inbound faxes with recipients are queued for email,
+received faxes are billed in the same transaction
 Output: First Value: LastInsertId, Second Value: error
 If success return (id, nil) else return (nil, err)
 */
-func (fs *FaxStore) CreateFax(fax *model.Fax, name string, size int64, apiId string, plan string) (int64, error) {
+func (fs *FaxStore) CreateFax(record *model.Fax, name string, size int64, apiId string, plan string) (int64, error) {
 	now := time.Now()
+	var nextAttemptAt *time.Time
+	if record.Status == fax.StatusQueued {
+		nextAttemptAt = &now
+	}
+	var emailTo, emailStatus, emailLocale *string
+	if len(record.EmailTo) > 0 {
+		recipients := strings.Join(record.EmailTo, ",")
+		status := fax.EmailPending
+		emailTo, emailStatus, emailLocale = &recipients, &status, &record.EmailLocale
+		record.EmailStatus = status
+	}
+
+	// outbound faxes may be sent without a call
+	var callId *int
+	if record.CallId != 0 {
+		callId = &record.CallId
+	}
 
-	stmt, err := fs.db.Prepare("INSERT INTO faxes (`uri`, `size`, `name`, `user_id`, `call_id`, `workspace_id`, `api_id`, `plan`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	tx, err := fs.db.Begin()
 	if err != nil {
 		return -1, err
 	}
-	defer stmt.Close()
-	res, err := stmt.Exec(fax.Uri, size, name, fax.UserId, fax.CallId, fax.WorkspaceId, apiId, plan, now, now)
+	defer tx.Rollback()
+	res, err := tx.Exec("INSERT INTO faxes (`uri`, `size`, `name`, `user_id`, `call_id`, `workspace_id`, `api_id`, `plan`, `content_type`, `pages`, `resolution`, `status`, `direction`, `from_number`, `to_number`, `attempts`, `next_attempt_at`, `email_to`, `email_status`, `email_locale`, `email_attempts`, `email_available_at`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?, 0, ?, ?, ?)",
+		record.Uri, size, name, record.UserId, callId, record.WorkspaceId, apiId, plan, record.ContentType, record.Pages, record.Resolution, record.Status, record.Direction, record.From, record.To, nextAttemptAt, emailTo, emailStatus, emailLocale, now, now, now)
 	if err != nil {
 		return -1, err
 	}
-
 	faxId, err := res.LastInsertId()
-	return faxId, err
+	if err != nil {
+		return -1, err
+	}
+	if record.Status == fax.StatusReceived {
+		_, err = insertAPIUsageDebit(tx, plan, faxDebit(record), int(faxId))
+		if err != nil {
+			return -1, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return -1, err
+	}
+	return faxId, nil
+}
+
+// faxDebit charges the pages of a received or delivered fax.
+func faxDebit(record *model.Fax) *model.DebitAPI {
+	return &model.DebitAPI{
+		UserId:      record.UserId,
+		WorkspaceId: record.WorkspaceId,
+		Type:        "FAX",
+		Params:      model.DebitAPIParams{Pages: record.Pages}}
 }
 
 /*
@@ -64,3 +171,264 @@ func (fs *FaxStore) GetFaxCount(id int) (*int, error) {
 	}
 	return &count, nil
 }
+
+/*
+Input: workspaceId, id
+Todo : Get Fax with matching id of the workspace
+Output: First Value: Fax model, Second Value: error
+If success return (Fax model, nil) else (nil, err)
+*/
+func (fs *FaxStore) GetFaxFromDB(workspaceId int, id int) (*model.Fax, error) {
+	row := fs.db.QueryRow("SELECT "+faxColumns+" FROM faxes WHERE id = ? AND workspace_id = ?", id, workspaceId)
+	return scanFax(row)
+}
+
+/*
+Input: Fax model, from, nextAttemptAt
+Todo : Store the status, reason and attempts of a fax of the workspace if its status is still from, a fax queued for a retry is due at nextAttemptAt
+Output: First Value: whether the fax was updated, Second Value: error
+A fax that is delivered is billed in the same transaction, so it is billed exactly once
+*/
+func (fs *FaxStore) SetFaxStatus(record *model.Fax, from string, nextAttemptAt *time.Time) (bool, error) {
+	now := time.Now()
+	tx, err := fs.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec("UPDATE faxes SET `status` = ?, `reason_code` = ?, `reason` = ?, `attempts` = ?, `next_attempt_at` = ?, `claim_token` = NULL, `claimed_until` = NULL, `updated_at` = ? WHERE id = ? AND `workspace_id` = ? AND `status` = ?",
+		record.Status, record.ReasonCode, record.Reason, record.Attempts, nextAttemptAt, now, record.Id, record.WorkspaceId, from)
+	if err != nil {
+		return false, err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if updated != 1 {
+		return false, nil
+	}
+	if record.Status == fax.StatusDelivered {
+		var plan sql.NullString
+		row := tx.QueryRow("SELECT `plan` FROM faxes WHERE id = ?", record.Id)
+		err = row.Scan(&plan)
+		if err != nil {
+			return false, err
+		}
+		_, err = insertAPIUsageDebit(tx, plan.String, faxDebit(record), record.Id)
+		if err != nil {
+			return false, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	record.UpdatedAt = now.UTC().Format(time.RFC3339)
+	return true, nil
+}
+
+/*
+Input: FaxFilter model
+Todo : Get a page of the faxes of a workspace matching the filter, newest first
+Output: First Value: FaxList model, Second Value: error
+*/
+func (fs *FaxStore) ListFaxes(filter *model.FaxFilter) (*model.FaxList, error) {
+	query := "SELECT " + faxColumns + " FROM faxes WHERE workspace_id = ?"
+	args := []interface{}{filter.WorkspaceId}
+	if filter.BeforeId > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeId)
+	}
+	if filter.Direction == fax.DirectionInbound {
+		// faxes stored before outbound faxes were queued here are inbound
+		query += " AND (direction = ? OR direction IS NULL)"
+		args = append(args, filter.Direction)
+	} else if filter.Direction != "" {
+		query += " AND direction = ?"
+		args = append(args, filter.Direction)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.Number != "" {
+		query += " AND (from_number = ? OR to_number = ?)"
+		args = append(args, filter.Number, filter.Number)
+	}
+	if filter.CallId != nil {
+		query += " AND call_id = ?"
+		args = append(args, *filter.CallId)
+	}
+	if filter.From != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.To)
+	}
+	// one extra row tells whether there is a next page
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	results, err := fs.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	list := model.FaxList{Faxes: []model.Fax{}}
+	for results.Next() {
+		record, err := scanFax(results)
+		if err != nil {
+			return nil, err
+		}
+		list.Faxes = append(list.Faxes, *record)
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list.Faxes) > filter.Limit {
+		list.Faxes = list.Faxes[:filter.Limit]
+		list.NextCursor = utils.EncodeCursor(list.Faxes[filter.Limit-1].Id)
+	}
+	return &list, nil
+}
+
+/*
+Input: limit, timeout
+Todo : Claim outbound faxes that are due to be transmitted and record the attempt they are requested for
+Output: First Value: list of Fax models, Second Value: error
+An attempt is requested once, it is requested again after a failure moved the fax to its next attempt
+or when the media server did not pick it up within the timeout
+*/
+func (fs *FaxStore) ClaimDueFaxes(limit int, timeout time.Duration) ([]model.Fax, error) {
+	now := time.Now()
+	token := utils.CreateAPIID("fxs")
+
+	_, err := fs.db.Exec("UPDATE faxes SET `claim_token` = ?, `claimed_until` = ?, `requested_attempt` = `attempts` + 1, `updated_at` = ? WHERE `direction` = ? AND `status` = ? AND `next_attempt_at` <= ? AND (`requested_attempt` IS NULL OR `requested_attempt` <= `attempts` OR `claimed_until` IS NULL OR `claimed_until` < ?) ORDER BY `id` ASC LIMIT ?",
+		token, now.Add(timeout), now, fax.DirectionOutbound, fax.StatusQueued, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fs.claimed("SELECT "+faxColumns+" FROM faxes WHERE `claim_token` = ? AND `status` = ? ORDER BY `id` ASC", token, fax.StatusQueued)
+}
+
+/*
+Input: faxId
+Todo : Forget the requested attempt of a queued fax whose request could not be published
+Output: If success return nil else return err
+*/
+func (fs *FaxStore) ReleaseFax(faxId int) error {
+	_, err := fs.db.Exec("UPDATE faxes SET `claim_token` = NULL, `claimed_until` = NULL, `requested_attempt` = NULL WHERE id = ? AND `status` = ?",
+		faxId, fax.StatusQueued)
+	return err
+}
+
+/*
+Input: limit, lease
+Todo : Claim inbound faxes waiting for their email delivery for the length of the lease
+Output: First Value: list of Fax models, Second Value: error
+*/
+func (fs *FaxStore) ClaimFaxEmails(limit int, lease time.Duration) ([]model.Fax, error) {
+	now := time.Now()
+	token := utils.CreateAPIID("fxe")
+
+	_, err := fs.db.Exec("UPDATE faxes SET `email_claim_token` = ?, `email_claimed_until` = ? WHERE `email_status` = ? AND `email_available_at` <= ? AND (`email_claimed_until` IS NULL OR `email_claimed_until` < ?) ORDER BY `id` ASC LIMIT ?",
+		token, now.Add(lease), fax.EmailPending, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	return fs.claimed("SELECT "+faxColumns+" FROM faxes WHERE `email_claim_token` = ? AND `email_status` = ? ORDER BY `id` ASC", token, fax.EmailPending)
+}
+
+func (fs *FaxStore) claimed(query string, args ...interface{}) ([]model.Fax, error) {
+	results, err := fs.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	faxes := []model.Fax{}
+	for results.Next() {
+		record, err := scanFax(results)
+		if err != nil {
+			return nil, err
+		}
+		faxes = append(faxes, *record)
+	}
+	return faxes, results.Err()
+}
+
+/*
+Input: faxId
+Todo : Mark the email of a fax as delivered
+Output: If success return nil else return err
+*/
+func (fs *FaxStore) CompleteFaxEmail(faxId int) error {
+	_, err := fs.db.Exec("UPDATE faxes SET `email_status` = ?, `email_attempts` = `email_attempts` + 1, `email_error` = NULL, `email_claim_token` = NULL, `email_claimed_until` = NULL WHERE id = ?",
+		fax.EmailSent, faxId)
+	return err
+}
+
+/*
+Input: faxId, lastError, retryAt
+Todo : Record a failed email delivery, it is retried at retryAt or failed for good without one
+Output: If success return nil else return err
+*/
+func (fs *FaxStore) FailFaxEmail(faxId int, lastError string, retryAt *time.Time) error {
+	if retryAt == nil {
+		_, err := fs.db.Exec("UPDATE faxes SET `email_status` = ?, `email_attempts` = `email_attempts` + 1, `email_error` = ?, `email_claim_token` = NULL, `email_claimed_until` = NULL WHERE id = ?",
+			fax.EmailFailed, lastError, faxId)
+		return err
+	}
+	_, err := fs.db.Exec("UPDATE faxes SET `email_attempts` = `email_attempts` + 1, `email_error` = ?, `email_available_at` = ?, `email_claim_token` = NULL, `email_claimed_until` = NULL WHERE id = ?",
+		lastError, *retryAt, faxId)
+	return err
+}
+
+/*
+Input: workspaceId
+Todo : Get the fax-to-email settings of a workspace
+Output: First Value: FaxEmailSettings model, Second Value: error
+Workspaces without settings get disabled ones
+*/
+func (fs *FaxStore) GetFaxEmailSettings(workspaceId int) (*model.FaxEmailSettings, error) {
+	var recipients, locale sql.NullString
+	settings := model.FaxEmailSettings{WorkspaceId: workspaceId, Recipients: []string{}}
+	row := fs.db.QueryRow("SELECT `enabled`, `recipients`, `locale` FROM fax_email_settings WHERE workspace_id = ?", workspaceId)
+	err := row.Scan(&settings.Enabled, &recipients, &locale)
+	if err == sql.ErrNoRows {
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	settings.Locale = locale.String
+	if recipients.String != "" {
+		err = json.Unmarshal([]byte(recipients.String), &settings.Recipients)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &settings, nil
+}
+
+/*
+Input: FaxEmailSettings model
+Todo : Create or replace the fax-to-email settings of a workspace
+Output: If success return nil else return err
+*/
+func (fs *FaxStore) SaveFaxEmailSettings(settings *model.FaxEmailSettings) error {
+	recipients, err := json.Marshal(settings.Recipients)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = fs.db.Exec("INSERT INTO fax_email_settings (`workspace_id`, `enabled`, `recipients`, `locale`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ? ) "+
+		"ON DUPLICATE KEY UPDATE `enabled` = VALUES(`enabled`), `recipients` = VALUES(`recipients`), `locale` = VALUES(`locale`), `updated_at` = VALUES(`updated_at`)",
+		settings.WorkspaceId, settings.Enabled, string(recipients), settings.Locale, now, now)
+	return err
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/fax"
+	"lineblocs.com/api/model"
+)
+
+func TestFaxBilling(t *testing.T) {
+	t.Run("Should bill a received fax with its row", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		faxStore := NewFaxStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO faxes").WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectExec("INSERT INTO users_debits").
+			WithArgs(2, 3, 3, "API usage - FAX", 12, "starter", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		record := &model.Fax{UserId: 3, WorkspaceId: 2, Pages: 3, Status: fax.StatusReceived, Direction: fax.DirectionInbound}
+		faxId, err := faxStore.CreateFax(record, "fax.pdf", 1024, "fax-1", "starter")
+		require.NoError(t, err)
+		assert.Equal(t, int64(12), faxId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should not store a received fax that could not be billed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		faxStore := NewFaxStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO faxes").WillReturnResult(sqlmock.NewResult(12, 1))
+		mock.ExpectExec("INSERT INTO users_debits").WillReturnError(assert.AnError)
+		mock.ExpectRollback()
+
+		record := &model.Fax{UserId: 3, WorkspaceId: 2, Pages: 3, Status: fax.StatusReceived, Direction: fax.DirectionInbound}
+		_, err = faxStore.CreateFax(record, "fax.pdf", 1024, "fax-1", "starter")
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should bill a delivered fax with its status", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		faxStore := NewFaxStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE faxes").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("SELECT `plan` FROM faxes").WithArgs(12).WillReturnRows(sqlmock.NewRows([]string{"plan"}).AddRow("pro"))
+		mock.ExpectExec("INSERT INTO users_debits").
+			WithArgs(2, 3, 2, "API usage - FAX", 12, "pro", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		record := &model.Fax{Id: 12, UserId: 3, WorkspaceId: 2, Pages: 2, Status: fax.StatusDelivered, Direction: fax.DirectionOutbound}
+		updated, err := faxStore.SetFaxStatus(record, fax.StatusSending, nil)
+		require.NoError(t, err)
+		assert.True(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should not bill a fax another update moved first", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		faxStore := NewFaxStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE faxes").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		record := &model.Fax{Id: 12, UserId: 3, WorkspaceId: 2, Pages: 2, Status: fax.StatusDelivered, Direction: fax.DirectionOutbound}
+		updated, err := faxStore.SetFaxStatus(record, fax.StatusSending, nil)
+		require.NoError(t, err)
+		assert.False(t, updated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
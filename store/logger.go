@@ -1,12 +1,10 @@
 package store
 
 import (
-	"context"
+	"database/sql"
 	"strconv"
 	"time"
 
-	helpers "github.com/Lineblocs/go-helpers"
-	"github.com/mailgun/mailgun-go/v4"
 	"github.com/sirupsen/logrus"
 	"lineblocs.com/api/model"
 	"lineblocs.com/api/utils"
@@ -29,22 +27,18 @@ func NewLoggerStore(db *database.MySQLConn) *LoggerStore {
 
 /*
 Input: Log model
-Todo : Create log model and store to db, send log email
+Todo : Create log model and store to db with its flow
 Output: First Value: LastInsertId, Second Value: error
 If success return (logId, nil) else return (nil, err)
 */
 func (ls *LoggerStore) StartLogRoutine(workspace *model.Workspace, log *model.LogRoutine) (*string, error) {
-	var user *helpers.User
-
-	user, err := helpers.GetUserFromDB(log.UserId)
-	if err != nil {
-		utils.Log(logrus.ErrorLevel, "Could not get user..")
-		return nil, err
-	}
-
 	now := time.Now()
 	apiId := utils.CreateAPIID("log")
-	stmt, err := ls.db.Prepare("INSERT INTO debugger_logs (`from`, `to`, `title`, `report`, `workspace_id`, `level`, `api_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ? )")
+	var flowId *int
+	if log.FlowId > 0 {
+		flowId = &log.FlowId
+	}
+	stmt, err := ls.db.Prepare("INSERT INTO debugger_logs (`from`, `to`, `title`, `report`, `workspace_id`, `flow_id`, `level`, `api_id`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ? )")
 
 	if err != nil {
 		utils.Log(logrus.ErrorLevel, "Could not prepare query..")
@@ -52,7 +46,7 @@ func (ls *LoggerStore) StartLogRoutine(workspace *model.Workspace, log *model.Lo
 	}
 
 	defer stmt.Close()
-	res, err := stmt.Exec(log.From, log.To, log.Title, log.Report, workspace.Id, log.Level, apiId, now, now)
+	res, err := stmt.Exec(log.From, log.To, log.Title, log.Report, workspace.Id, flowId, log.Level, apiId, now, now)
 	if err != nil {
 		utils.Log(logrus.ErrorLevel, "Could not execute query..")
 		return nil, err
@@ -65,45 +59,183 @@ func (ls *LoggerStore) StartLogRoutine(workspace *model.Workspace, log *model.Lo
 	}
 	logIdStr := strconv.FormatInt(logId, 10)
 
-	go sendLogRoutineEmail(log, user, workspace)
-
 	return &logIdStr, err
 }
 
+// logColumns are read by scanLog.
+const logColumns = "`id`, `api_id`, `workspace_id`, `flow_id`, `level`, `title`, `report`, `from`, `to`, `created_at`"
+
+func scanLog(row rowScanner) (*model.DebuggerLog, error) {
+	var flowId sql.NullInt64
+	var apiId, level, title, report, from, to sql.NullString
+	var createdAt time.Time
+	var log model.DebuggerLog
+	err := row.Scan(&log.Id, &apiId, &log.WorkspaceId, &flowId, &level, &title, &report, &from, &to, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	log.APIId = apiId.String
+	log.FlowId = int(flowId.Int64)
+	log.Level = level.String
+	log.Title = title.String
+	log.Report = report.String
+	log.From = from.String
+	log.To = to.String
+	log.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	return &log, nil
+}
+
+/*
+Input: workspaceId, id
+Todo : Get the debugger log of the workspace with matching id
+Output: First Value: DebuggerLog model, Second Value: error
+*/
+func (ls *LoggerStore) GetLog(workspaceId int, id int) (*model.DebuggerLog, error) {
+	row := ls.db.QueryRow("SELECT "+logColumns+" FROM debugger_logs WHERE id = ? AND workspace_id = ?", id, workspaceId)
+	return scanLog(row)
+}
+
 /*
-Input: LogRoutine model, User model, Workspace model
-Todo : Send Log email.
+Input: LogFilter model
+Todo : Get a page of the debugger logs of a workspace matching the filter, newest first
+Output: First Value: LogList model, Second Value: error
+*/
+func (ls *LoggerStore) ListLogs(filter *model.LogFilter) (*model.LogList, error) {
+	query := "SELECT " + logColumns + " FROM debugger_logs WHERE workspace_id = ?"
+	args := []interface{}{filter.WorkspaceId}
+	if filter.BeforeId > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeId)
+	}
+	if filter.Level != "" {
+		query += " AND `level` = ?"
+		args = append(args, filter.Level)
+	}
+	if filter.FlowId != nil {
+		query += " AND flow_id = ?"
+		args = append(args, *filter.FlowId)
+	}
+	if filter.From != "" {
+		query += " AND `from` = ?"
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		query += " AND `to` = ?"
+		args = append(args, filter.To)
+	}
+	if filter.Start != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *filter.Start)
+	}
+	if filter.End != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.End)
+	}
+	// one extra row tells whether there is a next page
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	results, err := ls.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	list := model.LogList{Logs: []model.DebuggerLog{}}
+	for results.Next() {
+		log, err := scanLog(results)
+		if err != nil {
+			return nil, err
+		}
+		list.Logs = append(list.Logs, *log)
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list.Logs) > filter.Limit {
+		list.Logs = list.Logs[:filter.Limit]
+		list.NextCursor = utils.EncodeCursor(list.Logs[filter.Limit-1].Id)
+	}
+	return &list, nil
+}
+
+/*
+Input: workspaceId
+Todo : Get the notification channels of a workspace, oldest first
+Output: First Value: list of NotificationChannel model, Second Value: error
+*/
+func (ls *LoggerStore) GetNotificationChannels(workspaceId int) ([]model.NotificationChannel, error) {
+	results, err := ls.db.Query("SELECT `id`, `workspace_id`, `type`, `min_level`, `target`, `secret` FROM debugger_notification_channels WHERE workspace_id = ? ORDER BY id", workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	channels := []model.NotificationChannel{}
+	for results.Next() {
+		var target, secret sql.NullString
+		var channel model.NotificationChannel
+		err = results.Scan(&channel.Id, &channel.WorkspaceId, &channel.Type, &channel.MinLevel, &target, &secret)
+		if err != nil {
+			return nil, err
+		}
+		channel.Target = target.String
+		channel.Secret = secret.String
+		channels = append(channels, channel)
+	}
+	return channels, results.Err()
+}
+
+/*
+Input: NotificationChannel model
+Todo : Create the channel when it has no id, else update the channel of the workspace with its id. An empty secret keeps the saved one
+Output: If success return nil and set the id of new channels else return err
+Returns sql.ErrNoRows when the channel is not in the workspace
+*/
+func (ls *LoggerStore) SaveNotificationChannel(channel *model.NotificationChannel) error {
+	now := time.Now()
+	if channel.Id == 0 {
+		res, err := ls.db.Exec("INSERT INTO debugger_notification_channels (`workspace_id`, `type`, `min_level`, `target`, `secret`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ? )",
+			channel.WorkspaceId, channel.Type, channel.MinLevel, channel.Target, channel.Secret, now, now)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+		channel.Id = int(id)
+		return nil
+	}
+
+	var exists int
+	err := ls.db.QueryRow("SELECT id FROM debugger_notification_channels WHERE id = ? AND workspace_id = ?", channel.Id, channel.WorkspaceId).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	_, err = ls.db.Exec("UPDATE debugger_notification_channels SET `type` = ?, `min_level` = ?, `target` = ?, `secret` = COALESCE(NULLIF(?, ''), `secret`), `updated_at` = ? WHERE id = ? AND workspace_id = ?",
+		channel.Type, channel.MinLevel, channel.Target, channel.Secret, now, channel.Id, channel.WorkspaceId)
+	return err
+}
+
+/*
+Input: workspaceId, id
+Todo : Delete the notification channel of the workspace with matching id
 Output: If success return nil else return err
+Returns sql.ErrNoRows when the channel is not in the workspace
 */
-func sendLogRoutineEmail(log *model.LogRoutine, user *helpers.User, workspace *model.Workspace) error {
-	mg := mailgun.NewMailgun(utils.Config("MAILGUN_DOMAIN"), utils.Config("MAILGUN_API_KEY"))
-	m := mg.NewMessage(
-		"Lineblocs <monitor@lineblocs.com>",
-		"Debug Monitor",
-		"Debug Monitor",
-		user.Email)
-	m.AddCC("contact@lineblocs.com")
-	//m.AddBCC("bar@example.com")
-
-	body := `<html>
-<head></head>
-<body>
-	<h1>Lineblocs Monitor Report</h1>
-	<h5>` + log.Title + `</h5>
-	<p>` + log.Report + `</p>
-</body>
-</html>`
-
-	m.SetHtml(body)
-	//m.AddAttachment("files/test.jpg")
-	//m.AddAttachment("files/test.txt")
-
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
-
-	_, _, err := mg.Send(ctx, m)
+func (ls *LoggerStore) DeleteNotificationChannel(workspaceId int, id int) error {
+	res, err := ls.db.Exec("DELETE FROM debugger_notification_channels WHERE id = ? AND workspace_id = ?", id, workspaceId)
 	if err != nil {
 		return err
 	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return sql.ErrNoRows
+	}
 	return nil
 }
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+)
+
+var logColumnNames = []string{"id", "api_id", "workspace_id", "flow_id", "level", "title", "report", "from", "to", "created_at"}
+
+func TestLoggerStore(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should store the flow of a log", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		loggerStore := NewLoggerStore(database.NewMySQLConn(db))
+
+		mock.ExpectPrepare("INSERT INTO debugger_logs").ExpectExec().
+			WithArgs("+15145550100", "", "Call failed", "busy", 1, 3, "error", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(9, 1))
+		mock.ExpectPrepare("INSERT INTO debugger_logs").ExpectExec().
+			WithArgs("", "", "Flow saved", "", 1, nil, "info", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(10, 1))
+
+		workspace := &model.Workspace{Id: 1}
+		logId, err := loggerStore.StartLogRoutine(workspace, &model.LogRoutine{WorkspaceId: 1, From: "+15145550100", Title: "Call failed", Report: "busy", FlowId: 3, Level: "error"})
+		require.NoError(t, err)
+		assert.Equal(t, "9", *logId)
+		_, err = loggerStore.StartLogRoutine(workspace, &model.LogRoutine{WorkspaceId: 1, Title: "Flow saved", Level: "info"})
+		require.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should get logs of the workspace only", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		loggerStore := NewLoggerStore(database.NewMySQLConn(db))
+
+		mock.ExpectQuery("FROM debugger_logs WHERE id = \\? AND workspace_id = \\?").WithArgs(9, 1).
+			WillReturnRows(sqlmock.NewRows(logColumnNames).AddRow(9, "log-9", 1, nil, "error", "Call failed", "busy", "+15145550100", nil, createdAt))
+		mock.ExpectQuery("FROM debugger_logs WHERE id = \\? AND workspace_id = \\?").WithArgs(9, 2).
+			WillReturnError(sql.ErrNoRows)
+
+		log, err := loggerStore.GetLog(1, 9)
+		require.NoError(t, err)
+		assert.Equal(t, &model.DebuggerLog{Id: 9, APIId: "log-9", WorkspaceId: 1, Level: "error", Title: "Call failed", Report: "busy", From: "+15145550100", CreatedAt: "2024-05-01T10:00:00Z"}, log)
+
+		_, err = loggerStore.GetLog(2, 9)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should list a page of logs matching the filter", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		loggerStore := NewLoggerStore(database.NewMySQLConn(db))
+
+		flowId := 3
+		mock.ExpectQuery("FROM debugger_logs WHERE workspace_id = \\? AND id < \\? AND `level` = \\? AND flow_id = \\? ORDER BY id DESC LIMIT \\?").
+			WithArgs(1, 20, "error", 3, 3).
+			WillReturnRows(sqlmock.NewRows(logColumnNames).
+				AddRow(12, "log-12", 1, 3, "error", "a", "", "", "", createdAt).
+				AddRow(11, "log-11", 1, 3, "error", "b", "", "", "", createdAt).
+				AddRow(10, "log-10", 1, 3, "error", "c", "", "", "", createdAt))
+
+		list, err := loggerStore.ListLogs(&model.LogFilter{WorkspaceId: 1, Level: "error", FlowId: &flowId, BeforeId: 20, Limit: 2})
+		require.NoError(t, err)
+		if assert.Len(t, list.Logs, 2) {
+			assert.Equal(t, 12, list.Logs[0].Id)
+			assert.Equal(t, 3, list.Logs[0].FlowId)
+		}
+		assert.NotEmpty(t, list.NextCursor)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+)
+
+/*
+Implementation of Object Access Store
+*/
+
+type ObjectAccessStore struct {
+	db *database.MySQLConn
+}
+
+func NewObjectAccessStore(db *database.MySQLConn) *ObjectAccessStore {
+	return &ObjectAccessStore{
+		db: db,
+	}
+}
+
+/*
+Input: ObjectAccessLog model
+Todo : Store an access to a recording or fax in object_access_logs
+Output: If success return nil else return err
+*/
+func (oa *ObjectAccessStore) CreateAccessLog(entry *model.ObjectAccessLog) error {
+	var expiresAt sql.NullTime
+	if entry.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, entry.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		expiresAt = sql.NullTime{Time: parsed, Valid: true}
+	}
+	_, err := oa.db.Exec("INSERT INTO object_access_logs (`workspace_id`, `object_type`, `object_id`, `object_key`, `action`, `remote_ip`, `user_agent`, `expires_at`, `created_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ? )",
+		entry.WorkspaceId, entry.ObjectType, entry.ObjectId, entry.Key, entry.Action, entry.RemoteIp, entry.UserAgent, expiresAt, time.Now())
+	return err
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"time"
+
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+)
+
+/*
+Implementation of Object Key Store
+*/
+
+type ObjectKeyStore struct {
+	db *database.MySQLConn
+}
+
+func NewObjectKeyStore(db *database.MySQLConn) *ObjectKeyStore {
+	return &ObjectKeyStore{
+		db: db,
+	}
+}
+
+/*
+Input: ObjectDataKey model
+Todo : Store the wrapped data key of an object, replacing the key of an object stored again or rewrapped
+Output: If success return nil else return err
+*/
+func (ok *ObjectKeyStore) SaveDataKey(key *model.ObjectDataKey) error {
+	now := time.Now()
+	_, err := ok.db.Exec("INSERT INTO object_data_keys (`workspace_id`, `object_key`, `key_id`, `algorithm`, `wrapped_key`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ? ) "+
+		"ON DUPLICATE KEY UPDATE `key_id` = VALUES(`key_id`), `algorithm` = VALUES(`algorithm`), `wrapped_key` = VALUES(`wrapped_key`), `updated_at` = VALUES(`updated_at`)",
+		key.WorkspaceId, key.Key, key.KeyId, key.Algorithm, key.WrappedKey, now, now)
+	return err
+}
+
+/*
+Input: workspaceId, key
+Todo : Get the wrapped data key of an object
+Output: First Value: ObjectDataKey model, Second Value: error
+Returns sql.ErrNoRows for objects that are not encrypted
+*/
+func (ok *ObjectKeyStore) GetDataKey(workspaceId int, key string) (*model.ObjectDataKey, error) {
+	dataKey := model.ObjectDataKey{WorkspaceId: workspaceId, Key: key}
+	row := ok.db.QueryRow("SELECT `id`, `key_id`, `algorithm`, `wrapped_key` FROM object_data_keys WHERE workspace_id = ? AND object_key = ?", workspaceId, key)
+	err := row.Scan(&dataKey.Id, &dataKey.KeyId, &dataKey.Algorithm, &dataKey.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dataKey, nil
+}
+
+/*
+Input: workspaceId, key
+Todo : Remove the data key of a deleted object, the object can not be decrypted afterwards
+Output: If success return nil else return err
+*/
+func (ok *ObjectKeyStore) DeleteDataKey(workspaceId int, key string) error {
+	_, err := ok.db.Exec("DELETE FROM object_data_keys WHERE workspace_id = ? AND object_key = ?", workspaceId, key)
+	return err
+}
+
+/*
+Input: workspaceId, keyId, afterId, limit
+Todo : Get the data keys of a workspace that are not wrapped with keyId, in id order after afterId
+Output: First Value: list of ObjectDataKey model, Second Value: error
+*/
+func (ok *ObjectKeyStore) GetDataKeysToRewrap(workspaceId int, keyId string, afterId int, limit int) ([]model.ObjectDataKey, error) {
+	results, err := ok.db.Query("SELECT `id`, `object_key`, `key_id`, `algorithm`, `wrapped_key` FROM object_data_keys WHERE workspace_id = ? AND key_id <> ? AND id > ? ORDER BY id ASC LIMIT ?", workspaceId, keyId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	keys := []model.ObjectDataKey{}
+	for results.Next() {
+		dataKey := model.ObjectDataKey{WorkspaceId: workspaceId}
+		err = results.Scan(&dataKey.Id, &dataKey.Key, &dataKey.KeyId, &dataKey.Algorithm, &dataKey.WrappedKey)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, dataKey)
+	}
+	return keys, results.Err()
+}
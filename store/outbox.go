@@ -0,0 +1,136 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Implementation of Outbox Store
+
+Events are written to event_outbox in the same transaction as the state
+change they describe and published later by outbox.Relay.
+*/
+
+const callActivityQueue = "call_activity"
+
+type OutboxStore struct {
+	db *database.MySQLConn
+}
+
+func NewOutboxStore(db *database.MySQLConn) *OutboxStore {
+	return &OutboxStore{
+		db: db,
+	}
+}
+
+/*
+Input: OutboxEvent model
+Todo : Store a single event in the outbox outside of any other transaction
+Output: If success return nil else return err
+*/
+func (ob *OutboxStore) Enqueue(event *model.OutboxEvent) error {
+	tx, err := ob.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = insertOutboxEvent(tx, event)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/*
+Input: limit, lease
+Todo : Lease up to limit pending events to this relay so concurrent relays do not publish the same rows
+Output: First Value: list of OutboxEvent model, Second Value: error
+*/
+func (ob *OutboxStore) ClaimPending(limit int, lease time.Duration) ([]model.OutboxEvent, error) {
+	now := time.Now()
+	token := utils.CreateAPIID("relay")
+
+	_, err := ob.db.Exec("UPDATE event_outbox SET `claim_token` = ?, `claimed_until` = ? WHERE `published_at` IS NULL AND `available_at` <= ? AND (`claimed_until` IS NULL OR `claimed_until` < ?) ORDER BY `id` ASC LIMIT ?",
+		token, now.Add(lease), now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ob.db.Query("SELECT `id`, `queue`, `event_type`, `payload`, `attempts`, `created_at` FROM event_outbox WHERE `claim_token` = ? AND `published_at` IS NULL ORDER BY `id` ASC", token)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	events := []model.OutboxEvent{}
+	for results.Next() {
+		event := model.OutboxEvent{}
+		err = results.Scan(&event.Id, &event.Queue, &event.EventType, &event.Payload, &event.Attempts, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, results.Err()
+}
+
+/*
+Input: id
+Todo : Mark an event as confirmed by the broker
+Output: If success return nil else return err
+*/
+func (ob *OutboxStore) MarkPublished(id int64) error {
+	_, err := ob.db.Exec("UPDATE event_outbox SET `published_at` = ?, `claim_token` = NULL, `claimed_until` = NULL WHERE `id` = ?", time.Now(), id)
+	return err
+}
+
+/*
+Input: id, lastError, retryAt
+Todo : Release a failed event so it is retried at retryAt
+Output: If success return nil else return err
+*/
+func (ob *OutboxStore) MarkFailed(id int64, lastError string, retryAt time.Time) error {
+	_, err := ob.db.Exec("UPDATE event_outbox SET `attempts` = `attempts` + 1, `last_error` = ?, `available_at` = ?, `claim_token` = NULL, `claimed_until` = NULL WHERE `id` = ?", lastError, retryAt, id)
+	return err
+}
+
+func insertOutboxEvent(tx *sql.Tx, event *model.OutboxEvent) error {
+	now := time.Now()
+	res, err := tx.Exec("INSERT INTO event_outbox ( `queue`, `event_type`, `payload`, `attempts`, `available_at`, `created_at` ) VALUES ( ?, ?, ?, 0, ?, ? )",
+		event.Queue, event.EventType, event.Payload, now, now)
+	if err != nil {
+		return err
+	}
+	event.Id, err = res.LastInsertId()
+	event.CreatedAt = now.Format(time.RFC3339)
+	return err
+}
+
+// enqueueCallActivity writes a call_activity task for call to the outbox within tx.
+func enqueueCallActivity(tx *sql.Tx, eventType string, call *model.Call) error {
+	task := model.CallActivityTask{
+		RunID:       strconv.Itoa(call.Id),
+		APIID:       call.APIId,
+		EventType:   eventType,
+		From:        call.From,
+		To:          call.To,
+		WorkspaceID: strconv.Itoa(call.WorkspaceId),
+	}
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	return insertOutboxEvent(tx, &model.OutboxEvent{
+		Queue:     callActivityQueue,
+		EventType: eventType,
+		Payload:   payload,
+	})
+}
@@ -37,7 +37,7 @@ func (rs *RecordingStore) CreateRecording(workspace *model.Workspace, recording
 	now := time.Now()
 
 	// Perform a db.Query insert
-	stmt, err := rs.db.Prepare("INSERT INTO recordings (`user_id`, `call_id`, `workspace_id`, `status`, `name`, `uri`, `tag`, `api_id`, `plan_snapshot`, `storage_id`, `storage_server_ip`, `trim`, `transcription_text`, `s3_url`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	stmt, err := rs.db.Prepare("INSERT INTO recordings (`user_id`, `call_id`, `workspace_id`, `status`, `name`, `uri`, `tag`, `api_id`, `plan_snapshot`, `storage_id`, `storage_server_ip`, `trim`, `transcribe`, `transcription_text`, `s3_url`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
 	if err != nil {
 		return -1, err
 	}
@@ -55,6 +55,7 @@ func (rs *RecordingStore) CreateRecording(workspace *model.Workspace, recording
 		recording.StorageId,
 		recording.StorageServerIp,
 		recording.Trim,
+		recording.Transcribe,
 		"", // transcription text
 		"", // s3 URL
 		now,
@@ -95,20 +96,23 @@ Output: First Value: Recording model, Second Value: error
 If success return (Recording model, nil) else (nil, err)
 */
 func (rs *RecordingStore) GetRecordingFromDB(id int) (*model.Recording, error) {
-	var apiId string
 	var ready int
-	var size int
-	var text string
-	row := rs.db.QueryRow("SELECT api_id, transcription_ready, transcription_text, size FROM recordings WHERE id=?", id)
+	var text, uri, status, codec sql.NullString
+	record := model.Recording{Id: id}
+	row := rs.db.QueryRow("SELECT api_id, user_id, workspace_id, status, uri, `trim`, transcribe, transcription_ready, transcription_text, size, COALESCE(duration, 0), codec, COALESCE(sample_rate, 0), COALESCE(channels, 0) FROM recordings WHERE id=?", id)
 
-	err := row.Scan(&apiId, &ready, &text, &size)
-	if err == sql.ErrNoRows {
+	err := row.Scan(&record.APIId, &record.UserId, &record.WorkspaceId, &status, &uri, &record.Trim, &record.Transcribe, &ready, &text, &record.Size, &record.Duration, &codec, &record.SampleRate, &record.Channels)
+	if err != nil {
 		return nil, err
 	}
+	record.Status = status.String
+	record.Uri = uri.String
+	record.Codec = codec.String
 	if ready == 1 {
-		return &model.Recording{APIId: apiId, Id: id, TranscriptionReady: true, TranscriptionText: text, Size: size}, nil
+		record.TranscriptionReady = true
+		record.TranscriptionText = text.String
 	}
-	return &model.Recording{APIId: apiId, Id: id, Size: size}, nil
+	return &record, nil
 }
 
 /*
@@ -119,7 +123,8 @@ If success return (size, nil) else (nil, err)
 */
 func (rs *RecordingStore) GetRecordingSpace(id int) (int, error) {
 	var bytes int
-	row := rs.db.QueryRow(`SELECT SUM(size) FROM recordings WHERE workspace_id=?`, id)
+	// archived and deleted recordings no longer take up plan space
+	row := rs.db.QueryRow(`SELECT COALESCE(SUM(size), 0) FROM recordings WHERE workspace_id=? AND archived_at IS NULL AND deleted_at IS NULL`, id)
 
 	err := row.Scan(&bytes)
 	if err == sql.ErrNoRows {
@@ -131,6 +136,68 @@ func (rs *RecordingStore) GetRecordingSpace(id int) (int, error) {
 	return bytes, nil
 }
 
+/*
+Input: workspaceId
+Todo : Get sum of duration in seconds for recordings with matching workspace_id
+Output: First Value: seconds, Second Value: error
+*/
+func (rs *RecordingStore) GetRecordingDuration(workspaceId int) (int, error) {
+	var seconds int
+	row := rs.db.QueryRow("SELECT COALESCE(SUM(duration), 0) FROM recordings WHERE workspace_id = ? AND archived_at IS NULL AND deleted_at IS NULL", workspaceId)
+	err := row.Scan(&seconds)
+	if err != nil {
+		return 0, err
+	}
+	return seconds, nil
+}
+
+/*
+Input: workspaceId
+Todo : Get the recording minutes included in the plan of the workspace
+Output: First Value: minutes, 0 means the plan does not limit the length of recordings, Second Value: error
+*/
+func (rs *RecordingStore) GetPlanRecordingMinutes(workspaceId int) (int, error) {
+	var minutes int
+	row := rs.db.QueryRow(`SELECT COALESCE(service_plans.recording_minutes, 0) FROM subscriptions
+		INNER JOIN service_plans ON service_plans.id = subscriptions.current_plan_id
+		WHERE subscriptions.workspace_id = ?`, workspaceId)
+	err := row.Scan(&minutes)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return minutes, err
+}
+
+/*
+Input: workspaceId
+Todo : Get the recording space of the plan of the workspace in bytes, 0 when the space is unlimited
+Output: First Value: bytes, Second Value: error
+*/
+func (rs *RecordingStore) GetPlanRecordingSpace(workspaceId int) (int64, error) {
+	var payAsYouGo sql.NullBool
+	var recordingSpace sql.NullString
+	row := rs.db.QueryRow(`SELECT service_plans.pay_as_you_go, service_plans.recording_space FROM subscriptions
+		INNER JOIN service_plans ON service_plans.id = subscriptions.current_plan_id
+		WHERE subscriptions.workspace_id = ?`, workspaceId)
+	err := row.Scan(&payAsYouGo, &recordingSpace)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	// pay as you go plans are billed for the space they use
+	space := strings.TrimSuffix(strings.TrimSpace(recordingSpace.String), "gb")
+	if payAsYouGo.Bool || space == "" {
+		return 0, nil
+	}
+	spaceGB, err := strconv.ParseInt(space, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return spaceGB * 1024 * 1024 * 1024, nil
+}
+
 /*
 Input: apiId, status
 Todo : set recording status
@@ -152,19 +219,22 @@ func (rs *RecordingStore) SetRecordingStatus(recordingId int, status string) err
 }
 
 /*
-Input: apiId, status, size, recordingId
-Todo : Update recordings with matching id
+Input: recordingId, status, size, uri, RecordingAudio model
+Todo : Update recordings with matching id once the recording file is stored, audio is nil when the file could not be read
 Output: If success return nil else return err
 */
-func (rs *RecordingStore) UpdateRecording(apiId string, status string, size int64, recordingId int) error {
+func (rs *RecordingStore) UpdateRecording(recordingId int, status string, size int64, uri string, audio *model.RecordingAudio) error {
 	now := time.Now()
-	//uri := utils.CreateS3URL("recordings", apiId)
-	stmt, err := rs.db.Prepare("UPDATE `recordings` SET `status` = ?, `size` = ?, `updated_at` = ? WHERE `api_id` = ?")
+	if audio == nil {
+		audio = &model.RecordingAudio{}
+	}
+	// s3_url is kept in step with uri for readers of the old column
+	stmt, err := rs.db.Prepare("UPDATE `recordings` SET `status` = ?, `size` = ?, `uri` = ?, `s3_url` = ?, `duration` = ?, `codec` = ?, `sample_rate` = ?, `channels` = ?, `updated_at` = ? WHERE `id` = ?")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
-	_, err = stmt.Exec(status, size, now, recordingId)
+	_, err = stmt.Exec(status, size, uri, uri, audio.Duration, audio.Codec, audio.SampleRate, audio.Channels, now, recordingId)
 	if err != nil {
 		return err
 	}
@@ -206,7 +276,7 @@ func (rs *RecordingStore) IsUserAllowedToRecord(workspaceId int) (bool, error) {
 		return false, err
 	}
 
-	row = rs.db.QueryRow("SELECT COALESCE(SUM(size), 0) FROM recordings WHERE workspace_id = ?", workspaceId)
+	row = rs.db.QueryRow("SELECT COALESCE(SUM(size), 0) FROM recordings WHERE workspace_id = ? AND archived_at IS NULL AND deleted_at IS NULL", workspaceId)
 	utils.Log(logrus.InfoLevel, fmt.Sprintf("Workspace %d has used %d bytes of recording space", workspaceId, totalBytes))
 	err = row.Scan(&totalBytes)
 	if err != nil {
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+/*
+Input: workspaceId
+Todo : Get the recording retention policy of a workspace
+Output: First Value: RecordingRetentionPolicy model, Second Value: error
+Workspaces without a policy get an empty one, recordings are then kept forever
+*/
+func (rs *RecordingStore) GetRetentionPolicy(workspaceId int) (*model.RecordingRetentionPolicy, error) {
+	var keepTags sql.NullString
+	policy := model.RecordingRetentionPolicy{WorkspaceId: workspaceId, KeepTags: []string{}}
+	row := rs.db.QueryRow("SELECT `archive_after_days`, `delete_after_days`, `keep_tags` FROM recording_retention_policies WHERE workspace_id = ?", workspaceId)
+	err := row.Scan(&policy.ArchiveAfterDays, &policy.DeleteAfterDays, &keepTags)
+	if err == sql.ErrNoRows {
+		return &policy, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if keepTags.String != "" {
+		err = json.Unmarshal([]byte(keepTags.String), &policy.KeepTags)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &policy, nil
+}
+
+/*
+Input: RecordingRetentionPolicy model
+Todo : Create or replace the recording retention policy of a workspace
+Output: If success return nil else return err
+*/
+func (rs *RecordingStore) SaveRetentionPolicy(policy *model.RecordingRetentionPolicy) error {
+	keepTags, err := json.Marshal(policy.KeepTags)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = rs.db.Exec("INSERT INTO recording_retention_policies (`workspace_id`, `archive_after_days`, `delete_after_days`, `keep_tags`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, ?, ? ) "+
+		"ON DUPLICATE KEY UPDATE `archive_after_days` = VALUES(`archive_after_days`), `delete_after_days` = VALUES(`delete_after_days`), `keep_tags` = VALUES(`keep_tags`), `updated_at` = VALUES(`updated_at`)",
+		policy.WorkspaceId, policy.ArchiveAfterDays, policy.DeleteAfterDays, string(keepTags), now, now)
+	return err
+}
+
+/*
+Input: _
+Todo : Get every retention policy that archives or deletes recordings
+Output: First Value: list of RecordingRetentionPolicy models, Second Value: error
+*/
+func (rs *RecordingStore) GetRetentionPolicies() ([]model.RecordingRetentionPolicy, error) {
+	results, err := rs.db.Query("SELECT `workspace_id` FROM recording_retention_policies WHERE archive_after_days > 0 OR delete_after_days > 0")
+	if err != nil {
+		return nil, err
+	}
+	var workspaceIds []int
+	for results.Next() {
+		var workspaceId int
+		err = results.Scan(&workspaceId)
+		if err != nil {
+			results.Close()
+			return nil, err
+		}
+		workspaceIds = append(workspaceIds, workspaceId)
+	}
+	results.Close()
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	policies := []model.RecordingRetentionPolicy{}
+	for _, workspaceId := range workspaceIds {
+		policy, err := rs.GetRetentionPolicy(workspaceId)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+	return policies, nil
+}
+
+/*
+Input: workspaceId, createdBefore, includeArchived, keepTags, afterId, limit
+Todo : Get recordings after afterId created before a time that are not deleted yet and carry none of keepTags
+Output: First Value: list of Recording models, Second Value: error
+*/
+func (rs *RecordingStore) GetRecordingsForRetention(workspaceId int, createdBefore time.Time, includeArchived bool, keepTags []string, afterId int, limit int) ([]model.Recording, error) {
+	query := "SELECT `id`, `api_id`, `user_id`, `workspace_id`, `size` FROM recordings WHERE workspace_id = ? AND id > ? AND created_at < ? AND deleted_at IS NULL"
+	args := []interface{}{workspaceId, afterId, createdBefore}
+	if !includeArchived {
+		query += " AND archived_at IS NULL"
+	}
+	if len(keepTags) > 0 {
+		query += " AND NOT EXISTS (SELECT 1 FROM recording_tags WHERE recording_tags.recording_id = recordings.id AND recording_tags.tag IN (?" + strings.Repeat(", ?", len(keepTags)-1) + "))"
+		for _, tag := range keepTags {
+			args = append(args, tag)
+		}
+	}
+	query += " ORDER BY id ASC LIMIT ?"
+	args = append(args, limit)
+
+	results, err := rs.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	recordings := []model.Recording{}
+	for results.Next() {
+		var recording model.Recording
+		err = results.Scan(&recording.Id, &recording.APIId, &recording.UserId, &recording.WorkspaceId, &recording.Size)
+		if err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, recording)
+	}
+	return recordings, results.Err()
+}
+
+/*
+Input: recordingId
+Todo : Mark a recording as moved to cold storage, it no longer counts towards the plan recording space
+Output: If success return nil else return err
+*/
+func (rs *RecordingStore) MarkRecordingArchived(recordingId int) error {
+	now := time.Now()
+	_, err := rs.db.Exec("UPDATE recordings SET `status` = ?, `archived_at` = ?, `updated_at` = ? WHERE id = ? AND archived_at IS NULL", "ARCHIVED", now, now, recordingId)
+	return err
+}
+
+/*
+Input: recordingId
+Todo : Mark a recording whose file was deleted, it no longer counts towards the plan recording space
+Output: If success return nil else return err
+*/
+func (rs *RecordingStore) MarkRecordingDeleted(recordingId int) error {
+	now := time.Now()
+	_, err := rs.db.Exec("UPDATE recordings SET `status` = ?, `deleted_at` = ?, `uri` = '', `s3_url` = '', `updated_at` = ? WHERE id = ? AND deleted_at IS NULL", "DELETED", now, now, recordingId)
+	return err
+}
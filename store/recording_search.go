@@ -0,0 +1,151 @@
+package store
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Input: RecordingFilter model
+Todo : Get a page of the recordings of a workspace matching the filter, newest first, with their tags
+Output: First Value: RecordingList model, Second Value: error
+The text search needs the FULLTEXT index on recordings.transcription_text
+*/
+func (rs *RecordingStore) ListRecordings(filter *model.RecordingFilter) (*model.RecordingList, error) {
+	query := "SELECT `id`, `api_id`, `user_id`, `call_id`, `workspace_id`, `status`, `uri`, `storage_id`, `storage_server_ip`, `size`, COALESCE(`duration`, 0), `codec`, COALESCE(`sample_rate`, 0), COALESCE(`channels`, 0), `trim`, `transcribe`, `transcription_ready`, `transcription_text`, `created_at` FROM recordings WHERE workspace_id = ?"
+	args := []interface{}{filter.WorkspaceId}
+	if filter.BeforeId > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeId)
+	}
+	if filter.CallId != nil {
+		query += " AND call_id = ?"
+		args = append(args, *filter.CallId)
+	}
+	if filter.Status != "" {
+		query += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.From != nil {
+		query += " AND created_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.To)
+	}
+	if filter.Text != "" {
+		query += " AND MATCH(transcription_text) AGAINST (? IN NATURAL LANGUAGE MODE)"
+		args = append(args, filter.Text)
+	}
+	if len(filter.Tags) > 0 {
+		query += " AND id IN (SELECT recording_id FROM recording_tags WHERE tag IN (?" + strings.Repeat(", ?", len(filter.Tags)-1) + ") GROUP BY recording_id HAVING COUNT(DISTINCT tag) = ?)"
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(filter.Tags))
+	}
+	// one extra row tells whether there is a next page
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit+1)
+
+	results, err := rs.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	list := model.RecordingList{Recordings: []model.Recording{}}
+	for results.Next() {
+		var callId sql.NullInt64
+		var status, uri, storageId, storageServerIp, text, codec sql.NullString
+		var ready int
+		var createdAt time.Time
+		var recording model.Recording
+		err = results.Scan(
+			&recording.Id,
+			&recording.APIId,
+			&recording.UserId,
+			&callId,
+			&recording.WorkspaceId,
+			&status,
+			&uri,
+			&storageId,
+			&storageServerIp,
+			&recording.Size,
+			&recording.Duration,
+			&codec,
+			&recording.SampleRate,
+			&recording.Channels,
+			&recording.Trim,
+			&recording.Transcribe,
+			&ready,
+			&text,
+			&createdAt)
+		if err != nil {
+			return nil, err
+		}
+		if callId.Valid {
+			id := int(callId.Int64)
+			recording.CallId = &id
+		}
+		recording.Status = status.String
+		recording.Uri = uri.String
+		recording.StorageId = storageId.String
+		recording.StorageServerIp = storageServerIp.String
+		recording.Codec = codec.String
+		recording.TranscriptionReady = ready == 1
+		recording.TranscriptionText = text.String
+		recording.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+		recording.Tags = &[]string{}
+		list.Recordings = append(list.Recordings, recording)
+	}
+	if err = results.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(list.Recordings) > filter.Limit {
+		list.Recordings = list.Recordings[:filter.Limit]
+		list.NextCursor = utils.EncodeCursor(list.Recordings[filter.Limit-1].Id)
+	}
+	err = rs.loadRecordingTags(list.Recordings)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// loadRecordingTags fills in the tags of recordings with a single query.
+func (rs *RecordingStore) loadRecordingTags(recordings []model.Recording) error {
+	if len(recordings) == 0 {
+		return nil
+	}
+	byId := make(map[int]*model.Recording, len(recordings))
+	args := make([]interface{}, 0, len(recordings))
+	for i := range recordings {
+		byId[recordings[i].Id] = &recordings[i]
+		args = append(args, recordings[i].Id)
+	}
+
+	results, err := rs.db.Query("SELECT `recording_id`, `tag` FROM recording_tags WHERE recording_id IN (?"+strings.Repeat(", ?", len(args)-1)+") ORDER BY id ASC", args...)
+	if err != nil {
+		return err
+	}
+	defer results.Close()
+	for results.Next() {
+		var recordingId int
+		var tag string
+		err = results.Scan(&recordingId, &tag)
+		if err != nil {
+			return err
+		}
+		if recording, ok := byId[recordingId]; ok {
+			*recording.Tags = append(*recording.Tags, tag)
+		}
+	}
+	return results.Err()
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"time"
+
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/recording"
+)
+
+/*
+Input: RecordingUpload model
+Todo : Start a resumable upload of the audio of a recording
+Output: First Value: upload id, Second Value: error
+*/
+func (rs *RecordingStore) CreateRecordingUpload(upload *model.RecordingUpload) (int64, error) {
+	now := time.Now()
+	expiresAt := now.Add(recording.UploadExpiry)
+	res, err := rs.db.Exec("INSERT INTO recording_uploads (`api_id`, `recording_id`, `workspace_id`, `size`, `received`, `checksum`, `content_type`, `status`, `expires_at`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, 0, ?, ?, ?, ?, ?, ? )",
+		upload.UploadId, upload.RecordingId, upload.WorkspaceId, upload.Size, upload.Checksum, upload.ContentType, recording.UploadInProgress, expiresAt, now, now)
+	if err != nil {
+		return -1, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return -1, err
+	}
+	upload.Id = int(id)
+	upload.Received = 0
+	upload.Status = recording.UploadInProgress
+	upload.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	return id, nil
+}
+
+/*
+Input: uploadId
+Todo : Get a resumable upload by its API id, uploads past their expiry are reported as expired
+Output: First Value: RecordingUpload model, Second Value: error
+*/
+func (rs *RecordingStore) GetRecordingUpload(uploadId string) (*model.RecordingUpload, error) {
+	var expiresAt time.Time
+	upload := model.RecordingUpload{UploadId: uploadId}
+	row := rs.db.QueryRow("SELECT `id`, `recording_id`, `workspace_id`, `size`, `received`, `checksum`, `content_type`, `status`, `expires_at` FROM recording_uploads WHERE api_id = ?", uploadId)
+	err := row.Scan(&upload.Id, &upload.RecordingId, &upload.WorkspaceId, &upload.Size, &upload.Received, &upload.Checksum, &upload.ContentType, &upload.Status, &expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status == recording.UploadInProgress && time.Now().After(expiresAt) {
+		upload.Status = recording.UploadExpired
+	}
+	upload.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	return &upload, nil
+}
+
+/*
+Input: id of the upload, RecordingUploadChunk model
+Todo : Record a chunk that continues an upload at the received size
+Output: If success return nil else return err
+Returns recording.ErrOffsetMismatch when the chunk does not continue the upload
+*/
+func (rs *RecordingStore) AddRecordingUploadChunk(uploadId int, chunk *model.RecordingUploadChunk) error {
+	now := time.Now()
+	tx, err := rs.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the received size only moves when no other request appended in between
+	res, err := tx.Exec("UPDATE recording_uploads SET `received` = `received` + ?, `updated_at` = ? WHERE id = ? AND `received` = ? AND `status` = ? AND `expires_at` > ?",
+		chunk.Size, now, uploadId, chunk.Offset, recording.UploadInProgress, now)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return recording.ErrOffsetMismatch
+	}
+	_, err = tx.Exec("INSERT INTO recording_upload_chunks (`upload_id`, `offset`, `size`, `checksum`, `created_at`) VALUES ( ?, ?, ?, ?, ? )",
+		uploadId, chunk.Offset, chunk.Size, chunk.Checksum, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+/*
+Input: id of the upload
+Todo : Get the received chunks of an upload in order
+Output: First Value: list of RecordingUploadChunk model, Second Value: error
+*/
+func (rs *RecordingStore) GetRecordingUploadChunks(uploadId int) ([]model.RecordingUploadChunk, error) {
+	results, err := rs.db.Query("SELECT `offset`, `size`, `checksum` FROM recording_upload_chunks WHERE upload_id = ? ORDER BY `offset` ASC", uploadId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	chunks := []model.RecordingUploadChunk{}
+	for results.Next() {
+		var chunk model.RecordingUploadChunk
+		err = results.Scan(&chunk.Offset, &chunk.Size, &chunk.Checksum)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, results.Err()
+}
+
+/*
+Input: id of the upload, status
+Todo : Close an upload once it completed, failed or expired
+Output: If success return nil else return err
+*/
+func (rs *RecordingStore) SetRecordingUploadStatus(uploadId int, status string) error {
+	_, err := rs.db.Exec("UPDATE recording_uploads SET `status` = ?, `updated_at` = ? WHERE id = ?", status, time.Now(), uploadId)
+	return err
+}
+
+/*
+Input: id of the upload
+Todo : Move an unexpired upload in progress to completing in one conditional update, so only one request assembles it
+Output: If success return nil else return err
+Returns recording.ErrUploadClosed when the upload is no longer in progress
+*/
+func (rs *RecordingStore) ClaimRecordingUpload(uploadId int) error {
+	now := time.Now()
+	res, err := rs.db.Exec("UPDATE recording_uploads SET `status` = ?, `updated_at` = ? WHERE id = ? AND `status` = ? AND `expires_at` >= ?", recording.UploadCompleting, now, uploadId, recording.UploadInProgress, now)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return recording.ErrUploadClosed
+	}
+	return nil
+}
+
+/*
+Input: before, limit
+Todo : Get uploads still in progress that expired before a time
+Output: First Value: list of RecordingUpload model, Second Value: error
+*/
+func (rs *RecordingStore) GetExpiredRecordingUploads(before time.Time, limit int) ([]model.RecordingUpload, error) {
+	// uploads left completing by an instance that died expire as well
+	results, err := rs.db.Query("SELECT `id`, `api_id`, `recording_id`, `workspace_id` FROM recording_uploads WHERE `status` IN (?, ?) AND `expires_at` < ? ORDER BY id ASC LIMIT ?", recording.UploadInProgress, recording.UploadCompleting, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	uploads := []model.RecordingUpload{}
+	for results.Next() {
+		upload := model.RecordingUpload{Status: recording.UploadInProgress}
+		err = results.Scan(&upload.Id, &upload.UploadId, &upload.RecordingId, &upload.WorkspaceId)
+		if err != nil {
+			return nil, err
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, results.Err()
+}
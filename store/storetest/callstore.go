@@ -0,0 +1,154 @@
+// Package storetest holds contract tests every call store backend must pass.
+package storetest
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/model"
+)
+
+// CallFixture points the contract at an existing workspace and user,
+// call stores read the plan of the workspace when creating calls.
+type CallFixture struct {
+	WorkspaceId int
+	UserId      int
+}
+
+// RunCallStoreContract checks the call record behaviour of store: create,
+// status transitions, SIP call id lookups and call sessions.
+func RunCallStoreContract(t *testing.T, store CallRecordStore, fixture CallFixture) {
+	newCall := func() *model.Call {
+		return &model.Call{
+			From:        "+15550100",
+			To:          "+15550199",
+			Direction:   "OUTBOUND",
+			ChannelId:   fmt.Sprintf("contract-%d", time.Now().UnixNano()),
+			UserId:      fixture.UserId,
+			WorkspaceId: fixture.WorkspaceId,
+			APIId:       fmt.Sprintf("call-contract-%d", time.Now().UnixNano()),
+		}
+	}
+
+	t.Run("CreateCall assigns an id and starts as initiated", func(t *testing.T) {
+		call := newCall()
+		id, err := store.CreateCall(call)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.Itoa(call.Id), id)
+		assert.NotEmpty(t, call.SessionId)
+
+		stored, err := store.GetCallFromDB(call.Id)
+		require.NoError(t, err)
+		assert.Equal(t, callstate.StatusInitiated, stored.Status)
+		assert.Equal(t, call.From, stored.From)
+		assert.Equal(t, call.To, stored.To)
+		assert.Equal(t, call.WorkspaceId, stored.WorkspaceId)
+		assert.Equal(t, call.APIId, stored.APIId)
+		assert.Equal(t, call.SessionId, stored.SessionId)
+		assert.NotEmpty(t, stored.StartedAt)
+	})
+
+	t.Run("CreateCall rejects terminal initial statuses", func(t *testing.T) {
+		call := newCall()
+		call.Status = callstate.StatusEnded
+		_, err := store.CreateCall(call)
+		assert.ErrorIs(t, err, callstate.ErrUnknownStatus)
+	})
+
+	t.Run("UpdateCall follows the lifecycle and ends the call", func(t *testing.T) {
+		call := newCall()
+		_, err := store.CreateCall(call)
+		require.NoError(t, err)
+
+		for _, status := range []string{callstate.StatusRinging, callstate.StatusAnswered, callstate.StatusEnded} {
+			require.NoError(t, store.UpdateCall(&model.CallUpdate{CallId: call.Id, Status: status}), status)
+		}
+
+		stored, err := store.GetCallFromDB(call.Id)
+		require.NoError(t, err)
+		assert.Equal(t, callstate.StatusEnded, stored.Status)
+		assert.NotEmpty(t, stored.RingingAt)
+		assert.NotEmpty(t, stored.AnsweredAt)
+		assert.NotEmpty(t, stored.EndedAt)
+		assert.GreaterOrEqual(t, stored.Duration, 0)
+	})
+
+	t.Run("UpdateCall rejects transitions out of terminal states", func(t *testing.T) {
+		call := newCall()
+		_, err := store.CreateCall(call)
+		require.NoError(t, err)
+		require.NoError(t, store.UpdateCall(&model.CallUpdate{CallId: call.Id, Status: callstate.StatusBusy}))
+
+		err = store.UpdateCall(&model.CallUpdate{CallId: call.Id, Status: callstate.StatusAnswered})
+		assert.ErrorIs(t, err, callstate.ErrInvalidTransition)
+	})
+
+	t.Run("SetSIPCallID makes the call available by SIP call id", func(t *testing.T) {
+		call := newCall()
+		_, err := store.CreateCall(call)
+		require.NoError(t, err)
+
+		sipCallId := fmt.Sprintf("%d@contract.test", time.Now().UnixNano())
+		require.NoError(t, store.SetSIPCallID(sipCallId, strconv.Itoa(call.Id)))
+
+		stored, err := store.GetCallBySIPCallId(sipCallId)
+		require.NoError(t, err)
+		assert.Equal(t, call.Id, stored.Id)
+		assert.Equal(t, sipCallId, stored.SIPCallId)
+	})
+
+	t.Run("Child legs join the session of their parent", func(t *testing.T) {
+		parent := newCall()
+		_, err := store.CreateCall(parent)
+		require.NoError(t, err)
+
+		child := newCall()
+		child.ParentCallId = parent.Id
+		_, err = store.CreateCall(child)
+		require.NoError(t, err)
+		assert.Equal(t, parent.SessionId, child.SessionId)
+
+		legs, err := store.GetCallsBySessionId(fixture.WorkspaceId, parent.SessionId)
+		require.NoError(t, err)
+		if assert.Len(t, legs, 2) {
+			assert.Equal(t, parent.Id, legs[0].Id)
+			assert.Equal(t, parent.Id, legs[1].ParentCallId)
+		}
+	})
+
+	t.Run("Legs can only join existing sessions of their workspace", func(t *testing.T) {
+		parent := newCall()
+		_, err := store.CreateCall(parent)
+		require.NoError(t, err)
+
+		leg := newCall()
+		leg.SessionId = parent.SessionId
+		_, err = store.CreateCall(leg)
+		require.NoError(t, err)
+		assert.Equal(t, parent.SessionId, leg.SessionId)
+
+		unknown := newCall()
+		unknown.SessionId = "session-unknown"
+		_, err = store.CreateCall(unknown)
+		assert.ErrorIs(t, err, callstate.ErrSessionNotFound)
+
+		orphan := newCall()
+		orphan.ParentCallId = -1
+		_, err = store.CreateCall(orphan)
+		assert.ErrorIs(t, err, callstate.ErrParentCallNotFound)
+
+		legs, err := store.GetCallsBySessionId(fixture.WorkspaceId+1, parent.SessionId)
+		require.NoError(t, err)
+		assert.Empty(t, legs)
+	})
+
+	t.Run("Fetching an unknown call fails", func(t *testing.T) {
+		_, err := store.GetCallFromDB(-1)
+		assert.Error(t, err)
+	})
+}
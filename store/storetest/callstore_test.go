@@ -0,0 +1,82 @@
+package storetest
+
+import (
+	"database/sql"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gocql/gocql"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/store"
+)
+
+// The contract always runs against MemoryCallStore, the queries of the MySQL
+// store are also checked with sqlmock in store/call_test.go. The database runs
+// are skipped unless the databases are configured:
+//
+//	TEST_MYSQL_DSN          user:pass@tcp(host:3306)/lineblocs?parseTime=true
+//	TEST_WORKSPACE_ID       id of an existing workspace
+//	TEST_USER_ID            id of an existing user
+//	TEST_CASSANDRA_HOSTS    comma separated hosts, enables the Cassandra and dual runs
+//	TEST_CASSANDRA_KEYSPACE keyspace for the call tables
+func setup(t *testing.T) (*database.MySQLConn, CallFixture) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set")
+	}
+	helpers.InitLogrus("stdout")
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	workspaceId, _ := strconv.Atoi(os.Getenv("TEST_WORKSPACE_ID"))
+	userId, _ := strconv.Atoi(os.Getenv("TEST_USER_ID"))
+	return database.NewMySQLConn(db), CallFixture{WorkspaceId: workspaceId, UserId: userId}
+}
+
+func cassandraSession(t *testing.T) *gocql.Session {
+	hosts := os.Getenv("TEST_CASSANDRA_HOSTS")
+	if hosts == "" {
+		t.Skip("TEST_CASSANDRA_HOSTS is not set")
+	}
+	cluster := gocql.NewCluster(strings.Split(hosts, ",")...)
+	cluster.Keyspace = os.Getenv("TEST_CASSANDRA_KEYSPACE")
+	cluster.ProtoVersion = 4
+	session, err := cluster.CreateSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(session.Close)
+
+	err = store.CreateCassandraCallTables(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestMemoryCallStore(t *testing.T) {
+	RunCallStoreContract(t, NewMemoryCallStore(), CallFixture{WorkspaceId: 1, UserId: 1})
+}
+
+func TestMySQLCallStore(t *testing.T) {
+	db, fixture := setup(t)
+	RunCallStoreContract(t, store.NewCallStore(db), fixture)
+}
+
+func TestCassandraCallStore(t *testing.T) {
+	db, fixture := setup(t)
+	RunCallStoreContract(t, store.NewCassandraCallStore(db, cassandraSession(t), 1), fixture)
+}
+
+func TestDualCallStore(t *testing.T) {
+	db, fixture := setup(t)
+	RunCallStoreContract(t, store.NewDualCallStore(db, cassandraSession(t)), fixture)
+}
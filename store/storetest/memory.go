@@ -0,0 +1,172 @@
+package storetest
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	callstate "lineblocs.com/api/call"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/utils"
+)
+
+// CallRecordStore is the call record part of call.CallStoreInterface covered by the contract.
+type CallRecordStore interface {
+	CreateCall(*model.Call) (string, error)
+	UpdateCall(*model.CallUpdate) error
+	GetCallFromDB(int) (*model.Call, error)
+	SetSIPCallID(string, string) error
+	GetCallBySIPCallId(sipCallId string) (*model.Call, error)
+	GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error)
+}
+
+// MemoryCallStore keeps call records in memory. It is the reference the
+// contract runs against when no database is configured.
+type MemoryCallStore struct {
+	mu     sync.Mutex
+	lastId int
+	calls  map[int]model.Call
+}
+
+func NewMemoryCallStore() *MemoryCallStore {
+	return &MemoryCallStore{calls: map[int]model.Call{}}
+}
+
+func (s *MemoryCallStore) CreateCall(call *model.Call) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := callstate.NormalizeStatus(call.Status)
+	if status == "" {
+		status = callstate.StatusInitiated
+	}
+	if !callstate.IsKnownStatus(status) || callstate.IsTerminalStatus(status) {
+		return "-1", callstate.ErrUnknownStatus
+	}
+
+	if call.ParentCallId != 0 {
+		parent, ok := s.calls[call.ParentCallId]
+		if !ok {
+			return "-1", callstate.ErrParentCallNotFound
+		}
+		if parent.WorkspaceId != call.WorkspaceId {
+			return "-1", callstate.ErrParentWorkspaceMismatch
+		}
+		call.SessionId = parent.SessionId
+	} else if call.SessionId != "" && len(s.session(call.WorkspaceId, call.SessionId)) == 0 {
+		return "-1", callstate.ErrSessionNotFound
+	}
+	if call.SessionId == "" {
+		call.SessionId = utils.CreateAPIID("session")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	s.lastId++
+	call.Id = s.lastId
+	call.Status = status
+	call.Duration = 0
+	call.StartedAt = now
+	call.CreatedAt = now
+	call.UpdatedAt = now
+	if status == callstate.StatusRinging {
+		call.RingingAt = now
+	}
+	if status == callstate.StatusAnswered {
+		call.AnsweredAt = now
+	}
+	s.calls[call.Id] = *call
+	return strconv.Itoa(call.Id), nil
+}
+
+func (s *MemoryCallStore) UpdateCall(update *model.CallUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, ok := s.calls[update.CallId]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	status := callstate.NormalizeStatus(update.Status)
+	err := callstate.ValidateTransition(call.Status, status)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	switch status {
+	case callstate.StatusRinging:
+		call.RingingAt = now.Format(time.RFC3339)
+	case callstate.StatusAnswered:
+		call.AnsweredAt = now.Format(time.RFC3339)
+	default:
+		if answeredAt, err := time.Parse(time.RFC3339, call.AnsweredAt); err == nil {
+			call.Duration = int(now.Sub(answeredAt).Seconds())
+		}
+		call.EndedAt = now.Format(time.RFC3339)
+	}
+	call.Status = status
+	call.UpdatedAt = now.Format(time.RFC3339)
+	s.calls[call.Id] = call
+	return nil
+}
+
+func (s *MemoryCallStore) GetCallFromDB(id int) (*model.Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	call, ok := s.calls[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &call, nil
+}
+
+func (s *MemoryCallStore) SetSIPCallID(callid string, apiid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, err := strconv.Atoi(apiid)
+	if err != nil {
+		return err
+	}
+	call, ok := s.calls[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	call.SIPCallId = callid
+	s.calls[id] = call
+	return nil
+}
+
+func (s *MemoryCallStore) GetCallBySIPCallId(sipCallId string) (*model.Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, call := range s.calls {
+		if call.SIPCallId == sipCallId {
+			return &call, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *MemoryCallStore) GetCallsBySessionId(workspaceId int, sessionId string) ([]model.Call, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.session(workspaceId, sessionId), nil
+}
+
+// session returns the legs of a session ordered by id, the caller holds the lock.
+func (s *MemoryCallStore) session(workspaceId int, sessionId string) []model.Call {
+	calls := []model.Call{}
+	for _, call := range s.calls {
+		if call.WorkspaceId == workspaceId && call.SessionId == sessionId {
+			calls = append(calls, call)
+		}
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Id < calls[j].Id })
+	return calls
+}
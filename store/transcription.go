@@ -0,0 +1,244 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/transcription"
+	"lineblocs.com/api/utils"
+)
+
+/*
+Implementation of Transcription Store
+
+Every recording has at most one job in recording_transcription_jobs, jobs
+are leased to a transcription.Worker the same way the outbox leases events.
+*/
+
+type TranscriptionStore struct {
+	db *database.MySQLConn
+}
+
+func NewTranscriptionStore(db *database.MySQLConn) *TranscriptionStore {
+	return &TranscriptionStore{
+		db: db,
+	}
+}
+
+/*
+Input: TranscriptionJob model
+Todo : Queue the transcription of a recording, a failed job is queued again
+Output: First Value: job id, Second Value: error
+Returns transcription.ErrJobExists when the recording is queued or transcribed already
+*/
+func (ts *TranscriptionStore) CreateTranscriptionJob(job *model.TranscriptionJob) (int64, error) {
+	now := time.Now()
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return -1, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	var status string
+	err = tx.QueryRow("SELECT `id`, `status` FROM recording_transcription_jobs WHERE recording_id = ? FOR UPDATE", job.RecordingId).Scan(&id, &status)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.Exec("INSERT INTO recording_transcription_jobs (`recording_id`, `workspace_id`, `user_id`, `status`, `attempts`, `language`, `available_at`, `created_at`, `updated_at`) VALUES ( ?, ?, ?, ?, 0, ?, ?, ?, ? )",
+			job.RecordingId, job.WorkspaceId, job.UserId, transcription.StatusPending, job.Language, now, now, now)
+		if err != nil {
+			return -1, err
+		}
+		id, err = res.LastInsertId()
+		if err != nil {
+			return -1, err
+		}
+	case err != nil:
+		return -1, err
+	case status != transcription.StatusFailed:
+		return id, transcription.ErrJobExists
+	default:
+		_, err = tx.Exec("UPDATE recording_transcription_jobs SET `status` = ?, `attempts` = 0, `last_error` = NULL, `language` = ?, `available_at` = ?, `claim_token` = NULL, `claimed_until` = NULL, `updated_at` = ? WHERE id = ?",
+			transcription.StatusPending, job.Language, now, now, id)
+		if err != nil {
+			return -1, err
+		}
+	}
+
+	job.Id = int(id)
+	job.Status = transcription.StatusPending
+	job.Attempts = 0
+	return id, tx.Commit()
+}
+
+/*
+Input: recordingId
+Todo : Get the transcription job of a recording with its result once completed
+Output: First Value: Transcription model, Second Value: error
+If the recording was never queued return sql.ErrNoRows
+*/
+func (ts *TranscriptionStore) GetTranscription(recordingId int) (*model.Transcription, error) {
+	var provider, lastError, language, words, text sql.NullString
+	var duration sql.NullFloat64
+	var createdAt time.Time
+	var completedAt sql.NullTime
+	job := model.TranscriptionJob{RecordingId: recordingId}
+	row := ts.db.QueryRow("SELECT j.`id`, j.`workspace_id`, j.`user_id`, r.`api_id`, j.`provider`, j.`status`, j.`attempts`, j.`last_error`, j.`language`, j.`duration`, j.`words`, j.`created_at`, j.`completed_at`, r.`transcription_text` "+
+		"FROM recording_transcription_jobs j INNER JOIN recordings r ON r.id = j.recording_id WHERE j.recording_id = ?", recordingId)
+	err := row.Scan(&job.Id, &job.WorkspaceId, &job.UserId, &job.APIId, &provider, &job.Status, &job.Attempts, &lastError, &language, &duration, &words, &createdAt, &completedAt, &text)
+	if err != nil {
+		return nil, err
+	}
+	job.Provider = provider.String
+	job.LastError = lastError.String
+	job.Language = language.String
+	job.Duration = duration.Float64
+	job.CreatedAt = createdAt.UTC().Format(time.RFC3339)
+	if completedAt.Valid {
+		job.CompletedAt = completedAt.Time.UTC().Format(time.RFC3339)
+	}
+
+	transcript := model.Transcription{Job: job}
+	if job.Status != transcription.StatusCompleted {
+		return &transcript, nil
+	}
+	result := model.TranscriptionResult{Text: text.String, Language: job.Language, Duration: job.Duration, Words: []model.TranscriptionWord{}}
+	if words.String != "" {
+		err = json.Unmarshal([]byte(words.String), &result.Words)
+		if err != nil {
+			return nil, err
+		}
+	}
+	transcript.Result = &result
+	return &transcript, nil
+}
+
+/*
+Input: limit, lease
+Todo : Lease up to limit queued jobs to this worker so concurrent workers do not transcribe the same recording
+Output: First Value: list of TranscriptionJob model, Second Value: error
+*/
+func (ts *TranscriptionStore) ClaimTranscriptionJobs(limit int, lease time.Duration) ([]model.TranscriptionJob, error) {
+	now := time.Now()
+	token := utils.CreateAPIID("stt")
+
+	// jobs left PROCESSING by a worker that died are claimed again once the lease expired
+	_, err := ts.db.Exec("UPDATE recording_transcription_jobs SET `status` = ?, `claim_token` = ?, `claimed_until` = ?, `updated_at` = ? WHERE `status` IN (?, ?) AND `available_at` <= ? AND (`claimed_until` IS NULL OR `claimed_until` < ?) ORDER BY `id` ASC LIMIT ?",
+		transcription.StatusProcessing, token, now.Add(lease), now, transcription.StatusPending, transcription.StatusProcessing, now, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := ts.db.Query("SELECT j.`id`, j.`recording_id`, j.`workspace_id`, j.`user_id`, r.`api_id`, j.`attempts`, j.`language`, w.`plan`, COALESCE(r.`duration`, 0) "+
+		"FROM recording_transcription_jobs j INNER JOIN recordings r ON r.id = j.recording_id INNER JOIN workspaces w ON w.id = j.workspace_id "+
+		"WHERE j.`claim_token` = ? AND j.`status` = ? ORDER BY j.`id` ASC", token, transcription.StatusProcessing)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	jobs := []model.TranscriptionJob{}
+	for results.Next() {
+		var language sql.NullString
+		job := model.TranscriptionJob{Status: transcription.StatusProcessing}
+		err = results.Scan(&job.Id, &job.RecordingId, &job.WorkspaceId, &job.UserId, &job.APIId, &job.Attempts, &language, &job.Plan, &job.Duration)
+		if err != nil {
+			return nil, err
+		}
+		job.Language = language.String
+		jobs = append(jobs, job)
+	}
+	return jobs, results.Err()
+}
+
+/*
+Input: TranscriptionJob model, TranscriptionResult model
+Todo : Store the result of a job and the transcription of its recording, and debit the audio length as STT usage in the same transaction
+Output: If success return nil else return err
+A job that is no longer processing, e.g. completed by another worker after its lease expired, returns transcription.ErrJobNotProcessing and is not billed again
+*/
+func (ts *TranscriptionStore) CompleteTranscriptionJob(job *model.TranscriptionJob, result *model.TranscriptionResult) error {
+	words, err := json.Marshal(result.Words)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	tx, err := ts.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE recording_transcription_jobs SET `status` = ?, `provider` = ?, `language` = ?, `duration` = ?, `words` = ?, `last_error` = NULL, `claim_token` = NULL, `claimed_until` = NULL, `completed_at` = ?, `updated_at` = ? WHERE id = ? AND `status` = ?",
+		transcription.StatusCompleted, job.Provider, result.Language, job.Duration, string(words), now, now, job.Id, transcription.StatusProcessing)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return transcription.ErrJobNotProcessing
+	}
+	_, err = tx.Exec("UPDATE recordings SET `transcription_ready` = 1, `transcription_text` = ?, `updated_at` = ? WHERE id = ?", result.Text, now, job.RecordingId)
+	if err != nil {
+		return err
+	}
+	if job.Duration > 0 {
+		debitApi := model.DebitAPI{
+			UserId:      job.UserId,
+			WorkspaceId: job.WorkspaceId,
+			Type:        "STT",
+			Params:      model.DebitAPIParams{RecordingLength: job.Duration}}
+		_, err = insertAPIUsageDebit(tx, job.Plan, &debitApi, job.Id)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+/*
+Input: jobId, lastError, retryAt
+Todo : Release a failed job so it is retried at retryAt, without retryAt the job fails for good
+Output: If success return nil else return err
+*/
+func (ts *TranscriptionStore) FailTranscriptionJob(jobId int, lastError string, retryAt *time.Time) error {
+	now := time.Now()
+	if retryAt == nil {
+		_, err := ts.db.Exec("UPDATE recording_transcription_jobs SET `status` = ?, `attempts` = `attempts` + 1, `last_error` = ?, `claim_token` = NULL, `claimed_until` = NULL, `updated_at` = ? WHERE id = ?",
+			transcription.StatusFailed, lastError, now, jobId)
+		return err
+	}
+	_, err := ts.db.Exec("UPDATE recording_transcription_jobs SET `status` = ?, `attempts` = `attempts` + 1, `last_error` = ?, `available_at` = ?, `claim_token` = NULL, `claimed_until` = NULL, `updated_at` = ? WHERE id = ?",
+		transcription.StatusPending, lastError, *retryAt, now, jobId)
+	return err
+}
+
+/*
+Input: workspaceId
+Todo : Get the webhooks notified when a transcription of the workspace is ready, with the API secret of the workspace that signs them
+Output: First Value: list of TranscriptionWebhookTarget model, Second Value: error
+*/
+func (ts *TranscriptionStore) GetTranscriptionWebhooks(workspaceId int) ([]model.TranscriptionWebhookTarget, error) {
+	results, err := ts.db.Query("SELECT h.`http_uri`, w.`api_secret` FROM workspaces_transcription_webhooks h INNER JOIN workspaces w ON w.id = h.workspace_id WHERE h.workspace_id = ?", workspaceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	targets := []model.TranscriptionWebhookTarget{}
+	for results.Next() {
+		var target model.TranscriptionWebhookTarget
+		err = results.Scan(&target.URL, &target.Secret)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, results.Err()
+}
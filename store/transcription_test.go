@@ -0,0 +1,49 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"lineblocs.com/api/database"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/transcription"
+)
+
+func TestCompleteTranscriptionJob(t *testing.T) {
+	job := &model.TranscriptionJob{Id: 7, RecordingId: 5, WorkspaceId: 2, UserId: 3, Provider: "whisper", Duration: 75, Plan: "starter"}
+	result := &model.TranscriptionResult{Text: "hello", Language: "en"}
+
+	t.Run("Should debit the audio length as STT usage in the same transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		transcriptionStore := NewTranscriptionStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE recording_transcription_jobs").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("UPDATE recordings").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO users_debits").
+			WithArgs(2, 3, 3, "API usage - STT", 7, "starter", sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, transcriptionStore.CompleteTranscriptionJob(job, result))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Should not debit a job completed by another worker", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		transcriptionStore := NewTranscriptionStore(database.NewMySQLConn(db))
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE recording_transcription_jobs").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, transcriptionStore.CompleteTranscriptionJob(job, result), transcription.ErrJobNotProcessing)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,133 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+const (
+	DefaultDeepgramURL   = "https://api.deepgram.com/v1/listen"
+	DefaultDeepgramModel = "nova-2"
+	deepgramTimeout      = 10 * time.Minute
+)
+
+// DeepgramConfig configures a DeepgramTranscriber, only APIKey is required.
+type DeepgramConfig struct {
+	APIKey string
+	URL    string
+	Model  string
+}
+
+// DeepgramTranscriber sends recordings to the Deepgram pre-recorded audio API.
+type DeepgramTranscriber struct {
+	config DeepgramConfig
+	client *http.Client
+}
+
+func NewDeepgramTranscriber(config DeepgramConfig) (*DeepgramTranscriber, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("transcription: deepgram api key is not configured")
+	}
+	if config.URL == "" {
+		config.URL = DefaultDeepgramURL
+	}
+	if config.Model == "" {
+		config.Model = DefaultDeepgramModel
+	}
+	return &DeepgramTranscriber{
+		config: config,
+		client: &http.Client{Timeout: deepgramTimeout},
+	}, nil
+}
+
+func (d *DeepgramTranscriber) Name() string {
+	return "deepgram"
+}
+
+type deepgramResponse struct {
+	Metadata struct {
+		Duration float64 `json:"duration"`
+	} `json:"metadata"`
+	Results struct {
+		Channels []struct {
+			DetectedLanguage string `json:"detected_language"`
+			Alternatives     []struct {
+				Transcript string `json:"transcript"`
+				Words      []struct {
+					Word           string  `json:"word"`
+					PunctuatedWord string  `json:"punctuated_word"`
+					Start          float64 `json:"start"`
+					End            float64 `json:"end"`
+					Confidence     float64 `json:"confidence"`
+				} `json:"words"`
+			} `json:"alternatives"`
+		} `json:"channels"`
+	} `json:"results"`
+}
+
+func (d *DeepgramTranscriber) Transcribe(ctx context.Context, audio io.Reader, contentType string, opts Options) (*model.TranscriptionResult, error) {
+	query := url.Values{"model": {d.config.Model}, "punctuate": {"true"}}
+	if opts.Language != "" {
+		query.Set("language", opts.Language)
+	} else {
+		query.Set("detect_language", "true")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.config.URL+"?"+query.Encode(), audio)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+d.config.APIKey)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("transcription: deepgram request failed: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnsupportedMediaType {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return nil, fmt.Errorf("%w: deepgram: %s", ErrUnsupportedAudio, strings.TrimSpace(string(body)))
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("transcription: deepgram returned %s", res.Status)
+	}
+
+	var decoded deepgramResponse
+	err = json.NewDecoder(res.Body).Decode(&decoded)
+	if err != nil {
+		return nil, fmt.Errorf("transcription: could not decode deepgram response: %w", err)
+	}
+
+	result := model.TranscriptionResult{
+		Language: opts.Language,
+		Duration: decoded.Metadata.Duration,
+		Words:    []model.TranscriptionWord{},
+	}
+	if len(decoded.Results.Channels) == 0 || len(decoded.Results.Channels[0].Alternatives) == 0 {
+		return &result, nil
+	}
+	channel := decoded.Results.Channels[0]
+	if channel.DetectedLanguage != "" {
+		result.Language = channel.DetectedLanguage
+	}
+	best := channel.Alternatives[0]
+	result.Text = best.Transcript
+	for _, word := range best.Words {
+		text := word.PunctuatedWord
+		if text == "" {
+			text = word.Word
+		}
+		result.Words = append(result.Words, model.TranscriptionWord{Word: text, Start: word.Start, End: word.End, Confidence: word.Confidence})
+	}
+	return &result, nil
+}
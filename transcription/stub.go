@@ -0,0 +1,49 @@
+package transcription
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"lineblocs.com/api/model"
+)
+
+// stubWordLength is the time the stub gives every word, in seconds.
+const stubWordLength = 0.5
+
+// StubTranscriber returns a fixed transcription without calling a vendor, for
+// tests and local development.
+type StubTranscriber struct {
+	Text     string
+	Language string
+	Err      error
+}
+
+func NewStubTranscriber(text string, language string) *StubTranscriber {
+	return &StubTranscriber{Text: text, Language: language}
+}
+
+func (s *StubTranscriber) Name() string {
+	return "stub"
+}
+
+func (s *StubTranscriber) Transcribe(ctx context.Context, audio io.Reader, contentType string, opts Options) (*model.TranscriptionResult, error) {
+	if s.Err != nil {
+		return nil, s.Err
+	}
+	if _, err := io.Copy(io.Discard, audio); err != nil {
+		return nil, err
+	}
+	language := s.Language
+	if opts.Language != "" {
+		language = opts.Language
+	}
+
+	result := model.TranscriptionResult{Text: s.Text, Language: language, Words: []model.TranscriptionWord{}}
+	for i, word := range strings.Fields(s.Text) {
+		start := float64(i) * stubWordLength
+		result.Words = append(result.Words, model.TranscriptionWord{Word: word, Start: start, End: start + stubWordLength, Confidence: 1})
+	}
+	result.Duration = float64(len(result.Words)) * stubWordLength
+	return &result, nil
+}
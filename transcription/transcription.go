@@ -0,0 +1,55 @@
+// Package transcription turns recordings into text with a pluggable
+// speech-to-text vendor. Jobs are queued in the database and processed by a
+// Worker, which bills the audio length and notifies the workspace.
+package transcription
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"lineblocs.com/api/model"
+)
+
+// Statuses of a transcription job.
+const (
+	StatusPending    = "PENDING"
+	StatusProcessing = "PROCESSING"
+	StatusCompleted  = "COMPLETED"
+	StatusFailed     = "FAILED"
+)
+
+var (
+	// ErrUnsupportedAudio is returned by a Transcriber for audio it can never
+	// transcribe, such jobs fail without being retried.
+	ErrUnsupportedAudio = errors.New("unsupported audio")
+	ErrJobExists        = errors.New("recording already has a transcription job")
+	// ErrJobNotProcessing is returned when completing a job another worker already completed
+	ErrJobNotProcessing = errors.New("transcription job is not processing")
+)
+
+// Options of a single transcription. An empty Language lets the vendor detect it.
+type Options struct {
+	Language string
+}
+
+// Transcriber converts audio to text with word timings.
+type Transcriber interface {
+	// Name identifies the vendor in job records.
+	Name() string
+	Transcribe(ctx context.Context, audio io.Reader, contentType string, opts Options) (*model.TranscriptionResult, error)
+}
+
+/*
+Interface of Transcription Store.
+Implementation of Transcription Store is located /store/transcription
+*/
+type TranscriptionStoreInterface interface {
+	CreateTranscriptionJob(job *model.TranscriptionJob) (int64, error)
+	GetTranscription(recordingId int) (*model.Transcription, error)
+	ClaimTranscriptionJobs(limit int, lease time.Duration) ([]model.TranscriptionJob, error)
+	CompleteTranscriptionJob(job *model.TranscriptionJob, result *model.TranscriptionResult) error
+	FailTranscriptionJob(jobId int, lastError string, retryAt *time.Time) error
+	GetTranscriptionWebhooks(workspaceId int) ([]model.TranscriptionWebhookTarget, error)
+}
@@ -0,0 +1,275 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"lineblocs.com/api/alert"
+	"lineblocs.com/api/eventbus"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+	"lineblocs.com/api/utils"
+)
+
+const (
+	DefaultPollInterval = 5 * time.Second
+	DefaultBatchSize    = 10
+	// DefaultLease must outlast the slowest transcription, an expired lease
+	// lets another worker pick the job up
+	DefaultLease       = 30 * time.Minute
+	DefaultMaxAttempts = 5
+	// ContentType of the stored recordings
+	ContentType      = "audio/wav"
+	WebhookEvent     = "recording.transcribed"
+	webhookAttempts  = 3
+	webhookTimeout   = 10 * time.Second
+	minRetryDelay    = 30 * time.Second
+	maxRetryDelay    = time.Hour
+	webhookRetryBase = time.Second
+)
+
+// Worker transcribes the recordings of queued jobs. A completed job stores
+// the text and word timings with the recording and bills the audio length as
+// STT usage in one transaction, then publishes recording.transcribed and posts
+// the workspace webhooks, signed like the alert webhooks.
+type Worker struct {
+	store       TranscriptionStoreInterface
+	transcriber Transcriber
+	objects     *objectstore.Manager
+	bus         eventbus.EventBus
+	client      *http.Client
+	checkTarget func(ctx context.Context, rawURL string) error
+	interval    time.Duration
+	batchSize   int
+	lease       time.Duration
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewWorker(store TranscriptionStoreInterface, transcriber Transcriber, objects *objectstore.Manager) *Worker {
+	return &Worker{
+		store:       store,
+		transcriber: transcriber,
+		objects:     objects,
+		client:      alert.NewPublicClient(webhookTimeout),
+		checkTarget: alert.CheckTarget,
+		interval:    DefaultPollInterval,
+		batchSize:   DefaultBatchSize,
+		lease:       DefaultLease,
+		maxAttempts: DefaultMaxAttempts,
+		now:         time.Now,
+	}
+}
+
+// SetEventBus publishes recording.transcribed for completed jobs to bus.
+func (w *Worker) SetEventBus(bus eventbus.EventBus) {
+	w.bus = bus
+}
+
+// Run processes queued jobs until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		// keep going while full batches come back
+		for {
+			n, err := w.ProcessBatch(ctx)
+			if err != nil {
+				utils.Log(logrus.ErrorLevel, "transcription worker could not claim jobs: "+err.Error())
+				break
+			}
+			if n < w.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch transcribes one batch of queued jobs and returns how many were claimed.
+func (w *Worker) ProcessBatch(ctx context.Context) (int, error) {
+	jobs, err := w.store.ClaimTranscriptionJobs(w.batchSize, w.lease)
+	if err != nil {
+		return 0, err
+	}
+	for i := range jobs {
+		if ctx.Err() != nil {
+			// the lease expires and another worker picks the rest up
+			return len(jobs), nil
+		}
+		w.process(ctx, &jobs[i])
+	}
+	return len(jobs), nil
+}
+
+func (w *Worker) process(ctx context.Context, job *model.TranscriptionJob) {
+	result, err := w.transcribe(ctx, job)
+	if err != nil {
+		w.fail(job, err)
+		return
+	}
+
+	job.Provider = w.transcriber.Name()
+	// the length measured when the recording was stored is billed, the vendor
+	// figure only covers recordings stored before their audio was analyzed
+	if job.Duration <= 0 {
+		job.Duration = result.Duration
+	}
+	err = w.store.CompleteTranscriptionJob(job, result)
+	if errors.Is(err, ErrJobNotProcessing) {
+		// another worker completed and billed the job after this lease expired
+		utils.Log(logrus.InfoLevel, fmt.Sprintf("transcription job id = %d was already completed", job.Id))
+		return
+	}
+	if err != nil {
+		// the job is transcribed again once its lease runs out
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("transcription worker could not complete job id = %d: %s", job.Id, err.Error()))
+		return
+	}
+	job.Status = StatusCompleted
+	job.Language = result.Language
+
+	w.publish(ctx, job)
+	w.notify(ctx, job, result)
+}
+
+func (w *Worker) transcribe(ctx context.Context, job *model.TranscriptionJob) (*model.TranscriptionResult, error) {
+	objects, err := w.objects.ForWorkspace(job.WorkspaceId)
+	if err != nil {
+		return nil, err
+	}
+	audio, err := objects.Get(ctx, objectstore.Key(objectstore.FolderRecordings, job.APIId))
+	if err != nil {
+		return nil, err
+	}
+	defer audio.Close()
+	return w.transcriber.Transcribe(ctx, audio, ContentType, Options{Language: job.Language})
+}
+
+// fail reschedules a job with exponential backoff, jobs that can not succeed
+// or ran out of attempts are failed for good.
+func (w *Worker) fail(job *model.TranscriptionJob, cause error) {
+	var retryAt *time.Time
+	permanent := errors.Is(cause, ErrUnsupportedAudio) || errors.Is(cause, objectstore.ErrNotFound)
+	if !permanent && job.Attempts+1 < w.maxAttempts {
+		at := w.now().Add(RetryDelay(job.Attempts + 1))
+		retryAt = &at
+	}
+	utils.Log(logrus.WarnLevel, fmt.Sprintf("transcription of recording id = %d failed on attempt %d: %s", job.RecordingId, job.Attempts+1, cause.Error()))
+
+	err := w.store.FailTranscriptionJob(job.Id, cause.Error(), retryAt)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "transcription worker could not record failure: "+err.Error())
+	}
+}
+
+func (w *Worker) publish(ctx context.Context, job *model.TranscriptionJob) {
+	if w.bus == nil {
+		return
+	}
+	event, err := eventbus.NewEvent(job.WorkspaceId, eventbus.RecordingTranscribed{RecordingId: job.RecordingId, Ready: true, Language: job.Language})
+	if err == nil {
+		err = w.bus.Publish(ctx, event)
+	}
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "transcription worker could not publish event: "+err.Error())
+	}
+}
+
+// notify posts the result to every transcription webhook of the workspace.
+// Delivery is retried a few times, a failing endpoint does not fail the job.
+func (w *Worker) notify(ctx context.Context, job *model.TranscriptionJob, result *model.TranscriptionResult) {
+	targets, err := w.store.GetTranscriptionWebhooks(job.WorkspaceId)
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, fmt.Sprintf("could not get transcription webhooks of workspace id = %d: %s", job.WorkspaceId, err.Error()))
+		return
+	}
+	if len(targets) == 0 {
+		return
+	}
+	payload, err := json.Marshal(model.TranscriptionWebhook{
+		Event:       WebhookEvent,
+		WorkspaceId: job.WorkspaceId,
+		RecordingId: job.RecordingId,
+		APIId:       job.APIId,
+		JobId:       job.Id,
+		Language:    result.Language,
+		Duration:    job.Duration,
+		Text:        result.Text,
+	})
+	if err != nil {
+		utils.Log(logrus.ErrorLevel, "could not encode transcription webhook: "+err.Error())
+		return
+	}
+	for _, target := range targets {
+		err := w.postWebhook(ctx, target, payload)
+		if err != nil {
+			utils.Log(logrus.WarnLevel, fmt.Sprintf("could not deliver transcription webhook of job id = %d: %s", job.Id, err.Error()))
+		}
+	}
+}
+
+// postWebhook posts payload to a webhook on a public address, like the
+// alert webhooks the workspace can not point it at internal services.
+func (w *Worker) postWebhook(ctx context.Context, target model.TranscriptionWebhookTarget, payload []byte) error {
+	// a private target fails every attempt, it is not retried
+	if err := w.checkTarget(ctx, target.URL); err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+	signature := "sha256=" + alert.Sign([]byte(target.Secret), timestamp, payload)
+
+	var err error
+	delay := webhookRetryBase
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay *= 2
+		}
+
+		var req *http.Request
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(alert.TimestampHeader, timestamp)
+		req.Header.Set(alert.SignatureHeader, signature)
+		var res *http.Response
+		res, err = w.client.Do(req)
+		if err != nil {
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode < 300 {
+			return nil
+		}
+		err = fmt.Errorf("webhook returned %s", res.Status)
+		if res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+			return err
+		}
+	}
+	return err
+}
+
+// RetryDelay backs off exponentially from 30 seconds, capped at an hour.
+func RetryDelay(attempts int) time.Duration {
+	return utils.Backoff(attempts, minRetryDelay, maxRetryDelay)
+}
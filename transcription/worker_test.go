@@ -0,0 +1,188 @@
+package transcription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	helpers "github.com/Lineblocs/go-helpers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"lineblocs.com/api/alert"
+	"lineblocs.com/api/mocks"
+	"lineblocs.com/api/model"
+	"lineblocs.com/api/objectstore"
+)
+
+func newObjects(t *testing.T, apiIds ...string) *objectstore.Manager {
+	local, err := objectstore.NewLocalStore(t.TempDir(), "")
+	assert.NoError(t, err)
+	for _, apiId := range apiIds {
+		key := objectstore.Key(objectstore.FolderRecordings, apiId)
+		assert.NoError(t, local.Put(context.Background(), key, strings.NewReader("RIFF"), 4, ContentType))
+	}
+	return objectstore.NewStaticManager(local)
+}
+
+func TestProcessBatch(t *testing.T) {
+	helpers.InitLogrus("stdout")
+
+	t.Run("Should store, bill and announce completed transcriptions", func(t *testing.T) {
+		var delivered model.TranscriptionWebhook
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			timestamp := r.Header.Get(alert.TimestampHeader)
+			assert.Equal(t, "sha256="+alert.Sign([]byte("workspace-secret"), timestamp, body), r.Header.Get(alert.SignatureHeader))
+			assert.NoError(t, json.Unmarshal(body, &delivered))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		store := mocks.NewTranscriptionStoreInterface(t)
+		job := model.TranscriptionJob{Id: 3, RecordingId: 9, WorkspaceId: 7, UserId: 2, APIId: "rec-1", Plan: "pro"}
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{job}, nil)
+		store.EXPECT().CompleteTranscriptionJob(mock.Anything, mock.Anything).
+			RunAndReturn(func(job *model.TranscriptionJob, result *model.TranscriptionResult) error {
+				assert.Equal(t, "stub", job.Provider)
+				assert.Equal(t, "hello there", result.Text)
+				assert.Len(t, result.Words, 2)
+				assert.Equal(t, 1.0, job.Duration)
+				return nil
+			})
+		store.EXPECT().GetTranscriptionWebhooks(7).Return([]model.TranscriptionWebhookTarget{{URL: server.URL, Secret: "workspace-secret"}}, nil)
+
+		worker := NewWorker(store, NewStubTranscriber("hello there", "en"), newObjects(t, "rec-1"))
+		// the test server listens on loopback
+		worker.client, worker.checkTarget = server.Client(), allowTarget
+		n, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, WebhookEvent, delivered.Event)
+		assert.Equal(t, 9, delivered.RecordingId)
+		assert.Equal(t, "en", delivered.Language)
+	})
+
+	t.Run("Should not post webhooks to private addresses", func(t *testing.T) {
+		posted := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			posted = true
+		}))
+		defer server.Close()
+
+		store := mocks.NewTranscriptionStoreInterface(t)
+		job := model.TranscriptionJob{Id: 3, RecordingId: 9, WorkspaceId: 7, APIId: "rec-1"}
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{job}, nil)
+		store.EXPECT().CompleteTranscriptionJob(mock.Anything, mock.Anything).Return(nil)
+		store.EXPECT().GetTranscriptionWebhooks(7).Return([]model.TranscriptionWebhookTarget{{URL: server.URL, Secret: "workspace-secret"}}, nil)
+
+		worker := NewWorker(store, NewStubTranscriber("hello there", "en"), newObjects(t, "rec-1"))
+		n, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.False(t, posted)
+	})
+
+	t.Run("Should bill the measured length of the recording", func(t *testing.T) {
+		store := mocks.NewTranscriptionStoreInterface(t)
+		job := model.TranscriptionJob{Id: 4, RecordingId: 9, WorkspaceId: 7, APIId: "rec-1", Plan: "pro", Duration: 42}
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{job}, nil)
+		store.EXPECT().CompleteTranscriptionJob(mock.Anything, mock.Anything).
+			RunAndReturn(func(job *model.TranscriptionJob, result *model.TranscriptionResult) error {
+				assert.Equal(t, 42.0, job.Duration)
+				return nil
+			})
+		store.EXPECT().GetTranscriptionWebhooks(7).Return(nil, nil)
+
+		worker := NewWorker(store, NewStubTranscriber("hello there", "en"), newObjects(t, "rec-1"))
+		_, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should not announce jobs completed by another worker", func(t *testing.T) {
+		store := mocks.NewTranscriptionStoreInterface(t)
+		job := model.TranscriptionJob{Id: 5, RecordingId: 9, WorkspaceId: 7, APIId: "rec-1", Duration: 42}
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{job}, nil)
+		store.EXPECT().CompleteTranscriptionJob(mock.Anything, mock.Anything).Return(ErrJobNotProcessing)
+
+		worker := NewWorker(store, NewStubTranscriber("hello there", "en"), newObjects(t, "rec-1"))
+		_, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should retry failures with backoff until attempts run out", func(t *testing.T) {
+		store := mocks.NewTranscriptionStoreInterface(t)
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{
+			{Id: 1, WorkspaceId: 7, APIId: "rec-1", Attempts: 1},
+			{Id: 2, WorkspaceId: 7, APIId: "rec-1", Attempts: DefaultMaxAttempts - 1},
+		}, nil)
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		retryAt := now.Add(RetryDelay(2))
+		store.EXPECT().FailTranscriptionJob(1, "vendor unavailable", &retryAt).Return(nil)
+		store.EXPECT().FailTranscriptionJob(2, "vendor unavailable", (*time.Time)(nil)).Return(nil)
+
+		transcriber := &StubTranscriber{Err: errors.New("vendor unavailable")}
+		worker := NewWorker(store, transcriber, newObjects(t, "rec-1"))
+		worker.now = func() time.Time { return now }
+		_, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("Should fail jobs without audio for good", func(t *testing.T) {
+		store := mocks.NewTranscriptionStoreInterface(t)
+		store.EXPECT().ClaimTranscriptionJobs(DefaultBatchSize, DefaultLease).Return([]model.TranscriptionJob{{Id: 1, WorkspaceId: 7, APIId: "missing"}}, nil)
+		store.EXPECT().FailTranscriptionJob(1, objectstore.ErrNotFound.Error(), (*time.Time)(nil)).Return(nil)
+
+		worker := NewWorker(store, NewStubTranscriber("", "en"), newObjects(t))
+		_, err := worker.ProcessBatch(context.Background())
+		assert.NoError(t, err)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, RetryDelay(0))
+	assert.Equal(t, 2*time.Minute, RetryDelay(3))
+	assert.Equal(t, time.Hour, RetryDelay(20))
+}
+
+func TestDeepgramTranscriber(t *testing.T) {
+	t.Run("Should return the text, word timings and detected language", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+			assert.Equal(t, "true", r.URL.Query().Get("detect_language"))
+			w.Write([]byte(`{"metadata":{"duration":2.5},"results":{"channels":[{"detected_language":"fr","alternatives":[{"transcript":"bonjour","words":[{"word":"bonjour","punctuated_word":"Bonjour.","start":0.1,"end":0.6,"confidence":0.9}]}]}]}}`))
+		}))
+		defer server.Close()
+
+		transcriber, err := NewDeepgramTranscriber(DeepgramConfig{APIKey: "secret", URL: server.URL})
+		assert.NoError(t, err)
+		result, err := transcriber.Transcribe(context.Background(), strings.NewReader("RIFF"), ContentType, Options{})
+		assert.NoError(t, err)
+		assert.Equal(t, "bonjour", result.Text)
+		assert.Equal(t, "fr", result.Language)
+		assert.Equal(t, 2.5, result.Duration)
+		assert.Equal(t, []model.TranscriptionWord{{Word: "Bonjour.", Start: 0.1, End: 0.6, Confidence: 0.9}}, result.Words)
+	})
+
+	t.Run("Should report audio the vendor rejects as unsupported", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "corrupt audio", http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		transcriber, err := NewDeepgramTranscriber(DeepgramConfig{APIKey: "secret", URL: server.URL})
+		assert.NoError(t, err)
+		_, err = transcriber.Transcribe(context.Background(), strings.NewReader("RIFF"), ContentType, Options{})
+		assert.ErrorIs(t, err, ErrUnsupportedAudio)
+	})
+}
+
+func allowTarget(ctx context.Context, rawURL string) error {
+	return nil
+}
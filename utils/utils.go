@@ -1,8 +1,9 @@
 package utils
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"mime/multipart"
 	"net"
 	"net/http"
 	"os"
@@ -12,9 +13,6 @@ import (
 	"strconv"
 
 	helpers "github.com/Lineblocs/go-helpers"
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
 	guuid "github.com/google/uuid"
 	"github.com/joho/godotenv"
 	"github.com/labstack/echo/v4"
@@ -70,34 +68,13 @@ func CalculateSTTCosts(recordingLength float64) float64 {
 	return 0.006 * billable
 }
 
-func CreateS3URL(folder string, id string) string {
-	return "https://lineblocs.s3.ca-central-1.amazonaws.com/" + folder + "/" + id
-}
-
-func UploadS3(folder string, name string, file multipart.File) error {
-	bucket := "lineblocs"
-	key := folder + "/" + name
-	// The session the S3 Uploader will use
-	session, err := session.NewSession(&aws.Config{
-		Region: aws.String("ca-central-1")})
-	if err != nil {
-		return fmt.Errorf("S3 session err: %s", err)
-	}
-
-	// Create an uploader with the session and default options
-	uploader := s3manager.NewUploader(session)
-
-	// Upload the file to S3.
-	result, err := uploader.Upload(&s3manager.UploadInput{
-		Bucket: aws.String(bucket),
-		Key:    aws.String(key),
-		Body:   file,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to upload file, %v", err)
+// Faxes are billed per page, FAX_RATE_PER_PAGE overrides the default rate in dollars
+func CalculateFaxCosts(pages int) float64 {
+	rate, err := strconv.ParseFloat(Config("FAX_RATE_PER_PAGE"), 64)
+	if err != nil || rate < 0 {
+		rate = 0.01
 	}
-	Log(logrus.InfoLevel, fmt.Sprintf("file uploaded to, %s\n", aws.StringValue(&result.Location)))
-	return nil
+	return float64(pages) * rate
 }
 
 func GetPlanRecordingLimit(workspace *model.Workspace) (int, error) {
@@ -113,6 +90,8 @@ func GetPlanRecordingLimit(workspace *model.Workspace) (int, error) {
 	return 0, nil
 }
 
+// GetPlanFaxLimit returns the faxes a workspace may store, 0 means the plan
+// does not limit faxes.
 func GetPlanFaxLimit(workspace *model.Workspace) (*int, error) {
 	var res int
 	switch workspace.Plan {
@@ -248,6 +227,36 @@ func HandlePaymentRequired(msg string, err error, c echo.Context) error {
 	}
 }
 
+func HandleBadRequest(msg string, err error, c echo.Context) error {
+	if err != nil {
+		Log(logrus.ErrorLevel, msg +  ". error message: " + err.Error())
+		return c.JSON(http.StatusBadRequest, err.Error())
+	} else {
+		Log(logrus.ErrorLevel, msg)
+		return c.JSON(http.StatusBadRequest, msg)
+	}
+}
+
+func HandleForbidden(msg string, err error, c echo.Context) error {
+	if err != nil {
+		Log(logrus.ErrorLevel, msg +  ". error message: " + err.Error())
+		return c.JSON(http.StatusForbidden, err.Error())
+	} else {
+		Log(logrus.ErrorLevel, msg)
+		return c.JSON(http.StatusForbidden, msg)
+	}
+}
+
+func HandleConflict(msg string, err error, c echo.Context) error {
+	if err != nil {
+		Log(logrus.ErrorLevel, msg +  ". error message: " + err.Error())
+		return c.JSON(http.StatusConflict, err.Error())
+	} else {
+		Log(logrus.ErrorLevel, msg)
+		return c.JSON(http.StatusConflict, msg)
+	}
+}
+
 func SetSetting(gs model.GlobalSettings) {
 	settings = &gs
 }
@@ -295,10 +304,6 @@ func Config(key string) string {
 	return os.Getenv(key)
 }
 
-func CanPlaceAdditionalCalls() (bool, error) {
-	return true, nil
-}
-
 // TODO:
 // send this CDR to any configured locations. handle
 // RADIUS servers, external databases and more
@@ -369,4 +374,64 @@ func IsExtensionToExtensionCall(from string, number string) bool {
 	// Assuming extension numbers are purely numeric and 1-6 digits long
 	matched, _ := regexp.MatchString(`^\d{1,6}$`, number)
 	return matched
-}
\ No newline at end of file
+}
+
+// Page sizes of the list endpoints.
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor returns the opaque cursor of the page that starts after id.
+func EncodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// DecodeCursor returns the id encoded by EncodeCursor, 0 for the first page.
+func DecodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(decoded))
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// ParsePageLimit reads the limit query parameter of a list endpoint.
+func ParsePageLimit(value string) (int, error) {
+	if value == "" {
+		return DefaultPageLimit, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("limit must be a positive number")
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+	return limit, nil
+}
+
+// Backoff returns the delay before the retry of attempts failed attempts,
+// doubling from base and capped at max.
+func Backoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
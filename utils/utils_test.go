@@ -26,7 +26,8 @@ func Test_CreateAPIID(t *testing.T) {
 
 func Test_LookupBestCallRate(t *testing.T) {
 	t.Run("Should return the correct CallRate struct", func(t *testing.T) {
-		expectedRate := 9.99
+		// every number is charged the flat rate until rates are looked up
+		expectedRate := 0.014
 		result := LookupBestCallRate("someNumber", "someType")
 
 		assert.Equal(t, expectedRate, result.CallRate)
@@ -63,14 +64,10 @@ func Test_CalculateSTTCosts(t *testing.T) {
 	})
 }
 
-func Test_CreateS3URL(t *testing.T) {
-	t.Run("Should create a valid S3 URL", func(t *testing.T) {
-		folder := "myfolder"
-		id := "myid"
-		expectedURL := "https://lineblocs.s3.ca-central-1.amazonaws.com/myfolder/myid"
-		result := CreateS3URL(folder, id)
-
-		assert.Equal(t, expectedURL, result)
+func Test_CalculateFaxCosts(t *testing.T) {
+	t.Run("Should bill faxes per page", func(t *testing.T) {
+		assert.InDelta(t, 0.0, CalculateFaxCosts(0), 0.000001)
+		assert.InDelta(t, 0.03, CalculateFaxCosts(3), 0.000001)
 	})
 }
 
@@ -307,14 +304,6 @@ func Test_Config(t *testing.T) {
 	})
 }
 
-func Test_CanPlaceAdditionalCalls(t *testing.T) {
-	t.Run("Should always return true without errors", func(t *testing.T) {
-		result, err := CanPlaceAdditionalCalls()
-		assert.NoError(t, err)
-		assert.True(t, result)
-	})
-}
-
 func Test_CheckIfCarrier(t *testing.T) {
 	t.Run("Should return true for a valid carrier token", func(t *testing.T) {
 		token := "validToken"
@@ -331,3 +320,49 @@ func Test_SetSetting(t *testing.T) {
 		assert.Equal(t, expectedSetting, *actualSetting)
 	})
 }
+
+func Test_Cursor(t *testing.T) {
+	t.Run("Should decode the cursor it encoded", func(t *testing.T) {
+		id, err := DecodeCursor(EncodeCursor(1234))
+		assert.NoError(t, err)
+		assert.Equal(t, 1234, id)
+	})
+
+	t.Run("Should start at the first page without a cursor", func(t *testing.T) {
+		id, err := DecodeCursor("")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, id)
+	})
+
+	t.Run("Should reject cursors it did not encode", func(t *testing.T) {
+		_, err := DecodeCursor("not a cursor")
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+		_, err = DecodeCursor(EncodeCursor(-1))
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func Test_ParsePageLimit(t *testing.T) {
+	t.Run("Should default and cap the limit", func(t *testing.T) {
+		limit, err := ParsePageLimit("")
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultPageLimit, limit)
+
+		limit, err = ParsePageLimit("5000")
+		assert.NoError(t, err)
+		assert.Equal(t, MaxPageLimit, limit)
+
+		_, err = ParsePageLimit("0")
+		assert.Error(t, err)
+	})
+}
+
+func Test_Backoff(t *testing.T) {
+	t.Run("Should double the delay up to the cap", func(t *testing.T) {
+		assert.Equal(t, time.Second, Backoff(0, time.Second, time.Minute))
+		assert.Equal(t, time.Second, Backoff(1, time.Second, time.Minute))
+		assert.Equal(t, 8*time.Second, Backoff(4, time.Second, time.Minute))
+		assert.Equal(t, time.Minute, Backoff(7, time.Second, time.Minute))
+		assert.Equal(t, time.Minute, Backoff(500, time.Second, time.Minute))
+	})
+}